@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const auditUsage = `usage: api audit verify [-org <organization ID>] [-global]
+
+Walks audit hash chains and reports the first broken link in each. Without
+flags every chain is verified. Exits 1 if any chain is broken.
+`
+
+// runAudit runs the audit subcommand and returns the exit code.
+func runAudit(args []string) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fmt.Fprint(os.Stderr, auditUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, auditUsage) }
+	org := fs.String("org", "", "verify only this organization's chain")
+	global := fs.Bool("global", false, "verify only the chain of events outside any organization")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "connect to database:", err)
+		return 1
+	}
+	defer pool.Close()
+
+	auditLog := audit.New(pool)
+	if cfg.AuditSigningKey != "" {
+		key, err := audit.ParseSigningKey(cfg.AuditSigningKey)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		auditLog.SetSigningKey(key)
+	}
+
+	var chains []uuid.UUID
+	switch {
+	case *org != "":
+		id, err := uuid.Parse(*org)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid organization ID:", err)
+			return 2
+		}
+		chains = []uuid.UUID{id}
+	case *global:
+		chains = []uuid.UUID{audit.GlobalChain}
+	default:
+		if chains, err = auditLog.Chains(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	broken := 0
+	for _, chainID := range chains {
+		result, err := auditLog.Verify(ctx, chainID)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printVerification(os.Stdout, result)
+		if !result.Valid {
+			broken++
+		}
+	}
+	if broken > 0 {
+		fmt.Printf("%d of %d chains broken\n", broken, len(chains))
+		return 1
+	}
+	return 0
+}
+
+func printVerification(w io.Writer, v *audit.Verification) {
+	name := v.ChainID.String()
+	if v.ChainID == audit.GlobalChain {
+		name = "global"
+	}
+	if v.Valid {
+		fmt.Fprintf(w, "%s: ok, %d events, head %d %s, %d checkpoints verified, %d unverified\n",
+			name, v.Events, v.HeadSeq, v.HeadHash, v.Checkpoints, v.UnverifiedCheckpoints)
+		return
+	}
+	event := ""
+	if v.Break.EventID != nil {
+		event = " (event " + v.Break.EventID.String() + ")"
+	}
+	fmt.Fprintf(w, "%s: BROKEN at %d%s: %s\n", name, v.Break.Seq, event, v.Break.Reason)
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"agenteur.ai/api/internal/app"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAudit(os.Args[2:]))
+	}
+
 	app := app.NewApp()
 	err := app.Start()
 	if err != nil {
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// AuditHandler serves the audit log, per org and across orgs for
+// superadmins.
+type AuditHandler struct {
+	auditLog *audit.Log
+}
+
+func NewAuditHandler(auditLog *audit.Log) *AuditHandler {
+	return &AuditHandler{auditLog: auditLog}
+}
+
+// ListOrg returns the org's audit events, newest first.
+func (h *AuditHandler) ListOrg(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	filter, details := parseAuditFilter(r.URL.Query())
+	if len(details) > 0 {
+		httputil.ValidationError(w, "Validation failed", details)
+		return
+	}
+	filter.OrganizationID = orgID
+	h.list(w, r, filter)
+}
+
+// List returns audit events across every org. The organizationId query
+// parameter narrows it to one.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, details := parseAuditFilter(q)
+	if v := q.Get("organizationId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			details["organizationId"] = "Must be a UUID"
+		}
+		filter.OrganizationID = id
+	}
+	if len(details) > 0 {
+		httputil.ValidationError(w, "Validation failed", details)
+		return
+	}
+	h.list(w, r, filter)
+}
+
+func (h *AuditHandler) list(w http.ResponseWriter, r *http.Request, filter audit.Filter) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	events, total, err := h.auditLog.List(r.Context(), filter, page, perPage)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"events":  events,
+		"total":   total,
+		"page":    page,
+		"perPage": perPage,
+	})
+}
+
+// ExportOrg downloads the org's audit events between since and until.
+func (h *AuditHandler) ExportOrg(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	filter, details := parseAuditFilter(r.URL.Query())
+	filter.OrganizationID = orgID
+	h.export(w, r, filter, details)
+}
+
+// Export downloads audit events across every org between since and until.
+// The organizationId query parameter narrows it to one.
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, details := parseAuditFilter(q)
+	if v := q.Get("organizationId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			details["organizationId"] = "Must be a UUID"
+		}
+		filter.OrganizationID = id
+	}
+	h.export(w, r, filter, details)
+}
+
+// export streams the events matching filter, oldest first, as NDJSON or,
+// with format=csv, CSV. Both ends of the date range are required.
+func (h *AuditHandler) export(w http.ResponseWriter, r *http.Request, filter audit.Filter, details map[string]string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = audit.FormatNDJSON
+	}
+	if format != audit.FormatNDJSON && format != audit.FormatCSV {
+		details["format"] = "Must be ndjson or csv"
+	}
+	if filter.Since.IsZero() {
+		details["since"] = "Required"
+	}
+	if filter.Until.IsZero() {
+		details["until"] = "Required"
+	} else if !filter.Until.After(filter.Since) {
+		details["until"] = "Must be after since"
+	}
+	if len(details) > 0 {
+		httputil.ValidationError(w, "Validation failed", details)
+		return
+	}
+
+	out := &responseStarted{w: w}
+	enc, err := audit.NewEncoder(format, out)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", audit.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-log-%s-%s.%s"`,
+		filter.Since.UTC().Format("20060102T150405Z"), filter.Until.UTC().Format("20060102T150405Z"), format))
+
+	if _, err := h.auditLog.Export(r.Context(), filter, enc); err != nil {
+		if !out.started {
+			w.Header().Del("Content-Disposition")
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+			return
+		}
+		// The status is already sent; aborting the response tells the
+		// client the download is incomplete.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// responseStarted records whether any of the response body was written.
+type responseStarted struct {
+	w       io.Writer
+	started bool
+}
+
+func (s *responseStarted) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
+
+// VerifyOrg walks the org's audit chain and reports the first broken link.
+func (h *AuditHandler) VerifyOrg(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	h.verify(w, r, orgID)
+}
+
+// Verify walks one audit chain: the organizationId query parameter's, or
+// without it, the chain of events outside any organization.
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	chainID := audit.GlobalChain
+	if v := r.URL.Query().Get("organizationId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"organizationId": "Must be a UUID"})
+			return
+		}
+		chainID = id
+	}
+	h.verify(w, r, chainID)
+}
+
+func (h *AuditHandler) verify(w http.ResponseWriter, r *http.Request, chainID uuid.UUID) {
+	result, err := h.auditLog.Verify(r.Context(), chainID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	httputil.JSON(w, http.StatusOK, result)
+}
+
+// parseAuditFilter reads the filters shared by both listings: action,
+// actorId, targetType, targetId, and since and until as RFC 3339 times.
+func parseAuditFilter(q url.Values) (audit.Filter, map[string]string) {
+	filter := audit.Filter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("targetType"),
+	}
+	details := map[string]string{}
+	for name, dst := range map[string]*uuid.UUID{"actorId": &filter.ActorID, "targetId": &filter.TargetID} {
+		if v := q.Get(name); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				details[name] = "Must be a UUID"
+			}
+			*dst = id
+		}
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				details[name] = "Must be an RFC 3339 time"
+			}
+			*dst = t
+		}
+	}
+	return filter, details
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type DomainHandler struct {
+	domainService *services.DomainService
+}
+
+func NewDomainHandler(domainService *services.DomainService) *DomainHandler {
+	return &DomainHandler{domainService: domainService}
+}
+
+type addDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+type updateDomainRequest struct {
+	JoinPolicy string `json:"joinPolicy"`
+}
+
+type domainResponse struct {
+	ID         string  `json:"id"`
+	Domain     string  `json:"domain"`
+	Verified   bool    `json:"verified"`
+	VerifiedAt *string `json:"verifiedAt"`
+	JoinPolicy string  `json:"joinPolicy"`
+	// The TXT record to publish to verify the domain.
+	RecordName  string `json:"recordName"`
+	RecordValue string `json:"recordValue"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type joinRequestResponse struct {
+	ID            string  `json:"id"`
+	UserID        string  `json:"userId"`
+	Email         string  `json:"email"`
+	FirstName     string  `json:"firstName"`
+	LastName      string  `json:"lastName"`
+	EmailVerified bool    `json:"emailVerified"`
+	Status        string  `json:"status"`
+	DecidedAt     *string `json:"decidedAt"`
+	CreatedAt     string  `json:"createdAt"`
+}
+
+func (h *DomainHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	domains, err := h.domainService.List(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]domainResponse, len(domains))
+	for i, d := range domains {
+		resp[i] = newDomainResponse(d)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"domains": resp})
+}
+
+func (h *DomainHandler) Add(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req addDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	d, err := h.domainService.AddDomain(r.Context(), orgID, req.Domain)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDomain):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"domain": "Valid domain is required"})
+		case errors.Is(err, services.ErrPublicEmailDomain):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"domain": "Public email provider domains cannot be claimed"})
+		case errors.Is(err, services.ErrDomainExists):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Domain already added to this organization")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, newDomainResponse(d))
+}
+
+// Verify checks the domain's DNS TXT record.
+func (h *DomainHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	orgID, domainID, ok := parseDomainIDs(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.domainService.Verify(r.Context(), orgID, domainID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDomainNotFound):
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Domain not found")
+		case errors.Is(err, services.ErrDomainNotVerified):
+			httputil.Error(w, http.StatusUnprocessableEntity, "VERIFICATION_FAILED", "Verification TXT record not found")
+		case errors.Is(err, services.ErrDomainClaimed):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Domain is already verified by another organization")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newDomainResponse(d))
+}
+
+func (h *DomainHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, domainID, ok := parseDomainIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if !types.IsValidJoinPolicy(req.JoinPolicy) {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"joinPolicy": "Join policy must be 'off', 'auto_join' or 'request'"})
+		return
+	}
+
+	d, err := h.domainService.SetJoinPolicy(r.Context(), orgID, domainID, req.JoinPolicy)
+	if err != nil {
+		if errors.Is(err, services.ErrDomainNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Domain not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newDomainResponse(d))
+}
+
+func (h *DomainHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, domainID, ok := parseDomainIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.Delete(r.Context(), orgID, domainID); err != nil {
+		if errors.Is(err, services.ErrDomainNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Domain not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "domain removed"})
+}
+
+func (h *DomainHandler) ListJoinRequests(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	requests, err := h.domainService.ListJoinRequests(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]joinRequestResponse, len(requests))
+	for i, j := range requests {
+		resp[i] = newJoinRequestResponse(j)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"joinRequests": resp})
+}
+
+func (h *DomainHandler) ApproveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideJoinRequest(w, r, h.domainService.ApproveJoinRequest)
+}
+
+func (h *DomainHandler) RejectJoinRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideJoinRequest(w, r, h.domainService.RejectJoinRequest)
+}
+
+func (h *DomainHandler) decideJoinRequest(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, orgID, requestID, decidedBy uuid.UUID) (*types.JoinRequest, error)) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid join request ID")
+		return
+	}
+
+	j, err := decide(r.Context(), orgID, requestID, claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrJoinRequestNotFound):
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Join request not found")
+		case errors.Is(err, services.ErrJoinRequestDecided):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Join request has already been decided")
+		case errors.Is(err, services.ErrEmailNotVerified):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "User has not verified their email address yet")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newJoinRequestResponse(j))
+}
+
+func parseDomainIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	domainID, err := uuid.Parse(chi.URLParam(r, "domainID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid domain ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, domainID, true
+}
+
+func newDomainResponse(d *types.OrgDomain) domainResponse {
+	name, value := services.ChallengeRecord(d)
+	resp := domainResponse{
+		ID:          d.ID.String(),
+		Domain:      d.Domain,
+		Verified:    d.VerifiedAt != nil,
+		JoinPolicy:  d.JoinPolicy,
+		RecordName:  name,
+		RecordValue: value,
+		CreatedAt:   d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.VerifiedAt != nil {
+		v := d.VerifiedAt.Format(time.RFC3339)
+		resp.VerifiedAt = &v
+	}
+	return resp
+}
+
+func newJoinRequestResponse(j *types.JoinRequest) joinRequestResponse {
+	resp := joinRequestResponse{
+		ID:            j.ID.String(),
+		UserID:        j.UserID.String(),
+		Email:         j.Email,
+		FirstName:     j.FirstName,
+		LastName:      j.LastName,
+		EmailVerified: j.EmailVerified,
+		Status:        j.Status,
+		CreatedAt:     j.CreatedAt.Format(time.RFC3339),
+	}
+	if j.DecidedAt != nil {
+		v := j.DecidedAt.Format(time.RFC3339)
+		resp.DecidedAt = &v
+	}
+	return resp
+}
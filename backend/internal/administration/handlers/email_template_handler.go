@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/mailer"
+	"github.com/go-chi/chi/v5"
+)
+
+// EmailTemplateHandler lets superadmins see how transactional emails look.
+type EmailTemplateHandler struct {
+	templates *mailer.Templates
+}
+
+func NewEmailTemplateHandler(templates *mailer.Templates) *EmailTemplateHandler {
+	return &EmailTemplateHandler{templates: templates}
+}
+
+type emailTemplateResponse struct {
+	Name    string   `json:"name"`
+	Locales []string `json:"locales"`
+}
+
+type emailPreviewResponse struct {
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	HTML     string `json:"html"`
+}
+
+func (h *EmailTemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	names := h.templates.Names()
+	resp := make([]emailTemplateResponse, len(names))
+	for i, name := range names {
+		resp[i] = emailTemplateResponse{Name: name, Locales: mailer.Locales}
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"templates": resp})
+}
+
+// Preview renders a template with sample data. The locale, brandName,
+// brandColor and brandLogoUrl query parameters show it as an org would send
+// it. By default the rendered parts are returned as JSON; format=html or
+// format=text returns just that part, for viewing in a browser.
+func (h *EmailTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "html" && format != "text" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"format": "Must be json, html or text"})
+		return
+	}
+
+	sample, ok := h.templates.Sample(name)
+	if !ok {
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Email template not found")
+		return
+	}
+	locale := mailer.ResolveLocale(q.Get("locale"))
+	brand := mailer.Branding{
+		Name:         q.Get("brandName"),
+		PrimaryColor: q.Get("brandColor"),
+		LogoURL:      q.Get("brandLogoUrl"),
+	}
+	rendered, err := h.templates.Render(name, locale, brand, sample)
+	if err != nil {
+		if errors.Is(err, mailer.ErrUnknownTemplate) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Email template not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	switch format {
+	case "html":
+		// The preview is served from the API's origin; keep it inert.
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src https:; style-src 'unsafe-inline'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(rendered.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(rendered.Text))
+	default:
+		httputil.JSON(w, http.StatusOK, emailPreviewResponse{
+			Template: name,
+			Locale:   locale,
+			Subject:  rendered.Subject,
+			Text:     rendered.Text,
+			HTML:     rendered.HTML,
+		})
+	}
+}
@@ -3,11 +3,17 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
 	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	authservices "agenteur.ai/api/internal/auth/services"
 	"agenteur.ai/api/internal/httputil"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -16,15 +22,21 @@ import (
 type InvitationHandler struct {
 	invitationService *services.InvitationService
 	orgService        *services.OrgService
+	cookies           *authhandlers.AuthCookies
 }
 
-func NewInvitationHandler(invitationService *services.InvitationService, orgService *services.OrgService) *InvitationHandler {
+func NewInvitationHandler(invitationService *services.InvitationService, orgService *services.OrgService, cookies *authhandlers.AuthCookies) *InvitationHandler {
 	return &InvitationHandler{
 		invitationService: invitationService,
 		orgService:        orgService,
+		cookies:           cookies,
 	}
 }
 
+// maxImportBodyBytes bounds a bulk invitation request body, comfortably
+// above MaxInvitationImportRows rows of CSV.
+const maxImportBodyBytes = 2 << 20
+
 type createInvitationRequest struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
@@ -39,6 +51,20 @@ type invitationResponse struct {
 	CreatedAt string `json:"createdAt"`
 }
 
+type invitationListItemResponse struct {
+	invitationResponse
+	InvitedBy     string `json:"invitedBy"`
+	InvitedByName string `json:"invitedByName"`
+}
+
+// invitationSignupRequest holds the account fields for signing up through an
+// invitation. The email address is the invitation's.
+type invitationSignupRequest struct {
+	Password  string `json:"password"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
 type invitationDetailResponse struct {
 	OrganizationName string `json:"organizationName"`
 	Email            string `json:"email"`
@@ -71,14 +97,6 @@ func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
 		httputil.ValidationError(w, "Validation failed", map[string]string{"email": "Email is required"})
 		return
 	}
-	if req.Role == "" {
-		req.Role = "user"
-	}
-	if req.Role != "admin" && req.Role != "user" {
-		httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role must be 'admin' or 'user'"})
-		return
-	}
-
 	// Get org name and inviter name for the email
 	org, err := h.orgService.Get(r.Context(), orgID)
 	if err != nil {
@@ -86,8 +104,20 @@ func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inv, err := h.invitationService.Create(r.Context(), orgID, claims.UserID, req.Email, req.Role, claims.Email, org.Name)
+	inv, err := h.invitationService.Create(r.Context(), orgID, claims.UserID, req.Email, req.Role, claims.Email, org.Name, GetOrgPermissions(r.Context()))
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidRole) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role does not exist in this organization"})
+			return
+		}
+		if errors.Is(err, services.ErrInviteDomain) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"email": "Email domain is not allowed by organization settings"})
+			return
+		}
+		if errors.Is(err, services.ErrRoleEscalation) {
+			writeRoleError(w, err)
+			return
+		}
 		if errors.Is(err, services.ErrInvitationExists) {
 			httputil.Error(w, http.StatusConflict, "CONFLICT", "Invitation already pending for this email")
 			return
@@ -100,16 +130,145 @@ func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httputil.JSON(w, http.StatusCreated, invitationResponse{
+	httputil.JSON(w, http.StatusCreated, newInvitationResponse(inv))
+}
+
+func newInvitationResponse(inv *types.Invitation) invitationResponse {
+	return invitationResponse{
 		ID:        inv.ID.String(),
 		Email:     inv.Email,
 		Role:      inv.Role,
 		Status:    inv.Status,
 		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
 		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// List returns a page of the org's invitations. The status query parameter
+// takes a comma-separated list of statuses to include.
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	q := r.URL.Query()
+	params := types.ListInvitationsParams{Search: strings.TrimSpace(q.Get("search"))}
+	if raw := q.Get("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			status = strings.TrimSpace(status)
+			if !slices.Contains(types.InvitationStatuses, status) {
+				httputil.ValidationError(w, "Validation failed", map[string]string{
+					"status": "Must be one of " + strings.Join(types.InvitationStatuses, ", "),
+				})
+				return
+			}
+			params.Statuses = append(params.Statuses, status)
+		}
+	}
+	params.Page, _ = strconv.Atoi(q.Get("page"))
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	params.PerPage, _ = strconv.Atoi(q.Get("perPage"))
+	if params.PerPage < 1 || params.PerPage > 100 {
+		params.PerPage = 20
+	}
+
+	invitations, total, err := h.invitationService.List(r.Context(), orgID, params)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]invitationListItemResponse, len(invitations))
+	for i, inv := range invitations {
+		resp[i] = invitationListItemResponse{
+			invitationResponse: newInvitationResponse(&inv.Invitation),
+			InvitedBy:          inv.InvitedBy.String(),
+			InvitedByName:      inv.InvitedByName,
+		}
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"invitations": resp,
+		"total":       total,
+		"page":        params.Page,
+		"perPage":     params.PerPage,
 	})
 }
 
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	orgID, invitationID, ok := parseInvitationPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.invitationService.Revoke(r.Context(), orgID, invitationID); err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Resend emails an invitation again with a new link, invalidating the old one.
+func (h *InvitationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	orgID, invitationID, ok := parseInvitationPath(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.orgService.Get(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	claims := authhandlers.GetUserClaims(r.Context())
+	inv, err := h.invitationService.Resend(r.Context(), orgID, invitationID, claims.Email, org.Name, GetOrgPermissions(r.Context()))
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newInvitationResponse(inv))
+}
+
+func parseInvitationPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	invitationID, err := uuid.Parse(chi.URLParam(r, "invitationID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid invitation ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, invitationID, true
+}
+
+func writeInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvitationNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Invitation not found")
+	case errors.Is(err, services.ErrInvitationClosed):
+		httputil.Error(w, http.StatusConflict, "INVITATION_CLOSED", "Invitation has already been accepted or revoked")
+	case errors.Is(err, services.ErrInvitationExists):
+		httputil.Error(w, http.StatusConflict, "CONFLICT", "Another invitation is already pending for this email")
+	case errors.Is(err, services.ErrAlreadyMember):
+		httputil.Error(w, http.StatusConflict, "CONFLICT", "User is already a member")
+	case errors.Is(err, services.ErrInviteDomain):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"email": "Email domain is not allowed by organization settings"})
+	case errors.Is(err, services.ErrRoleEscalation):
+		writeRoleError(w, err)
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
+
 func (h *InvitationHandler) GetByToken(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
 	if token == "" {
@@ -136,6 +295,68 @@ func (h *InvitationHandler) GetByToken(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SignupAndAccept creates an account for the invited email, accepts the
+// invitation and signs the new user in. The token is the auth, so this route
+// is public.
+func (h *InvitationHandler) SignupAndAccept(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_TOKEN", "Token is required")
+		return
+	}
+
+	var req invitationSignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	errs := make(map[string]string)
+	if len(req.Password) < 8 {
+		errs["password"] = "Password must be at least 8 characters"
+	}
+	if req.FirstName == "" {
+		errs["firstName"] = "First name is required"
+	}
+	if len(errs) > 0 {
+		httputil.ValidationError(w, "Validation failed", errs)
+		return
+	}
+
+	signup, err := h.invitationService.SignupAndAccept(r.Context(), token, req.Password, req.FirstName, req.LastName)
+	if err != nil {
+		if errors.Is(err, services.ErrInvitationNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Invitation not found or expired")
+			return
+		}
+		if errors.Is(err, authservices.ErrEmailExists) {
+			httputil.Error(w, http.StatusConflict, "ACCOUNT_EXISTS", "An account already exists for this email; log in to accept the invitation")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	h.cookies.Set(w, signup.AccessToken, signup.RefreshToken)
+	httputil.JSON(w, http.StatusCreated, map[string]any{
+		"user": map[string]any{
+			"id":            signup.User.ID.String(),
+			"email":         signup.User.Email,
+			"firstName":     signup.User.FirstName,
+			"lastName":      signup.User.LastName,
+			"isSuperadmin":  signup.User.IsSuperadmin,
+			"emailVerified": signup.User.EmailVerified(),
+			"createdAt":     signup.User.CreatedAt.Format(time.RFC3339),
+		},
+		"membership": map[string]string{
+			"userId":         signup.Membership.UserID.String(),
+			"organizationId": signup.Membership.OrganizationID.String(),
+			"role":           signup.Membership.Role,
+			"joinedAt":       signup.Membership.CreatedAt.Format(time.RFC3339),
+		},
+	})
+}
+
 func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
 	claims := authhandlers.GetUserClaims(r.Context())
 	if claims == nil {
@@ -176,3 +397,118 @@ func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// createImportRequest carries a bulk invitation list, either as CSV text or
+// as JSON rows. Exactly one of the two must be set.
+type createImportRequest struct {
+	CSV  *string                       `json:"csv"`
+	Rows []types.InvitationImportInput `json:"rows"`
+}
+
+type importJobResponse struct {
+	ID          string                      `json:"id"`
+	Status      string                      `json:"status"`
+	Progress    types.ImportProgress        `json:"progress"`
+	Rows        []types.InvitationImportRow `json:"rows"`
+	Error       string                      `json:"error,omitempty"`
+	CreatedAt   string                      `json:"createdAt"`
+	CompletedAt *string                     `json:"completedAt"`
+}
+
+// CreateImport queues a bulk invitation import and returns the job, which
+// can be polled with GetImport while it runs.
+func (h *InvitationHandler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req createImportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBodyBytes)).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if (req.CSV == nil) == (req.Rows == nil) {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"csv": "Provide either csv or rows"})
+		return
+	}
+
+	inputs := req.Rows
+	if req.CSV != nil {
+		inputs, err = services.ParseInvitationCSV(*req.CSV)
+		if err != nil {
+			writeImportError(w, err)
+			return
+		}
+	}
+
+	org, err := h.orgService.Get(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	job, err := h.invitationService.CreateImport(r.Context(), orgID, claims.UserID, claims.Email, org.Name, inputs, GetOrgPermissions(r.Context()))
+	if err != nil {
+		writeImportError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusAccepted, newImportJobResponse(job))
+}
+
+func writeImportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCSV):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"csv": err.Error()})
+	case errors.Is(err, services.ErrImportEmpty):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"rows": "At least one row is required"})
+	case errors.Is(err, services.ErrImportTooLarge):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"rows": fmt.Sprintf("At most %d rows can be imported at once", types.MaxInvitationImportRows)})
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
+
+func (h *InvitationHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid import ID")
+		return
+	}
+
+	job, err := h.invitationService.GetImport(r.Context(), orgID, jobID)
+	if err != nil {
+		if errors.Is(err, services.ErrImportNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Import not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newImportJobResponse(job))
+}
+
+func newImportJobResponse(job *types.InvitationImportJob) importJobResponse {
+	resp := importJobResponse{
+		ID:        job.ID.String(),
+		Status:    job.Status,
+		Progress:  job.Progress(),
+		Rows:      job.Rows,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt.Format(time.RFC3339),
+	}
+	if job.CompletedAt != nil {
+		completedAt := job.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &completedAt
+	}
+	return resp
+}
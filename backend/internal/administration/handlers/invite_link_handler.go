@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type InviteLinkHandler struct {
+	linkService *services.InviteLinkService
+}
+
+func NewInviteLinkHandler(linkService *services.InviteLinkService) *InviteLinkHandler {
+	return &InviteLinkHandler{linkService: linkService}
+}
+
+type createInviteLinkRequest struct {
+	Role           string `json:"role"`
+	MaxUses        int    `json:"maxUses"`
+	ExpiresInHours int    `json:"expiresInHours"`
+	AllowedDomain  string `json:"allowedDomain"`
+}
+
+type inviteLinkResponse struct {
+	ID            string  `json:"id"`
+	Role          string  `json:"role"`
+	MaxUses       int     `json:"maxUses"`
+	UseCount      int     `json:"useCount"`
+	AllowedDomain *string `json:"allowedDomain"`
+	Active        bool    `json:"active"`
+	ExpiresAt     string  `json:"expiresAt"`
+	DisabledAt    *string `json:"disabledAt"`
+	CreatedBy     string  `json:"createdBy"`
+	CreatedAt     string  `json:"createdAt"`
+}
+
+// createdInviteLinkResponse includes the link URL, which is only ever
+// returned when the link is created.
+type createdInviteLinkResponse struct {
+	inviteLinkResponse
+	URL string `json:"url"`
+}
+
+type inviteLinkDetailResponse struct {
+	OrganizationName string  `json:"organizationName"`
+	Role             string  `json:"role"`
+	AllowedDomain    *string `json:"allowedDomain"`
+	ExpiresAt        string  `json:"expiresAt"`
+}
+
+type inviteLinkUseResponse struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	JoinedAt  string `json:"joinedAt"`
+}
+
+func (h *InviteLinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	links, err := h.linkService.List(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	now := time.Now()
+	resp := make([]inviteLinkResponse, len(links))
+	for i, l := range links {
+		resp[i] = newInviteLinkResponse(l, now)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"inviteLinks": resp})
+}
+
+func (h *InviteLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req createInviteLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	claims := authhandlers.GetUserClaims(r.Context())
+	link, url, err := h.linkService.Create(r.Context(), orgID, claims.UserID, services.CreateInviteLinkInput{
+		Role:           req.Role,
+		MaxUses:        req.MaxUses,
+		ExpiresInHours: req.ExpiresInHours,
+		AllowedDomain:  req.AllowedDomain,
+	}, GetOrgPermissions(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMaxUses):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"maxUses": err.Error()})
+		case errors.Is(err, services.ErrInvalidLinkExpiry):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"expiresInHours": err.Error()})
+		case errors.Is(err, services.ErrInvalidDomain):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"allowedDomain": "Invalid domain"})
+		case errors.Is(err, services.ErrInviteDomain):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"allowedDomain": "Domain is not allowed by organization settings"})
+		case errors.Is(err, services.ErrInvalidRole):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role does not exist in this organization"})
+		case errors.Is(err, services.ErrRoleEscalation):
+			writeRoleError(w, err)
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, createdInviteLinkResponse{
+		inviteLinkResponse: newInviteLinkResponse(link, time.Now()),
+		URL:                url,
+	})
+}
+
+func (h *InviteLinkHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	orgID, linkID, ok := parseInviteLinkPath(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.linkService.Disable(r.Context(), orgID, linkID)
+	if err != nil {
+		writeInviteLinkError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newInviteLinkResponse(link, time.Now()))
+}
+
+// ListUses returns who joined the org through a link.
+func (h *InviteLinkHandler) ListUses(w http.ResponseWriter, r *http.Request) {
+	orgID, linkID, ok := parseInviteLinkPath(w, r)
+	if !ok {
+		return
+	}
+
+	uses, err := h.linkService.ListUses(r.Context(), orgID, linkID)
+	if err != nil {
+		writeInviteLinkError(w, err)
+		return
+	}
+
+	resp := make([]inviteLinkUseResponse, len(uses))
+	for i, u := range uses {
+		resp[i] = inviteLinkUseResponse{
+			UserID:    u.UserID.String(),
+			Email:     u.Email,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			JoinedAt:  u.JoinedAt.Format(time.RFC3339),
+		}
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"uses": resp})
+}
+
+func (h *InviteLinkHandler) GetByToken(w http.ResponseWriter, r *http.Request) {
+	link, err := h.linkService.GetByToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeInviteLinkError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, inviteLinkDetailResponse{
+		OrganizationName: link.OrganizationName,
+		Role:             link.Role,
+		AllowedDomain:    link.AllowedDomain,
+		ExpiresAt:        link.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *InviteLinkHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	membership, err := h.linkService.Accept(r.Context(), chi.URLParam(r, "token"), claims.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailNotVerified):
+			httputil.Error(w, http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Verify your email address to use this link")
+		case errors.Is(err, services.ErrInviteDomain):
+			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "Your email domain is not allowed to use this link")
+		case errors.Is(err, services.ErrAlreadyMember):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Already a member of this organization")
+		default:
+			writeInviteLinkError(w, err)
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"membership": map[string]string{
+			"userId":         membership.UserID.String(),
+			"organizationId": membership.OrganizationID.String(),
+			"role":           membership.Role,
+			"joinedAt":       membership.CreatedAt.Format(time.RFC3339),
+		},
+	})
+}
+
+func newInviteLinkResponse(l *types.InviteLink, now time.Time) inviteLinkResponse {
+	resp := inviteLinkResponse{
+		ID:            l.ID.String(),
+		Role:          l.Role,
+		MaxUses:       l.MaxUses,
+		UseCount:      l.UseCount,
+		AllowedDomain: l.AllowedDomain,
+		Active:        l.Usable(now),
+		ExpiresAt:     l.ExpiresAt.Format(time.RFC3339),
+		CreatedBy:     l.CreatedBy.String(),
+		CreatedAt:     l.CreatedAt.Format(time.RFC3339),
+	}
+	if l.DisabledAt != nil {
+		disabledAt := l.DisabledAt.Format(time.RFC3339)
+		resp.DisabledAt = &disabledAt
+	}
+	return resp
+}
+
+func parseInviteLinkPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	linkID, err := uuid.Parse(chi.URLParam(r, "linkID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid invite link ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, linkID, true
+}
+
+func writeInviteLinkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInviteLinkNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Invite link not found")
+	case errors.Is(err, services.ErrInviteLinkInactive):
+		httputil.Error(w, http.StatusGone, "INVITE_LINK_INACTIVE", "Invite link is disabled, expired or has been used up")
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
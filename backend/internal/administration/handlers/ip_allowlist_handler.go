@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type IPAllowlistHandler struct {
+	allowlistService *services.IPAllowlistService
+}
+
+func NewIPAllowlistHandler(allowlistService *services.IPAllowlistService) *IPAllowlistHandler {
+	return &IPAllowlistHandler{allowlistService: allowlistService}
+}
+
+type replaceIPAllowlistRequest struct {
+	Entries []types.IPAllowlistInput `json:"entries"`
+}
+
+type ipAllowlistEntryResponse struct {
+	ID          string  `json:"id"`
+	CIDR        string  `json:"cidr"`
+	Description string  `json:"description"`
+	CreatedBy   *string `json:"createdBy"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+type ipAllowlistResponse struct {
+	Entries []ipAllowlistEntryResponse `json:"entries"`
+	// CurrentIP is the caller's address as the server sees it, so clients
+	// can warn before saving a list that would exclude it.
+	CurrentIP string `json:"currentIp"`
+}
+
+func (h *IPAllowlistHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	entries, err := h.allowlistService.List(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newIPAllowlistResponse(r, entries))
+}
+
+// Replace sets the whole allowlist. An empty list allows every address.
+func (h *IPAllowlistHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req replaceIPAllowlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	claims := authhandlers.GetUserClaims(r.Context())
+	clientIP := middleware.GetClientIP(r.Context())
+	entries, err := h.allowlistService.Replace(r.Context(), orgID, claims.UserID, clientIP, req.Entries)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCIDR), errors.Is(err, services.ErrIPAllowlistTooLarge):
+			httputil.ValidationError(w, "Validation failed", map[string]string{"entries": err.Error()})
+		case errors.Is(err, services.ErrIPAllowlistLockout):
+			httputil.Error(w, http.StatusConflict, "SELF_LOCKOUT", "The allowlist must include your current IP address ("+clientIP.String()+")")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newIPAllowlistResponse(r, entries))
+}
+
+func newIPAllowlistResponse(r *http.Request, entries []*types.IPAllowlistEntry) ipAllowlistResponse {
+	resp := ipAllowlistResponse{
+		Entries:   make([]ipAllowlistEntryResponse, len(entries)),
+		CurrentIP: middleware.GetClientIP(r.Context()).String(),
+	}
+	for i, e := range entries {
+		resp.Entries[i] = ipAllowlistEntryResponse{
+			ID:          e.ID.String(),
+			CIDR:        e.CIDR.String(),
+			Description: e.Description,
+			CreatedBy:   uuidString(e.CreatedBy),
+			CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return resp
+}
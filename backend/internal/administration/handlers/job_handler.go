@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/jobs"
+)
+
+// JobHandler shows superadmins how the background job queue is doing.
+type JobHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{queue: queue}
+}
+
+// Stats returns job counts per kind and the size of this instance's worker
+// pool.
+func (h *JobHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	kinds, err := h.queue.Stats(r.Context())
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"kinds":   kinds,
+		"workers": h.queue.Workers(),
+	})
+}
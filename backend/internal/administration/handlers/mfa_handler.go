@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type MFAHandler struct {
+	mfaPolicyService *services.MFAPolicyService
+}
+
+func NewMFAHandler(mfaPolicyService *services.MFAPolicyService) *MFAHandler {
+	return &MFAHandler{mfaPolicyService: mfaPolicyService}
+}
+
+// ComplianceReport lists members who have not set up MFA and their
+// deadlines under the org's policy.
+func (h *MFAHandler) ComplianceReport(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	report, err := h.mfaPolicyService.ComplianceReport(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, report)
+}
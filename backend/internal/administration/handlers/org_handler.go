@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
 	authhandlers "agenteur.ai/api/internal/auth/handlers"
 	"agenteur.ai/api/internal/httputil"
 	"github.com/go-chi/chi/v5"
@@ -14,17 +15,31 @@ import (
 )
 
 type OrgHandler struct {
-	orgService *services.OrgService
+	orgService  *services.OrgService
+	roleService *services.RoleService
 }
 
-func NewOrgHandler(orgService *services.OrgService) *OrgHandler {
-	return &OrgHandler{orgService: orgService}
+func NewOrgHandler(orgService *services.OrgService, roleService *services.RoleService) *OrgHandler {
+	return &OrgHandler{orgService: orgService, roleService: roleService}
 }
 
 type createOrgRequest struct {
 	Name string `json:"name"`
 }
 
+type updateOrgRequest struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type updateMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type transferOwnershipRequest struct {
+	UserID string `json:"userId"`
+}
+
 type orgResponse struct {
 	ID        string `json:"id"`
 	Name      string `json:"name"`
@@ -32,6 +47,18 @@ type orgResponse struct {
 	CreatedAt string `json:"createdAt"`
 }
 
+type resolvedOrgResponse struct {
+	orgResponse
+	Redirected bool `json:"redirected"`
+}
+
+type membershipResponse struct {
+	UserID         string `json:"userId"`
+	OrganizationID string `json:"organizationId"`
+	Role           string `json:"role"`
+	JoinedAt       string `json:"joinedAt"`
+}
+
 type memberResponse struct {
 	UserID    string `json:"userId"`
 	Email     string `json:"email"`
@@ -125,6 +152,35 @@ func (h *OrgHandler) Get(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *OrgHandler) GetBySlug(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	slug := chi.URLParam(r, "slug")
+	org, redirected, err := h.orgService.ResolveSlug(r.Context(), claims.UserID, claims.IsSuperadmin, slug)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, resolvedOrgResponse{
+		orgResponse: orgResponse{
+			ID:        org.ID.String(),
+			Name:      org.Name,
+			Slug:      org.Slug,
+			CreatedAt: org.CreatedAt.Format(time.RFC3339),
+		},
+		Redirected: redirected,
+	})
+}
+
 func (h *OrgHandler) Update(w http.ResponseWriter, r *http.Request) {
 	orgIDStr := chi.URLParam(r, "orgID")
 	orgID, err := uuid.Parse(orgIDStr)
@@ -133,19 +189,93 @@ func (h *OrgHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req createOrgRequest
+	var req updateOrgRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
 		return
 	}
 
-	if req.Name == "" {
-		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name is required"})
+	if req.Name == "" && req.Slug == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name or slug is required"})
+		return
+	}
+
+	org, err := h.orgService.Update(r.Context(), orgID, types.UpdateOrgParams{Name: req.Name, Slug: req.Slug})
+	if err != nil {
+		if errors.Is(err, services.ErrSlugInvalid) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"slug": "Slug must be 3-48 lowercase letters, digits or single hyphens"})
+			return
+		}
+		if errors.Is(err, services.ErrSlugReserved) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"slug": "Slug is reserved"})
+			return
+		}
+		if errors.Is(err, services.ErrSlugTaken) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Slug is already in use")
+			return
+		}
+		if errors.Is(err, services.ErrNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, orgResponse{
+		ID:        org.ID.String(),
+		Name:      org.Name,
+		Slug:      org.Slug,
+		CreatedAt: org.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+func (h *OrgHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
 		return
 	}
 
-	org, err := h.orgService.Update(r.Context(), orgID, req.Name)
+	_, restoreBy, err := h.orgService.Delete(r.Context(), orgID)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{
+		"message":   "organization deleted",
+		"restoreBy": restoreBy.Format(time.RFC3339),
+	})
+}
+
+func (h *OrgHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	org, err := h.orgService.Restore(r.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+			return
+		}
+		if errors.Is(err, services.ErrOrgNotDeleted) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Organization is not deleted")
+			return
+		}
+		if errors.Is(err, services.ErrRestoreExpired) {
+			httputil.Error(w, http.StatusGone, "RESTORE_EXPIRED", "Organization can no longer be restored")
+			return
+		}
 		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
 		return
 	}
@@ -202,12 +332,16 @@ func (h *OrgHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.orgService.RemoveMember(r.Context(), orgID, userID)
+	err = h.orgService.RemoveMember(r.Context(), orgID, userID, GetOrgPermissions(r.Context()))
 	if err != nil {
 		if errors.Is(err, services.ErrLastAdmin) {
 			httputil.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Cannot remove the last admin")
 			return
 		}
+		if errors.Is(err, services.ErrMemberOutranks) {
+			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You cannot remove a member whose role has permissions you do not hold")
+			return
+		}
 		if errors.Is(err, services.ErrMemberNotFound) {
 			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Member not found")
 			return
@@ -218,3 +352,139 @@ func (h *OrgHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 
 	httputil.JSON(w, http.StatusOK, map[string]string{"message": "member removed"})
 }
+
+func (h *OrgHandler) Leave(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	err = h.orgService.Leave(r.Context(), orgID, claims.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			httputil.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "The last admin cannot leave; transfer ownership first")
+			return
+		}
+		if errors.Is(err, services.ErrMemberNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "You are not a member of this organization")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "left organization"})
+}
+
+func (h *OrgHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	userIDStr := chi.URLParam(r, "userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid user ID")
+		return
+	}
+
+	var req updateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	if req.Role == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role is required"})
+		return
+	}
+	if !resolveAssignableRole(w, r, h.roleService, orgID, req.Role) {
+		return
+	}
+
+	membership, err := h.orgService.UpdateMemberRole(r.Context(), orgID, userID, req.Role, GetOrgPermissions(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			httputil.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Cannot demote the last admin")
+			return
+		}
+		if errors.Is(err, services.ErrMemberOutranks) {
+			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You cannot change the role of a member whose role has permissions you do not hold")
+			return
+		}
+		if errors.Is(err, services.ErrMemberNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Member not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{"membership": newMembershipResponse(membership)})
+}
+
+func (h *OrgHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req transferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	targetID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"userId": "Valid user ID is required"})
+		return
+	}
+	if !resolveAssignableRole(w, r, h.roleService, orgID, types.RoleAdmin) {
+		return
+	}
+
+	membership, err := h.orgService.TransferOwnership(r.Context(), orgID, claims.UserID, targetID)
+	if err != nil {
+		if errors.Is(err, services.ErrTransferToSelf) {
+			httputil.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Cannot transfer ownership to yourself")
+			return
+		}
+		if errors.Is(err, services.ErrMemberNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Target user is not a member of this organization")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{"membership": newMembershipResponse(membership)})
+}
+
+func newMembershipResponse(m *types.OrgMembership) membershipResponse {
+	return membershipResponse{
+		UserID:         m.UserID.String(),
+		OrganizationID: m.OrganizationID.String(),
+		Role:           m.Role,
+		JoinedAt:       m.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/outbox"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// OutboxHandler lets superadmins inspect queued deliveries and replay dead
+// ones. Payloads are never returned: they can hold tokens.
+type OutboxHandler struct {
+	outbox *outbox.Outbox
+}
+
+func NewOutboxHandler(outbox *outbox.Outbox) *OutboxHandler {
+	return &OutboxHandler{outbox: outbox}
+}
+
+// List returns messages with the status query parameter, dead by default.
+func (h *OutboxHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := q.Get("status")
+	switch status {
+	case "":
+		status = outbox.StatusDead
+	case outbox.StatusPending, outbox.StatusDelivered, outbox.StatusDead:
+	default:
+		httputil.ValidationError(w, "Validation failed", map[string]string{
+			"status": "Must be one of pending, delivered, dead",
+		})
+		return
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	msgs, total, err := h.outbox.List(r.Context(), status, page, perPage)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"messages": msgs,
+		"total":    total,
+		"page":     page,
+		"perPage":  perPage,
+	})
+}
+
+// Replay queues a dead message for delivery again.
+func (h *OutboxHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "messageID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid message ID")
+		return
+	}
+
+	msg, err := h.outbox.Replay(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, outbox.ErrNotFound):
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Outbox message not found")
+		case errors.Is(err, outbox.ErrNotDead):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Only dead messages can be replayed")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+	httputil.JSON(w, http.StatusOK, msg)
+}
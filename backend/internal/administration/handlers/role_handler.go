@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+type createRoleRequest struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type updateRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type roleResponse struct {
+	ID          string   `json:"id"`
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Builtin     bool     `json:"builtin"`
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+type createGrantRequest struct {
+	TeamID     string `json:"teamId"`
+	UserID     string `json:"userId"`
+	Permission string `json:"permission"`
+}
+
+type grantResponse struct {
+	ID         string  `json:"id"`
+	TeamID     *string `json:"teamId"`
+	UserID     *string `json:"userId"`
+	Permission string  `json:"permission"`
+	CreatedAt  string  `json:"createdAt"`
+}
+
+// ListPermissions returns the permission catalogue.
+func (h *RoleHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, map[string]any{"permissions": types.PermissionCatalogue})
+}
+
+func (h *RoleHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	roles, err := h.roleService.List(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	result := make([]roleResponse, len(roles))
+	for i, role := range roles {
+		result[i] = newRoleResponse(role)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"roles": result})
+}
+
+func (h *RoleHandler) Create(w http.ResponseWriter, r *http.Request) {
+	orgIDStr := chi.URLParam(r, "orgID")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req createRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	req.Key = strings.TrimSpace(req.Key)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name is required"})
+		return
+	}
+
+	role, err := h.roleService.Create(r.Context(), orgID, types.CreateRoleParams{
+		Key:         req.Key,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}, GetOrgPermissions(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRoleKey) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"key": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrRoleKeyTaken) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Role key is already in use")
+			return
+		}
+		writeRoleError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, newRoleResponse(role))
+}
+
+func (h *RoleHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, roleID, ok := parseRoleIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name is required"})
+		return
+	}
+
+	role, err := h.roleService.Update(r.Context(), orgID, roleID, types.UpdateRoleParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}, GetOrgPermissions(r.Context()))
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newRoleResponse(role))
+}
+
+func (h *RoleHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, roleID, ok := parseRoleIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.Delete(r.Context(), orgID, roleID); err != nil {
+		if errors.Is(err, services.ErrRoleInUse) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Role is assigned to members, invitations or invite links")
+			return
+		}
+		writeRoleError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "role deleted"})
+}
+
+func (h *RoleHandler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	grants, err := h.roleService.ListGrants(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]grantResponse, len(grants))
+	for i, g := range grants {
+		resp[i] = newGrantResponse(g)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"grants": resp})
+}
+
+// CreateGrant grants a permission to a team or an individual member.
+func (h *RoleHandler) CreateGrant(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req createGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	params := types.CreateGrantParams{Permission: req.Permission}
+	errs := map[string]string{}
+	switch {
+	case req.TeamID != "" && req.UserID != "", req.TeamID == "" && req.UserID == "":
+		errs["teamId"] = "Exactly one of teamId or userId is required"
+	case req.TeamID != "":
+		id, err := uuid.Parse(req.TeamID)
+		if err != nil {
+			errs["teamId"] = "Invalid team ID"
+		}
+		params.TeamID = &id
+	default:
+		id, err := uuid.Parse(req.UserID)
+		if err != nil {
+			errs["userId"] = "Invalid user ID"
+		}
+		params.UserID = &id
+	}
+	if req.Permission == "" {
+		errs["permission"] = "Permission is required"
+	}
+	if len(errs) > 0 {
+		httputil.ValidationError(w, "Validation failed", errs)
+		return
+	}
+
+	grant, err := h.roleService.Grant(r.Context(), orgID, params, GetOrgPermissions(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrGrantExists) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Permission is already granted")
+			return
+		}
+		if errors.Is(err, services.ErrGrantTarget) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Team or member not found in this organization")
+			return
+		}
+		writeRoleError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, newGrantResponse(grant))
+}
+
+func (h *RoleHandler) DeleteGrant(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	grantID, err := uuid.Parse(chi.URLParam(r, "grantID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid grant ID")
+		return
+	}
+
+	if err := h.roleService.RevokeGrant(r.Context(), orgID, grantID); err != nil {
+		if errors.Is(err, services.ErrGrantNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Permission grant not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "permission grant revoked"})
+}
+
+func parseRoleIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	roleID, err := uuid.Parse(chi.URLParam(r, "roleID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid role ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, roleID, true
+}
+
+// writeRoleError maps role service errors shared by several endpoints.
+func writeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Role not found")
+	case errors.Is(err, services.ErrBuiltinRole):
+		httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "Built-in roles cannot be modified")
+	case errors.Is(err, services.ErrInvalidPermission):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"permissions": "Unknown permission"})
+	case errors.Is(err, services.ErrRoleEscalation):
+		httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You cannot grant permissions you do not hold")
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
+
+// resolveAssignableRole checks that key names a role the current member may
+// assign in the org, writing an error response and returning false otherwise.
+func resolveAssignableRole(w http.ResponseWriter, r *http.Request, roleService *services.RoleService, orgID uuid.UUID, key string) bool {
+	_, err := roleService.ResolveAssignable(r.Context(), orgID, key, GetOrgPermissions(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRole) {
+			httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role does not exist in this organization"})
+			return false
+		}
+		writeRoleError(w, err)
+		return false
+	}
+	return true
+}
+
+func newRoleResponse(role *types.Role) roleResponse {
+	perms := role.EffectivePermissions()
+	if perms == nil {
+		perms = []string{}
+	}
+	return roleResponse{
+		ID:          role.ID.String(),
+		Key:         role.Key,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: perms,
+		Builtin:     role.IsBuiltin(),
+		CreatedAt:   role.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   role.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func newGrantResponse(g *types.PermissionGrant) grantResponse {
+	resp := grantResponse{
+		ID:         g.ID.String(),
+		Permission: g.Permission,
+		CreatedAt:  g.CreatedAt.Format(time.RFC3339),
+	}
+	if g.TeamID != nil {
+		id := g.TeamID.String()
+		resp.TeamID = &id
+	}
+	if g.UserID != nil {
+		id := g.UserID.String()
+		resp.UserID = &id
+	}
+	return resp
+}
@@ -3,11 +3,14 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"time"
 
+	adminservices "agenteur.ai/api/internal/administration/services"
 	admintypes "agenteur.ai/api/internal/administration/types"
 	authhandlers "agenteur.ai/api/internal/auth/handlers"
 	authtypes "agenteur.ai/api/internal/auth/types"
 	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,27 +19,51 @@ import (
 type roleContextKey string
 
 const (
-	membershipKey roleContextKey = "org_membership"
+	membershipKey  roleContextKey = "org_membership"
+	permissionsKey roleContextKey = "org_permissions"
 )
 
 // RoleMiddleware provides org-level and superadmin authorization checks.
 type RoleMiddleware struct {
 	pool           *pgxpool.Pool
+	orgRepo        admintypes.OrganizationRepository
 	membershipRepo admintypes.MembershipRepository
 	userRepo       authtypes.UserRepository
+	roleService    *adminservices.RoleService
+	mfaPolicy      *adminservices.MFAPolicyService
+	ipAllowlist    *adminservices.IPAllowlistService
 }
 
-func NewRoleMiddleware(pool *pgxpool.Pool, membershipRepo admintypes.MembershipRepository, userRepo authtypes.UserRepository) *RoleMiddleware {
+func NewRoleMiddleware(pool *pgxpool.Pool, orgRepo admintypes.OrganizationRepository, membershipRepo admintypes.MembershipRepository, userRepo authtypes.UserRepository, roleService *adminservices.RoleService, mfaPolicy *adminservices.MFAPolicyService, ipAllowlist *adminservices.IPAllowlistService) *RoleMiddleware {
 	return &RoleMiddleware{
 		pool:           pool,
+		orgRepo:        orgRepo,
 		membershipRepo: membershipRepo,
 		userRepo:       userRepo,
+		roleService:    roleService,
+		mfaPolicy:      mfaPolicy,
+		ipAllowlist:    ipAllowlist,
 	}
 }
 
 // RequireOrgMember checks that the authenticated user is a member of the org
-// (from {orgID} URL param) or is a superadmin.
+// (from {orgID} URL param) or is a superadmin, and stores the membership and
+// its effective permissions in context. Soft-deleted orgs are treated as not
+// found. Members connecting from outside the org's IP allowlist are refused
+// with IP_NOT_ALLOWED. If the org requires MFA, members whose session was not
+// MFA-authenticated are refused with MFA_REQUIRED once their grace period
+// ends; until then the deadline is sent in the X-MFA-Required-By header.
 func (m *RoleMiddleware) RequireOrgMember(next http.Handler) http.Handler {
+	return m.requireMember(next, false)
+}
+
+// RequireDeletedOrgMember is RequireOrgMember for routes that operate on
+// soft-deleted orgs, such as restore.
+func (m *RoleMiddleware) RequireDeletedOrgMember(next http.Handler) http.Handler {
+	return m.requireMember(next, true)
+}
+
+func (m *RoleMiddleware) requireMember(next http.Handler, allowDeleted bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := authhandlers.GetUserClaims(r.Context())
 		if claims == nil {
@@ -58,11 +85,15 @@ func (m *RoleMiddleware) RequireOrgMember(next http.Handler) http.Handler {
 			return
 		}
 		if user.IsSuperadmin {
+			if !m.orgVisible(w, r, orgID, allowDeleted) {
+				return
+			}
 			ctx := context.WithValue(r.Context(), membershipKey, &admintypes.OrgMembership{
 				UserID:         claims.UserID,
 				OrganizationID: orgID,
-				Role:           "admin",
+				Role:           admintypes.RoleAdmin,
 			})
+			ctx = context.WithValue(ctx, permissionsKey, admintypes.NewPermissionSet(admintypes.AllPermissions()...))
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -76,26 +107,90 @@ func (m *RoleMiddleware) RequireOrgMember(next http.Handler) http.Handler {
 			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to perform this action")
 			return
 		}
+		if !m.orgVisible(w, r, orgID, allowDeleted) {
+			return
+		}
+		if !m.ipAllowed(w, r, orgID) {
+			return
+		}
+		if !claims.HasMFA() && !m.mfaSatisfied(w, r, membership) {
+			return
+		}
+
+		perms, err := m.roleService.PermissionsFor(r.Context(), membership)
+		if err != nil {
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+			return
+		}
 
 		ctx := context.WithValue(r.Context(), membershipKey, membership)
+		ctx = context.WithValue(ctx, permissionsKey, perms)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// RequireOrgAdmin checks that the user has the admin role in the org or is a superadmin.
-func (m *RoleMiddleware) RequireOrgAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		membership := GetOrgMembership(r.Context())
-		if membership == nil {
-			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to perform this action")
-			return
-		}
-		if membership.Role != "admin" {
-			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to perform this action")
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// orgVisible writes a 404 and returns false if the org doesn't exist, or is
+// soft-deleted and allowDeleted is false.
+func (m *RoleMiddleware) orgVisible(w http.ResponseWriter, r *http.Request, orgID uuid.UUID, allowDeleted bool) bool {
+	org, err := m.orgRepo.GetByID(r.Context(), m.pool, orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+	if org == nil || (org.DeletedAt != nil && !allowDeleted) {
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+		return false
+	}
+	return true
+}
+
+// ipAllowed writes a 403 and returns false if the client IP is outside the
+// org's allowlist.
+func (m *RoleMiddleware) ipAllowed(w http.ResponseWriter, r *http.Request, orgID uuid.UUID) bool {
+	allowed, err := m.ipAllowlist.Allows(r.Context(), orgID, middleware.GetClientIP(r.Context()))
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+	if !allowed {
+		httputil.Error(w, http.StatusForbidden, "IP_NOT_ALLOWED", "Your IP address is not allowed to access this organization")
+		return false
+	}
+	return true
+}
+
+// mfaSatisfied writes a 403 and returns false if the org requires MFA and the
+// member's grace period has ended. The caller has already checked that the
+// session lacks MFA.
+func (m *RoleMiddleware) mfaSatisfied(w http.ResponseWriter, r *http.Request, membership *admintypes.OrgMembership) bool {
+	deadline, err := m.mfaPolicy.Deadline(r.Context(), membership)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+	if deadline == nil {
+		return true
+	}
+	if time.Now().After(*deadline) {
+		httputil.Error(w, http.StatusForbidden, "MFA_REQUIRED", "This organization requires multi-factor authentication")
+		return false
+	}
+	w.Header().Set("X-MFA-Required-By", deadline.Format(time.RFC3339))
+	return true
+}
+
+// RequirePermission checks that the org membership set by RequireOrgMember
+// grants perm.
+func (m *RoleMiddleware) RequirePermission(perm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !GetOrgPermissions(r.Context()).Has(perm) {
+				httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to perform this action")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 // RequireSuperadmin checks that the user is a superadmin (from DB).
@@ -129,3 +224,13 @@ func GetOrgMembership(ctx context.Context) *admintypes.OrgMembership {
 	}
 	return m
 }
+
+// GetOrgPermissions retrieves the effective org permissions from context, set
+// by RequireOrgMember. Returns an empty set if none are present.
+func GetOrgPermissions(ctx context.Context) admintypes.PermissionSet {
+	p, ok := ctx.Value(permissionsKey).(admintypes.PermissionSet)
+	if !ok {
+		return admintypes.NewPermissionSet()
+	}
+	return p
+}
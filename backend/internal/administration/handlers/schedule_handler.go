@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agenteur.ai/api/internal/httputil"
+	"agenteur.ai/api/internal/scheduler"
+	"github.com/go-chi/chi/v5"
+)
+
+// ScheduleHandler lets superadmins see recurring schedules and their run
+// history, and run a schedule on demand.
+type ScheduleHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewScheduleHandler(scheduler *scheduler.Scheduler) *ScheduleHandler {
+	return &ScheduleHandler{scheduler: scheduler}
+}
+
+func (h *ScheduleHandler) List(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.scheduler.List(r.Context())
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"schedules": schedules})
+}
+
+func (h *ScheduleHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	runs, total, err := h.scheduler.History(r.Context(), chi.URLParam(r, "name"), page, perPage)
+	if err != nil {
+		if errors.Is(err, scheduler.ErrUnknownSchedule) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Schedule not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"runs":    runs,
+		"total":   total,
+		"page":    page,
+		"perPage": perPage,
+	})
+}
+
+// Run starts a schedule now. The run continues in the background; its
+// outcome shows up in the run history.
+func (h *ScheduleHandler) Run(w http.ResponseWriter, r *http.Request) {
+	run, err := h.scheduler.Trigger(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownSchedule):
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Schedule not found")
+		case errors.Is(err, scheduler.ErrRunning):
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Schedule is already running")
+		default:
+			httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+	httputil.JSON(w, http.StatusAccepted, run)
+}
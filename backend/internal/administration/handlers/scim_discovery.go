@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	scimSchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+type scimAttribute struct {
+	Name          string          `json:"name"`
+	Type          string          `json:"type"`
+	MultiValued   bool            `json:"multiValued"`
+	Description   string          `json:"description"`
+	Required      bool            `json:"required"`
+	CaseExact     bool            `json:"caseExact"`
+	Mutability    string          `json:"mutability"`
+	Returned      string          `json:"returned"`
+	Uniqueness    string          `json:"uniqueness"`
+	SubAttributes []scimAttribute `json:"subAttributes,omitempty"`
+}
+
+// scimAttr describes a single-valued, optional, case-insensitive attribute.
+func scimAttr(name, typ, mutability, description string) scimAttribute {
+	return scimAttribute{
+		Name:        name,
+		Type:        typ,
+		Description: description,
+		Mutability:  mutability,
+		Returned:    "default",
+		Uniqueness:  "none",
+	}
+}
+
+func scimMultiAttr(name, mutability, description string, sub ...scimAttribute) scimAttribute {
+	a := scimAttr(name, "complex", mutability, description)
+	a.MultiValued = true
+	a.SubAttributes = sub
+	return a
+}
+
+func scimUserAttributes() []scimAttribute {
+	userName := scimAttr("userName", "string", "immutable", "The user's email address. It identifies the account across organizations and cannot be changed.")
+	userName.Required = true
+	userName.Uniqueness = "server"
+
+	name := scimAttr("name", "complex", "readWrite", "The components of the user's name.")
+	name.SubAttributes = []scimAttribute{
+		scimAttr("givenName", "string", "readWrite", "Given name."),
+		scimAttr("familyName", "string", "readWrite", "Family name."),
+		scimAttr("formatted", "string", "readOnly", "Full name, derived from the given and family names."),
+	}
+
+	return []scimAttribute{
+		userName,
+		name,
+		scimAttr("displayName", "string", "readOnly", "Full name, derived from the given and family names."),
+		scimMultiAttr("emails", "readOnly", "The user's email address, mirroring userName.",
+			scimAttr("value", "string", "readOnly", "Email address."),
+			scimAttr("type", "string", "readOnly", "Always 'work'."),
+			scimAttr("primary", "boolean", "readOnly", "Always true."),
+		),
+		scimAttr("active", "boolean", "readWrite", "Whether the user is a member of the organization. Deactivating removes the membership and signs the user out."),
+		scimMultiAttr("roles", "readWrite", "The user's organization role. Only the primary value is used.",
+			scimAttr("value", "string", "readWrite", "Organization role key, e.g. 'admin' or 'user'."),
+			scimAttr("primary", "boolean", "readWrite", "Marks the role to assign."),
+		),
+	}
+}
+
+func scimGroupAttributes() []scimAttribute {
+	displayName := scimAttr("displayName", "string", "readWrite", "The team name, unique within the organization.")
+	displayName.Required = true
+	displayName.Uniqueness = "server"
+
+	return []scimAttribute{
+		displayName,
+		scimMultiAttr("members", "readWrite", "Team members. Members must belong to the organization.",
+			scimAttr("value", "string", "immutable", "The user's id."),
+			scimAttr("display", "string", "readOnly", "The user's email address."),
+			scimAttr("$ref", "reference", "readOnly", "The user's URI."),
+		),
+	}
+}
+
+func (h *SCIMHandler) schemas(orgID string) []map[string]any {
+	return []map[string]any{
+		{
+			"schemas":     []string{scimSchemaSchema},
+			"id":          scimSchemaUser,
+			"name":        "User",
+			"description": "Organization member",
+			"attributes":  scimUserAttributes(),
+			"meta": scimMeta{
+				ResourceType: "Schema",
+				Location:     h.orgURL(orgID) + "/Schemas/" + scimSchemaUser,
+			},
+		},
+		{
+			"schemas":     []string{scimSchemaSchema},
+			"id":          scimSchemaGroup,
+			"name":        "Group",
+			"description": "Organization team",
+			"attributes":  scimGroupAttributes(),
+			"meta": scimMeta{
+				ResourceType: "Schema",
+				Location:     h.orgURL(orgID) + "/Schemas/" + scimSchemaGroup,
+			},
+		},
+	}
+}
+
+func (h *SCIMHandler) resourceTypes(orgID string) []map[string]any {
+	return []map[string]any{
+		{
+			"schemas":     []string{scimSchemaResourceType},
+			"id":          "User",
+			"name":        "User",
+			"endpoint":    "/Users",
+			"description": "Organization member",
+			"schema":      scimSchemaUser,
+			"meta": scimMeta{
+				ResourceType: "ResourceType",
+				Location:     h.orgURL(orgID) + "/ResourceTypes/User",
+			},
+		},
+		{
+			"schemas":     []string{scimSchemaResourceType},
+			"id":          "Group",
+			"name":        "Group",
+			"endpoint":    "/Groups",
+			"description": "Organization team",
+			"schema":      scimSchemaGroup,
+			"meta": scimMeta{
+				ResourceType: "ResourceType",
+				Location:     h.orgURL(orgID) + "/ResourceTypes/Group",
+			},
+		},
+	}
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r).String()
+	writeSCIM(w, http.StatusOK, map[string]any{
+		"schemas":        []string{scimSchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Organization SCIM token sent in the Authorization header",
+			"primary":     true,
+		}},
+		"meta": scimMeta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     h.orgURL(orgID) + "/ServiceProviderConfig",
+		},
+	})
+}
+
+func (h *SCIMHandler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas := h.schemas(scimOrgID(r).String())
+	resources := make([]any, len(schemas))
+	for i, s := range schemas {
+		resources[i] = s
+	}
+	writeSCIM(w, http.StatusOK, newSCIMListResponse(resources, len(resources), 1))
+}
+
+func (h *SCIMHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	for _, s := range h.schemas(scimOrgID(r).String()) {
+		if s["id"] == id {
+			writeSCIM(w, http.StatusOK, s)
+			return
+		}
+	}
+	writeSCIMError(w, http.StatusNotFound, "", "Schema not found")
+}
+
+func (h *SCIMHandler) ListResourceTypes(w http.ResponseWriter, r *http.Request) {
+	resourceTypes := h.resourceTypes(scimOrgID(r).String())
+	resources := make([]any, len(resourceTypes))
+	for i, t := range resourceTypes {
+		resources[i] = t
+	}
+	writeSCIM(w, http.StatusOK, newSCIMListResponse(resources, len(resources), 1))
+}
+
+func (h *SCIMHandler) GetResourceType(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	for _, t := range h.resourceTypes(scimOrgID(r).String()) {
+		if t["id"] == id {
+			writeSCIM(w, http.StatusOK, t)
+			return
+		}
+	}
+	writeSCIMError(w, http.StatusNotFound, "", "Resource type not found")
+}
@@ -0,0 +1,664 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const scimContentType = "application/scim+json"
+
+const (
+	scimSchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Default and maximum page sizes for SCIM list requests.
+const (
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+// SCIMHandler serves the SCIM 2.0 provisioning API and the endpoints org
+// admins use to manage SCIM tokens.
+type SCIMHandler struct {
+	scimService *services.SCIMService
+	baseURL     string
+}
+
+// NewSCIMHandler creates a SCIMHandler. baseURL is the public URL of the
+// SCIM root, without the org ID, used for resource locations.
+func NewSCIMHandler(scimService *services.SCIMService, baseURL string) *SCIMHandler {
+	return &SCIMHandler{scimService: scimService, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+type createSCIMTokenRequest struct {
+	Description string `json:"description"`
+}
+
+type scimTokenResponse struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Token       string  `json:"token,omitempty"`
+	LastUsedAt  *string `json:"lastUsedAt"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+type scimName struct {
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+	Formatted  string `json:"formatted,omitempty"`
+}
+
+type scimMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location"`
+}
+
+type scimUserResource struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        scimName         `json:"name"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Emails      []scimMultiValue `json:"emails"`
+	Active      bool             `json:"active"`
+	Roles       []scimMultiValue `json:"roles,omitempty"`
+	Meta        scimMeta         `json:"meta"`
+}
+
+type scimGroupResource struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id"`
+	ExternalID  string           `json:"externalId,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []scimMultiValue `json:"members"`
+	Meta        scimMeta         `json:"meta"`
+}
+
+type scimListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimUserRequest struct {
+	UserName   string           `json:"userName"`
+	ExternalID string           `json:"externalId"`
+	Name       scimName         `json:"name"`
+	Emails     []scimMultiValue `json:"emails"`
+	Active     *bool            `json:"active"`
+	Roles      []scimMultiValue `json:"roles"`
+}
+
+type scimGroupRequest struct {
+	DisplayName string           `json:"displayName"`
+	ExternalID  string           `json:"externalId"`
+	Members     []scimMultiValue `json:"members"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string            `json:"schemas"`
+	Operations []types.SCIMPatchOp `json:"Operations"`
+}
+
+// Authenticate checks the org-scoped SCIM bearer token.
+func (h *SCIMHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+		if err != nil {
+			writeSCIMError(w, http.StatusNotFound, "", "Organization not found")
+			return
+		}
+
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(raw) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="SCIM"`)
+			writeSCIMError(w, http.StatusUnauthorized, "", "Bearer token required")
+			return
+		}
+
+		if _, err := h.scimService.Authenticate(r.Context(), orgID, strings.TrimSpace(raw)); err != nil {
+			if errors.Is(err, services.ErrSCIMUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="SCIM", error="invalid_token"`)
+				writeSCIMError(w, http.StatusUnauthorized, "", "Invalid SCIM token")
+				return
+			}
+			writeSCIMError(w, http.StatusInternalServerError, "", "Internal server error")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *SCIMHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	tokens, err := h.scimService.ListTokens(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]scimTokenResponse, len(tokens))
+	for i, t := range tokens {
+		resp[i] = newSCIMTokenResponse(t, "")
+	}
+	httputil.JSON(w, http.StatusOK, resp)
+}
+
+// CreateToken issues a SCIM token. The raw token is only returned here.
+func (h *SCIMHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req createSCIMTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if len(req.Description) > 200 {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"description": "Description must be at most 200 characters"})
+		return
+	}
+
+	token, raw, err := h.scimService.CreateToken(r.Context(), orgID, claims.UserID, req.Description, GetOrgPermissions(r.Context()))
+	if err != nil {
+		if errors.Is(err, services.ErrRoleEscalation) {
+			httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "SCIM tokens can only be created by members holding every permission")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, newSCIMTokenResponse(token, raw))
+}
+
+func (h *SCIMHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	tokenID, err := uuid.Parse(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid token ID")
+		return
+	}
+
+	if err := h.scimService.RevokeToken(r.Context(), orgID, tokenID); err != nil {
+		if errors.Is(err, services.ErrSCIMTokenNotFound) {
+			httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "SCIM token not found")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "SCIM token revoked"})
+}
+
+func (h *SCIMHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r).String()
+	params := parseSCIMListParams(r)
+
+	users, total, err := h.scimService.ListUsers(r.Context(), scimOrgID(r), params)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+
+	resources := make([]any, len(users))
+	for i, u := range users {
+		resources[i] = h.newUserResource(orgID, u)
+	}
+	writeSCIM(w, http.StatusOK, newSCIMListResponse(resources, total, params.StartIndex))
+}
+
+func (h *SCIMHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.scimService.GetUser(r.Context(), orgID, userID)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newUserResource(orgID.String(), u))
+}
+
+func (h *SCIMHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r)
+
+	desired, ok := decodeSCIMUser(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.scimService.CreateUser(r.Context(), orgID, desired)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+
+	res := h.newUserResource(orgID.String(), u)
+	w.Header().Set("Location", res.Meta.Location)
+	writeSCIM(w, http.StatusCreated, res)
+}
+
+func (h *SCIMHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	desired, ok := decodeSCIMUser(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.scimService.ReplaceUser(r.Context(), orgID, userID, desired)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newUserResource(orgID.String(), u))
+}
+
+func (h *SCIMHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	ops, ok := decodeSCIMPatch(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := h.scimService.PatchUser(r.Context(), orgID, userID, ops)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newUserResource(orgID.String(), u))
+}
+
+// DeleteUser deprovisions a user from the org. The user's account is kept.
+func (h *SCIMHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	orgID, userID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteUser(r.Context(), orgID, userID); err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r).String()
+	params := parseSCIMListParams(r)
+
+	groups, total, err := h.scimService.ListGroups(r.Context(), scimOrgID(r), params)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+
+	resources := make([]any, len(groups))
+	for i, g := range groups {
+		resources[i] = h.newGroupResource(orgID, g)
+	}
+	writeSCIM(w, http.StatusOK, newSCIMListResponse(resources, total, params.StartIndex))
+}
+
+func (h *SCIMHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	orgID, groupID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.scimService.GetGroup(r.Context(), orgID, groupID)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newGroupResource(orgID.String(), g))
+}
+
+func (h *SCIMHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	orgID := scimOrgID(r)
+
+	desired, ok := decodeSCIMGroup(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.scimService.CreateGroup(r.Context(), orgID, desired)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+
+	res := h.newGroupResource(orgID.String(), g)
+	w.Header().Set("Location", res.Meta.Location)
+	writeSCIM(w, http.StatusCreated, res)
+}
+
+func (h *SCIMHandler) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	orgID, groupID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	desired, ok := decodeSCIMGroup(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.scimService.ReplaceGroup(r.Context(), orgID, groupID, desired)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newGroupResource(orgID.String(), g))
+}
+
+func (h *SCIMHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	orgID, groupID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	ops, ok := decodeSCIMPatch(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.scimService.PatchGroup(r.Context(), orgID, groupID, ops)
+	if err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	writeSCIM(w, http.StatusOK, h.newGroupResource(orgID.String(), g))
+}
+
+func (h *SCIMHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	orgID, groupID, ok := parseSCIMResourceID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteGroup(r.Context(), orgID, groupID); err != nil {
+		writeSCIMServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) orgURL(orgID string) string {
+	return h.baseURL + "/" + orgID
+}
+
+func (h *SCIMHandler) newUserResource(orgID string, u *types.SCIMUser) scimUserResource {
+	displayName := strings.TrimSpace(u.GivenName + " " + u.FamilyName)
+	res := scimUserResource{
+		Schemas:     []string{scimSchemaUser},
+		ID:          u.ID.String(),
+		ExternalID:  u.ExternalID,
+		UserName:    u.UserName,
+		Name:        scimName{GivenName: u.GivenName, FamilyName: u.FamilyName, Formatted: displayName},
+		DisplayName: displayName,
+		Emails:      []scimMultiValue{{Value: u.UserName, Type: "work", Primary: true}},
+		Active:      u.Active,
+		Meta: scimMeta{
+			ResourceType: "User",
+			Created:      u.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: u.UpdatedAt.UTC().Format(time.RFC3339),
+			Location:     h.orgURL(orgID) + "/Users/" + u.ID.String(),
+		},
+	}
+	if u.Role != "" {
+		res.Roles = []scimMultiValue{{Value: u.Role, Primary: true}}
+	}
+	return res
+}
+
+func (h *SCIMHandler) newGroupResource(orgID string, g *types.SCIMGroup) scimGroupResource {
+	members := make([]scimMultiValue, len(g.Members))
+	for i, m := range g.Members {
+		members[i] = scimMultiValue{
+			Value:   m.UserID.String(),
+			Display: m.Display,
+			Ref:     h.orgURL(orgID) + "/Users/" + m.UserID.String(),
+		}
+	}
+	return scimGroupResource{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          g.ID.String(),
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Members:     members,
+		Meta: scimMeta{
+			ResourceType: "Group",
+			Created:      g.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: g.UpdatedAt.UTC().Format(time.RFC3339),
+			Location:     h.orgURL(orgID) + "/Groups/" + g.ID.String(),
+		},
+	}
+}
+
+func newSCIMListResponse(resources []any, total, startIndex int) scimListResponse {
+	return scimListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+func newSCIMTokenResponse(t *types.SCIMToken, raw string) scimTokenResponse {
+	resp := scimTokenResponse{
+		ID:          t.ID.String(),
+		Description: t.Description,
+		Token:       raw,
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+	}
+	if t.LastUsedAt != nil {
+		s := t.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &s
+	}
+	return resp
+}
+
+// parseSCIMListParams reads startIndex and count, clamping them to valid
+// values as RFC 7644 section 3.4.2.4 asks rather than rejecting them.
+func parseSCIMListParams(r *http.Request) services.SCIMListParams {
+	q := r.URL.Query()
+	params := services.SCIMListParams{
+		Filter:     q.Get("filter"),
+		StartIndex: 1,
+		Count:      scimDefaultCount,
+	}
+	if n, err := strconv.Atoi(q.Get("startIndex")); err == nil && n > 1 {
+		params.StartIndex = n
+	}
+	if n, err := strconv.Atoi(q.Get("count")); err == nil {
+		params.Count = min(max(n, 0), scimMaxCount)
+	}
+	return params
+}
+
+// scimOrgID returns the org ID of a SCIM request, already validated by
+// Authenticate.
+func scimOrgID(r *http.Request) uuid.UUID {
+	return uuid.MustParse(chi.URLParam(r, "orgID"))
+}
+
+// parseSCIMResourceID parses the org ID and the {id} of a User or Group.
+// Malformed IDs can't match a resource, so they are reported as not found.
+func parseSCIMResourceID(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID := scimOrgID(r)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeSCIMError(w, http.StatusNotFound, "", "Resource not found")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, id, true
+}
+
+func decodeSCIMUser(w http.ResponseWriter, r *http.Request) (types.SCIMUser, bool) {
+	var req scimUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Invalid JSON body")
+		return types.SCIMUser{}, false
+	}
+
+	userName := req.UserName
+	if userName == "" {
+		for _, e := range req.Emails {
+			if e.Primary || userName == "" {
+				userName = e.Value
+			}
+		}
+	}
+
+	u := types.SCIMUser{
+		UserName:   userName,
+		GivenName:  req.Name.GivenName,
+		FamilyName: req.Name.FamilyName,
+		ExternalID: req.ExternalID,
+		Active:     req.Active == nil || *req.Active,
+	}
+	for _, role := range req.Roles {
+		if role.Primary || u.Role == "" {
+			u.Role = role.Value
+		}
+	}
+	return u, true
+}
+
+func decodeSCIMGroup(w http.ResponseWriter, r *http.Request) (types.SCIMGroup, bool) {
+	var req scimGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Invalid JSON body")
+		return types.SCIMGroup{}, false
+	}
+
+	g := types.SCIMGroup{DisplayName: req.DisplayName, ExternalID: req.ExternalID}
+	for _, m := range req.Members {
+		id, err := uuid.Parse(m.Value)
+		if err != nil {
+			writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Group member values must be user IDs")
+			return types.SCIMGroup{}, false
+		}
+		g.Members = append(g.Members, types.SCIMGroupMember{UserID: id, Display: m.Display})
+	}
+	return g, true
+}
+
+func decodeSCIMPatch(w http.ResponseWriter, r *http.Request) ([]types.SCIMPatchOp, bool) {
+	var req scimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "Invalid JSON body")
+		return nil, false
+	}
+	if len(req.Operations) == 0 {
+		writeSCIMError(w, http.StatusBadRequest, "invalidSyntax", "PATCH request has no operations")
+		return nil, false
+	}
+	return req.Operations, true
+}
+
+func writeSCIM(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeSCIMError writes an error in the SCIM error schema (RFC 7644 section
+// 3.12). scimType may be empty.
+func writeSCIMError(w http.ResponseWriter, status int, scimType, detail string) {
+	body := map[string]any{
+		"schemas": []string{scimSchemaError},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	writeSCIM(w, status, body)
+}
+
+func writeSCIMServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFilter):
+		writeSCIMError(w, http.StatusBadRequest, "invalidFilter", "Invalid or unsupported filter")
+	case errors.Is(err, services.ErrSCIMUserNotFound), errors.Is(err, services.ErrTeamNotFound):
+		writeSCIMError(w, http.StatusNotFound, "", "Resource not found")
+	case errors.Is(err, services.ErrSCIMUserExists):
+		writeSCIMError(w, http.StatusConflict, "uniqueness", "User is already provisioned in this organization")
+	case errors.Is(err, services.ErrSCIMUserTaken):
+		writeSCIMError(w, http.StatusConflict, "uniqueness", "A user with this userName already exists")
+	case errors.Is(err, services.ErrTeamNameTaken):
+		writeSCIMError(w, http.StatusConflict, "uniqueness", "A group with this name already exists")
+	case errors.Is(err, services.ErrSCIMMutability):
+		writeSCIMError(w, http.StatusBadRequest, "mutability", "userName cannot be changed")
+	case errors.Is(err, services.ErrSCIMInvalidValue):
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Invalid attribute value")
+	case errors.Is(err, services.ErrMemberNotFound):
+		writeSCIMError(w, http.StatusBadRequest, "invalidValue", "Group members must be members of the organization")
+	case errors.Is(err, services.ErrSCIMInvalidPatch):
+		writeSCIMError(w, http.StatusBadRequest, "invalidPath", "Unsupported patch operation or path")
+	case errors.Is(err, services.ErrLastAdmin):
+		writeSCIMError(w, http.StatusConflict, "", "Cannot remove the last admin from the organization")
+	default:
+		writeSCIMError(w, http.StatusInternalServerError, "", "Internal server error")
+	}
+}
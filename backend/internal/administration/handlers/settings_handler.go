@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+}
+
+func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// updateSettingsRequest is a JSON merge patch of settings. Version, when set,
+// must match the current version or the update is rejected.
+type updateSettingsRequest struct {
+	Version  *int                       `json:"version"`
+	Settings map[string]json.RawMessage `json:"settings"`
+}
+
+type settingsResponse struct {
+	Version   int               `json:"version"`
+	Settings  types.OrgSettings `json:"settings"`
+	UpdatedBy *string           `json:"updatedBy"`
+	UpdatedAt *string           `json:"updatedAt"`
+}
+
+type settingsVersionResponse struct {
+	Version   int                        `json:"version"`
+	Settings  map[string]json.RawMessage `json:"settings"`
+	UpdatedBy *string                    `json:"updatedBy"`
+	UpdatedAt string                     `json:"updatedAt"`
+}
+
+func (h *SettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	settings, err := h.settingsService.Get(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newSettingsResponse(settings))
+}
+
+// Schema returns the definition of every org setting.
+func (h *SettingsHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, map[string]any{"schema": types.OrgSettingsSchema})
+}
+
+func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req updateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if len(req.Settings) == 0 {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"settings": "At least one setting is required"})
+		return
+	}
+
+	claims := authhandlers.GetUserClaims(r.Context())
+	settings, err := h.settingsService.Update(r.Context(), orgID, claims.UserID, req.Settings, req.Version)
+	if err != nil {
+		var validationErr *services.SettingsValidationError
+		if errors.As(err, &validationErr) {
+			httputil.ValidationError(w, "Validation failed", validationErr.Fields)
+			return
+		}
+		if errors.Is(err, services.ErrSettingsVersionConflict) {
+			httputil.Error(w, http.StatusConflict, "VERSION_CONFLICT", "Settings were changed by someone else; reload and try again")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newSettingsResponse(settings))
+}
+
+func (h *SettingsHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	versions, err := h.settingsService.ListVersions(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]settingsVersionResponse, len(versions))
+	for i, v := range versions {
+		values := v.Values
+		if values == nil {
+			values = map[string]json.RawMessage{}
+		}
+		resp[i] = settingsVersionResponse{
+			Version:   v.Version,
+			Settings:  values,
+			UpdatedBy: uuidString(v.UpdatedBy),
+			UpdatedAt: v.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"versions": resp})
+}
+
+func newSettingsResponse(s *types.VersionedOrgSettings) settingsResponse {
+	resp := settingsResponse{
+		Version:   s.Version,
+		Settings:  s.Settings,
+		UpdatedBy: uuidString(s.UpdatedBy),
+	}
+	if s.UpdatedAt != nil {
+		updatedAt := s.UpdatedAt.Format(time.RFC3339)
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
+
+func uuidString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const maxTeamNameLength = 100
+
+type TeamHandler struct {
+	teamService *services.TeamService
+}
+
+func NewTeamHandler(teamService *services.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: teamService}
+}
+
+type teamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type addTeamMemberRequest struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role"`
+}
+
+type updateTeamMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type teamResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MemberCount int    `json:"memberCount"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+}
+
+type teamMemberResponse struct {
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Role      string `json:"role"`
+	JoinedAt  string `json:"joinedAt"`
+}
+
+type teamMembershipResponse struct {
+	TeamID   string `json:"teamId"`
+	UserID   string `json:"userId"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joinedAt"`
+}
+
+type userTeamResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Role        string `json:"role"`
+	JoinedAt    string `json:"joinedAt"`
+}
+
+func (h *TeamHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	req, ok := decodeTeamRequest(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.Create(r.Context(), orgID, claims.UserID, types.CreateTeamParams{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, newTeamResponse(team))
+}
+
+func (h *TeamHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	teams, err := h.teamService.List(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]teamResponse, len(teams))
+	for i, t := range teams {
+		resp[i] = newTeamResponse(t)
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"teams": resp})
+}
+
+func (h *TeamHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.Get(r.Context(), orgID, teamID)
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newTeamResponse(team))
+}
+
+func (h *TeamHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok || !h.requireTeamManager(w, r, teamID) {
+		return
+	}
+
+	req, ok := decodeTeamRequest(w, r)
+	if !ok {
+		return
+	}
+
+	team, err := h.teamService.Update(r.Context(), orgID, teamID, types.UpdateTeamParams{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, newTeamResponse(team))
+}
+
+func (h *TeamHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok || !h.requireTeamManager(w, r, teamID) {
+		return
+	}
+
+	if err := h.teamService.Delete(r.Context(), orgID, teamID); err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "team deleted"})
+}
+
+func (h *TeamHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.teamService.ListMembers(r.Context(), orgID, teamID)
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	resp := make([]teamMemberResponse, len(members))
+	for i, m := range members {
+		resp[i] = teamMemberResponse{
+			UserID:    m.UserID.String(),
+			Email:     m.Email,
+			FirstName: m.FirstName,
+			LastName:  m.LastName,
+			Role:      m.Role,
+			JoinedAt:  m.JoinedAt.Format(time.RFC3339),
+		}
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"members": resp})
+}
+
+func (h *TeamHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok || !h.requireTeamManager(w, r, teamID) {
+		return
+	}
+
+	var req addTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	errs := map[string]string{}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		errs["userId"] = "Valid user ID is required"
+	}
+	if req.Role == "" {
+		req.Role = types.TeamRoleMember
+	}
+	if !types.IsValidTeamRole(req.Role) {
+		errs["role"] = "Role must be 'maintainer' or 'member'"
+	}
+	if len(errs) > 0 {
+		httputil.ValidationError(w, "Validation failed", errs)
+		return
+	}
+
+	membership, err := h.teamService.AddMember(r.Context(), orgID, teamID, userID, req.Role)
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusCreated, map[string]any{"membership": newTeamMembershipResponse(membership)})
+}
+
+func (h *TeamHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok || !h.requireTeamManager(w, r, teamID) {
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid user ID")
+		return
+	}
+
+	var req updateTeamMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if !types.IsValidTeamRole(req.Role) {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"role": "Role must be 'maintainer' or 'member'"})
+		return
+	}
+
+	membership, err := h.teamService.UpdateMemberRole(r.Context(), orgID, teamID, userID, req.Role)
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]any{"membership": newTeamMembershipResponse(membership)})
+}
+
+// RemoveMember removes a member from a team. Members may always remove
+// themselves; removing others requires managing the team.
+func (h *TeamHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgID, teamID, ok := parseTeamIDs(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid user ID")
+		return
+	}
+	if userID != claims.UserID && !h.requireTeamManager(w, r, teamID) {
+		return
+	}
+
+	if err := h.teamService.RemoveMember(r.Context(), orgID, teamID, userID); err != nil {
+		writeTeamError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "team member removed"})
+}
+
+// ListUserTeams lists the teams an org member belongs to.
+func (h *TeamHandler) ListUserTeams(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid user ID")
+		return
+	}
+
+	teams, err := h.teamService.ListUserTeams(r.Context(), orgID, userID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	resp := make([]userTeamResponse, len(teams))
+	for i, t := range teams {
+		resp[i] = userTeamResponse{
+			ID:          t.ID.String(),
+			Name:        t.Name,
+			Description: t.Description,
+			Role:        t.Role,
+			JoinedAt:    t.JoinedAt.Format(time.RFC3339),
+		}
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"teams": resp})
+}
+
+// requireTeamManager writes a 403 and returns false unless the caller holds
+// teams.manage or maintains the team.
+func (h *TeamHandler) requireTeamManager(w http.ResponseWriter, r *http.Request, teamID uuid.UUID) bool {
+	claims := authhandlers.GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return false
+	}
+
+	ok, err := h.teamService.CanManage(r.Context(), teamID, claims.UserID, GetOrgPermissions(r.Context()))
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+	if !ok {
+		httputil.Error(w, http.StatusForbidden, "FORBIDDEN", "You don't have permission to perform this action")
+		return false
+	}
+	return true
+}
+
+func decodeTeamRequest(w http.ResponseWriter, r *http.Request) (teamRequest, bool) {
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return req, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name is required"})
+		return req, false
+	}
+	if len(req.Name) > maxTeamNameLength {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"name": "Name must be at most 100 characters"})
+		return req, false
+	}
+	return req, true
+}
+
+func parseTeamIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	teamID, err := uuid.Parse(chi.URLParam(r, "teamID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid team ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, teamID, true
+}
+
+func writeTeamError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrTeamNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Team not found")
+	case errors.Is(err, services.ErrTeamMemberNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Team member not found")
+	case errors.Is(err, services.ErrMemberNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "User is not a member of this organization")
+	case errors.Is(err, services.ErrTeamNameTaken):
+		httputil.Error(w, http.StatusConflict, "CONFLICT", "A team with this name already exists")
+	case errors.Is(err, services.ErrAlreadyTeamMember):
+		httputil.Error(w, http.StatusConflict, "CONFLICT", "User is already a member of this team")
+	case errors.Is(err, services.ErrLastMaintainer):
+		httputil.Error(w, http.StatusBadRequest, "VALIDATION_ERROR", "Cannot remove or demote the last maintainer; delete the team instead")
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
+
+func newTeamResponse(t *types.Team) teamResponse {
+	return teamResponse{
+		ID:          t.ID.String(),
+		Name:        t.Name,
+		Description: t.Description,
+		MemberCount: t.MemberCount,
+		CreatedAt:   t.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   t.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func newTeamMembershipResponse(m *types.TeamMembership) teamMembershipResponse {
+	return teamMembershipResponse{
+		TeamID:   m.TeamID.String(),
+		UserID:   m.UserID.String(),
+		Role:     m.Role,
+		JoinedAt: m.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"agenteur.ai/api/internal/administration/services"
+	"agenteur.ai/api/internal/administration/types"
+	authhandlers "agenteur.ai/api/internal/auth/handlers"
+	"agenteur.ai/api/internal/httputil"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// EventTypes lists the event types endpoints can subscribe to.
+func (h *WebhookHandler) EventTypes(w http.ResponseWriter, r *http.Request) {
+	httputil.JSON(w, http.StatusOK, map[string]any{"eventTypes": types.WebhookEventTypes})
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	endpoints, err := h.webhookService.ListEndpoints(r.Context(), orgID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{"webhooks": endpoints})
+}
+
+// Create adds an endpoint. The response includes the signing secret, which
+// is not returned again.
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return
+	}
+
+	var req types.CreateWebhookEndpointParams
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	claims := authhandlers.GetUserClaims(r.Context())
+	created, err := h.webhookService.CreateEndpoint(r.Context(), orgID, claims.UserID, req)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusCreated, created)
+}
+
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, ok := parseWebhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(r.Context(), orgID, endpointID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, endpoint)
+}
+
+// Update changes an endpoint's URL, description, event types or enabled
+// state. Omitted fields are left unchanged.
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, ok := parseWebhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.UpdateWebhookEndpointParams
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(r.Context(), orgID, endpointID, req)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, endpoint)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, ok := parseWebhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(r.Context(), orgID, endpointID); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns a page of an endpoint's deliveries, newest first.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, ok := parseWebhookPath(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(r.Context(), orgID, endpointID, page, perPage)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, map[string]any{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"perPage":    perPage,
+	})
+}
+
+// GetDelivery returns a delivery with the request and response of each
+// attempt.
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, deliveryID, ok := parseWebhookDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), orgID, endpointID, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusOK, delivery)
+}
+
+// Redeliver queues a delivery to be sent again.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	orgID, endpointID, deliveryID, ok := parseWebhookDeliveryPath(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), orgID, endpointID, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httputil.JSON(w, http.StatusAccepted, delivery)
+}
+
+func parseWebhookPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orgID, err := uuid.Parse(chi.URLParam(r, "orgID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	endpointID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orgID, endpointID, true
+}
+
+func parseWebhookDeliveryPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	orgID, endpointID, ok := parseWebhookPath(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_ID", "Invalid delivery ID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return orgID, endpointID, deliveryID, true
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Webhook not found")
+	case errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "Webhook delivery not found")
+	case errors.Is(err, services.ErrWebhookDisabled):
+		httputil.Error(w, http.StatusConflict, "WEBHOOK_DISABLED", "Enable the webhook before redelivering")
+	case errors.Is(err, services.ErrTooManyWebhooks):
+		httputil.Error(w, http.StatusConflict, "TOO_MANY_WEBHOOKS", err.Error())
+	case errors.Is(err, services.ErrInvalidWebhookURL):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"url": err.Error()})
+	case errors.Is(err, services.ErrInvalidWebhookEvent):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"eventTypes": err.Error()})
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const domainColumns = `id, organization_id, domain, verification_token, verified_at, join_policy, created_at, updated_at`
+
+type pgxDomainRepository struct{}
+
+func NewDomainRepository() types.DomainRepository {
+	return &pgxDomainRepository{}
+}
+
+func scanDomain(row pgx.Row) (*types.OrgDomain, error) {
+	var d types.OrgDomain
+	err := row.Scan(&d.ID, &d.OrganizationID, &d.Domain, &d.VerificationToken, &d.VerifiedAt, &d.JoinPolicy, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *pgxDomainRepository) Create(ctx context.Context, db database.DBTX, orgID uuid.UUID, domain, verificationToken string) (*types.OrgDomain, error) {
+	d, err := scanDomain(db.QueryRow(ctx,
+		`INSERT INTO org_domains (organization_id, domain, verification_token)
+		 VALUES ($1, $2, $3)
+		 RETURNING `+domainColumns,
+		orgID, domain, verificationToken))
+	if err != nil {
+		return nil, fmt.Errorf("create org domain: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxDomainRepository) GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.OrgDomain, error) {
+	d, err := scanDomain(db.QueryRow(ctx,
+		`SELECT `+domainColumns+` FROM org_domains WHERE organization_id = $1 AND id = $2`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get org domain: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxDomainRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.OrgDomain, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+domainColumns+` FROM org_domains WHERE organization_id = $1 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list org domains: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*types.OrgDomain
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan org domain: %w", err)
+		}
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
+// GetVerifiedByDomain returns the verified claim on domain, ignoring orgs that
+// are soft-deleted.
+func (r *pgxDomainRepository) GetVerifiedByDomain(ctx context.Context, db database.DBTX, domain string) (*types.OrgDomain, error) {
+	d, err := scanDomain(db.QueryRow(ctx,
+		`SELECT d.id, d.organization_id, d.domain, d.verification_token, d.verified_at, d.join_policy, d.created_at, d.updated_at
+		 FROM org_domains d
+		 JOIN organizations o ON o.id = d.organization_id
+		 WHERE LOWER(d.domain) = LOWER($1) AND d.verified_at IS NOT NULL AND o.deleted_at IS NULL`, domain))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get verified domain: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxDomainRepository) MarkVerified(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.OrgDomain, error) {
+	d, err := scanDomain(db.QueryRow(ctx,
+		`UPDATE org_domains SET verified_at = NOW(), updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+domainColumns, id))
+	if err != nil {
+		return nil, fmt.Errorf("mark domain verified: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxDomainRepository) UpdateJoinPolicy(ctx context.Context, db database.DBTX, orgID, id uuid.UUID, policy string) (*types.OrgDomain, error) {
+	d, err := scanDomain(db.QueryRow(ctx,
+		`UPDATE org_domains SET join_policy = $3, updated_at = NOW()
+		 WHERE organization_id = $1 AND id = $2
+		 RETURNING `+domainColumns, orgID, id, policy))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update domain join policy: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxDomainRepository) Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM org_domains WHERE organization_id = $1 AND id = $2`, orgID, id)
+	if err != nil {
+		return false, fmt.Errorf("delete org domain: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authtypes "agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidDomain       = errors.New("invalid domain")
+	ErrPublicEmailDomain   = errors.New("public email provider domains cannot be claimed")
+	ErrDomainExists        = errors.New("domain already added to this organization")
+	ErrDomainNotFound      = errors.New("domain not found")
+	ErrDomainClaimed       = errors.New("domain is already verified by another organization")
+	ErrDomainNotVerified   = errors.New("domain verification record not found")
+	ErrJoinRequestNotFound = errors.New("join request not found")
+	ErrJoinRequestDecided  = errors.New("join request has already been decided")
+	ErrEmailNotVerified    = errors.New("user has not verified their email address")
+)
+
+const (
+	// domainChallengePrefix is prepended to the domain to form the TXT record
+	// name, e.g. _agenteur-challenge.acme.com.
+	domainChallengePrefix = "_agenteur-challenge."
+	// domainChallengeValue prefixes the token in the TXT record value.
+	domainChallengeValue = "agenteur-domain-verification="
+)
+
+var validDomain = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// publicEmailDomains are shared mailbox providers; verifying one would let an
+// org auto-join strangers.
+var publicEmailDomains = map[string]struct{}{
+	"gmail.com":      {},
+	"googlemail.com": {},
+	"outlook.com":    {},
+	"hotmail.com":    {},
+	"live.com":       {},
+	"yahoo.com":      {},
+	"icloud.com":     {},
+	"me.com":         {},
+	"aol.com":        {},
+	"proton.me":      {},
+	"protonmail.com": {},
+	"gmx.com":        {},
+}
+
+// DomainService manages verified email domains and implements the org join
+// policy applied by the auth service on signup and email verification.
+type DomainService struct {
+	pool            *pgxpool.Pool
+	domainRepo      types.DomainRepository
+	joinRequestRepo types.JoinRequestRepository
+	membershipRepo  types.MembershipRepository
+	eventBus        *events.Bus
+	auditLog        *audit.Log
+	resolver        types.TXTResolver
+}
+
+func NewDomainService(
+	pool *pgxpool.Pool,
+	domainRepo types.DomainRepository,
+	joinRequestRepo types.JoinRequestRepository,
+	membershipRepo types.MembershipRepository,
+	eventBus *events.Bus,
+	auditLog *audit.Log,
+	resolver types.TXTResolver,
+) *DomainService {
+	return &DomainService{
+		pool:            pool,
+		domainRepo:      domainRepo,
+		joinRequestRepo: joinRequestRepo,
+		membershipRepo:  membershipRepo,
+		eventBus:        eventBus,
+		auditLog:        auditLog,
+		resolver:        resolver,
+	}
+}
+
+// NormalizeDomain lowercases and validates a domain name.
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) > 253 || !validDomain.MatchString(domain) {
+		return "", ErrInvalidDomain
+	}
+	if _, ok := publicEmailDomains[domain]; ok {
+		return "", ErrPublicEmailDomain
+	}
+	return domain, nil
+}
+
+// ChallengeRecord returns the TXT record name and value that prove control of
+// a domain.
+func ChallengeRecord(d *types.OrgDomain) (name, value string) {
+	return domainChallengePrefix + d.Domain, domainChallengeValue + d.VerificationToken
+}
+
+// AddDomain claims a domain for an org. It stays unverified until the DNS
+// challenge passes.
+func (s *DomainService) AddDomain(ctx context.Context, orgID uuid.UUID, domain string) (*types.OrgDomain, error) {
+	domain, err := NormalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generate domain token: %w", err)
+	}
+
+	var d *types.OrgDomain
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		d, err = s.domainRepo.Create(ctx, tx, orgID, domain, hex.EncodeToString(b))
+		if err != nil {
+			return err
+		}
+		return s.recordDomain(ctx, tx, audit.ActionDomainAdded, nil, d)
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrDomainExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *DomainService) List(ctx context.Context, orgID uuid.UUID) ([]*types.OrgDomain, error) {
+	return s.domainRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+func (s *DomainService) Get(ctx context.Context, orgID, domainID uuid.UUID) (*types.OrgDomain, error) {
+	d, err := s.domainRepo.GetByID(ctx, s.pool, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDomainNotFound
+	}
+	return d, nil
+}
+
+// Verify checks the domain's DNS TXT challenge and marks it verified.
+func (s *DomainService) Verify(ctx context.Context, orgID, domainID uuid.UUID) (*types.OrgDomain, error) {
+	d, err := s.Get(ctx, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if d.VerifiedAt != nil {
+		return d, nil
+	}
+
+	if err := checkTXTChallenge(ctx, s.resolver, d); err != nil {
+		return nil, err
+	}
+
+	var verified *types.OrgDomain
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		verified, err = s.domainRepo.MarkVerified(ctx, tx, d.ID)
+		if err != nil {
+			return err
+		}
+		return s.recordDomain(ctx, tx, audit.ActionDomainVerified, d, verified)
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrDomainClaimed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return verified, nil
+}
+
+// SetJoinPolicy changes what happens when someone with an address at the
+// domain signs up or verifies their email.
+func (s *DomainService) SetJoinPolicy(ctx context.Context, orgID, domainID uuid.UUID, policy string) (*types.OrgDomain, error) {
+	existing, err := s.Get(ctx, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var d *types.OrgDomain
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		d, err = s.domainRepo.UpdateJoinPolicy(ctx, tx, orgID, domainID, policy)
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			return ErrDomainNotFound
+		}
+		return s.recordDomain(ctx, tx, audit.ActionDomainJoinPolicyUpdated, existing, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *DomainService) Delete(ctx context.Context, orgID, domainID uuid.UUID) error {
+	existing, err := s.Get(ctx, orgID, domainID)
+	if err != nil {
+		return err
+	}
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		deleted, err := s.domainRepo.Delete(ctx, tx, orgID, domainID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrDomainNotFound
+		}
+		return s.recordDomain(ctx, tx, audit.ActionDomainDeleted, existing, nil)
+	})
+}
+
+// recordDomain records a change to a domain; before or after is nil when the
+// domain is added or deleted.
+func (s *DomainService) recordDomain(ctx context.Context, tx pgx.Tx, action string, before, after *types.OrgDomain) error {
+	e := audit.Entry{Action: action, TargetType: audit.TargetDomain}
+	if before != nil {
+		e.OrganizationID, e.TargetID, e.Before = before.OrganizationID, before.ID, domainSnapshot(before)
+	}
+	if after != nil {
+		e.OrganizationID, e.TargetID, e.After = after.OrganizationID, after.ID, domainSnapshot(after)
+	}
+	return s.auditLog.Record(ctx, tx, e)
+}
+
+// ApplyJoinPolicy implements authtypes.OrgJoinPolicy. If the user's email
+// domain is verified by an org, they are added as a user (auto_join, once
+// their email is verified) or a pending join request is recorded (request).
+// It runs inside the caller's transaction.
+func (s *DomainService) ApplyJoinPolicy(ctx context.Context, tx database.DBTX, user *authtypes.User) error {
+	domain := emailDomain(user.Email)
+	if domain == "" {
+		return nil
+	}
+
+	d, err := s.domainRepo.GetVerifiedByDomain(ctx, tx, domain)
+	if err != nil {
+		return err
+	}
+	if d == nil || d.JoinPolicy == types.JoinPolicyOff {
+		return nil
+	}
+
+	existing, err := s.membershipRepo.GetByUserAndOrg(ctx, tx, user.ID, d.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	switch d.JoinPolicy {
+	case types.JoinPolicyAutoJoin:
+		// Anyone can sign up with any address; only a verified mailbox
+		// proves they belong to the domain.
+		if !user.EmailVerified() {
+			return nil
+		}
+		m, err := s.membershipRepo.Create(ctx, tx, user.ID, d.OrganizationID, types.RoleUser)
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.MemberJoined{OrgID: m.OrganizationID, UserID: m.UserID, Role: m.Role, Via: events.ViaDomain})
+	case types.JoinPolicyRequest:
+		return s.joinRequestRepo.CreatePending(ctx, tx, d.OrganizationID, user.ID)
+	}
+	return nil
+}
+
+func (s *DomainService) ListJoinRequests(ctx context.Context, orgID uuid.UUID) ([]*types.JoinRequest, error) {
+	return s.joinRequestRepo.ListPendingByOrg(ctx, s.pool, orgID)
+}
+
+// ApproveJoinRequest adds the requesting user to the org as a user. The user
+// must have verified their email address.
+func (s *DomainService) ApproveJoinRequest(ctx context.Context, orgID, requestID, decidedBy uuid.UUID) (*types.JoinRequest, error) {
+	var result *types.JoinRequest
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		req, err := s.pendingJoinRequest(ctx, tx, orgID, requestID)
+		if err != nil {
+			return err
+		}
+		if !req.EmailVerified {
+			return ErrEmailNotVerified
+		}
+
+		existing, err := s.membershipRepo.GetByUserAndOrg(ctx, tx, req.UserID, orgID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			m, err := s.membershipRepo.Create(ctx, tx, req.UserID, orgID, types.RoleUser)
+			if err != nil {
+				return err
+			}
+			if err := s.eventBus.Publish(ctx, tx, events.MemberJoined{OrgID: orgID, UserID: m.UserID, Role: m.Role, Via: events.ViaJoinRequest}); err != nil {
+				return err
+			}
+		}
+
+		result, err = s.joinRequestRepo.Decide(ctx, tx, req.ID, "approved", decidedBy)
+		if err != nil {
+			return err
+		}
+		return s.recordJoinRequest(ctx, tx, audit.ActionJoinRequestApproved, req, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *DomainService) RejectJoinRequest(ctx context.Context, orgID, requestID, decidedBy uuid.UUID) (*types.JoinRequest, error) {
+	var result *types.JoinRequest
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		req, err := s.pendingJoinRequest(ctx, tx, orgID, requestID)
+		if err != nil {
+			return err
+		}
+		result, err = s.joinRequestRepo.Decide(ctx, tx, req.ID, "rejected", decidedBy)
+		if err != nil {
+			return err
+		}
+		return s.recordJoinRequest(ctx, tx, audit.ActionJoinRequestRejected, req, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *DomainService) recordJoinRequest(ctx context.Context, tx pgx.Tx, action string, before, after *types.JoinRequest) error {
+	return s.auditLog.Record(ctx, tx, audit.Entry{
+		OrganizationID: after.OrganizationID,
+		Action:         action,
+		TargetType:     audit.TargetJoinRequest,
+		TargetID:       after.ID,
+		Before:         joinRequestAuditState{Status: before.Status},
+		After:          joinRequestAuditState{Status: after.Status},
+		Metadata:       map[string]any{"userId": after.UserID, "email": after.Email},
+	})
+}
+
+func (s *DomainService) pendingJoinRequest(ctx context.Context, tx pgx.Tx, orgID, requestID uuid.UUID) (*types.JoinRequest, error) {
+	req, err := s.joinRequestRepo.GetByIDForUpdate(ctx, tx, orgID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	if req.Status != "pending" {
+		return nil, ErrJoinRequestDecided
+	}
+	return req, nil
+}
+
+// checkTXTChallenge looks for the domain's challenge value among the TXT
+// records at its challenge name.
+func checkTXTChallenge(ctx context.Context, resolver types.TXTResolver, d *types.OrgDomain) error {
+	name, want := ChallengeRecord(d)
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDomainNotVerified, err)
+	}
+	for _, r := range records {
+		if strings.TrimSpace(r) == want {
+			return nil
+		}
+	}
+	return ErrDomainNotVerified
+}
+
+// emailDomain returns the lowercased domain part of an email address.
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// domainAuditState is the part of a domain recorded in audit events. The
+// verification token is left out.
+type domainAuditState struct {
+	Domain     string `json:"domain"`
+	Verified   bool   `json:"verified"`
+	JoinPolicy string `json:"joinPolicy"`
+}
+
+func domainSnapshot(d *types.OrgDomain) domainAuditState {
+	return domainAuditState{Domain: d.Domain, Verified: d.VerifiedAt != nil, JoinPolicy: d.JoinPolicy}
+}
+
+// joinRequestAuditState is the part of a join request recorded in audit
+// events.
+type joinRequestAuditState struct {
+	Status string `json:"status"`
+}
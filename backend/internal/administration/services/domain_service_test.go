@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/mailer"
+	"github.com/google/uuid"
+)
+
+type fakeResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (f *fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.records[name], nil
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"Acme.com", "acme.com", nil},
+		{" eu.acme.co.uk. ", "eu.acme.co.uk", nil},
+		{"acme", "", ErrInvalidDomain},
+		{"-acme.com", "", ErrInvalidDomain},
+		{"acme..com", "", ErrInvalidDomain},
+		{"user@acme.com", "", ErrInvalidDomain},
+		{"Gmail.com", "", ErrPublicEmailDomain},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeDomain(tt.in)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("NormalizeDomain(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTXTChallenge(t *testing.T) {
+	d := &types.OrgDomain{Domain: "acme.com", VerificationToken: "abc123"}
+	name, value := ChallengeRecord(d)
+	if name != "_agenteur-challenge.acme.com" {
+		t.Fatalf("unexpected challenge name %q", name)
+	}
+
+	ok := &fakeResolver{records: map[string][]string{name: {"v=spf1 -all", value}}}
+	if err := checkTXTChallenge(context.Background(), ok, d); err != nil {
+		t.Fatalf("expected challenge to pass, got %v", err)
+	}
+
+	wrong := &fakeResolver{records: map[string][]string{name: {"agenteur-domain-verification=other"}}}
+	if err := checkTXTChallenge(context.Background(), wrong, d); !errors.Is(err, ErrDomainNotVerified) {
+		t.Fatalf("expected ErrDomainNotVerified, got %v", err)
+	}
+
+	failing := &fakeResolver{err: errors.New("no such host")}
+	if err := checkTXTChallenge(context.Background(), failing, d); !errors.Is(err, ErrDomainNotVerified) {
+		t.Fatalf("expected ErrDomainNotVerified on lookup failure, got %v", err)
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	if got := emailDomain("Jane.Doe@Acme.COM"); got != "acme.com" {
+		t.Fatalf("got %q", got)
+	}
+	if got := emailDomain("not-an-email"); got != "" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+// captureSender records emails instead of sending them, keeping the last
+// verification link.
+type captureSender struct {
+	emails []mailer.Email
+	url    string
+}
+
+func (c *captureSender) Send(_ context.Context, _ database.DBTX, email mailer.Email) error {
+	c.emails = append(c.emails, email)
+	if data, ok := email.Data.(mailer.EmailVerificationData); ok {
+		c.url = data.VerifyURL
+	}
+	return nil
+}
+
+func TestSignupAutoJoinsVerifiedDomainAfterEmailVerification(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	orgSvc := newTestOrgService(pool)
+	owner := createTestUser(t, pool)
+	org, err := orgSvc.Create(ctx, owner.ID, "Domain Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	resolver := &fakeResolver{records: map[string][]string{}}
+	membershipRepo := NewMembershipRepository()
+	bus := newTestBus()
+	var joined []events.MemberJoined
+	events.Subscribe(bus, "record", func(_ context.Context, _ database.DBTX, e events.MemberJoined) error {
+		joined = append(joined, e)
+		return nil
+	})
+	domainSvc := NewDomainService(pool, NewDomainRepository(), NewJoinRequestRepository(), membershipRepo, bus, audit.New(pool), resolver)
+
+	domain := "d" + strings.ReplaceAll(uuid.NewString(), "-", "") + ".example.com"
+	d, err := domainSvc.AddDomain(ctx, org.ID, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, value := ChallengeRecord(d)
+	resolver.records[name] = []string{value}
+	if _, err := domainSvc.Verify(ctx, org.ID, d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := domainSvc.SetJoinPolicy(ctx, org.ID, d.ID, types.JoinPolicyAutoJoin); err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &captureSender{}
+	authSvc := authservices.NewAuthService(
+		pool, authservices.NewUserRepository(), authservices.NewRefreshTokenRepository(),
+		authservices.NewEmailVerificationRepository(), authservices.NewTOTPRepository(), sender, domainSvc, nil, newTestBus(),
+		"test-secret", time.Minute, time.Hour, 4, "http://verify", time.Hour,
+	)
+
+	user, _, _, err := authSvc.Signup(ctx, "new@"+domain, "password123", "New", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, user.ID)
+	})
+
+	m, err := membershipRepo.GetByUserAndOrg(ctx, pool, user.ID, org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != nil {
+		t.Fatal("unverified signup should not auto-join")
+	}
+
+	if _, err := authSvc.VerifyEmail(ctx, strings.TrimPrefix(sender.url, "http://verify/")); err != nil {
+		t.Fatal(err)
+	}
+	m, err = membershipRepo.GetByUserAndOrg(ctx, pool, user.ID, org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Role != types.RoleUser {
+		t.Fatalf("expected user membership after verification, got %+v", m)
+	}
+	if len(joined) != 1 || joined[0].UserID != user.ID || joined[0].Via != events.ViaDomain {
+		t.Errorf("MemberJoined events = %+v, want one via domain", joined)
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const grantColumns = `id, organization_id, team_id, user_id, permission, created_at`
+
+type pgxPermissionGrantRepository struct{}
+
+func NewPermissionGrantRepository() types.PermissionGrantRepository {
+	return &pgxPermissionGrantRepository{}
+}
+
+func (r *pgxPermissionGrantRepository) Create(ctx context.Context, db database.DBTX, params types.CreateGrantParams) (*types.PermissionGrant, error) {
+	var g types.PermissionGrant
+	err := db.QueryRow(ctx,
+		`INSERT INTO permission_grants (organization_id, team_id, user_id, permission)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING `+grantColumns,
+		params.OrganizationID, params.TeamID, params.UserID, params.Permission,
+	).Scan(&g.ID, &g.OrganizationID, &g.TeamID, &g.UserID, &g.Permission, &g.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create permission grant: %w", err)
+	}
+	return &g, nil
+}
+
+func (r *pgxPermissionGrantRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.PermissionGrant, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+grantColumns+` FROM permission_grants
+		 WHERE organization_id = $1
+		 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list permission grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []*types.PermissionGrant
+	for rows.Next() {
+		var g types.PermissionGrant
+		if err := rows.Scan(&g.ID, &g.OrganizationID, &g.TeamID, &g.UserID, &g.Permission, &g.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan permission grant: %w", err)
+		}
+		grants = append(grants, &g)
+	}
+	return grants, nil
+}
+
+func (r *pgxPermissionGrantRepository) Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.PermissionGrant, error) {
+	var g types.PermissionGrant
+	err := db.QueryRow(ctx,
+		`DELETE FROM permission_grants WHERE organization_id = $1 AND id = $2
+		 RETURNING `+grantColumns, orgID, id,
+	).Scan(&g.ID, &g.OrganizationID, &g.TeamID, &g.UserID, &g.Permission, &g.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("delete permission grant: %w", err)
+	}
+	return &g, nil
+}
+
+// ListPermissionsForUser returns the distinct permissions granted to a member
+// directly or through any of their teams in the org.
+func (r *pgxPermissionGrantRepository) ListPermissionsForUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) ([]string, error) {
+	rows, err := db.Query(ctx,
+		`SELECT DISTINCT g.permission
+		 FROM permission_grants g
+		 WHERE g.organization_id = $1
+		   AND (g.user_id = $2
+		        OR g.team_id IN (SELECT team_id FROM team_memberships
+		                         WHERE organization_id = $1 AND user_id = $2))`,
+		orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list granted permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var perms []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("scan granted permission: %w", err)
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// inviteImportBatchSize is how many rows are invited per transaction.
+	inviteImportBatchSize = 50
+	// inviteImportStaleAfter is how long a running import may go without
+	// progress before another worker takes it over.
+	inviteImportStaleAfter = 5 * time.Minute
+)
+
+var (
+	ErrImportEmpty     = errors.New("import has no rows")
+	ErrImportTooLarge  = fmt.Errorf("import has more than %d rows", types.MaxInvitationImportRows)
+	ErrInvalidCSV      = errors.New("invalid CSV")
+	ErrImportNotFound  = errors.New("invitation import not found")
+	errImportRowFailed = errors.New("import row failed")
+)
+
+// ParseInvitationCSV reads email and role pairs from CSV. If the first record
+// has an "email" column it is treated as a header and the email and role
+// columns are found by name; otherwise the first column is the email and the
+// optional second column the role.
+func ParseInvitationCSV(data string) ([]types.InvitationImportInput, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	emailCol, roleCol := 0, 1
+	var inputs []types.InvitationImportInput
+	first := true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		line, _ := r.FieldPos(0)
+
+		if first {
+			first = false
+			if header, ok := csvHeader(record); ok {
+				emailCol, roleCol = header[0], header[1]
+				continue
+			}
+		}
+
+		input := types.InvitationImportInput{Line: line}
+		if emailCol < len(record) {
+			input.Email = record[emailCol]
+		}
+		if roleCol >= 0 && roleCol < len(record) {
+			input.Role = record[roleCol]
+		}
+		inputs = append(inputs, input)
+		if len(inputs) > types.MaxInvitationImportRows {
+			return nil, ErrImportTooLarge
+		}
+	}
+	return inputs, nil
+}
+
+// csvHeader reports whether record is a header row and, if so, the indexes of
+// its email and role columns. The role index is -1 if there is no role column.
+func csvHeader(record []string) ([2]int, bool) {
+	cols := [2]int{-1, -1}
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "email":
+			cols[0] = i
+		case "role":
+			cols[1] = i
+		}
+	}
+	return cols, cols[0] >= 0
+}
+
+// CreateImport validates a bulk invitation list and queues it as an import
+// job. Rows are checked up front the same way Create checks a single invite;
+// rows that fail are recorded as invalid and the rest are invited by
+// ProcessImports.
+func (s *InvitationService) CreateImport(ctx context.Context, orgID, createdBy uuid.UUID, inviterName, orgName string, inputs []types.InvitationImportInput, actor types.PermissionSet) (*types.InvitationImportJob, error) {
+	if len(inputs) == 0 {
+		return nil, ErrImportEmpty
+	}
+	if len(inputs) > types.MaxInvitationImportRows {
+		return nil, ErrImportTooLarge
+	}
+
+	settings, err := s.settingsService.Effective(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get org settings: %w", err)
+	}
+
+	roleErrs := make(map[string]error)
+	resolveRole := func(role string) error {
+		if err, ok := roleErrs[role]; ok {
+			return err
+		}
+		_, err := s.roleService.ResolveAssignable(ctx, orgID, role, actor)
+		if err != nil && !errors.Is(err, ErrInvalidRole) && !errors.Is(err, ErrRoleEscalation) {
+			return err
+		}
+		roleErrs[role] = err
+		return err
+	}
+
+	rows, err := validateImportRows(inputs, settings, resolveRole)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.importRepo.Create(ctx, s.pool, orgID, createdBy, inviterName, orgName, rows)
+}
+
+// validateImportRows turns inputs into import rows, marking rows invalid when
+// the email is malformed, outside the org's allowed domains or repeated, or
+// the role cannot be assigned. resolveRole returns ErrInvalidRole or
+// ErrRoleEscalation for unassignable roles; any other error aborts.
+func validateImportRows(inputs []types.InvitationImportInput, settings types.OrgSettings, resolveRole func(role string) error) ([]types.InvitationImportRow, error) {
+	rows := make([]types.InvitationImportRow, len(inputs))
+	seen := make(map[string]int, len(inputs))
+	for i, in := range inputs {
+		line := in.Line
+		if line == 0 {
+			line = i + 1
+		}
+		row := types.InvitationImportRow{
+			Line:   line,
+			Email:  strings.ToLower(strings.TrimSpace(in.Email)),
+			Role:   strings.TrimSpace(in.Role),
+			Status: types.ImportRowPending,
+		}
+		if row.Role == "" {
+			row.Role = settings.DefaultInviteRole
+		}
+
+		if msg := importRowProblem(row, settings, seen); msg != "" {
+			row.Status = types.ImportRowInvalid
+			row.Message = msg
+		} else if err := resolveRole(row.Role); err != nil {
+			switch {
+			case errors.Is(err, ErrInvalidRole):
+				row.Status, row.Message = types.ImportRowInvalid, "Role does not exist in this organization"
+			case errors.Is(err, ErrRoleEscalation):
+				row.Status, row.Message = types.ImportRowInvalid, "You cannot assign a role with permissions you do not have"
+			default:
+				return nil, err
+			}
+		}
+		if row.Status == types.ImportRowPending {
+			seen[row.Email] = row.Line
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func importRowProblem(row types.InvitationImportRow, settings types.OrgSettings, seen map[string]int) string {
+	if row.Email == "" {
+		return "Email is required"
+	}
+	addr, err := mail.ParseAddress(row.Email)
+	if err != nil || addr.Address != row.Email {
+		return "Email is not a valid address"
+	}
+	if len(settings.AllowedInviteDomains) > 0 && !slices.Contains(settings.AllowedInviteDomains, emailDomain(row.Email)) {
+		return "Email domain is not allowed by organization settings"
+	}
+	if line, ok := seen[row.Email]; ok {
+		return fmt.Sprintf("Duplicate of line %d", line)
+	}
+	return ""
+}
+
+// GetImport returns an org's invitation import job.
+func (s *InvitationService) GetImport(ctx context.Context, orgID, jobID uuid.UUID) (*types.InvitationImportJob, error) {
+	job, err := s.importRepo.GetByID(ctx, s.pool, orgID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+// ProcessImports runs queued invitation imports until none are left and
+// returns how many it finished. Imports whose worker stopped mid-way are
+// picked up again once they go stale; rows already settled are not redone.
+func (s *InvitationService) ProcessImports(ctx context.Context) (int, error) {
+	n := 0
+	for {
+		job, err := s.importRepo.ClaimNext(ctx, s.pool, time.Now().Add(-inviteImportStaleAfter))
+		if err != nil {
+			return n, err
+		}
+		if job == nil {
+			return n, nil
+		}
+		if err := s.runImport(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				return n, err
+			}
+			if ferr := s.importRepo.Finish(ctx, s.pool, job.ID, types.ImportJobFailed, err.Error()); ferr != nil {
+				return n, ferr
+			}
+			return n, fmt.Errorf("run invitation import %s: %w", job.ID, err)
+		}
+		n++
+	}
+}
+
+func (s *InvitationService) runImport(ctx context.Context, job *types.InvitationImportJob) error {
+	settings, err := s.settingsService.Effective(ctx, s.pool, job.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("get org settings: %w", err)
+	}
+	expiresAt := time.Now().Add(s.inviteTTL(settings))
+
+	var pending []int
+	for i, row := range job.Rows {
+		if row.Status == types.ImportRowPending {
+			pending = append(pending, i)
+		}
+	}
+
+	for batch := range slices.Chunk(pending, inviteImportBatchSize) {
+		err := s.inviteImportBatch(ctx, job, batch, expiresAt)
+		if errors.Is(err, errImportRowFailed) {
+			// Retry the rows one by one so a single bad row doesn't fail the
+			// rest of its batch.
+			for _, i := range batch {
+				err := s.inviteImportBatch(ctx, job, []int{i}, expiresAt)
+				if errors.Is(err, errImportRowFailed) {
+					job.Rows[i].Status = types.ImportRowFailed
+					job.Rows[i].Message = "Invitation could not be created"
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.importRepo.UpdateRows(ctx, s.pool, job.ID, job.Rows); err != nil {
+			return err
+		}
+	}
+
+	return s.importRepo.Finish(ctx, s.pool, job.ID, types.ImportJobCompleted, "")
+}
+
+// inviteImportBatch invites the given rows of job in one transaction,
+// publishing InvitationSent for each, and records their outcomes. Row-level
+// database failures roll the batch back and are reported as
+// errImportRowFailed, leaving the rows pending.
+func (s *InvitationService) inviteImportBatch(ctx context.Context, job *types.InvitationImportJob, batch []int, expiresAt time.Time) error {
+	type outcome struct {
+		status string
+		id     *uuid.UUID
+	}
+	outcomes := make([]outcome, len(batch))
+
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		for n, i := range batch {
+			row := job.Rows[i]
+			err := s.checkInvitable(ctx, tx, job.OrganizationID, row.Email)
+			switch {
+			case errors.Is(err, ErrAlreadyMember):
+				outcomes[n] = outcome{status: types.ImportRowSkippedMember}
+				continue
+			case errors.Is(err, ErrInvitationExists):
+				outcomes[n] = outcome{status: types.ImportRowSkippedPending}
+				continue
+			case err != nil:
+				return fmt.Errorf("%w: %v", errImportRowFailed, err)
+			}
+
+			rawToken, tokenHash, err := authservices.GenerateRandomToken()
+			if err != nil {
+				return fmt.Errorf("generate token: %w", err)
+			}
+			inv, err := s.invitationRepo.Create(ctx, tx, types.CreateInvitationParams{
+				OrganizationID: job.OrganizationID,
+				InvitedBy:      job.CreatedBy,
+				Email:          row.Email,
+				TokenHash:      tokenHash,
+				Role:           row.Role,
+				ExpiresAt:      expiresAt,
+			})
+			if err != nil {
+				return fmt.Errorf("%w: %v", errImportRowFailed, err)
+			}
+			err = s.publishSent(ctx, tx, inv, job.InviterName, job.OrgName, rawToken)
+			if err != nil {
+				return fmt.Errorf("%w: %v", errImportRowFailed, err)
+			}
+			err = s.auditLog.Record(ctx, tx, audit.Entry{
+				OrganizationID: job.OrganizationID,
+				ActorID:        job.CreatedBy,
+				Action:         audit.ActionInvitationCreated,
+				TargetType:     audit.TargetInvitation,
+				TargetID:       inv.ID,
+				After:          invitationSnapshot(inv),
+				Metadata:       map[string]uuid.UUID{"importJobId": job.ID},
+			})
+			if err != nil {
+				return err
+			}
+			outcomes[n] = outcome{status: types.ImportRowCreated, id: &inv.ID}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for n, i := range batch {
+		job.Rows[i].Status = outcomes[n].status
+		job.Rows[i].InvitationID = outcomes[n].id
+		switch outcomes[n].status {
+		case types.ImportRowSkippedMember:
+			job.Rows[i].Message = "Already a member"
+		case types.ImportRowSkippedPending:
+			job.Rows[i].Message = "Invitation already pending"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const invitationImportColumns = `id, organization_id, created_by, inviter_name, org_name, status, rows, error, created_at, updated_at, completed_at`
+
+type pgxInvitationImportRepository struct{}
+
+func NewInvitationImportRepository() types.InvitationImportRepository {
+	return &pgxInvitationImportRepository{}
+}
+
+func scanInvitationImportJob(row pgx.Row) (*types.InvitationImportJob, error) {
+	var j types.InvitationImportJob
+	err := row.Scan(&j.ID, &j.OrganizationID, &j.CreatedBy, &j.InviterName, &j.OrgName, &j.Status, &j.Rows, &j.Error,
+		&j.CreatedAt, &j.UpdatedAt, &j.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *pgxInvitationImportRepository) Create(ctx context.Context, db database.DBTX, orgID, createdBy uuid.UUID, inviterName, orgName string, rows []types.InvitationImportRow) (*types.InvitationImportJob, error) {
+	j, err := scanInvitationImportJob(db.QueryRow(ctx,
+		`INSERT INTO invitation_import_jobs (organization_id, created_by, inviter_name, org_name, rows)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+invitationImportColumns,
+		orgID, createdBy, inviterName, orgName, rows))
+	if err != nil {
+		return nil, fmt.Errorf("create invitation import job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *pgxInvitationImportRepository) GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.InvitationImportJob, error) {
+	j, err := scanInvitationImportJob(db.QueryRow(ctx,
+		`SELECT `+invitationImportColumns+` FROM invitation_import_jobs WHERE organization_id = $1 AND id = $2`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get invitation import job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *pgxInvitationImportRepository) ClaimNext(ctx context.Context, db database.DBTX, staleBefore time.Time) (*types.InvitationImportJob, error) {
+	j, err := scanInvitationImportJob(db.QueryRow(ctx,
+		`UPDATE invitation_import_jobs SET status = 'running', updated_at = NOW()
+		 WHERE id = (
+		     SELECT id FROM invitation_import_jobs
+		     WHERE status = 'pending' OR (status = 'running' AND updated_at < $1)
+		     ORDER BY created_at
+		     LIMIT 1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+invitationImportColumns, staleBefore))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim invitation import job: %w", err)
+	}
+	return j, nil
+}
+
+// UpdateRows saves row outcomes and refreshes updated_at, which marks the
+// job as still being worked on.
+func (r *pgxInvitationImportRepository) UpdateRows(ctx context.Context, db database.DBTX, id uuid.UUID, rows []types.InvitationImportRow) error {
+	_, err := db.Exec(ctx,
+		`UPDATE invitation_import_jobs SET rows = $2, updated_at = NOW() WHERE id = $1`, id, rows)
+	if err != nil {
+		return fmt.Errorf("update invitation import rows: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxInvitationImportRepository) Finish(ctx context.Context, db database.DBTX, id uuid.UUID, status, errMsg string) error {
+	_, err := db.Exec(ctx,
+		`UPDATE invitation_import_jobs
+		 SET status = $2, error = $3, updated_at = NOW(), completed_at = NOW()
+		 WHERE id = $1`, id, status, errMsg)
+	if err != nil {
+		return fmt.Errorf("finish invitation import job: %w", err)
+	}
+	return nil
+}
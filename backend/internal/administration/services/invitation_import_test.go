@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+)
+
+func TestParseInvitationCSV(t *testing.T) {
+	inputs, err := ParseInvitationCSV("Role,Email\nadmin, a@acme.com\n\n,b@acme.com\n")
+	if err != nil {
+		t.Fatalf("ParseInvitationCSV: %v", err)
+	}
+	want := []types.InvitationImportInput{
+		{Line: 2, Email: "a@acme.com", Role: "admin"},
+		{Line: 4, Email: "b@acme.com", Role: ""},
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("inputs = %+v, want %+v", inputs, want)
+	}
+	for i := range want {
+		if inputs[i] != want[i] {
+			t.Errorf("inputs[%d] = %+v, want %+v", i, inputs[i], want[i])
+		}
+	}
+
+	inputs, err = ParseInvitationCSV("a@acme.com,admin\nb@acme.com\n")
+	if err != nil {
+		t.Fatalf("ParseInvitationCSV without header: %v", err)
+	}
+	if len(inputs) != 2 || inputs[0].Role != "admin" || inputs[1].Email != "b@acme.com" || inputs[1].Line != 2 {
+		t.Errorf("inputs = %+v", inputs)
+	}
+
+	if _, err := ParseInvitationCSV("email\n\"unterminated\n"); !errors.Is(err, ErrInvalidCSV) {
+		t.Errorf("err = %v, want ErrInvalidCSV", err)
+	}
+
+	big := strings.Repeat("x@acme.com\n", types.MaxInvitationImportRows+1)
+	if _, err := ParseInvitationCSV(big); !errors.Is(err, ErrImportTooLarge) {
+		t.Errorf("err = %v, want ErrImportTooLarge", err)
+	}
+}
+
+func TestValidateImportRows(t *testing.T) {
+	settings := types.DefaultOrgSettings()
+	settings.AllowedInviteDomains = []string{"acme.com"}
+	resolveRole := func(role string) error {
+		switch role {
+		case "owner":
+			return ErrRoleEscalation
+		case "ghost":
+			return ErrInvalidRole
+		}
+		return nil
+	}
+
+	rows, err := validateImportRows([]types.InvitationImportInput{
+		{Email: " A@Acme.com "},
+		{Email: "a@acme.com", Role: "admin"},
+		{Email: "not-an-email"},
+		{Email: "c@other.com"},
+		{Email: "d@acme.com", Role: "owner"},
+		{Email: "e@acme.com", Role: "ghost"},
+		{Email: ""},
+	}, settings, resolveRole)
+	if err != nil {
+		t.Fatalf("validateImportRows: %v", err)
+	}
+
+	if rows[0].Status != types.ImportRowPending || rows[0].Email != "a@acme.com" || rows[0].Role != types.RoleUser || rows[0].Line != 1 {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[1].Status != types.ImportRowInvalid || rows[1].Message != "Duplicate of line 1" {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+	for i := 2; i < len(rows); i++ {
+		if rows[i].Status != types.ImportRowInvalid || rows[i].Message == "" {
+			t.Errorf("rows[%d] = %+v, want invalid", i, rows[i])
+		}
+	}
+
+	boom := errors.New("boom")
+	if _, err := validateImportRows([]types.InvitationImportInput{{Email: "a@acme.com"}}, settings, func(string) error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want role lookup error", err)
+	}
+}
+
+func TestImportJobProgress(t *testing.T) {
+	job := types.InvitationImportJob{Rows: []types.InvitationImportRow{
+		{Status: types.ImportRowCreated},
+		{Status: types.ImportRowSkippedMember},
+		{Status: types.ImportRowSkippedPending},
+		{Status: types.ImportRowInvalid},
+		{Status: types.ImportRowFailed},
+		{Status: types.ImportRowPending},
+	}}
+	want := types.ImportProgress{Total: 6, Processed: 5, Created: 1, Skipped: 2, Invalid: 1, Failed: 1}
+	if got := job.Progress(); got != want {
+		t.Errorf("Progress() = %+v, want %+v", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"agenteur.ai/api/internal/administration/types"
 	"agenteur.ai/api/internal/database"
@@ -19,9 +21,15 @@ func NewInvitationRepository() types.InvitationRepository {
 func (r *pgxInvitationRepository) Create(ctx context.Context, db database.DBTX, params types.CreateInvitationParams) (*types.Invitation, error) {
 	var inv types.Invitation
 	err := db.QueryRow(ctx,
-		`INSERT INTO invitations (organization_id, invited_by, email, token_hash, role, expires_at)
-		 VALUES ($1, $2, $3, $4, $5, $6)
-		 RETURNING id, organization_id, invited_by, email, token_hash, role, status, expires_at, created_at, updated_at`,
+		`WITH i AS (
+		     INSERT INTO invitations (organization_id, invited_by, email, token_hash, role_id, expires_at)
+		     VALUES ($1, $2, $3, $4,
+		             (SELECT id FROM org_roles WHERE key = $5 AND (organization_id IS NULL OR organization_id = $1)),
+		             $6)
+		     RETURNING id, organization_id, invited_by, email, token_hash, role_id, status, expires_at, created_at, updated_at
+		 )
+		 SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at
+		 FROM i JOIN org_roles r ON r.id = i.role_id`,
 		params.OrganizationID, params.InvitedBy, params.Email, params.TokenHash, params.Role, params.ExpiresAt,
 	).Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt)
 	if err != nil {
@@ -33,11 +41,12 @@ func (r *pgxInvitationRepository) Create(ctx context.Context, db database.DBTX,
 func (r *pgxInvitationRepository) GetByTokenHash(ctx context.Context, db database.DBTX, hash string) (*types.InvitationWithOrg, error) {
 	var inv types.InvitationWithOrg
 	err := db.QueryRow(ctx,
-		`SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, i.role, i.status, i.expires_at, i.created_at, i.updated_at,
+		`SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at,
 		        o.name, COALESCE(u.first_name || ' ' || u.last_name, u.email)
 		 FROM invitations i
 		 JOIN organizations o ON o.id = i.organization_id
 		 JOIN users u ON u.id = i.invited_by
+		 JOIN org_roles r ON r.id = i.role_id
 		 WHERE i.token_hash = $1`, hash,
 	).Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt,
 		&inv.OrganizationName, &inv.InvitedByName)
@@ -53,9 +62,10 @@ func (r *pgxInvitationRepository) GetByTokenHash(ctx context.Context, db databas
 func (r *pgxInvitationRepository) GetPendingByEmailAndOrg(ctx context.Context, db database.DBTX, email string, orgID uuid.UUID) (*types.Invitation, error) {
 	var inv types.Invitation
 	err := db.QueryRow(ctx,
-		`SELECT id, organization_id, invited_by, email, token_hash, role, status, expires_at, created_at, updated_at
-		 FROM invitations
-		 WHERE LOWER(email) = LOWER($1) AND organization_id = $2 AND status = 'pending'`,
+		`SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at
+		 FROM invitations i
+		 JOIN org_roles r ON r.id = i.role_id
+		 WHERE LOWER(i.email) = LOWER($1) AND i.organization_id = $2 AND i.status = 'pending'`,
 		email, orgID,
 	).Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt)
 	if err != nil {
@@ -67,6 +77,99 @@ func (r *pgxInvitationRepository) GetPendingByEmailAndOrg(ctx context.Context, d
 	return &inv, nil
 }
 
+func (r *pgxInvitationRepository) List(ctx context.Context, db database.DBTX, orgID uuid.UUID, params types.ListInvitationsParams) ([]*types.InvitationWithOrg, int, error) {
+	where := `i.organization_id = $1`
+	args := []any{orgID}
+	if len(params.Statuses) > 0 {
+		args = append(args, params.Statuses)
+		where += fmt.Sprintf(` AND i.status = ANY($%d::invitation_status[])`, len(args))
+	}
+	if params.Search != "" {
+		args = append(args, "%"+strings.ToLower(params.Search)+"%")
+		where += fmt.Sprintf(` AND LOWER(i.email) LIKE $%d`, len(args))
+	}
+
+	var total int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM invitations i WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count invitations: %w", err)
+	}
+
+	args = append(args, params.PerPage, (params.Page-1)*params.PerPage)
+	rows, err := db.Query(ctx,
+		`SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at,
+		        o.name, COALESCE(u.first_name || ' ' || u.last_name, u.email)
+		 FROM invitations i
+		 JOIN organizations o ON o.id = i.organization_id
+		 JOIN users u ON u.id = i.invited_by
+		 JOIN org_roles r ON r.id = i.role_id
+		 WHERE `+where+fmt.Sprintf(` ORDER BY i.created_at DESC, i.id LIMIT $%d OFFSET $%d`, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []*types.InvitationWithOrg
+	for rows.Next() {
+		var inv types.InvitationWithOrg
+		if err := rows.Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt,
+			&inv.OrganizationName, &inv.InvitedByName); err != nil {
+			return nil, 0, fmt.Errorf("scan invitation: %w", err)
+		}
+		invitations = append(invitations, &inv)
+	}
+	return invitations, total, rows.Err()
+}
+
+// GetByIDForUpdate returns an org's invitation with its row locked. Must be
+// called inside a transaction.
+func (r *pgxInvitationRepository) GetByIDForUpdate(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.Invitation, error) {
+	var inv types.Invitation
+	err := db.QueryRow(ctx,
+		`SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at
+		 FROM invitations i
+		 JOIN org_roles r ON r.id = i.role_id
+		 WHERE i.organization_id = $1 AND i.id = $2
+		 FOR UPDATE OF i`,
+		orgID, id,
+	).Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+func (r *pgxInvitationRepository) Reissue(ctx context.Context, db database.DBTX, id uuid.UUID, tokenHash string, expiresAt time.Time) (*types.Invitation, error) {
+	var inv types.Invitation
+	err := db.QueryRow(ctx,
+		`WITH i AS (
+		     UPDATE invitations SET token_hash = $2, expires_at = $3, status = 'pending', updated_at = NOW()
+		     WHERE id = $1
+		     RETURNING id, organization_id, invited_by, email, token_hash, role_id, status, expires_at, created_at, updated_at
+		 )
+		 SELECT i.id, i.organization_id, i.invited_by, i.email, i.token_hash, r.key, i.status, i.expires_at, i.created_at, i.updated_at
+		 FROM i JOIN org_roles r ON r.id = i.role_id`,
+		id, tokenHash, expiresAt,
+	).Scan(&inv.ID, &inv.OrganizationID, &inv.InvitedBy, &inv.Email, &inv.TokenHash, &inv.Role, &inv.Status, &inv.ExpiresAt, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("reissue invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+func (r *pgxInvitationRepository) ExpirePending(ctx context.Context, db database.DBTX, now time.Time) (int64, error) {
+	tag, err := db.Exec(ctx,
+		`UPDATE invitations SET status = 'expired', updated_at = NOW()
+		 WHERE status = 'pending' AND expires_at < $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("expire invitations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
 func (r *pgxInvitationRepository) UpdateStatus(ctx context.Context, db database.DBTX, id uuid.UUID, status string) error {
 	_, err := db.Exec(ctx,
 		`UPDATE invitations SET status = $2, updated_at = NOW() WHERE id = $1`,
@@ -76,3 +179,14 @@ func (r *pgxInvitationRepository) UpdateStatus(ctx context.Context, db database.
 	}
 	return nil
 }
+
+// RevokePendingByOrg revokes every pending invitation for an org.
+func (r *pgxInvitationRepository) RevokePendingByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) (int64, error) {
+	tag, err := db.Exec(ctx,
+		`UPDATE invitations SET status = 'revoked', updated_at = NOW()
+		 WHERE organization_id = $1 AND status = 'pending'`, orgID)
+	if err != nil {
+		return 0, fmt.Errorf("revoke pending invitations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
 	authservices "agenteur.ai/api/internal/auth/services"
 	authtypes "agenteur.ai/api/internal/auth/types"
 	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -21,62 +24,77 @@ var (
 	ErrAlreadyMember      = errors.New("user is already a member")
 	ErrInvitationNotFound = errors.New("invitation not found or expired")
 	ErrEmailMismatch      = errors.New("email does not match invitation")
+	ErrInviteDomain       = errors.New("email domain is not allowed by organization settings")
+	ErrInvitationClosed   = errors.New("invitation has already been accepted or revoked")
 )
 
 type InvitationService struct {
-	pool           *pgxpool.Pool
-	invitationRepo types.InvitationRepository
-	membershipRepo types.MembershipRepository
-	emailService   types.EmailService
-	userRepo       authtypes.UserRepository
-	inviteBaseURL  string
-	inviteTokenTTL time.Duration
+	pool            *pgxpool.Pool
+	invitationRepo  types.InvitationRepository
+	importRepo      types.InvitationImportRepository
+	membershipRepo  types.MembershipRepository
+	eventBus        events.Publisher
+	userRepo        authtypes.UserRepository
+	authService     *authservices.AuthService
+	roleService     *RoleService
+	settingsService *SettingsService
+	auditLog        *audit.Log
+	inviteBaseURL   string
+	inviteTokenTTL  time.Duration
 }
 
 func NewInvitationService(
 	pool *pgxpool.Pool,
 	invitationRepo types.InvitationRepository,
+	importRepo types.InvitationImportRepository,
 	membershipRepo types.MembershipRepository,
-	emailService types.EmailService,
+	eventBus events.Publisher,
 	userRepo authtypes.UserRepository,
+	authService *authservices.AuthService,
+	roleService *RoleService,
+	settingsService *SettingsService,
+	auditLog *audit.Log,
 	inviteBaseURL string,
 	inviteTokenTTL time.Duration,
 ) *InvitationService {
 	return &InvitationService{
-		pool:           pool,
-		invitationRepo: invitationRepo,
-		membershipRepo: membershipRepo,
-		emailService:   emailService,
-		userRepo:       userRepo,
-		inviteBaseURL:  inviteBaseURL,
-		inviteTokenTTL: inviteTokenTTL,
+		pool:            pool,
+		invitationRepo:  invitationRepo,
+		importRepo:      importRepo,
+		membershipRepo:  membershipRepo,
+		eventBus:        eventBus,
+		userRepo:        userRepo,
+		authService:     authService,
+		roleService:     roleService,
+		settingsService: settingsService,
+		auditLog:        auditLog,
+		inviteBaseURL:   inviteBaseURL,
+		inviteTokenTTL:  inviteTokenTTL,
 	}
 }
 
-// Create creates a new invitation for an email to join an organization.
-func (s *InvitationService) Create(ctx context.Context, orgID, invitedByUserID uuid.UUID, email, role, inviterName, orgName string) (*types.Invitation, error) {
-	// Check if already a member
-	existingUser, err := s.userRepo.GetByEmail(ctx, s.pool, email)
+// Create creates a new invitation for an email to join an organization. An
+// empty role uses the org's default invite role; either way the actor must
+// hold every permission the role grants. The org's settings also decide
+// which email domains may be invited and how long the invitation lasts.
+func (s *InvitationService) Create(ctx context.Context, orgID, invitedByUserID uuid.UUID, email, role, inviterName, orgName string, actor types.PermissionSet) (*types.Invitation, error) {
+	settings, err := s.settingsService.Effective(ctx, s.pool, orgID)
 	if err != nil {
-		return nil, fmt.Errorf("check user: %w", err)
+		return nil, fmt.Errorf("get org settings: %w", err)
 	}
-	if existingUser != nil {
-		membership, err := s.membershipRepo.GetByUserAndOrg(ctx, s.pool, existingUser.ID, orgID)
-		if err != nil {
-			return nil, fmt.Errorf("check membership: %w", err)
-		}
-		if membership != nil {
-			return nil, ErrAlreadyMember
-		}
+	if len(settings.AllowedInviteDomains) > 0 && !slices.Contains(settings.AllowedInviteDomains, emailDomain(email)) {
+		return nil, ErrInviteDomain
 	}
-
-	// Check for existing pending invite
-	existing, err := s.invitationRepo.GetPendingByEmailAndOrg(ctx, s.pool, email, orgID)
-	if err != nil {
-		return nil, fmt.Errorf("check existing invite: %w", err)
+	if role == "" {
+		role = settings.DefaultInviteRole
 	}
-	if existing != nil {
-		return nil, ErrInvitationExists
+	if _, err := s.roleService.ResolveAssignable(ctx, orgID, role, actor); err != nil {
+		return nil, err
+	}
+	ttl := s.inviteTTL(settings)
+
+	if err := s.checkInvitable(ctx, s.pool, orgID, email); err != nil {
+		return nil, err
 	}
 
 	rawToken, tokenHash, err := authservices.GenerateRandomToken()
@@ -84,24 +102,91 @@ func (s *InvitationService) Create(ctx context.Context, orgID, invitedByUserID u
 		return nil, fmt.Errorf("generate token: %w", err)
 	}
 
-	inv, err := s.invitationRepo.Create(ctx, s.pool, types.CreateInvitationParams{
-		OrganizationID: orgID,
-		InvitedBy:      invitedByUserID,
-		Email:          email,
-		TokenHash:      tokenHash,
-		Role:           role,
-		ExpiresAt:      time.Now().Add(s.inviteTokenTTL),
+	var inv *types.Invitation
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		inv, err = s.invitationRepo.Create(ctx, tx, types.CreateInvitationParams{
+			OrganizationID: orgID,
+			InvitedBy:      invitedByUserID,
+			Email:          email,
+			TokenHash:      tokenHash,
+			Role:           role,
+			ExpiresAt:      time.Now().Add(ttl),
+		})
+		if err != nil {
+			return fmt.Errorf("create invitation: %w", err)
+		}
+		if err := s.publishSent(ctx, tx, inv, inviterName, orgName, rawToken); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        invitedByUserID,
+			Action:         audit.ActionInvitationCreated,
+			TargetType:     audit.TargetInvitation,
+			TargetID:       inv.ID,
+			After:          invitationSnapshot(inv),
+		})
 	})
+	if database.IsUniqueViolation(err) {
+		// Another pending invitation to the email was created concurrently.
+		return nil, ErrInvitationExists
+	}
 	if err != nil {
-		return nil, fmt.Errorf("create invitation: %w", err)
+		return nil, err
+	}
+	return inv, nil
+}
+
+// inviteTTL returns how long new invitations to an org last.
+func (s *InvitationService) inviteTTL(settings types.OrgSettings) time.Duration {
+	if settings.InviteTTLHours != nil {
+		return time.Duration(*settings.InviteTTLHours) * time.Hour
 	}
+	return s.inviteTokenTTL
+}
 
-	inviteURL := s.inviteBaseURL + "/" + rawToken
-	if err := s.emailService.SendInvitation(ctx, email, inviterName, orgName, inviteURL); err != nil {
-		return nil, fmt.Errorf("send invitation email: %w", err)
+// checkInvitable returns ErrAlreadyMember or ErrInvitationExists if email
+// already belongs to a member of the org or has a pending invitation to it.
+// A pending invitation that has run out is marked expired instead, so the
+// email can be invited again before the sweeper gets to it.
+func (s *InvitationService) checkInvitable(ctx context.Context, db database.DBTX, orgID uuid.UUID, email string) error {
+	if err := s.checkNotMember(ctx, db, orgID, email); err != nil {
+		return err
 	}
 
-	return inv, nil
+	existing, err := s.invitationRepo.GetPendingByEmailAndOrg(ctx, db, email, orgID)
+	if err != nil {
+		return fmt.Errorf("check existing invite: %w", err)
+	}
+	if existing != nil {
+		if time.Now().Before(existing.ExpiresAt) {
+			return ErrInvitationExists
+		}
+		if err := s.invitationRepo.UpdateStatus(ctx, db, existing.ID, types.InvitationExpired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNotMember returns ErrAlreadyMember if email belongs to a member of the
+// org.
+func (s *InvitationService) checkNotMember(ctx context.Context, db database.DBTX, orgID uuid.UUID, email string) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, db, email)
+	if err != nil {
+		return fmt.Errorf("check user: %w", err)
+	}
+	if existingUser == nil {
+		return nil
+	}
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, db, existingUser.ID, orgID)
+	if err != nil {
+		return fmt.Errorf("check membership: %w", err)
+	}
+	if membership != nil {
+		return ErrAlreadyMember
+	}
+	return nil
 }
 
 // GetByToken looks up an invitation by raw token for display.
@@ -149,10 +234,249 @@ func (s *InvitationService) Accept(ctx context.Context, rawToken string, userID
 			return fmt.Errorf("create membership: %w", err)
 		}
 
-		return s.invitationRepo.UpdateStatus(ctx, tx, inv.ID, "accepted")
+		if err := s.invitationRepo.UpdateStatus(ctx, tx, inv.ID, types.InvitationAccepted); err != nil {
+			return err
+		}
+		if err := s.recordAccepted(ctx, tx, &inv.Invitation, userID); err != nil {
+			return err
+		}
+		return s.publishJoined(ctx, tx, membership)
 	})
 	if err != nil {
 		return nil, err
 	}
 	return membership, nil
 }
+
+// InvitationSignup is the result of signing up through an invitation: the
+// new user, their membership and their first session's tokens.
+type InvitationSignup struct {
+	User         *authtypes.User
+	Membership   *types.OrgMembership
+	RefreshToken string
+	AccessToken  string
+}
+
+// SignupAndAccept creates an account for an invitation's email address and
+// accepts the invitation with it. Following the emailed link proves control
+// of the address, so the account starts verified. The user, membership and
+// session are created in one transaction.
+func (s *InvitationService) SignupAndAccept(ctx context.Context, rawToken, password, firstName, lastName string) (*InvitationSignup, error) {
+	hash := authservices.HashToken(rawToken)
+
+	var result InvitationSignup
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		inv, err := s.invitationRepo.GetByTokenHash(ctx, tx, hash)
+		if err != nil {
+			return fmt.Errorf("get invitation: %w", err)
+		}
+		if inv == nil || inv.Status != types.InvitationPending || time.Now().After(inv.ExpiresAt) {
+			return ErrInvitationNotFound
+		}
+
+		result.User, err = s.authService.CreateVerifiedUser(ctx, tx, inv.Email, password, firstName, lastName)
+		if err != nil {
+			return err
+		}
+
+		// The join policy may already have added the user to this org through
+		// a verified domain; the invitation's role still applies.
+		existing, err := s.membershipRepo.GetByUserAndOrg(ctx, tx, result.User.ID, inv.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("check membership: %w", err)
+		}
+		if existing != nil {
+			result.Membership, err = s.membershipRepo.UpdateRole(ctx, tx, result.User.ID, inv.OrganizationID, inv.Role)
+		} else {
+			result.Membership, err = s.membershipRepo.Create(ctx, tx, result.User.ID, inv.OrganizationID, inv.Role)
+		}
+		if err != nil {
+			return fmt.Errorf("create membership: %w", err)
+		}
+		if err := s.invitationRepo.UpdateStatus(ctx, tx, inv.ID, types.InvitationAccepted); err != nil {
+			return err
+		}
+		if err := s.recordAccepted(ctx, tx, &inv.Invitation, result.User.ID); err != nil {
+			return err
+		}
+		if existing == nil {
+			if err := s.publishJoined(ctx, tx, result.Membership); err != nil {
+				return err
+			}
+		}
+
+		result.RefreshToken, result.AccessToken, err = s.authService.StartSession(ctx, tx, result.User)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// List returns a page of an org's invitations and the total matching params.
+func (s *InvitationService) List(ctx context.Context, orgID uuid.UUID, params types.ListInvitationsParams) ([]*types.InvitationWithOrg, int, error) {
+	return s.invitationRepo.List(ctx, s.pool, orgID, params)
+}
+
+// Revoke revokes a pending invitation so its link stops working. Expired
+// invitations can be revoked too, which stops them from being resent.
+func (s *InvitationService) Revoke(ctx context.Context, orgID, invitationID uuid.UUID) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		inv, err := s.invitationRepo.GetByIDForUpdate(ctx, tx, orgID, invitationID)
+		if err != nil {
+			return err
+		}
+		if inv == nil {
+			return ErrInvitationNotFound
+		}
+		if inv.Status != types.InvitationPending && inv.Status != types.InvitationExpired {
+			return ErrInvitationClosed
+		}
+		if err := s.invitationRepo.UpdateStatus(ctx, tx, inv.ID, types.InvitationRevoked); err != nil {
+			return err
+		}
+		revoked := *inv
+		revoked.Status = types.InvitationRevoked
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionInvitationRevoked,
+			TargetType:     audit.TargetInvitation,
+			TargetID:       inv.ID,
+			Before:         invitationSnapshot(inv),
+			After:          invitationSnapshot(&revoked),
+		})
+	})
+}
+
+// Resend issues a pending or expired invitation a new token, which also
+// invalidates the old link, restarts its expiry and emails it again. The
+// actor must still be allowed to assign the invitation's role.
+func (s *InvitationService) Resend(ctx context.Context, orgID, invitationID uuid.UUID, inviterName, orgName string, actor types.PermissionSet) (*types.Invitation, error) {
+	settings, err := s.settingsService.Effective(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("get org settings: %w", err)
+	}
+
+	var inv *types.Invitation
+	var rawToken string
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.invitationRepo.GetByIDForUpdate(ctx, tx, orgID, invitationID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrInvitationNotFound
+		}
+		// The org may have restricted invite domains since it was sent.
+		if len(settings.AllowedInviteDomains) > 0 && !slices.Contains(settings.AllowedInviteDomains, emailDomain(current.Email)) {
+			return ErrInviteDomain
+		}
+		switch current.Status {
+		case types.InvitationPending:
+			err = s.checkNotMember(ctx, tx, orgID, current.Email)
+		case types.InvitationExpired:
+			// Another invitation may have been sent to the email since.
+			err = s.checkInvitable(ctx, tx, orgID, current.Email)
+		default:
+			return ErrInvitationClosed
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.roleService.ResolveAssignable(ctx, orgID, current.Role, actor); err != nil {
+			return err
+		}
+
+		var tokenHash string
+		rawToken, tokenHash, err = authservices.GenerateRandomToken()
+		if err != nil {
+			return fmt.Errorf("generate token: %w", err)
+		}
+		inv, err = s.invitationRepo.Reissue(ctx, tx, current.ID, tokenHash, time.Now().Add(s.inviteTTL(settings)))
+		if err != nil {
+			return err
+		}
+		if err := s.publishSent(ctx, tx, inv, inviterName, orgName, rawToken); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionInvitationResent,
+			TargetType:     audit.TargetInvitation,
+			TargetID:       inv.ID,
+			Before:         invitationSnapshot(current),
+			After:          invitationSnapshot(inv),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// publishSent publishes InvitationSent for an invitation just issued the
+// token rawToken, through db.
+func (s *InvitationService) publishSent(ctx context.Context, db database.DBTX, inv *types.Invitation, inviterName, orgName, rawToken string) error {
+	return s.eventBus.Publish(ctx, db, events.InvitationSent{
+		InvitationID: inv.ID,
+		OrgID:        inv.OrganizationID,
+		Email:        inv.Email,
+		Role:         inv.Role,
+		ExpiresAt:    inv.ExpiresAt,
+		InviterName:  inviterName,
+		OrgName:      orgName,
+		InviteURL:    s.inviteBaseURL + "/" + rawToken,
+	})
+}
+
+// recordAccepted records userID accepting a pending invitation and publishes
+// InvitationAccepted.
+func (s *InvitationService) recordAccepted(ctx context.Context, db database.DBTX, inv *types.Invitation, userID uuid.UUID) error {
+	accepted := *inv
+	accepted.Status = types.InvitationAccepted
+	err := s.auditLog.Record(ctx, db, audit.Entry{
+		OrganizationID: inv.OrganizationID,
+		ActorID:        userID,
+		Action:         audit.ActionInvitationAccepted,
+		TargetType:     audit.TargetInvitation,
+		TargetID:       inv.ID,
+		Before:         invitationSnapshot(inv),
+		After:          invitationSnapshot(&accepted),
+	})
+	if err != nil {
+		return err
+	}
+	return s.eventBus.Publish(ctx, db, events.InvitationAccepted{
+		InvitationID: inv.ID,
+		OrgID:        inv.OrganizationID,
+		Email:        inv.Email,
+		Role:         inv.Role,
+		UserID:       userID,
+	})
+}
+
+// publishJoined publishes MemberJoined for a membership created by an
+// invitation.
+func (s *InvitationService) publishJoined(ctx context.Context, db database.DBTX, m *types.OrgMembership) error {
+	return s.eventBus.Publish(ctx, db, events.MemberJoined{OrgID: m.OrganizationID, UserID: m.UserID, Role: m.Role, Via: events.ViaInvitation})
+}
+
+// invitationAuditState is the part of an invitation recorded in audit
+// events.
+type invitationAuditState struct {
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func invitationSnapshot(inv *types.Invitation) invitationAuditState {
+	return invitationAuditState{Email: inv.Email, Role: inv.Role, Status: inv.Status, ExpiresAt: inv.ExpiresAt}
+}
+
+// ExpireStale marks pending invitations past their expiry as expired and
+// returns how many it changed.
+func (s *InvitationService) ExpireStale(ctx context.Context) (int64, error) {
+	return s.invitationRepo.ExpirePending(ctx, s.pool, time.Now())
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/mailer"
+	"agenteur.ai/api/internal/mailer/mailertest"
+	"agenteur.ai/api/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newTestInvitationService(pool *pgxpool.Pool) *InvitationService {
+	roleRepo := NewRoleRepository()
+	authSvc := authservices.NewAuthService(
+		pool, authservices.NewUserRepository(), authservices.NewRefreshTokenRepository(),
+		authservices.NewEmailVerificationRepository(), authservices.NewTOTPRepository(), &captureSender{}, nil, nil, newTestBus(),
+		"test-secret", time.Minute, time.Hour, 4, "http://verify", time.Hour,
+	)
+	return NewInvitationService(
+		pool, NewInvitationRepository(), NewInvitationImportRepository(), NewMembershipRepository(),
+		newTestBus(), authservices.NewUserRepository(), authSvc,
+		NewRoleService(pool, roleRepo, NewPermissionGrantRepository(), audit.New(pool)),
+		NewSettingsService(pool, NewOrgSettingsRepository(), roleRepo, audit.New(pool)), audit.New(pool),
+		"http://localhost/invitations", time.Hour,
+	)
+}
+
+func TestInvitationRevokeResendAndExpire(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	owner := createTestUser(t, pool)
+	org, err := newTestOrgService(pool).Create(ctx, owner.ID, "Invite Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	svc := newTestInvitationService(pool)
+	actor := types.NewPermissionSet(types.AllPermissions()...)
+	inv, err := svc.Create(ctx, org.ID, owner.ID, "invitee-"+uuid.NewString()+"@example.com", "", "Owner", org.Name, actor)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Expiring the invitation lets it be resent with a fresh token.
+	if _, err := pool.Exec(ctx, `UPDATE invitations SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ExpireStale(ctx); err != nil {
+		t.Fatalf("ExpireStale: %v", err)
+	}
+	expired, _, err := svc.List(ctx, org.ID, types.ListInvitationsParams{Statuses: []string{types.InvitationExpired}, Page: 1, PerPage: 20})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != inv.ID {
+		t.Fatalf("expired invitations = %+v, want %s", expired, inv.ID)
+	}
+
+	resent, err := svc.Resend(ctx, org.ID, inv.ID, "Owner", org.Name, actor)
+	if err != nil {
+		t.Fatalf("Resend: %v", err)
+	}
+	if resent.Status != types.InvitationPending || resent.TokenHash == inv.TokenHash || !resent.ExpiresAt.After(time.Now()) {
+		t.Errorf("resent invitation = %+v", resent)
+	}
+
+	// Restricting invite domains stops the invitation from being resent.
+	patch := map[string]json.RawMessage{"allowedInviteDomains": json.RawMessage(`["example.org"]`)}
+	if _, err := svc.settingsService.Update(ctx, org.ID, owner.ID, patch, nil); err != nil {
+		t.Fatalf("Update settings: %v", err)
+	}
+	if _, err := svc.Resend(ctx, org.ID, inv.ID, "Owner", org.Name, actor); !errors.Is(err, ErrInviteDomain) {
+		t.Errorf("Resend to a disallowed domain err = %v, want ErrInviteDomain", err)
+	}
+
+	if err := svc.Revoke(ctx, org.ID, inv.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := svc.Revoke(ctx, org.ID, inv.ID); !errors.Is(err, ErrInvitationClosed) {
+		t.Errorf("second Revoke err = %v, want ErrInvitationClosed", err)
+	}
+	if _, err := svc.Resend(ctx, org.ID, inv.ID, "Owner", org.Name, actor); !errors.Is(err, ErrInvitationClosed) {
+		t.Errorf("Resend revoked err = %v, want ErrInvitationClosed", err)
+	}
+}
+
+func TestInvitationSignupAndAccept(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	owner := createTestUser(t, pool)
+	org, err := newTestOrgService(pool).Create(ctx, owner.ID, "Signup Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	svc := newTestInvitationService(pool)
+	email := "signup-" + uuid.NewString() + "@example.com"
+	rawToken, tokenHash, err := authservices.GenerateRandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, err := svc.invitationRepo.Create(ctx, pool, types.CreateInvitationParams{
+		OrganizationID: org.ID,
+		InvitedBy:      owner.ID,
+		Email:          email,
+		TokenHash:      tokenHash,
+		Role:           types.RoleAdmin,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signup, err := svc.SignupAndAccept(ctx, rawToken, "password123", "New", "User")
+	if err != nil {
+		t.Fatalf("SignupAndAccept: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, signup.User.ID)
+	})
+	if signup.User.Email != email || !signup.User.EmailVerified() {
+		t.Errorf("user = %+v, want verified %s", signup.User, email)
+	}
+	if signup.Membership.Role != types.RoleAdmin || signup.AccessToken == "" || signup.RefreshToken == "" {
+		t.Errorf("signup = %+v", signup)
+	}
+
+	// The invitation is used up.
+	if _, err := svc.SignupAndAccept(ctx, rawToken, "password123", "Again", ""); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("second SignupAndAccept err = %v, want ErrInvitationNotFound", err)
+	}
+
+	// A revoked invitation cannot be used.
+	if err := svc.invitationRepo.UpdateStatus(ctx, pool, inv.ID, types.InvitationPending); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Revoke(ctx, org.ID, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SignupAndAccept(ctx, rawToken, "password123", "Again", ""); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("revoked SignupAndAccept err = %v, want ErrInvitationNotFound", err)
+	}
+}
+
+// TestInvitationEmailOverSMTP follows an invitation from creation, through
+// the SMTP server, to signing up with the link in the email.
+func TestInvitationEmailOverSMTP(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	owner := createTestUser(t, pool)
+	org, err := newTestOrgService(pool).Create(ctx, owner.ID, "SMTP Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	srv := mailertest.NewServer(t, mailertest.Options{Username: "mailer", Password: "secret"})
+	transport, err := mailer.NewSMTPTransport(mailer.SMTPConfig{
+		Host:      srv.Host(),
+		Port:      srv.Port(),
+		Username:  "mailer",
+		Password:  "secret",
+		TLSConfig: srv.ClientTLSConfig(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { transport.Close() })
+
+	svc := newTestInvitationService(pool)
+	templates, err := mailer.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	box := outbox.New(pool, 3)
+	box.Handle(outbox.KindEmail, outbox.EmailHandler(mailer.NewMailer(transport, templates, "Agenteur <no-reply@example.com>")))
+	bus := newTestBus()
+	notifications := NewNotificationService(outbox.NewEmailQueue(box), NewMembershipRepository(), NewSettingsService(pool, NewOrgSettingsRepository(), NewRoleRepository(), audit.New(pool)))
+	events.Subscribe(bus, "invitation-email", notifications.InvitationSent)
+	svc.eventBus = bus
+
+	email := "smtp-" + uuid.NewString() + "@example.com"
+	actor := types.NewPermissionSet(types.AllPermissions()...)
+	if _, err := svc.Create(ctx, org.ID, owner.ID, email, "", "Owner", org.Name, actor); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(srv.Messages()) != 0 {
+		t.Fatal("email sent before the outbox was dispatched")
+	}
+	if _, err := box.Dispatch(ctx); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	var msgs []mailertest.Message
+	for _, m := range srv.Messages() {
+		if len(m.To) == 1 && m.To[0] == email {
+			msgs = append(msgs, m)
+		}
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("messages = %+v, want one to %s", srv.Messages(), email)
+	}
+	text, err := msgs[0].Part("text/plain")
+	if err != nil {
+		t.Fatalf("text part: %v", err)
+	}
+	match := regexp.MustCompile(`http://localhost/invitations/(\S+)`).FindStringSubmatch(text)
+	if match == nil {
+		t.Fatalf("no invite URL in email:\n%s", text)
+	}
+
+	signup, err := svc.SignupAndAccept(ctx, match[1], "password123", "Smtp", "User")
+	if err != nil {
+		t.Fatalf("SignupAndAccept with emailed token: %v", err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, signup.User.ID)
+	})
+	if signup.Membership.OrganizationID != org.ID || signup.User.Email != email {
+		t.Errorf("signup = %+v", signup)
+	}
+}
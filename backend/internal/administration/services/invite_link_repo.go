@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const inviteLinkColumns = `l.id, l.organization_id, l.token_hash, r.key, l.max_uses, l.use_count, l.allowed_domain,
+	l.expires_at, l.disabled_at, l.created_by, l.created_at`
+
+type pgxInviteLinkRepository struct{}
+
+func NewInviteLinkRepository() types.InviteLinkRepository {
+	return &pgxInviteLinkRepository{}
+}
+
+func inviteLinkScanArgs(l *types.InviteLink) []any {
+	return []any{&l.ID, &l.OrganizationID, &l.TokenHash, &l.Role, &l.MaxUses, &l.UseCount, &l.AllowedDomain,
+		&l.ExpiresAt, &l.DisabledAt, &l.CreatedBy, &l.CreatedAt}
+}
+
+func scanInviteLink(row pgx.Row) (*types.InviteLink, error) {
+	var l types.InviteLink
+	if err := row.Scan(inviteLinkScanArgs(&l)...); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *pgxInviteLinkRepository) Create(ctx context.Context, db database.DBTX, params types.CreateInviteLinkParams) (*types.InviteLink, error) {
+	l, err := scanInviteLink(db.QueryRow(ctx,
+		`WITH l AS (
+		     INSERT INTO org_invite_links (organization_id, token_hash, role_id, max_uses, allowed_domain, expires_at, created_by)
+		     VALUES ($1, $2,
+		             (SELECT id FROM org_roles WHERE key = $3 AND (organization_id IS NULL OR organization_id = $1)),
+		             $4, $5, $6, $7)
+		     RETURNING *
+		 )
+		 SELECT `+inviteLinkColumns+` FROM l JOIN org_roles r ON r.id = l.role_id`,
+		params.OrganizationID, params.TokenHash, params.Role, params.MaxUses, params.AllowedDomain, params.ExpiresAt, params.CreatedBy))
+	if err != nil {
+		return nil, fmt.Errorf("create invite link: %w", err)
+	}
+	return l, nil
+}
+
+func (r *pgxInviteLinkRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.InviteLink, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+inviteLinkColumns+`
+		 FROM org_invite_links l JOIN org_roles r ON r.id = l.role_id
+		 WHERE l.organization_id = $1
+		 ORDER BY l.created_at DESC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list invite links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*types.InviteLink
+	for rows.Next() {
+		l, err := scanInviteLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan invite link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (r *pgxInviteLinkRepository) GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.InviteLink, error) {
+	l, err := scanInviteLink(db.QueryRow(ctx,
+		`SELECT `+inviteLinkColumns+`
+		 FROM org_invite_links l JOIN org_roles r ON r.id = l.role_id
+		 WHERE l.organization_id = $1 AND l.id = $2`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get invite link: %w", err)
+	}
+	return l, nil
+}
+
+func (r *pgxInviteLinkRepository) GetByTokenHash(ctx context.Context, db database.DBTX, hash string) (*types.InviteLinkWithOrg, error) {
+	var l types.InviteLinkWithOrg
+	err := db.QueryRow(ctx,
+		`SELECT `+inviteLinkColumns+`, o.name
+		 FROM org_invite_links l
+		 JOIN org_roles r ON r.id = l.role_id
+		 JOIN organizations o ON o.id = l.organization_id
+		 WHERE l.token_hash = $1 AND o.deleted_at IS NULL`, hash,
+	).Scan(append(inviteLinkScanArgs(&l.InviteLink), &l.OrganizationName)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get invite link by token hash: %w", err)
+	}
+	return &l, nil
+}
+
+// Disable stops a link from being used. Disabling an already disabled link
+// keeps its original disabled_at.
+func (r *pgxInviteLinkRepository) Disable(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.InviteLink, error) {
+	l, err := scanInviteLink(db.QueryRow(ctx,
+		`WITH l AS (
+		     UPDATE org_invite_links SET disabled_at = COALESCE(disabled_at, NOW())
+		     WHERE organization_id = $1 AND id = $2
+		     RETURNING *
+		 )
+		 SELECT `+inviteLinkColumns+` FROM l JOIN org_roles r ON r.id = l.role_id`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("disable invite link: %w", err)
+	}
+	return l, nil
+}
+
+// Consume increments the link's use count if it is enabled, unexpired and
+// under its cap. The conditions are rechecked after the row lock is taken,
+// so concurrent joins cannot exceed max_uses.
+func (r *pgxInviteLinkRepository) Consume(ctx context.Context, db database.DBTX, id uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx,
+		`UPDATE org_invite_links SET use_count = use_count + 1
+		 WHERE id = $1 AND disabled_at IS NULL AND expires_at > NOW() AND use_count < max_uses`, id)
+	if err != nil {
+		return false, fmt.Errorf("consume invite link: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *pgxInviteLinkRepository) RecordUse(ctx context.Context, db database.DBTX, linkID, userID uuid.UUID) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO org_invite_link_uses (link_id, user_id) VALUES ($1, $2)`, linkID, userID)
+	if err != nil {
+		return fmt.Errorf("record invite link use: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxInviteLinkRepository) ListUses(ctx context.Context, db database.DBTX, linkID uuid.UUID) ([]*types.InviteLinkUse, error) {
+	rows, err := db.Query(ctx,
+		`SELECT u.id, u.email, u.first_name, u.last_name, lu.joined_at
+		 FROM org_invite_link_uses lu
+		 JOIN users u ON u.id = lu.user_id
+		 WHERE lu.link_id = $1
+		 ORDER BY lu.joined_at DESC`, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("list invite link uses: %w", err)
+	}
+	defer rows.Close()
+
+	var uses []*types.InviteLinkUse
+	for rows.Next() {
+		var u types.InviteLinkUse
+		if err := rows.Scan(&u.UserID, &u.Email, &u.FirstName, &u.LastName, &u.JoinedAt); err != nil {
+			return nil, fmt.Errorf("scan invite link use: %w", err)
+		}
+		uses = append(uses, &u)
+	}
+	return uses, rows.Err()
+}
@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	authtypes "agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInviteLinkNotFound = errors.New("invite link not found")
+	ErrInviteLinkInactive = errors.New("invite link is disabled, expired or used up")
+	ErrInvalidMaxUses     = fmt.Errorf("max uses must be between 1 and %d", types.MaxInviteLinkUses)
+	ErrInvalidLinkExpiry  = fmt.Errorf("expiry must be between 1 and %d hours", types.MaxInviteLinkTTLHours)
+)
+
+// CreateInviteLinkInput is an admin's request for a new invite link. An
+// empty Role uses the org's default invite role.
+type CreateInviteLinkInput struct {
+	Role           string
+	MaxUses        int
+	ExpiresInHours int
+	AllowedDomain  string
+}
+
+type InviteLinkService struct {
+	pool            *pgxpool.Pool
+	linkRepo        types.InviteLinkRepository
+	membershipRepo  types.MembershipRepository
+	userRepo        authtypes.UserRepository
+	roleService     *RoleService
+	settingsService *SettingsService
+	eventBus        events.Publisher
+	auditLog        *audit.Log
+	linkBaseURL     string
+}
+
+func NewInviteLinkService(
+	pool *pgxpool.Pool,
+	linkRepo types.InviteLinkRepository,
+	membershipRepo types.MembershipRepository,
+	userRepo authtypes.UserRepository,
+	roleService *RoleService,
+	settingsService *SettingsService,
+	eventBus events.Publisher,
+	auditLog *audit.Log,
+	linkBaseURL string,
+) *InviteLinkService {
+	return &InviteLinkService{
+		pool:            pool,
+		linkRepo:        linkRepo,
+		membershipRepo:  membershipRepo,
+		userRepo:        userRepo,
+		roleService:     roleService,
+		settingsService: settingsService,
+		eventBus:        eventBus,
+		auditLog:        auditLog,
+		linkBaseURL:     linkBaseURL,
+	}
+}
+
+// Create creates an invite link and returns it with its URL. The URL carries
+// the only copy of the token and cannot be retrieved later. As with
+// invitations, the actor must hold every permission the link's role grants.
+func (s *InviteLinkService) Create(ctx context.Context, orgID, actorID uuid.UUID, input CreateInviteLinkInput, actor types.PermissionSet) (*types.InviteLink, string, error) {
+	if err := normalizeInviteLinkInput(&input); err != nil {
+		return nil, "", err
+	}
+
+	settings, err := s.settingsService.Effective(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, "", fmt.Errorf("get org settings: %w", err)
+	}
+	if input.AllowedDomain != "" && len(settings.AllowedInviteDomains) > 0 && !slices.Contains(settings.AllowedInviteDomains, input.AllowedDomain) {
+		return nil, "", ErrInviteDomain
+	}
+	if input.Role == "" {
+		input.Role = settings.DefaultInviteRole
+	}
+	if _, err := s.roleService.ResolveAssignable(ctx, orgID, input.Role, actor); err != nil {
+		return nil, "", err
+	}
+
+	rawToken, tokenHash, err := authservices.GenerateRandomToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate token: %w", err)
+	}
+	params := types.CreateInviteLinkParams{
+		OrganizationID: orgID,
+		CreatedBy:      actorID,
+		TokenHash:      tokenHash,
+		Role:           input.Role,
+		MaxUses:        input.MaxUses,
+		ExpiresAt:      time.Now().Add(time.Duration(input.ExpiresInHours) * time.Hour),
+	}
+	if input.AllowedDomain != "" {
+		params.AllowedDomain = &input.AllowedDomain
+	}
+	var link *types.InviteLink
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		link, err = s.linkRepo.Create(ctx, tx, params)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        actorID,
+			Action:         audit.ActionInviteLinkCreated,
+			TargetType:     audit.TargetInviteLink,
+			TargetID:       link.ID,
+			After:          inviteLinkSnapshot(link),
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return link, s.linkBaseURL + "/" + rawToken, nil
+}
+
+// normalizeInviteLinkInput applies defaults, checks limits and normalizes the
+// allowed domain.
+func normalizeInviteLinkInput(input *CreateInviteLinkInput) error {
+	if input.MaxUses < 1 || input.MaxUses > types.MaxInviteLinkUses {
+		return ErrInvalidMaxUses
+	}
+	if input.ExpiresInHours == 0 {
+		input.ExpiresInHours = types.DefaultInviteLinkTTLHours
+	}
+	if input.ExpiresInHours < 1 || input.ExpiresInHours > types.MaxInviteLinkTTLHours {
+		return ErrInvalidLinkExpiry
+	}
+	if input.AllowedDomain != "" {
+		domains, msg := normalizeDomainList([]string{input.AllowedDomain})
+		if msg != "" {
+			return ErrInvalidDomain
+		}
+		input.AllowedDomain = domains[0]
+	}
+	return nil
+}
+
+func (s *InviteLinkService) List(ctx context.Context, orgID uuid.UUID) ([]*types.InviteLink, error) {
+	return s.linkRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+// Disable stops a link from being used. It cannot be re-enabled.
+// Disabling a disabled link is a no-op and is not recorded again.
+func (s *InviteLinkService) Disable(ctx context.Context, orgID, linkID uuid.UUID) (*types.InviteLink, error) {
+	var link *types.InviteLink
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		existing, err := s.linkRepo.GetByID(ctx, tx, orgID, linkID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrInviteLinkNotFound
+		}
+		link, err = s.linkRepo.Disable(ctx, tx, orgID, linkID)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return ErrInviteLinkNotFound
+		}
+		if existing.DisabledAt != nil {
+			return nil
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionInviteLinkDisabled,
+			TargetType:     audit.TargetInviteLink,
+			TargetID:       link.ID,
+			Before:         inviteLinkSnapshot(existing),
+			After:          inviteLinkSnapshot(link),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// ListUses returns the users who joined through a link, newest first.
+func (s *InviteLinkService) ListUses(ctx context.Context, orgID, linkID uuid.UUID) ([]*types.InviteLinkUse, error) {
+	link, err := s.linkRepo.GetByID(ctx, s.pool, orgID, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrInviteLinkNotFound
+	}
+	return s.linkRepo.ListUses(ctx, s.pool, linkID)
+}
+
+// GetByToken looks up a usable link by raw token for display.
+func (s *InviteLinkService) GetByToken(ctx context.Context, rawToken string) (*types.InviteLinkWithOrg, error) {
+	link, err := s.linkRepo.GetByTokenHash(ctx, s.pool, authservices.HashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrInviteLinkNotFound
+	}
+	if !link.Usable(time.Now()) {
+		return nil, ErrInviteLinkInactive
+	}
+	return link, nil
+}
+
+// Accept adds a user to the link's org with the link's role. Unlike an
+// invitation, a link is not tied to an email address; but if the link or
+// the org's settings restrict email domains, the user's address must be
+// verified and at an allowed domain.
+func (s *InviteLinkService) Accept(ctx context.Context, rawToken string, userID uuid.UUID) (*types.OrgMembership, error) {
+	hash := authservices.HashToken(rawToken)
+
+	var membership *types.OrgMembership
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		link, err := s.linkRepo.GetByTokenHash(ctx, tx, hash)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return ErrInviteLinkNotFound
+		}
+		if !link.Usable(time.Now()) {
+			return ErrInviteLinkInactive
+		}
+
+		user, err := s.userRepo.GetByID(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %s not found", userID)
+		}
+		settings, err := s.settingsService.Effective(ctx, tx, link.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("get org settings: %w", err)
+		}
+		if err := checkInviteLinkDomain(&link.InviteLink, settings, user); err != nil {
+			return err
+		}
+
+		existing, err := s.membershipRepo.GetByUserAndOrg(ctx, tx, userID, link.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("check membership: %w", err)
+		}
+		if existing != nil {
+			return ErrAlreadyMember
+		}
+
+		ok, err := s.linkRepo.Consume(ctx, tx, link.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInviteLinkInactive
+		}
+		membership, err = s.membershipRepo.Create(ctx, tx, userID, link.OrganizationID, link.Role)
+		if database.IsUniqueViolation(err) {
+			// The user joined concurrently, through this link or another way.
+			return ErrAlreadyMember
+		}
+		if err != nil {
+			return fmt.Errorf("create membership: %w", err)
+		}
+		if err := s.linkRepo.RecordUse(ctx, tx, link.ID, userID); err != nil {
+			return err
+		}
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: link.OrganizationID,
+			ActorID:        userID,
+			Action:         audit.ActionInviteLinkAccepted,
+			TargetType:     audit.TargetInviteLink,
+			TargetID:       link.ID,
+			After:          memberSnapshot{Role: link.Role},
+		})
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.MemberJoined{OrgID: link.OrganizationID, UserID: userID, Role: link.Role, Via: events.ViaInviteLink})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// checkInviteLinkDomain enforces the link's allowed domain and the org's
+// allowed invite domains. Anyone can sign up with any address, so only a
+// verified one counts.
+func checkInviteLinkDomain(link *types.InviteLink, settings types.OrgSettings, user *authtypes.User) error {
+	if link.AllowedDomain == nil && len(settings.AllowedInviteDomains) == 0 {
+		return nil
+	}
+	if !user.EmailVerified() {
+		return ErrEmailNotVerified
+	}
+	domain := emailDomain(user.Email)
+	if link.AllowedDomain != nil && domain != *link.AllowedDomain {
+		return ErrInviteDomain
+	}
+	if len(settings.AllowedInviteDomains) > 0 && !slices.Contains(settings.AllowedInviteDomains, domain) {
+		return ErrInviteDomain
+	}
+	return nil
+}
+
+// inviteLinkAuditState is the part of an invite link recorded in audit
+// events. The token hash is left out.
+type inviteLinkAuditState struct {
+	Role          string     `json:"role"`
+	MaxUses       int        `json:"maxUses"`
+	AllowedDomain *string    `json:"allowedDomain,omitempty"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	DisabledAt    *time.Time `json:"disabledAt,omitempty"`
+}
+
+func inviteLinkSnapshot(l *types.InviteLink) inviteLinkAuditState {
+	return inviteLinkAuditState{
+		Role:          l.Role,
+		MaxUses:       l.MaxUses,
+		AllowedDomain: l.AllowedDomain,
+		ExpiresAt:     l.ExpiresAt,
+		DisabledAt:    l.DisabledAt,
+	}
+}
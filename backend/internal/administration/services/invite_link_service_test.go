@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	authtypes "agenteur.ai/api/internal/auth/types"
+	"github.com/google/uuid"
+)
+
+func TestNormalizeInviteLinkInput(t *testing.T) {
+	input := CreateInviteLinkInput{MaxUses: 50, AllowedDomain: " Acme.COM. "}
+	if err := normalizeInviteLinkInput(&input); err != nil {
+		t.Fatalf("normalizeInviteLinkInput: %v", err)
+	}
+	if input.ExpiresInHours != types.DefaultInviteLinkTTLHours || input.AllowedDomain != "acme.com" {
+		t.Errorf("input = %+v", input)
+	}
+
+	for _, tc := range []struct {
+		input CreateInviteLinkInput
+		want  error
+	}{
+		{CreateInviteLinkInput{MaxUses: 0}, ErrInvalidMaxUses},
+		{CreateInviteLinkInput{MaxUses: types.MaxInviteLinkUses + 1}, ErrInvalidMaxUses},
+		{CreateInviteLinkInput{MaxUses: 1, ExpiresInHours: -1}, ErrInvalidLinkExpiry},
+		{CreateInviteLinkInput{MaxUses: 1, ExpiresInHours: types.MaxInviteLinkTTLHours + 1}, ErrInvalidLinkExpiry},
+		{CreateInviteLinkInput{MaxUses: 1, AllowedDomain: "not a domain"}, ErrInvalidDomain},
+	} {
+		if err := normalizeInviteLinkInput(&tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("normalizeInviteLinkInput(%+v) = %v, want %v", tc.input, err, tc.want)
+		}
+	}
+}
+
+func TestInviteLinkUsable(t *testing.T) {
+	now := time.Now()
+	disabled := now.Add(-time.Minute)
+	for _, tc := range []struct {
+		name string
+		link types.InviteLink
+		want bool
+	}{
+		{"open", types.InviteLink{MaxUses: 2, UseCount: 1, ExpiresAt: now.Add(time.Hour)}, true},
+		{"used up", types.InviteLink{MaxUses: 2, UseCount: 2, ExpiresAt: now.Add(time.Hour)}, false},
+		{"expired", types.InviteLink{MaxUses: 2, ExpiresAt: now.Add(-time.Hour)}, false},
+		{"disabled", types.InviteLink{MaxUses: 2, ExpiresAt: now.Add(time.Hour), DisabledAt: &disabled}, false},
+	} {
+		if got := tc.link.Usable(now); got != tc.want {
+			t.Errorf("%s: Usable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckInviteLinkDomain(t *testing.T) {
+	verifiedAt := time.Now()
+	verified := &authtypes.User{Email: "a@acme.com", EmailVerifiedAt: &verifiedAt}
+	unverified := &authtypes.User{Email: "a@acme.com"}
+	acme := "acme.com"
+	other := "other.com"
+	open := types.DefaultOrgSettings()
+	restricted := types.DefaultOrgSettings()
+	restricted.AllowedInviteDomains = []string{"other.com"}
+
+	for _, tc := range []struct {
+		name     string
+		domain   *string
+		settings types.OrgSettings
+		user     *authtypes.User
+		want     error
+	}{
+		{"unrestricted", nil, open, unverified, nil},
+		{"link domain", &acme, open, verified, nil},
+		{"link domain unverified", &acme, open, unverified, ErrEmailNotVerified},
+		{"link domain mismatch", &other, open, verified, ErrInviteDomain},
+		{"org domains mismatch", nil, restricted, verified, ErrInviteDomain},
+	} {
+		link := &types.InviteLink{AllowedDomain: tc.domain}
+		if err := checkInviteLinkDomain(link, tc.settings, tc.user); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestInviteLinkAcceptRespectsMaxUses(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	owner := createTestUser(t, pool)
+	org, err := newTestOrgService(pool).Create(ctx, owner.ID, "Link Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	roleRepo := NewRoleRepository()
+	svc := NewInviteLinkService(pool, NewInviteLinkRepository(), NewMembershipRepository(), authservices.NewUserRepository(),
+		NewRoleService(pool, roleRepo, NewPermissionGrantRepository(), audit.New(pool)),
+		NewSettingsService(pool, NewOrgSettingsRepository(), roleRepo, audit.New(pool)), newTestBus(), audit.New(pool),
+		"http://localhost/join")
+	link, url, err := svc.Create(ctx, org.ID, owner.ID, CreateInviteLinkInput{MaxUses: 2}, types.NewPermissionSet(types.AllPermissions()...))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	token := url[len("http://localhost/join/"):]
+
+	const joiners = 5
+	var wg sync.WaitGroup
+	errs := make([]error, joiners)
+	for i := range joiners {
+		user := createTestUser(t, pool)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = svc.Accept(ctx, token, user.ID)
+		}()
+	}
+	wg.Wait()
+
+	joined := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			joined++
+		case !errors.Is(err, ErrInviteLinkInactive):
+			t.Errorf("Accept: %v", err)
+		}
+	}
+	if joined != link.MaxUses {
+		t.Errorf("joined = %d, want %d", joined, link.MaxUses)
+	}
+	uses, err := svc.ListUses(ctx, org.ID, link.ID)
+	if err != nil {
+		t.Fatalf("ListUses: %v", err)
+	}
+	if len(uses) != link.MaxUses {
+		t.Errorf("uses = %d, want %d", len(uses), link.MaxUses)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const ipAllowlistColumns = `id, organization_id, cidr, description, created_by, created_at`
+
+type pgxIPAllowlistRepository struct{}
+
+func NewIPAllowlistRepository() types.IPAllowlistRepository {
+	return &pgxIPAllowlistRepository{}
+}
+
+func scanIPAllowlistEntry(row pgx.Row) (*types.IPAllowlistEntry, error) {
+	var e types.IPAllowlistEntry
+	if err := row.Scan(&e.ID, &e.OrganizationID, &e.CIDR, &e.Description, &e.CreatedBy, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *pgxIPAllowlistRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.IPAllowlistEntry, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+ipAllowlistColumns+` FROM org_ip_allowlist WHERE organization_id = $1 ORDER BY cidr`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list ip allowlist: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*types.IPAllowlistEntry
+	for rows.Next() {
+		e, err := scanIPAllowlistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan ip allowlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Replace deletes the org's entries and inserts the new ones. Entries whose
+// range is unchanged keep their ID and creation details. Must be called
+// inside a transaction.
+func (r *pgxIPAllowlistRepository) Replace(ctx context.Context, db database.DBTX, orgID, actorID uuid.UUID, entries []types.IPAllowlistEntryParams) ([]*types.IPAllowlistEntry, error) {
+	cidrs := make([]string, len(entries))
+	descriptions := make([]string, len(entries))
+	for i, e := range entries {
+		cidrs[i] = e.CIDR.String()
+		descriptions[i] = e.Description
+	}
+
+	_, err := db.Exec(ctx,
+		`DELETE FROM org_ip_allowlist
+		 WHERE organization_id = $1 AND NOT (cidr = ANY($2::cidr[]))`, orgID, cidrs)
+	if err != nil {
+		return nil, fmt.Errorf("delete ip allowlist entries: %w", err)
+	}
+	_, err = db.Exec(ctx,
+		`INSERT INTO org_ip_allowlist (organization_id, cidr, description, created_by)
+		 SELECT $1, e.cidr, e.description, $4
+		 FROM UNNEST($2::cidr[], $3::text[]) AS e(cidr, description)
+		 ON CONFLICT (organization_id, cidr) DO UPDATE SET description = EXCLUDED.description`,
+		orgID, cidrs, descriptions, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("insert ip allowlist entries: %w", err)
+	}
+	return r.ListByOrg(ctx, db, orgID)
+}
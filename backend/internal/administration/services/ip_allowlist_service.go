@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrInvalidCIDR         = errors.New("invalid IP address or CIDR range")
+	ErrIPAllowlistTooLarge = fmt.Errorf("an allowlist may have at most %d entries", types.MaxIPAllowlistEntries)
+	ErrIPAllowlistLockout  = errors.New("the allowlist does not include your current IP address")
+)
+
+const maxIPAllowlistDescriptionLength = 200
+
+type IPAllowlistService struct {
+	pool          *pgxpool.Pool
+	allowlistRepo types.IPAllowlistRepository
+	auditLog      *audit.Log
+}
+
+func NewIPAllowlistService(pool *pgxpool.Pool, allowlistRepo types.IPAllowlistRepository, auditLog *audit.Log) *IPAllowlistService {
+	return &IPAllowlistService{
+		pool:          pool,
+		allowlistRepo: allowlistRepo,
+		auditLog:      auditLog,
+	}
+}
+
+func (s *IPAllowlistService) List(ctx context.Context, orgID uuid.UUID) ([]*types.IPAllowlistEntry, error) {
+	return s.allowlistRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+// Replace sets the org's allowlist to entries; an empty list allows every
+// address. It refuses a non-empty list that excludes actorIP, so admins
+// cannot lock themselves out, and records an audit event if the list
+// changed.
+func (s *IPAllowlistService) Replace(ctx context.Context, orgID, actorID uuid.UUID, actorIP netip.Addr, entries []types.IPAllowlistInput) ([]*types.IPAllowlistEntry, error) {
+	params, err := parseIPAllowlist(entries)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make([]netip.Prefix, len(params))
+	for i, p := range params {
+		prefixes[i] = p.CIDR
+	}
+	if !allowlistPermits(prefixes, actorIP) {
+		return nil, ErrIPAllowlistLockout
+	}
+
+	var updated []*types.IPAllowlistEntry
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		before, err := s.allowlistRepo.ListByOrg(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		updated, err = s.allowlistRepo.Replace(ctx, tx, orgID, actorID, params)
+		if err != nil {
+			return err
+		}
+		if slices.Equal(allowlistSummary(before), allowlistSummary(updated)) {
+			return nil
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        actorID,
+			Action:         audit.ActionIPAllowlistUpdated,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       orgID,
+			Before:         map[string][]string{"entries": allowlistSummary(before)},
+			After:          map[string][]string{"entries": allowlistSummary(updated)},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Allows reports whether ip may access the org.
+func (s *IPAllowlistService) Allows(ctx context.Context, orgID uuid.UUID, ip netip.Addr) (bool, error) {
+	entries, err := s.allowlistRepo.ListByOrg(ctx, s.pool, orgID)
+	if err != nil {
+		return false, err
+	}
+	prefixes := make([]netip.Prefix, len(entries))
+	for i, e := range entries {
+		prefixes[i] = e.CIDR
+	}
+	return allowlistPermits(prefixes, ip), nil
+}
+
+// allowlistPermits reports whether ip falls in one of prefixes. An empty
+// allowlist permits every address, including an unknown one.
+func allowlistPermits(prefixes []netip.Prefix, ip netip.Addr) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	ip = ip.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPAllowlist validates and canonicalizes allowlist entries. Bare
+// addresses become single-address ranges, host bits are masked off, and
+// duplicate ranges are merged.
+func parseIPAllowlist(entries []types.IPAllowlistInput) ([]types.IPAllowlistEntryParams, error) {
+	if len(entries) > types.MaxIPAllowlistEntries {
+		return nil, ErrIPAllowlistTooLarge
+	}
+	params := make([]types.IPAllowlistEntryParams, 0, len(entries))
+	for _, e := range entries {
+		prefix, err := parseCIDR(e.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		description := strings.TrimSpace(e.Description)
+		if len(description) > maxIPAllowlistDescriptionLength {
+			return nil, fmt.Errorf("%w: description of %s is too long", ErrInvalidCIDR, prefix)
+		}
+		if slices.ContainsFunc(params, func(p types.IPAllowlistEntryParams) bool { return p.CIDR == prefix }) {
+			continue
+		}
+		params = append(params, types.IPAllowlistEntryParams{CIDR: prefix, Description: description})
+	}
+	return params, nil
+}
+
+func parseCIDR(raw string) (netip.Prefix, error) {
+	raw = strings.TrimSpace(raw)
+	if prefix, err := netip.ParsePrefix(raw); err == nil {
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		if prefix.IsValid() {
+			return prefix.Masked(), nil
+		}
+	}
+	if ip, err := netip.ParseAddr(raw); err == nil && ip.Zone() == "" {
+		ip = ip.Unmap()
+		return netip.PrefixFrom(ip, ip.BitLen()), nil
+	}
+	return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidCIDR, raw)
+}
+
+// allowlistSummary renders entries as sorted "cidr description" strings for
+// comparison and auditing.
+func allowlistSummary(entries []*types.IPAllowlistEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = strings.TrimSpace(e.CIDR.String() + " " + e.Description)
+	}
+	slices.Sort(out)
+	return out
+}
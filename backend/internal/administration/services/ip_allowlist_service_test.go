@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+)
+
+func TestParseIPAllowlist(t *testing.T) {
+	params, err := parseIPAllowlist([]types.IPAllowlistInput{
+		{CIDR: " 10.1.2.3/8 ", Description: " office "},
+		{CIDR: "198.51.100.7"},
+		{CIDR: "2001:db8::/32"},
+		{CIDR: "::ffff:192.0.2.0/120"},
+		{CIDR: "10.0.0.0/8", Description: "duplicate"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"10.0.0.0/8", "198.51.100.7/32", "2001:db8::/32", "192.0.2.0/24"}
+	if len(params) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), params)
+	}
+	for i, p := range params {
+		if p.CIDR.String() != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, p.CIDR, want[i])
+		}
+	}
+	if params[0].Description != "office" {
+		t.Errorf("description = %q, want trimmed", params[0].Description)
+	}
+}
+
+func TestParseIPAllowlistErrors(t *testing.T) {
+	for _, raw := range []string{"", "10.0.0.0/33", "example.com", "fe80::1%eth0"} {
+		if _, err := parseIPAllowlist([]types.IPAllowlistInput{{CIDR: raw}}); !errors.Is(err, ErrInvalidCIDR) {
+			t.Errorf("%q: err = %v, want ErrInvalidCIDR", raw, err)
+		}
+	}
+
+	tooMany := make([]types.IPAllowlistInput, types.MaxIPAllowlistEntries+1)
+	if _, err := parseIPAllowlist(tooMany); !errors.Is(err, ErrIPAllowlistTooLarge) {
+		t.Errorf("err = %v, want ErrIPAllowlistTooLarge", err)
+	}
+}
+
+func TestAllowlistPermits(t *testing.T) {
+	prefixes := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.20.30.40", true},
+		{"::ffff:10.20.30.40", true},
+		{"2001:db8::5", true},
+		{"192.168.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := allowlistPermits(prefixes, netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if allowlistPermits(prefixes, netip.Addr{}) {
+		t.Error("expected unknown address to be refused by a non-empty allowlist")
+	}
+	if !allowlistPermits(nil, netip.Addr{}) {
+		t.Error("expected an empty allowlist to permit every address")
+	}
+}
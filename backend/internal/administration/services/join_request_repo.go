@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const joinRequestSelect = `SELECT j.id, j.organization_id, j.user_id, u.email, u.first_name, u.last_name,
+	u.email_verified_at IS NOT NULL, j.status, j.decided_by, j.decided_at, j.created_at
+	FROM org_join_requests j
+	JOIN users u ON u.id = j.user_id`
+
+type pgxJoinRequestRepository struct{}
+
+func NewJoinRequestRepository() types.JoinRequestRepository {
+	return &pgxJoinRequestRepository{}
+}
+
+func scanJoinRequest(row pgx.Row) (*types.JoinRequest, error) {
+	var j types.JoinRequest
+	err := row.Scan(&j.ID, &j.OrganizationID, &j.UserID, &j.Email, &j.FirstName, &j.LastName,
+		&j.EmailVerified, &j.Status, &j.DecidedBy, &j.DecidedAt, &j.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// CreatePending records a pending join request. An existing pending request
+// for the same user and org is left as is.
+func (r *pgxJoinRequestRepository) CreatePending(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO org_join_requests (organization_id, user_id)
+		 VALUES ($1, $2)
+		 ON CONFLICT (organization_id, user_id) WHERE status = 'pending' DO NOTHING`,
+		orgID, userID)
+	if err != nil {
+		return fmt.Errorf("create join request: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxJoinRequestRepository) ListPendingByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.JoinRequest, error) {
+	rows, err := db.Query(ctx,
+		joinRequestSelect+` WHERE j.organization_id = $1 AND j.status = 'pending' ORDER BY j.created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list join requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*types.JoinRequest
+	for rows.Next() {
+		j, err := scanJoinRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan join request: %w", err)
+		}
+		requests = append(requests, j)
+	}
+	return requests, nil
+}
+
+// GetByIDForUpdate returns a join request with its row locked. Must be called
+// inside a transaction.
+func (r *pgxJoinRequestRepository) GetByIDForUpdate(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.JoinRequest, error) {
+	j, err := scanJoinRequest(db.QueryRow(ctx,
+		joinRequestSelect+` WHERE j.organization_id = $1 AND j.id = $2 FOR UPDATE OF j`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get join request: %w", err)
+	}
+	return j, nil
+}
+
+func (r *pgxJoinRequestRepository) Decide(ctx context.Context, db database.DBTX, id uuid.UUID, status string, decidedBy uuid.UUID) (*types.JoinRequest, error) {
+	j, err := scanJoinRequest(db.QueryRow(ctx,
+		`WITH j AS (
+		     UPDATE org_join_requests SET status = $2, decided_by = $3, decided_at = NOW()
+		     WHERE id = $1
+		     RETURNING *
+		 )
+		 SELECT j.id, j.organization_id, j.user_id, u.email, u.first_name, u.last_name,
+		        u.email_verified_at IS NOT NULL, j.status, j.decided_by, j.decided_at, j.created_at
+		 FROM j JOIN users u ON u.id = j.user_id`,
+		id, status, decidedBy))
+	if err != nil {
+		return nil, fmt.Errorf("decide join request: %w", err)
+	}
+	return j, nil
+}
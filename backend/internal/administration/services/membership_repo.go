@@ -10,6 +10,13 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// roleIDForKey resolves a role key ($3) to the built-in role or the custom
+// role of the org ($2). Custom role keys never shadow built-in keys.
+const roleIDForKey = `(SELECT id FROM org_roles WHERE key = $3 AND (organization_id IS NULL OR organization_id = $2))`
+
+// adminRoleID selects the built-in admin role.
+const adminRoleID = `(SELECT id FROM org_roles WHERE organization_id IS NULL AND key = 'admin')`
+
 type pgxMembershipRepository struct{}
 
 func NewMembershipRepository() types.MembershipRepository {
@@ -19,11 +26,15 @@ func NewMembershipRepository() types.MembershipRepository {
 func (r *pgxMembershipRepository) Create(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID, role string) (*types.OrgMembership, error) {
 	var m types.OrgMembership
 	err := db.QueryRow(ctx,
-		`INSERT INTO org_memberships (user_id, organization_id, role)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, user_id, organization_id, role, created_at, updated_at`,
+		`WITH m AS (
+		     INSERT INTO org_memberships (user_id, organization_id, role_id)
+		     VALUES ($1, $2, `+roleIDForKey+`)
+		     RETURNING id, user_id, organization_id, role_id, created_at, updated_at
+		 )
+		 SELECT m.id, m.user_id, m.organization_id, m.role_id, r.key, m.created_at, m.updated_at
+		 FROM m JOIN org_roles r ON r.id = m.role_id`,
 		userID, orgID, role,
-	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.RoleID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("create membership: %w", err)
 	}
@@ -33,10 +44,12 @@ func (r *pgxMembershipRepository) Create(ctx context.Context, db database.DBTX,
 func (r *pgxMembershipRepository) GetByUserAndOrg(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) (*types.OrgMembership, error) {
 	var m types.OrgMembership
 	err := db.QueryRow(ctx,
-		`SELECT id, user_id, organization_id, role, created_at, updated_at
-		 FROM org_memberships WHERE user_id = $1 AND organization_id = $2`,
+		`SELECT m.id, m.user_id, m.organization_id, m.role_id, r.key, m.created_at, m.updated_at
+		 FROM org_memberships m
+		 JOIN org_roles r ON r.id = m.role_id
+		 WHERE m.user_id = $1 AND m.organization_id = $2`,
 		userID, orgID,
-	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.RoleID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -46,12 +59,34 @@ func (r *pgxMembershipRepository) GetByUserAndOrg(ctx context.Context, db databa
 	return &m, nil
 }
 
+// GetByUserAndOrgForUpdate is GetByUserAndOrg with a row lock. Must be called
+// inside a transaction.
+func (r *pgxMembershipRepository) GetByUserAndOrgForUpdate(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) (*types.OrgMembership, error) {
+	var m types.OrgMembership
+	err := db.QueryRow(ctx,
+		`SELECT m.id, m.user_id, m.organization_id, m.role_id, r.key, m.created_at, m.updated_at
+		 FROM org_memberships m
+		 JOIN org_roles r ON r.id = m.role_id
+		 WHERE m.user_id = $1 AND m.organization_id = $2
+		 FOR UPDATE OF m`,
+		userID, orgID,
+	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.RoleID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get membership for update: %w", err)
+	}
+	return &m, nil
+}
+
 func (r *pgxMembershipRepository) ListByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) ([]*types.OrgWithRole, error) {
 	rows, err := db.Query(ctx,
-		`SELECT o.id, o.name, o.slug, m.role, o.created_at
+		`SELECT o.id, o.name, o.slug, r.key, o.created_at
 		 FROM org_memberships m
 		 JOIN organizations o ON o.id = m.organization_id
-		 WHERE m.user_id = $1
+		 JOIN org_roles r ON r.id = m.role_id
+		 WHERE m.user_id = $1 AND o.deleted_at IS NULL
 		 ORDER BY o.created_at DESC`, userID)
 	if err != nil {
 		return nil, fmt.Errorf("list orgs by user: %w", err)
@@ -71,9 +106,10 @@ func (r *pgxMembershipRepository) ListByUser(ctx context.Context, db database.DB
 
 func (r *pgxMembershipRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.MemberWithUser, error) {
 	rows, err := db.Query(ctx,
-		`SELECT u.id, u.email, u.first_name, u.last_name, m.role, m.created_at
+		`SELECT u.id, u.email, u.first_name, u.last_name, r.key, m.created_at
 		 FROM org_memberships m
 		 JOIN users u ON u.id = m.user_id
+		 JOIN org_roles r ON r.id = m.role_id
 		 WHERE m.organization_id = $1
 		 ORDER BY m.created_at ASC`, orgID)
 	if err != nil {
@@ -92,6 +128,52 @@ func (r *pgxMembershipRepository) ListByOrg(ctx context.Context, db database.DBT
 	return members, nil
 }
 
+func (r *pgxMembershipRepository) ListWithoutMFA(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.MemberWithUser, error) {
+	rows, err := db.Query(ctx,
+		`SELECT u.id, u.email, u.first_name, u.last_name, r.key, m.created_at
+		 FROM org_memberships m
+		 JOIN users u ON u.id = m.user_id
+		 JOIN org_roles r ON r.id = m.role_id
+		 LEFT JOIN user_totp t ON t.user_id = m.user_id AND t.confirmed_at IS NOT NULL
+		 WHERE m.organization_id = $1 AND t.user_id IS NULL
+		 ORDER BY m.created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list members without mfa: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*types.MemberWithUser
+	for rows.Next() {
+		var m types.MemberWithUser
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FirstName, &m.LastName, &m.Role, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("scan member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	return members, nil
+}
+
+func (r *pgxMembershipRepository) UpdateRole(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID, role string) (*types.OrgMembership, error) {
+	var m types.OrgMembership
+	err := db.QueryRow(ctx,
+		`WITH m AS (
+		     UPDATE org_memberships SET role_id = `+roleIDForKey+`, updated_at = NOW()
+		     WHERE user_id = $1 AND organization_id = $2
+		     RETURNING id, user_id, organization_id, role_id, created_at, updated_at
+		 )
+		 SELECT m.id, m.user_id, m.organization_id, m.role_id, r.key, m.created_at, m.updated_at
+		 FROM m JOIN org_roles r ON r.id = m.role_id`,
+		userID, orgID, role,
+	).Scan(&m.ID, &m.UserID, &m.OrganizationID, &m.RoleID, &m.Role, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update membership role: %w", err)
+	}
+	return &m, nil
+}
+
 func (r *pgxMembershipRepository) Delete(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) error {
 	tag, err := db.Exec(ctx,
 		`DELETE FROM org_memberships WHERE user_id = $1 AND organization_id = $2`,
@@ -108,7 +190,7 @@ func (r *pgxMembershipRepository) Delete(ctx context.Context, db database.DBTX,
 func (r *pgxMembershipRepository) CountAdmins(ctx context.Context, db database.DBTX, orgID uuid.UUID) (int, error) {
 	var count int
 	err := db.QueryRow(ctx,
-		`SELECT COUNT(*) FROM org_memberships WHERE organization_id = $1 AND role = 'admin'`,
+		`SELECT COUNT(*) FROM org_memberships WHERE organization_id = $1 AND role_id = `+adminRoleID,
 		orgID,
 	).Scan(&count)
 	if err != nil {
@@ -116,3 +198,28 @@ func (r *pgxMembershipRepository) CountAdmins(ctx context.Context, db database.D
 	}
 	return count, nil
 }
+
+// LockAdmins locks the org's admin membership rows and returns their user IDs.
+// Rows are locked in a stable order so concurrent callers queue behind each
+// other instead of deadlocking. Must be called inside a transaction.
+func (r *pgxMembershipRepository) LockAdmins(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx,
+		`SELECT user_id FROM org_memberships
+		 WHERE organization_id = $1 AND role_id = `+adminRoleID+`
+		 ORDER BY id
+		 FOR UPDATE`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("lock admins: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan admin: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MFAPolicyService applies an org's requireMfa setting to its members.
+type MFAPolicyService struct {
+	pool           *pgxpool.Pool
+	settingsRepo   types.OrgSettingsRepository
+	membershipRepo types.MembershipRepository
+}
+
+func NewMFAPolicyService(pool *pgxpool.Pool, settingsRepo types.OrgSettingsRepository, membershipRepo types.MembershipRepository) *MFAPolicyService {
+	return &MFAPolicyService{
+		pool:           pool,
+		settingsRepo:   settingsRepo,
+		membershipRepo: membershipRepo,
+	}
+}
+
+// Deadline returns when a member's sessions must be MFA-authenticated to
+// access the org, or nil if the org does not require MFA.
+func (s *MFAPolicyService) Deadline(ctx context.Context, membership *types.OrgMembership) (*time.Time, error) {
+	rec, err := s.settingsRepo.Get(ctx, s.pool, membership.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	requiredAt, grace, err := mfaPolicy(rec)
+	if err != nil || requiredAt == nil {
+		return nil, err
+	}
+	deadline := mfaDeadline(*requiredAt, membership.CreatedAt, grace)
+	return &deadline, nil
+}
+
+// ComplianceReport lists the org's members who have not set up MFA. It is
+// available whether or not the org requires MFA, so admins can see who would
+// be affected before switching it on.
+func (s *MFAPolicyService) ComplianceReport(ctx context.Context, orgID uuid.UUID) (*types.MFAComplianceReport, error) {
+	rec, err := s.settingsRepo.Get(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, err
+	}
+	requiredAt, grace, err := mfaPolicy(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.membershipRepo.ListWithoutMFA(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &types.MFAComplianceReport{
+		RequireMFA:          requiredAt != nil,
+		RequiredSince:       requiredAt,
+		GracePeriodDays:     grace,
+		NonCompliantMembers: make([]*types.NonCompliantMember, len(members)),
+	}
+	for i, m := range members {
+		member := &types.NonCompliantMember{
+			UserID:    m.UserID,
+			Email:     m.Email,
+			FirstName: m.FirstName,
+			LastName:  m.LastName,
+			Role:      m.Role,
+			JoinedAt:  m.JoinedAt,
+		}
+		if requiredAt != nil {
+			deadline := mfaDeadline(*requiredAt, m.JoinedAt, grace)
+			member.Deadline = &deadline
+			member.Overdue = now.After(deadline)
+		}
+		report.NonCompliantMembers[i] = member
+	}
+	return report, nil
+}
+
+// mfaPolicy reads when MFA became required (nil if it isn't) and the grace
+// period in days from a settings record.
+func mfaPolicy(rec *types.OrgSettingsRecord) (*time.Time, int, error) {
+	if rec == nil {
+		return nil, 0, nil
+	}
+	settings, err := effectiveSettings(rec.Values)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !settings.RequireMFA || rec.MFARequiredAt == nil {
+		return nil, settings.MFAGracePeriodDays, nil
+	}
+	return rec.MFARequiredAt, settings.MFAGracePeriodDays, nil
+}
+
+// mfaDeadline gives a member the grace period from when MFA became required,
+// or from when they joined if that was later.
+func mfaDeadline(requiredAt, joinedAt time.Time, graceDays int) time.Time {
+	start := requiredAt
+	if joinedAt.After(start) {
+		start = joinedAt
+	}
+	return start.AddDate(0, 0, graceDays)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+)
+
+func TestMFADeadline(t *testing.T) {
+	requiredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	existing := mfaDeadline(requiredAt, requiredAt.AddDate(-1, 0, 0), 14)
+	if want := requiredAt.AddDate(0, 0, 14); !existing.Equal(want) {
+		t.Errorf("existing member deadline = %s, want %s", existing, want)
+	}
+
+	joinedAt := requiredAt.AddDate(0, 0, 30)
+	newcomer := mfaDeadline(requiredAt, joinedAt, 14)
+	if want := joinedAt.AddDate(0, 0, 14); !newcomer.Equal(want) {
+		t.Errorf("new member deadline = %s, want %s", newcomer, want)
+	}
+
+	if immediate := mfaDeadline(requiredAt, requiredAt.AddDate(0, -1, 0), 0); !immediate.Equal(requiredAt) {
+		t.Errorf("no-grace deadline = %s, want %s", immediate, requiredAt)
+	}
+}
+
+func TestMFAPolicy(t *testing.T) {
+	requiredAt := time.Now()
+
+	if at, _, err := mfaPolicy(nil); err != nil || at != nil {
+		t.Fatalf("unset settings: at=%v err=%v", at, err)
+	}
+
+	rec := &types.OrgSettingsRecord{
+		Values: map[string]json.RawMessage{
+			types.SettingRequireMFA:         json.RawMessage(`true`),
+			types.SettingMFAGracePeriodDays: json.RawMessage(`7`),
+		},
+		MFARequiredAt: &requiredAt,
+	}
+	at, grace, err := mfaPolicy(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at == nil || !at.Equal(requiredAt) || grace != 7 {
+		t.Fatalf("policy = %v, %d; want %s, 7", at, grace, requiredAt)
+	}
+
+	rec.Values[types.SettingRequireMFA] = json.RawMessage(`false`)
+	if at, _, _ := mfaPolicy(rec); at != nil {
+		t.Fatalf("disabled policy returned required since %s", at)
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/mailer"
+)
+
+// NotificationService emails people about organization events. Its methods
+// are event handlers that run inside the publisher's transaction, so an
+// email is queued only if the change it reports commits.
+type NotificationService struct {
+	emailService    types.EmailService
+	membershipRepo  types.MembershipRepository
+	settingsService *SettingsService
+}
+
+func NewNotificationService(emailService types.EmailService, membershipRepo types.MembershipRepository, settingsService *SettingsService) *NotificationService {
+	return &NotificationService{
+		emailService:    emailService,
+		membershipRepo:  membershipRepo,
+		settingsService: settingsService,
+	}
+}
+
+// InvitationSent emails an invitation's link, in the org's email locale and
+// branding. The link is queued in the outbox payload with the email; see
+// events.InvitationSent for how long it is kept.
+func (s *NotificationService) InvitationSent(ctx context.Context, db database.DBTX, e events.InvitationSent) error {
+	settings, err := s.settingsService.Effective(ctx, db, e.OrgID)
+	if err != nil {
+		return fmt.Errorf("get org settings: %w", err)
+	}
+	err = s.emailService.Send(ctx, db, mailer.Email{
+		To:       e.Email,
+		Template: mailer.TemplateInvitation,
+		Locale:   settings.EmailLocale,
+		Branding: settings.EmailBranding(e.OrgName),
+		Data: mailer.InvitationData{
+			InviterName: e.InviterName,
+			OrgName:     e.OrgName,
+			InviteURL:   e.InviteURL,
+			ExpiresAt:   e.ExpiresAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send invitation email: %w", err)
+	}
+	return nil
+}
+
+// OrgDeleted tells every member of a deleted org until when it can be
+// restored.
+func (s *NotificationService) OrgDeleted(ctx context.Context, db database.DBTX, e events.OrgDeleted) error {
+	members, err := s.membershipRepo.ListByOrg(ctx, db, e.OrgID)
+	if err != nil {
+		return err
+	}
+	settings, err := s.settingsService.Effective(ctx, db, e.OrgID)
+	if err != nil {
+		return fmt.Errorf("get org settings: %w", err)
+	}
+	for _, m := range members {
+		err := s.emailService.Send(ctx, db, mailer.Email{
+			To:       m.Email,
+			Template: mailer.TemplateOrgDeleted,
+			Locale:   settings.EmailLocale,
+			Branding: settings.EmailBranding(e.Name),
+			Data:     mailer.OrgDeletedData{OrgName: e.Name, RestoreBy: e.RestoreBy},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"agenteur.ai/api/internal/administration/types"
 	"agenteur.ai/api/internal/database"
@@ -21,9 +22,9 @@ func (r *pgxOrganizationRepository) Create(ctx context.Context, db database.DBTX
 	err := db.QueryRow(ctx,
 		`INSERT INTO organizations (name, slug)
 		 VALUES ($1, $2)
-		 RETURNING id, name, slug, created_at, updated_at`,
+		 RETURNING id, name, slug, created_at, updated_at, deleted_at`,
 		params.Name, params.Slug,
-	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt)
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
 	if err != nil {
 		return nil, fmt.Errorf("create organization: %w", err)
 	}
@@ -33,8 +34,8 @@ func (r *pgxOrganizationRepository) Create(ctx context.Context, db database.DBTX
 func (r *pgxOrganizationRepository) GetByID(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.Organization, error) {
 	var o types.Organization
 	err := db.QueryRow(ctx,
-		`SELECT id, name, slug, created_at, updated_at FROM organizations WHERE id = $1`, id,
-	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt)
+		`SELECT id, name, slug, created_at, updated_at, deleted_at FROM organizations WHERE id = $1`, id,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -47,8 +48,8 @@ func (r *pgxOrganizationRepository) GetByID(ctx context.Context, db database.DBT
 func (r *pgxOrganizationRepository) GetBySlug(ctx context.Context, db database.DBTX, slug string) (*types.Organization, error) {
 	var o types.Organization
 	err := db.QueryRow(ctx,
-		`SELECT id, name, slug, created_at, updated_at FROM organizations WHERE LOWER(slug) = LOWER($1)`, slug,
-	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt)
+		`SELECT id, name, slug, created_at, updated_at, deleted_at FROM organizations WHERE LOWER(slug) = LOWER($1)`, slug,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -58,14 +59,81 @@ func (r *pgxOrganizationRepository) GetBySlug(ctx context.Context, db database.D
 	return &o, nil
 }
 
+// GetByHistoricalSlug finds the org that previously used slug.
+func (r *pgxOrganizationRepository) GetByHistoricalSlug(ctx context.Context, db database.DBTX, slug string) (*types.Organization, error) {
+	var o types.Organization
+	err := db.QueryRow(ctx,
+		`SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, o.deleted_at
+		 FROM organization_slug_history h
+		 JOIN organizations o ON o.id = h.organization_id
+		 WHERE LOWER(h.slug) = LOWER($1)`, slug,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get organization by historical slug: %w", err)
+	}
+	return &o, nil
+}
+
+// LockSlug takes a transaction-scoped advisory lock on a slug. Every path that
+// assigns a slug takes this lock first, so the availability check and the
+// write that follows cannot interleave with another transaction claiming the
+// same slug. Must be called inside a transaction.
+func (r *pgxOrganizationRepository) LockSlug(ctx context.Context, db database.DBTX, slug string) error {
+	_, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('organization_slug:' || LOWER($1)))`, slug)
+	if err != nil {
+		return fmt.Errorf("lock slug: %w", err)
+	}
+	return nil
+}
+
+// SlugInUse reports whether slug is the current or a historical slug of any
+// org other than excludeOrgID.
+func (r *pgxOrganizationRepository) SlugInUse(ctx context.Context, db database.DBTX, slug string, excludeOrgID uuid.UUID) (bool, error) {
+	var inUse bool
+	err := db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM organizations WHERE LOWER(slug) = LOWER($1) AND id <> $2)
+		     OR EXISTS (SELECT 1 FROM organization_slug_history WHERE LOWER(slug) = LOWER($1) AND organization_id <> $2)`,
+		slug, excludeOrgID,
+	).Scan(&inUse)
+	if err != nil {
+		return false, fmt.Errorf("check slug in use: %w", err)
+	}
+	return inUse, nil
+}
+
+func (r *pgxOrganizationRepository) AddSlugHistory(ctx context.Context, db database.DBTX, orgID uuid.UUID, slug string) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO organization_slug_history (organization_id, slug) VALUES ($1, $2)`,
+		orgID, slug)
+	if err != nil {
+		return fmt.Errorf("add slug history: %w", err)
+	}
+	return nil
+}
+
+// DeleteSlugHistory removes an org's own historical slug, used when the org
+// switches back to it.
+func (r *pgxOrganizationRepository) DeleteSlugHistory(ctx context.Context, db database.DBTX, orgID uuid.UUID, slug string) error {
+	_, err := db.Exec(ctx,
+		`DELETE FROM organization_slug_history WHERE organization_id = $1 AND LOWER(slug) = LOWER($2)`,
+		orgID, slug)
+	if err != nil {
+		return fmt.Errorf("delete slug history: %w", err)
+	}
+	return nil
+}
+
 func (r *pgxOrganizationRepository) Update(ctx context.Context, db database.DBTX, id uuid.UUID, params types.UpdateOrgParams) (*types.Organization, error) {
 	var o types.Organization
 	err := db.QueryRow(ctx,
-		`UPDATE organizations SET name = $2, updated_at = NOW()
+		`UPDATE organizations SET name = $2, slug = $3, updated_at = NOW()
 		 WHERE id = $1
-		 RETURNING id, name, slug, created_at, updated_at`,
-		id, params.Name,
-	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt)
+		 RETURNING id, name, slug, created_at, updated_at, deleted_at`,
+		id, params.Name, params.Slug,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
 	if err != nil {
 		return nil, fmt.Errorf("update organization: %w", err)
 	}
@@ -74,7 +142,9 @@ func (r *pgxOrganizationRepository) Update(ctx context.Context, db database.DBTX
 
 func (r *pgxOrganizationRepository) ListAll(ctx context.Context, db database.DBTX) ([]*types.Organization, error) {
 	rows, err := db.Query(ctx,
-		`SELECT id, name, slug, created_at, updated_at FROM organizations ORDER BY created_at DESC`)
+		`SELECT id, name, slug, created_at, updated_at, deleted_at FROM organizations
+		 WHERE deleted_at IS NULL
+		 ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, fmt.Errorf("list organizations: %w", err)
 	}
@@ -83,10 +153,57 @@ func (r *pgxOrganizationRepository) ListAll(ctx context.Context, db database.DBT
 	var orgs []*types.Organization
 	for rows.Next() {
 		var o types.Organization
-		if err := rows.Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		if err := rows.Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt); err != nil {
 			return nil, fmt.Errorf("scan organization: %w", err)
 		}
 		orgs = append(orgs, &o)
 	}
 	return orgs, nil
 }
+
+// SoftDelete marks an active organization as deleted. Returns nil if the org
+// does not exist or is already deleted.
+func (r *pgxOrganizationRepository) SoftDelete(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.Organization, error) {
+	var o types.Organization
+	err := db.QueryRow(ctx,
+		`UPDATE organizations SET deleted_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NULL
+		 RETURNING id, name, slug, created_at, updated_at, deleted_at`, id,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("soft delete organization: %w", err)
+	}
+	return &o, nil
+}
+
+// Restore clears deleted_at for an org deleted after deletedAfter. Returns nil
+// if the org is not deleted or its restore window has passed.
+func (r *pgxOrganizationRepository) Restore(ctx context.Context, db database.DBTX, id uuid.UUID, deletedAfter time.Time) (*types.Organization, error) {
+	var o types.Organization
+	err := db.QueryRow(ctx,
+		`UPDATE organizations SET deleted_at = NULL, updated_at = NOW()
+		 WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_at > $2
+		 RETURNING id, name, slug, created_at, updated_at, deleted_at`, id, deletedAfter,
+	).Scan(&o.ID, &o.Name, &o.Slug, &o.CreatedAt, &o.UpdatedAt, &o.DeletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("restore organization: %w", err)
+	}
+	return &o, nil
+}
+
+// PurgeDeleted hard-deletes orgs soft-deleted at or before deletedBefore.
+// Memberships and invitations are removed by ON DELETE CASCADE.
+func (r *pgxOrganizationRepository) PurgeDeleted(ctx context.Context, db database.DBTX, deletedBefore time.Time) (int64, error) {
+	tag, err := db.Exec(ctx,
+		`DELETE FROM organizations WHERE deleted_at IS NOT NULL AND deleted_at <= $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted organizations: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
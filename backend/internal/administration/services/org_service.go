@@ -2,60 +2,72 @@ package services
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"time"
 
 	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
 	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
-	ErrLastAdmin  = errors.New("cannot remove the last admin from the organization")
-	ErrNotFound   = errors.New("resource not found")
+	ErrLastAdmin      = errors.New("cannot remove the last admin from the organization")
+	ErrNotFound       = errors.New("resource not found")
 	ErrMemberNotFound = errors.New("member not found")
+	ErrTransferToSelf = errors.New("cannot transfer ownership to yourself")
+	ErrOrgNotDeleted  = errors.New("organization is not deleted")
+	ErrRestoreExpired = errors.New("organization restore window has expired")
+	ErrMemberOutranks = errors.New("member's role has permissions you do not hold")
 )
 
-var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
-
 type OrgService struct {
-	pool           *pgxpool.Pool
-	orgRepo        types.OrganizationRepository
-	membershipRepo types.MembershipRepository
+	pool                *pgxpool.Pool
+	orgRepo             types.OrganizationRepository
+	membershipRepo      types.MembershipRepository
+	invitationRepo      types.InvitationRepository
+	roleRepo            types.RoleRepository
+	eventBus            events.Publisher
+	auditLog            *audit.Log
+	deletionGracePeriod time.Duration
 }
 
-func NewOrgService(pool *pgxpool.Pool, orgRepo types.OrganizationRepository, membershipRepo types.MembershipRepository) *OrgService {
+func NewOrgService(
+	pool *pgxpool.Pool,
+	orgRepo types.OrganizationRepository,
+	membershipRepo types.MembershipRepository,
+	invitationRepo types.InvitationRepository,
+	roleRepo types.RoleRepository,
+	eventBus events.Publisher,
+	auditLog *audit.Log,
+	deletionGracePeriod time.Duration,
+) *OrgService {
 	return &OrgService{
-		pool:           pool,
-		orgRepo:        orgRepo,
-		membershipRepo: membershipRepo,
+		pool:                pool,
+		orgRepo:             orgRepo,
+		membershipRepo:      membershipRepo,
+		invitationRepo:      invitationRepo,
+		roleRepo:            roleRepo,
+		eventBus:            eventBus,
+		auditLog:            auditLog,
+		deletionGracePeriod: deletionGracePeriod,
 	}
 }
 
 // Create creates a new organization and assigns the user as admin.
 func (s *OrgService) Create(ctx context.Context, userID uuid.UUID, name string) (*types.Organization, error) {
-	slug := generateSlug(name)
+	base := generateSlug(name)
 
 	var org *types.Organization
 	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
-		// Check slug collision, append random suffix if needed
-		existing, err := s.orgRepo.GetBySlug(ctx, tx, slug)
+		slug, err := s.claimGeneratedSlug(ctx, tx, base)
 		if err != nil {
 			return err
 		}
-		if existing != nil {
-			suffix, err := randomSuffix()
-			if err != nil {
-				return err
-			}
-			slug = slug + "-" + suffix
-		}
 
 		org, err = s.orgRepo.Create(ctx, tx, types.CreateOrgParams{
 			Name: name,
@@ -65,8 +77,21 @@ func (s *OrgService) Create(ctx context.Context, userID uuid.UUID, name string)
 			return err
 		}
 
-		_, err = s.membershipRepo.Create(ctx, tx, userID, org.ID, "admin")
-		return err
+		if _, err := s.membershipRepo.Create(ctx, tx, userID, org.ID, types.RoleAdmin); err != nil {
+			return err
+		}
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: org.ID,
+			ActorID:        userID,
+			Action:         audit.ActionOrgCreated,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       org.ID,
+			After:          orgSnapshot(org),
+		})
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.OrgCreated{OrgID: org.ID, Name: org.Name, Slug: org.Slug, CreatedBy: userID})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create org: %w", err)
@@ -74,6 +99,30 @@ func (s *OrgService) Create(ctx context.Context, userID uuid.UUID, name string)
 	return org, nil
 }
 
+// claimGeneratedSlug finds a free slug derived from base, appending a random
+// suffix on collision. Each candidate is checked under its advisory lock, which
+// stays held until the transaction ends, so a concurrent create cannot claim
+// the same candidate between the check and the insert.
+func (s *OrgService) claimGeneratedSlug(ctx context.Context, tx pgx.Tx, base string) (string, error) {
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		candidate, err := slugCandidate(base, attempt)
+		if err != nil {
+			return "", err
+		}
+		if err := s.orgRepo.LockSlug(ctx, tx, candidate); err != nil {
+			return "", err
+		}
+		inUse, err := s.orgRepo.SlugInUse(ctx, tx, candidate, uuid.Nil)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return candidate, nil
+		}
+	}
+	return "", ErrSlugTaken
+}
+
 // List returns the user's orgs, or all orgs if superadmin.
 func (s *OrgService) List(ctx context.Context, userID uuid.UUID, isSuperadmin bool) ([]*types.OrgWithRole, error) {
 	if isSuperadmin {
@@ -87,7 +136,7 @@ func (s *OrgService) List(ctx context.Context, userID uuid.UUID, isSuperadmin bo
 				ID:        o.ID,
 				Name:      o.Name,
 				Slug:      o.Slug,
-				Role:      "admin",
+				Role:      types.RoleAdmin,
 				CreatedAt: o.CreatedAt,
 			}
 		}
@@ -102,15 +151,111 @@ func (s *OrgService) Get(ctx context.Context, orgID uuid.UUID) (*types.Organizat
 	if err != nil {
 		return nil, err
 	}
-	if org == nil {
+	if org == nil || org.DeletedAt != nil {
 		return nil, ErrNotFound
 	}
 	return org, nil
 }
 
-// Update updates an organization's name.
-func (s *OrgService) Update(ctx context.Context, orgID uuid.UUID, name string) (*types.Organization, error) {
-	return s.orgRepo.Update(ctx, s.pool, orgID, types.UpdateOrgParams{Name: name})
+// Update updates an organization's name and, optionally, its slug. Empty
+// fields are left unchanged. A replaced slug is kept in the slug history so
+// links using it still resolve, and stays reserved for this org.
+func (s *OrgService) Update(ctx context.Context, orgID uuid.UUID, params types.UpdateOrgParams) (*types.Organization, error) {
+	var org *types.Organization
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.orgRepo.GetByID(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.DeletedAt != nil {
+			return ErrNotFound
+		}
+
+		if params.Name == "" {
+			params.Name = current.Name
+		}
+		if params.Slug == "" || params.Slug == current.Slug {
+			params.Slug = current.Slug
+		} else {
+			if err := ValidateSlug(params.Slug); err != nil {
+				return err
+			}
+			if err := s.orgRepo.LockSlug(ctx, tx, params.Slug); err != nil {
+				return err
+			}
+			inUse, err := s.orgRepo.SlugInUse(ctx, tx, params.Slug, orgID)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return ErrSlugTaken
+			}
+			if err := s.orgRepo.DeleteSlugHistory(ctx, tx, orgID, params.Slug); err != nil {
+				return err
+			}
+			if err := s.orgRepo.AddSlugHistory(ctx, tx, orgID, current.Slug); err != nil {
+				return err
+			}
+		}
+
+		org, err = s.orgRepo.Update(ctx, tx, orgID, params)
+		if database.IsUniqueViolation(err) {
+			return ErrSlugTaken
+		}
+		if err != nil {
+			return err
+		}
+		if orgSnapshot(current) == orgSnapshot(org) {
+			return nil
+		}
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionOrgUpdated,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       orgID,
+			Before:         orgSnapshot(current),
+			After:          orgSnapshot(org),
+		})
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.OrgUpdated{OrgID: org.ID, Name: org.Name, Slug: org.Slug})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ResolveSlug finds an org by its current or a historical slug. redirected is
+// true when slug is historical and callers should switch to org.Slug. Orgs the
+// user cannot access are reported as ErrNotFound.
+func (s *OrgService) ResolveSlug(ctx context.Context, userID uuid.UUID, isSuperadmin bool, slug string) (org *types.Organization, redirected bool, err error) {
+	org, err = s.orgRepo.GetBySlug(ctx, s.pool, slug)
+	if err != nil {
+		return nil, false, err
+	}
+	if org == nil {
+		org, err = s.orgRepo.GetByHistoricalSlug(ctx, s.pool, slug)
+		if err != nil {
+			return nil, false, err
+		}
+		redirected = true
+	}
+	if org == nil || org.DeletedAt != nil {
+		return nil, false, ErrNotFound
+	}
+
+	if !isSuperadmin {
+		membership, err := s.membershipRepo.GetByUserAndOrg(ctx, s.pool, userID, org.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		if membership == nil {
+			return nil, false, ErrNotFound
+		}
+	}
+	return org, redirected, nil
 }
 
 // ListMembers returns all members of an organization.
@@ -118,43 +263,289 @@ func (s *OrgService) ListMembers(ctx context.Context, orgID uuid.UUID) ([]*types
 	return s.membershipRepo.ListByOrg(ctx, s.pool, orgID)
 }
 
-// RemoveMember removes a member from an organization.
-func (s *OrgService) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
-	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, s.pool, userID, orgID)
+// RemoveMember removes a member from an organization. actor is the removing
+// member's permission set, which must include every permission of the
+// member's role. The org's admin rows are locked for the duration of the
+// transaction, so two admins removing each other concurrently cannot leave
+// the org without an admin.
+func (s *OrgService) RemoveMember(ctx context.Context, orgID, userID uuid.UUID, actor types.PermissionSet) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		check := func(m *types.OrgMembership) error {
+			return s.checkOutranks(ctx, tx, orgID, m.Role, actor)
+		}
+		removed, err := removeMember(ctx, tx, s.membershipRepo, s.auditLog, orgID, userID, audit.ActionMemberRemoved, check)
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.MemberRemoved{OrgID: orgID, UserID: userID, Role: removed.Role, Via: events.ViaRemoved})
+	})
+}
+
+// removeMember deletes a membership inside the caller's transaction,
+// enforcing the last-admin rule under a lock on the org's admin rows, and
+// records it under action. check, if not nil, vets the locked membership
+// before it is deleted. It returns the deleted membership.
+func removeMember(ctx context.Context, tx pgx.Tx, membershipRepo types.MembershipRepository, auditLog *audit.Log, orgID, userID uuid.UUID, action string, check func(*types.OrgMembership) error) (*types.OrgMembership, error) {
+	admins, err := membershipRepo.LockAdmins(ctx, tx, orgID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	membership, err := membershipRepo.GetByUserAndOrgForUpdate(ctx, tx, userID, orgID)
+	if err != nil {
+		return nil, err
 	}
 	if membership == nil {
-		return ErrMemberNotFound
+		return nil, ErrMemberNotFound
+	}
+
+	if check != nil {
+		if err := check(membership); err != nil {
+			return nil, err
+		}
 	}
 
-	if membership.Role == "admin" {
-		count, err := s.membershipRepo.CountAdmins(ctx, s.pool, orgID)
+	if membership.Role == types.RoleAdmin && len(admins) <= 1 {
+		return nil, ErrLastAdmin
+	}
+
+	if err := membershipRepo.Delete(ctx, tx, userID, orgID); err != nil {
+		return nil, err
+	}
+	err = auditLog.Record(ctx, tx, audit.Entry{
+		OrganizationID: orgID,
+		Action:         action,
+		TargetType:     audit.TargetUser,
+		TargetID:       userID,
+		Before:         memberSnapshot{Role: membership.Role},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// Leave removes the calling user from an organization, subject to the same
+// last-admin rule as RemoveMember.
+func (s *OrgService) Leave(ctx context.Context, orgID, userID uuid.UUID) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		removed, err := removeMember(ctx, tx, s.membershipRepo, s.auditLog, orgID, userID, audit.ActionMemberLeft, nil)
 		if err != nil {
 			return err
 		}
-		if count <= 1 {
+		return s.eventBus.Publish(ctx, tx, events.MemberRemoved{OrgID: orgID, UserID: userID, Role: removed.Role, Via: events.ViaLeft})
+	})
+}
+
+// UpdateMemberRole changes a member's role. As with RemoveMember, actor must
+// hold every permission of the member's current role. Demoting an admin is
+// rejected with ErrLastAdmin if no other admin would remain. The org's admin
+// rows are locked for the duration of the transaction so concurrent demotions
+// are serialized.
+func (s *OrgService) UpdateMemberRole(ctx context.Context, orgID, userID uuid.UUID, role string, actor types.PermissionSet) (*types.OrgMembership, error) {
+	var membership *types.OrgMembership
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		admins, err := s.membershipRepo.LockAdmins(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := s.membershipRepo.GetByUserAndOrgForUpdate(ctx, tx, userID, orgID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrMemberNotFound
+		}
+		if err := s.checkOutranks(ctx, tx, orgID, existing.Role, actor); err != nil {
+			return err
+		}
+
+		if existing.Role == types.RoleAdmin && role != types.RoleAdmin && len(admins) <= 1 {
 			return ErrLastAdmin
 		}
+
+		membership, err = s.membershipRepo.UpdateRole(ctx, tx, userID, orgID, role)
+		if err != nil || existing.Role == role {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionMemberRoleUpdated,
+			TargetType:     audit.TargetUser,
+			TargetID:       userID,
+			Before:         memberSnapshot{Role: existing.Role},
+			After:          memberSnapshot{Role: role},
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
+	return membership, nil
+}
 
-	return s.membershipRepo.Delete(ctx, s.pool, userID, orgID)
+// checkOutranks returns ErrMemberOutranks unless actor holds every permission
+// of the role a member currently has, so a member cannot demote or remove
+// someone with more authority than their own.
+func (s *OrgService) checkOutranks(ctx context.Context, db database.DBTX, orgID uuid.UUID, roleKey string, actor types.PermissionSet) error {
+	role, err := s.roleRepo.GetByKey(ctx, db, orgID, roleKey)
+	if err != nil {
+		return err
+	}
+	if role != nil && !actor.HasAll(role.EffectivePermissions()) {
+		return ErrMemberOutranks
+	}
+	return nil
 }
 
-func generateSlug(name string) string {
-	slug := strings.ToLower(name)
-	slug = nonAlphanumeric.ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
-	if slug == "" {
-		slug = "org"
+// TransferOwnership promotes an existing member to admin and demotes the
+// current admin to user in a single transaction. When fromUserID has no
+// membership (a superadmin acting on the org), the target is only promoted.
+func (s *OrgService) TransferOwnership(ctx context.Context, orgID, fromUserID, toUserID uuid.UUID) (*types.OrgMembership, error) {
+	if fromUserID == toUserID {
+		return nil, ErrTransferToSelf
 	}
-	return slug
+
+	var membership *types.OrgMembership
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		if _, err := s.membershipRepo.LockAdmins(ctx, tx, orgID); err != nil {
+			return err
+		}
+
+		target, err := s.membershipRepo.GetByUserAndOrgForUpdate(ctx, tx, toUserID, orgID)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return ErrMemberNotFound
+		}
+
+		membership, err = s.membershipRepo.UpdateRole(ctx, tx, toUserID, orgID, types.RoleAdmin)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.membershipRepo.GetByUserAndOrgForUpdate(ctx, tx, fromUserID, orgID)
+		if err != nil {
+			return err
+		}
+		demoted := current != nil && current.Role == types.RoleAdmin
+		if demoted {
+			if _, err := s.membershipRepo.UpdateRole(ctx, tx, fromUserID, orgID, types.RoleUser); err != nil {
+				return err
+			}
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        fromUserID,
+			Action:         audit.ActionOrgOwnershipTransferred,
+			TargetType:     audit.TargetUser,
+			TargetID:       toUserID,
+			Before:         memberSnapshot{Role: target.Role},
+			After:          memberSnapshot{Role: types.RoleAdmin},
+			Metadata:       map[string]any{"fromUserId": fromUserID, "fromDemoted": demoted},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// Delete soft-deletes an organization and revokes its pending invitations.
+// The org stays restorable for the deletion grace period, after which
+// PurgeDeleted removes it. It publishes OrgDeleted, which subscribers use to
+// notify the members.
+func (s *OrgService) Delete(ctx context.Context, orgID uuid.UUID) (*types.Organization, time.Time, error) {
+	var org *types.Organization
+	var restoreBy time.Time
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		org, err = s.orgRepo.SoftDelete(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if org == nil {
+			return ErrNotFound
+		}
+
+		if _, err := s.invitationRepo.RevokePendingByOrg(ctx, tx, orgID); err != nil {
+			return err
+		}
+
+		restoreBy = org.DeletedAt.Add(s.deletionGracePeriod)
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionOrgDeleted,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       orgID,
+			Before:         orgSnapshot(org),
+			Metadata:       map[string]any{"restoreBy": restoreBy},
+		})
+		if err != nil {
+			return err
+		}
+		return s.eventBus.Publish(ctx, tx, events.OrgDeleted{OrgID: orgID, Name: org.Name, RestoreBy: restoreBy})
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return org, restoreBy, nil
 }
 
-func randomSuffix() (string, error) {
-	b := make([]byte, 4)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// Restore undoes a soft delete while the org is still within its grace period.
+// Invitations revoked by the deletion stay revoked.
+func (s *OrgService) Restore(ctx context.Context, orgID uuid.UUID) (*types.Organization, error) {
+	org, err := s.orgRepo.GetByID(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrNotFound
+	}
+	if org.DeletedAt == nil {
+		return nil, ErrOrgNotDeleted
+	}
+
+	var restored *types.Organization
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		restored, err = s.orgRepo.Restore(ctx, tx, orgID, time.Now().Add(-s.deletionGracePeriod))
+		if err != nil {
+			return err
+		}
+		if restored == nil {
+			return ErrRestoreExpired
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionOrgRestored,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       orgID,
+			After:          orgSnapshot(restored),
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
-	return hex.EncodeToString(b), nil
+	return restored, nil
+}
+
+// PurgeDeleted hard-deletes organizations whose grace period has elapsed.
+func (s *OrgService) PurgeDeleted(ctx context.Context) (int64, error) {
+	return s.orgRepo.PurgeDeleted(ctx, s.pool, time.Now().Add(-s.deletionGracePeriod))
+}
+
+// orgAuditState is the part of an org recorded in audit events.
+type orgAuditState struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func orgSnapshot(org *types.Organization) orgAuditState {
+	return orgAuditState{Name: org.Name, Slug: org.Slug}
+}
+
+// memberSnapshot is the part of a membership recorded in audit events.
+type memberSnapshot struct {
+	Role string `json:"role"`
 }
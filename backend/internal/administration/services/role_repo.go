@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const roleColumns = `id, organization_id, key, name, description, permissions, created_at, updated_at`
+
+type pgxRoleRepository struct{}
+
+func NewRoleRepository() types.RoleRepository {
+	return &pgxRoleRepository{}
+}
+
+func scanRole(row pgx.Row) (*types.Role, error) {
+	var role types.Role
+	err := row.Scan(&role.ID, &role.OrganizationID, &role.Key, &role.Name, &role.Description, &role.Permissions, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *pgxRoleRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.Role, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+roleColumns+` FROM org_roles
+		 WHERE organization_id IS NULL OR organization_id = $1
+		 ORDER BY organization_id NULLS FIRST, created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*types.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *pgxRoleRepository) GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.Role, error) {
+	role, err := scanRole(db.QueryRow(ctx,
+		`SELECT `+roleColumns+` FROM org_roles
+		 WHERE id = $2 AND (organization_id IS NULL OR organization_id = $1)`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get role by id: %w", err)
+	}
+	return role, nil
+}
+
+func (r *pgxRoleRepository) GetByKey(ctx context.Context, db database.DBTX, orgID uuid.UUID, key string) (*types.Role, error) {
+	role, err := scanRole(db.QueryRow(ctx,
+		`SELECT `+roleColumns+` FROM org_roles
+		 WHERE key = $2 AND (organization_id IS NULL OR organization_id = $1)`, orgID, key))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get role by key: %w", err)
+	}
+	return role, nil
+}
+
+func (r *pgxRoleRepository) Create(ctx context.Context, db database.DBTX, params types.CreateRoleParams) (*types.Role, error) {
+	role, err := scanRole(db.QueryRow(ctx,
+		`INSERT INTO org_roles (organization_id, key, name, description, permissions)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING `+roleColumns,
+		params.OrganizationID, params.Key, params.Name, params.Description, params.Permissions))
+	if err != nil {
+		return nil, fmt.Errorf("create role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *pgxRoleRepository) Update(ctx context.Context, db database.DBTX, id uuid.UUID, params types.UpdateRoleParams) (*types.Role, error) {
+	role, err := scanRole(db.QueryRow(ctx,
+		`UPDATE org_roles SET name = $2, description = $3, permissions = $4, updated_at = NOW()
+		 WHERE id = $1 AND organization_id IS NOT NULL
+		 RETURNING `+roleColumns,
+		id, params.Name, params.Description, params.Permissions))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *pgxRoleRepository) Delete(ctx context.Context, db database.DBTX, id uuid.UUID) error {
+	_, err := db.Exec(ctx, `DELETE FROM org_roles WHERE id = $1 AND organization_id IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrInvalidRole       = errors.New("role does not exist in this organization")
+	ErrBuiltinRole       = errors.New("built-in roles cannot be modified")
+	ErrRoleInUse         = errors.New("role is assigned to members, invitations or invite links")
+	ErrRoleKeyTaken      = errors.New("role key is already in use")
+	ErrInvalidRoleKey    = errors.New("role key must be 2-32 lowercase letters, digits, hyphens or underscores")
+	ErrInvalidPermission = errors.New("unknown permission")
+	ErrRoleEscalation    = errors.New("cannot grant permissions you do not hold")
+	ErrGrantNotFound     = errors.New("permission grant not found")
+	ErrGrantExists       = errors.New("permission is already granted")
+	ErrGrantTarget       = errors.New("grant target must be a team or member of this organization")
+)
+
+var validRoleKey = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+type RoleService struct {
+	pool      *pgxpool.Pool
+	roleRepo  types.RoleRepository
+	grantRepo types.PermissionGrantRepository
+	auditLog  *audit.Log
+}
+
+func NewRoleService(pool *pgxpool.Pool, roleRepo types.RoleRepository, grantRepo types.PermissionGrantRepository, auditLog *audit.Log) *RoleService {
+	return &RoleService{pool: pool, roleRepo: roleRepo, grantRepo: grantRepo, auditLog: auditLog}
+}
+
+// List returns the built-in roles followed by the org's custom roles.
+func (s *RoleService) List(ctx context.Context, orgID uuid.UUID) ([]*types.Role, error) {
+	return s.roleRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+// Create adds a custom role. actor is the creating member's permission set;
+// a role cannot carry permissions its creator does not hold.
+func (s *RoleService) Create(ctx context.Context, orgID uuid.UUID, params types.CreateRoleParams, actor types.PermissionSet) (*types.Role, error) {
+	if !validRoleKey.MatchString(params.Key) {
+		return nil, ErrInvalidRoleKey
+	}
+	if types.IsBuiltinRole(params.Key) {
+		return nil, ErrRoleKeyTaken
+	}
+	perms, err := normalizePermissions(params.Permissions, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	params.OrganizationID = orgID
+	params.Permissions = perms
+	var role *types.Role
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		role, err = s.roleRepo.Create(ctx, tx, params)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionRoleCreated,
+			TargetType:     audit.TargetRole,
+			TargetID:       role.ID,
+			After:          roleSnapshot(role),
+		})
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrRoleKeyTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// Update replaces a custom role's name, description and permissions.
+func (s *RoleService) Update(ctx context.Context, orgID, roleID uuid.UUID, params types.UpdateRoleParams, actor types.PermissionSet) (*types.Role, error) {
+	existing, err := s.customRole(ctx, orgID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.HasAll(existing.Permissions) {
+		return nil, ErrRoleEscalation
+	}
+	perms, err := normalizePermissions(params.Permissions, actor)
+	if err != nil {
+		return nil, err
+	}
+	params.Permissions = perms
+	var role *types.Role
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		role, err = s.roleRepo.Update(ctx, tx, roleID, params)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionRoleUpdated,
+			TargetType:     audit.TargetRole,
+			TargetID:       roleID,
+			Before:         roleSnapshot(existing),
+			After:          roleSnapshot(role),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// Delete removes a custom role that is not assigned to anyone.
+func (s *RoleService) Delete(ctx context.Context, orgID, roleID uuid.UUID) error {
+	existing, err := s.customRole(ctx, orgID, roleID)
+	if err != nil {
+		return err
+	}
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := s.roleRepo.Delete(ctx, tx, roleID); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionRoleDeleted,
+			TargetType:     audit.TargetRole,
+			TargetID:       roleID,
+			Before:         roleSnapshot(existing),
+		})
+	})
+	if database.IsForeignKeyViolation(err) {
+		return ErrRoleInUse
+	}
+	return err
+}
+
+// ResolveAssignable looks up a role by key for assignment to a member or
+// invitation. The actor must hold every permission the role grants.
+func (s *RoleService) ResolveAssignable(ctx context.Context, orgID uuid.UUID, key string, actor types.PermissionSet) (*types.Role, error) {
+	role, err := s.roleRepo.GetByKey(ctx, s.pool, orgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrInvalidRole
+	}
+	if !actor.HasAll(role.EffectivePermissions()) {
+		return nil, ErrRoleEscalation
+	}
+	return role, nil
+}
+
+// PermissionsFor returns the effective permissions of an org membership: its
+// role's permissions plus any granted to the member directly or through
+// their teams.
+func (s *RoleService) PermissionsFor(ctx context.Context, membership *types.OrgMembership) (types.PermissionSet, error) {
+	perms := types.NewPermissionSet()
+	role, err := s.roleRepo.GetByKey(ctx, s.pool, membership.OrganizationID, membership.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role != nil {
+		perms.Add(role.EffectivePermissions()...)
+	}
+
+	granted, err := s.grantRepo.ListPermissionsForUser(ctx, s.pool, membership.OrganizationID, membership.UserID)
+	if err != nil {
+		return nil, err
+	}
+	perms.Add(granted...)
+	return perms, nil
+}
+
+func (s *RoleService) ListGrants(ctx context.Context, orgID uuid.UUID) ([]*types.PermissionGrant, error) {
+	return s.grantRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+// Grant gives a permission to a team or an individual member. As with roles,
+// the actor must hold the permission being granted.
+func (s *RoleService) Grant(ctx context.Context, orgID uuid.UUID, params types.CreateGrantParams, actor types.PermissionSet) (*types.PermissionGrant, error) {
+	if (params.TeamID == nil) == (params.UserID == nil) {
+		return nil, ErrGrantTarget
+	}
+	if !types.IsValidPermission(params.Permission) {
+		return nil, ErrInvalidPermission
+	}
+	if !actor.Has(params.Permission) {
+		return nil, ErrRoleEscalation
+	}
+
+	params.OrganizationID = orgID
+	var grant *types.PermissionGrant
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		grant, err = s.grantRepo.Create(ctx, tx, params)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionGrantCreated,
+			TargetType:     audit.TargetGrant,
+			TargetID:       grant.ID,
+			After:          grant,
+		})
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrGrantExists
+	}
+	if database.IsForeignKeyViolation(err) {
+		return nil, ErrGrantTarget
+	}
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (s *RoleService) RevokeGrant(ctx context.Context, orgID, grantID uuid.UUID) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		grant, err := s.grantRepo.Delete(ctx, tx, orgID, grantID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			return ErrGrantNotFound
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionGrantDeleted,
+			TargetType:     audit.TargetGrant,
+			TargetID:       grantID,
+			Before:         grant,
+		})
+	})
+}
+
+func (s *RoleService) customRole(ctx context.Context, orgID, roleID uuid.UUID) (*types.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, s.pool, orgID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	if role.IsBuiltin() {
+		return nil, ErrBuiltinRole
+	}
+	return role, nil
+}
+
+// normalizePermissions validates and de-duplicates permission keys, rejecting
+// any the actor does not hold.
+func normalizePermissions(keys []string, actor types.PermissionSet) ([]string, error) {
+	set := types.NewPermissionSet()
+	for _, k := range keys {
+		if !types.IsValidPermission(k) {
+			return nil, ErrInvalidPermission
+		}
+		if !actor.Has(k) {
+			return nil, ErrRoleEscalation
+		}
+		set.Add(k)
+	}
+	return set.Keys(), nil
+}
+
+// roleAuditState is the part of a role recorded in audit events.
+type roleAuditState struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func roleSnapshot(r *types.Role) roleAuditState {
+	return roleAuditState{Key: r.Key, Name: r.Name, Description: r.Description, Permissions: r.Permissions}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+)
+
+func TestNormalizePermissions(t *testing.T) {
+	actor := types.NewPermissionSet(types.AllPermissions()...)
+
+	got, err := normalizePermissions([]string{types.PermAgentsDeploy, types.PermAgentsRead, types.PermAgentsDeploy}, actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{types.PermAgentsDeploy, types.PermAgentsRead}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if _, err := normalizePermissions([]string{"agents.destroy"}, actor); !errors.Is(err, ErrInvalidPermission) {
+		t.Fatalf("expected ErrInvalidPermission, got %v", err)
+	}
+}
+
+func TestNormalizePermissionsRejectsEscalation(t *testing.T) {
+	actor := types.NewPermissionSet(types.BuiltinRolePermissions(types.RoleUser)...)
+
+	if _, err := normalizePermissions([]string{types.PermAgentsDeploy}, actor); err != nil {
+		t.Fatalf("expected held permission to pass, got %v", err)
+	}
+	if _, err := normalizePermissions([]string{types.PermSecretsManage}, actor); !errors.Is(err, ErrRoleEscalation) {
+		t.Fatalf("expected ErrRoleEscalation, got %v", err)
+	}
+}
+
+func TestBuiltinRolePermissions(t *testing.T) {
+	admin := types.NewPermissionSet(types.BuiltinRolePermissions(types.RoleAdmin)...)
+	for _, p := range types.PermissionCatalogue {
+		if !admin.Has(p.Key) {
+			t.Fatalf("admin role missing %s", p.Key)
+		}
+	}
+
+	for _, k := range types.BuiltinRolePermissions(types.RoleUser) {
+		if !types.IsValidPermission(k) {
+			t.Fatalf("user role has permission %s outside the catalogue", k)
+		}
+	}
+	user := types.NewPermissionSet(types.BuiltinRolePermissions(types.RoleUser)...)
+	if user.Has(types.PermMembersRemove) {
+		t.Fatal("user role should not be able to remove members")
+	}
+}
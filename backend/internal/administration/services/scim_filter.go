@@ -0,0 +1,328 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidFilter is returned for SCIM filter expressions that cannot be
+// parsed or reference unsupported constructs.
+var ErrInvalidFilter = errors.New("invalid SCIM filter")
+
+// SCIMAttributes holds a resource's filterable attributes, keyed by
+// lowercased attribute path (e.g. "username", "name.givenname",
+// "emails.value"). Multi-valued attributes have several values.
+type SCIMAttributes map[string][]string
+
+// SCIMFilter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2).
+type SCIMFilter interface {
+	Match(attrs SCIMAttributes) bool
+}
+
+// ParseSCIMFilter parses a filter such as
+//
+//	userName eq "jane@acme.com" and (active eq true or externalId pr)
+//
+// Supported: the eq, ne, co, sw, ew, gt, ge, lt, le and pr operators, and,
+// or, not and grouping. Complex value paths like emails[type eq "work"] are
+// not supported. String comparisons are case-insensitive.
+func ParseSCIMFilter(s string) (SCIMFilter, error) {
+	tokens, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, ErrInvalidFilter
+	}
+	return f, nil
+}
+
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			// Find the closing quote, honouring backslash escapes, and let
+			// strconv decode the JSON-style string.
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, ErrInvalidFilter
+			}
+			v, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			tokens = append(tokens, filterToken{text: v, quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' && s[j] != '(' && s[j] != ')' && s[j] != '"' {
+				if s[j] == '[' || s[j] == ']' {
+					return nil, ErrInvalidFilter
+				}
+				j++
+			}
+			tokens = append(tokens, filterToken{text: s[i:j]})
+			i = j
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, ErrInvalidFilter
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekKeyword(kw string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, kw)
+}
+
+func (p *filterParser) next() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, true
+}
+
+func (p *filterParser) parseOr() (SCIMFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (SCIMFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (SCIMFilter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		if !p.peekKeyword("(") {
+			return nil, ErrInvalidFilter
+		}
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+	if p.peekKeyword("(") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekKeyword(")") {
+			return nil, ErrInvalidFilter
+		}
+		p.pos++
+		return f, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (SCIMFilter, error) {
+	attr, ok := p.next()
+	if !ok || attr.quoted || !isAttrPath(attr.text) {
+		return nil, ErrInvalidFilter
+	}
+	op, ok := p.next()
+	if !ok || op.quoted {
+		return nil, ErrInvalidFilter
+	}
+	c := comparisonFilter{attr: strings.ToLower(attr.text), op: strings.ToLower(op.text)}
+
+	switch c.op {
+	case "pr":
+		return c, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, ErrInvalidFilter
+	}
+
+	val, ok := p.next()
+	if !ok {
+		return nil, ErrInvalidFilter
+	}
+	if !val.quoted {
+		switch strings.ToLower(val.text) {
+		case "true", "false":
+			val.text = strings.ToLower(val.text)
+		case "null":
+			c.null = true
+		default:
+			if _, err := strconv.ParseFloat(val.text, 64); err != nil {
+				return nil, ErrInvalidFilter
+			}
+		}
+	}
+	c.value = strings.ToLower(val.text)
+	return c, nil
+}
+
+func isAttrPath(s string) bool {
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '_' && r != '-' && r != ':' && r != '$' {
+			return false
+		}
+	}
+	return true
+}
+
+type andFilter struct{ left, right SCIMFilter }
+
+func (f andFilter) Match(a SCIMAttributes) bool { return f.left.Match(a) && f.right.Match(a) }
+
+type orFilter struct{ left, right SCIMFilter }
+
+func (f orFilter) Match(a SCIMAttributes) bool { return f.left.Match(a) || f.right.Match(a) }
+
+type notFilter struct{ inner SCIMFilter }
+
+func (f notFilter) Match(a SCIMAttributes) bool { return !f.inner.Match(a) }
+
+type comparisonFilter struct {
+	attr  string
+	op    string
+	value string
+	null  bool
+}
+
+func (f comparisonFilter) Match(a SCIMAttributes) bool {
+	values := a[lookupAttr(a, f.attr)]
+	if f.op == "pr" {
+		for _, v := range values {
+			if v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if f.null {
+		present := len(values) > 0 && values[0] != ""
+		if f.op == "eq" {
+			return !present
+		}
+		return f.op == "ne" && present
+	}
+	if f.op == "ne" {
+		for _, v := range values {
+			if strings.ToLower(v) == f.value {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if compareFilterValue(strings.ToLower(v), f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalityValue returns the value f compares attr with when f is a single
+// "attr eq value" comparison, letting callers look the value up directly.
+func equalityValue(f SCIMFilter, attr string) (string, bool) {
+	c, ok := f.(comparisonFilter)
+	if !ok || c.op != "eq" || c.null {
+		return "", false
+	}
+	path := c.attr
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		path = path[i+1:]
+	}
+	if path != attr {
+		return "", false
+	}
+	return c.value, true
+}
+
+// lookupAttr resolves a filter attribute to a key of a, accepting paths
+// qualified with the core schema URN.
+func lookupAttr(a SCIMAttributes, attr string) string {
+	if _, ok := a[attr]; ok {
+		return attr
+	}
+	if i := strings.LastIndex(attr, ":"); i >= 0 {
+		return attr[i+1:]
+	}
+	return attr
+}
+
+func compareFilterValue(v, op, want string) bool {
+	switch op {
+	case "eq":
+		return v == want
+	case "co":
+		return strings.Contains(v, want)
+	case "sw":
+		return strings.HasPrefix(v, want)
+	case "ew":
+		return strings.HasSuffix(v, want)
+	case "gt":
+		return v > want
+	case "ge":
+		return v >= want
+	case "lt":
+		return v < want
+	case "le":
+		return v <= want
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSCIMFilter(t *testing.T) {
+	jane := SCIMAttributes{
+		"username":       {"Jane@Acme.com"},
+		"name.givenname": {"Jane"},
+		"externalid":     {"00u1"},
+		"active":         {"true"},
+		"emails.value":   {"jane@acme.com", "j@acme.io"},
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "jane@acme.com"`, true},
+		{`userName eq "john@acme.com"`, false},
+		{`userName ne "john@acme.com"`, true},
+		{`userName sw "jane"`, true},
+		{`userName ew "@acme.com"`, true},
+		{`name.givenName co "an"`, true},
+		{`emails.value eq "j@acme.io"`, true},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`externalId pr`, true},
+		{`title pr`, false},
+		{`title eq null`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "jane@acme.com"`, true},
+		{`userName eq "john@acme.com" or active eq true`, true},
+		{`userName eq "jane@acme.com" and active eq false`, false},
+		{`not (active eq false) and (externalId eq "00u1" or externalId eq "00u2")`, true},
+		{`userName eq "quote\"d"`, false},
+	}
+	for _, tt := range tests {
+		f, err := ParseSCIMFilter(tt.filter)
+		if err != nil {
+			t.Errorf("ParseSCIMFilter(%q): %v", tt.filter, err)
+			continue
+		}
+		if got := f.Match(jane); got != tt.want {
+			t.Errorf("%q matched %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestEqualityValue(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+		ok     bool
+	}{
+		{`userName eq "Jane@Acme.com"`, "jane@acme.com", true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "jane@acme.com"`, "jane@acme.com", true},
+		{`userName sw "jane"`, "", false},
+		{`userName eq null`, "", false},
+		{`externalId eq "00u1"`, "", false},
+		{`userName eq "jane@acme.com" and active eq true`, "", false},
+	}
+	for _, tt := range tests {
+		f, err := ParseSCIMFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParseSCIMFilter(%q): %v", tt.filter, err)
+		}
+		got, ok := equalityValue(f, "username")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("equalityValue(%q) = %q, %v, want %q, %v", tt.filter, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSCIMFilterInvalid(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName xx "a"`,
+		`userName eq "unterminated`,
+		`(userName eq "a"`,
+		`emails[type eq "work"]`,
+		`userName eq bare`,
+		`userName eq "a" extra`,
+	} {
+		if _, err := ParseSCIMFilter(filter); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("ParseSCIMFilter(%q) = %v, want ErrInvalidFilter", filter, err)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+
+	"agenteur.ai/api/internal/administration/types"
+	"github.com/google/uuid"
+)
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:user"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:group"
+)
+
+// applyUserPatch applies SCIM PATCH operations to a user and returns the
+// desired state. Attributes derived from userName (emails, displayName) are
+// accepted and ignored.
+func applyUserPatch(u types.SCIMUser, ops []types.SCIMPatchOp) (types.SCIMUser, error) {
+	for _, op := range ops {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return u, ErrSCIMInvalidPatch
+		}
+
+		path := normalizePatchPath(op.Path, scimUserSchema)
+		if path == "" {
+			if kind == "remove" {
+				return u, ErrSCIMInvalidPatch
+			}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return u, ErrSCIMInvalidPatch
+			}
+			for key, value := range values {
+				if err := setUserAttr(&u, normalizePatchPath(key, scimUserSchema), value); err != nil {
+					return u, err
+				}
+			}
+			continue
+		}
+
+		var err error
+		if kind == "remove" {
+			err = removeUserAttr(&u, path)
+		} else {
+			err = setUserAttr(&u, path, op.Value)
+		}
+		if err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+func setUserAttr(u *types.SCIMUser, path string, value json.RawMessage) error {
+	if isDerivedUserAttr(path) {
+		return nil
+	}
+	switch path {
+	case "username":
+		return decodePatchString(value, &u.UserName)
+	case "externalid":
+		return decodePatchString(value, &u.ExternalID)
+	case "name.givenname":
+		return decodePatchString(value, &u.GivenName)
+	case "name.familyname":
+		return decodePatchString(value, &u.FamilyName)
+	case "name":
+		var name struct {
+			GivenName  *string `json:"givenName"`
+			FamilyName *string `json:"familyName"`
+		}
+		if err := json.Unmarshal(value, &name); err != nil {
+			return ErrSCIMInvalidValue
+		}
+		if name.GivenName != nil {
+			u.GivenName = *name.GivenName
+		}
+		if name.FamilyName != nil {
+			u.FamilyName = *name.FamilyName
+		}
+		return nil
+	case "active":
+		active, err := decodePatchBool(value)
+		if err != nil {
+			return err
+		}
+		u.Active = active
+		return nil
+	case "roles":
+		role, err := decodePatchRole(value)
+		if err != nil {
+			return err
+		}
+		u.Role = role
+		return nil
+	}
+	return ErrSCIMInvalidPatch
+}
+
+func removeUserAttr(u *types.SCIMUser, path string) error {
+	if isDerivedUserAttr(path) {
+		return nil
+	}
+	switch path {
+	case "externalid":
+		u.ExternalID = ""
+	case "name.givenname":
+		u.GivenName = ""
+	case "name.familyname":
+		u.FamilyName = ""
+	case "name":
+		u.GivenName, u.FamilyName = "", ""
+	case "roles":
+		u.Role = types.RoleUser
+	case "username", "active":
+		return ErrSCIMMutability
+	default:
+		return ErrSCIMInvalidPatch
+	}
+	return nil
+}
+
+func isDerivedUserAttr(path string) bool {
+	return path == "displayname" || path == "name.formatted" || strings.HasPrefix(path, "emails")
+}
+
+// applyGroupPatch applies SCIM PATCH operations to a group and returns the
+// desired state. Members may be removed individually with a
+// members[value eq "<id>"] path.
+func applyGroupPatch(g types.SCIMGroup, ops []types.SCIMPatchOp) (types.SCIMGroup, error) {
+	for _, op := range ops {
+		kind := strings.ToLower(op.Op)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return g, ErrSCIMInvalidPatch
+		}
+
+		path := normalizePatchPath(op.Path, scimGroupSchema)
+		if path == "" {
+			if kind == "remove" {
+				return g, ErrSCIMInvalidPatch
+			}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return g, ErrSCIMInvalidPatch
+			}
+			for key, value := range values {
+				if err := setGroupAttr(&g, kind, normalizePatchPath(key, scimGroupSchema), value); err != nil {
+					return g, err
+				}
+			}
+			continue
+		}
+
+		var err error
+		if kind == "remove" {
+			err = removeGroupAttr(&g, path, op.Value)
+		} else {
+			err = setGroupAttr(&g, kind, path, op.Value)
+		}
+		if err != nil {
+			return g, err
+		}
+	}
+	return g, nil
+}
+
+func setGroupAttr(g *types.SCIMGroup, kind, path string, value json.RawMessage) error {
+	switch path {
+	case "displayname":
+		return decodePatchString(value, &g.DisplayName)
+	case "externalid":
+		return decodePatchString(value, &g.ExternalID)
+	case "members":
+		members, err := decodePatchMembers(value)
+		if err != nil {
+			return err
+		}
+		if kind == "replace" {
+			g.Members = members
+			return nil
+		}
+		for _, m := range members {
+			if !hasGroupMember(g.Members, m.UserID) {
+				g.Members = append(g.Members, m)
+			}
+		}
+		return nil
+	}
+	return ErrSCIMInvalidPatch
+}
+
+func removeGroupAttr(g *types.SCIMGroup, path string, value json.RawMessage) error {
+	switch {
+	case path == "externalid":
+		g.ExternalID = ""
+		return nil
+	case path == "displayname":
+		return ErrSCIMMutability
+	case path == "members":
+		// Without a value every member is removed; some IdPs instead list
+		// the members to remove in the value.
+		if len(value) == 0 || string(value) == "null" {
+			g.Members = nil
+			return nil
+		}
+		remove, err := decodePatchMembers(value)
+		if err != nil {
+			return err
+		}
+		for _, m := range remove {
+			g.Members = withoutGroupMember(g.Members, func(id uuid.UUID) bool { return id == m.UserID })
+		}
+		return nil
+	case strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]"):
+		filter, err := ParseSCIMFilter(path[len("members[") : len(path)-1])
+		if err != nil {
+			return ErrSCIMInvalidPatch
+		}
+		g.Members = withoutGroupMember(g.Members, func(id uuid.UUID) bool {
+			return filter.Match(SCIMAttributes{"value": {id.String()}})
+		})
+		return nil
+	}
+	return ErrSCIMInvalidPatch
+}
+
+func hasGroupMember(members []types.SCIMGroupMember, id uuid.UUID) bool {
+	for _, m := range members {
+		if m.UserID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutGroupMember(members []types.SCIMGroupMember, drop func(uuid.UUID) bool) []types.SCIMGroupMember {
+	kept := members[:0:0]
+	for _, m := range members {
+		if !drop(m.UserID) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// normalizePatchPath lowercases a PATCH path and strips the resource's core
+// schema URN, so "urn:...:User:name.givenName" becomes "name.givenname".
+func normalizePatchPath(path, schema string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	return strings.TrimPrefix(path, schema+":")
+}
+
+func decodePatchString(value json.RawMessage, dst *string) error {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return ErrSCIMInvalidValue
+	}
+	return nil
+}
+
+// decodePatchBool accepts JSON booleans and, for IdPs that send them, the
+// strings "true" and "false".
+func decodePatchBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, ErrSCIMInvalidValue
+}
+
+type scimRoleValue struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+// decodePatchRole reads an org role key from a roles value: a string, a
+// single {"value": ...} object, or an array of them (the primary entry
+// wins). An empty array resets the member to the default role.
+func decodePatchRole(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s, nil
+	}
+	var one scimRoleValue
+	if err := json.Unmarshal(value, &one); err == nil {
+		return one.Value, nil
+	}
+	var many []scimRoleValue
+	if err := json.Unmarshal(value, &many); err != nil {
+		return "", ErrSCIMInvalidValue
+	}
+	return primaryRole(many), nil
+}
+
+// primaryRole picks the primary role of a SCIM roles list, falling back to
+// the first entry and then to the default role.
+func primaryRole(roles []scimRoleValue) string {
+	if len(roles) == 0 {
+		return types.RoleUser
+	}
+	for _, r := range roles {
+		if r.Primary {
+			return r.Value
+		}
+	}
+	return roles[0].Value
+}
+
+func decodePatchMembers(value json.RawMessage) ([]types.SCIMGroupMember, error) {
+	var raw []struct {
+		Value   string `json:"value"`
+		Display string `json:"display"`
+	}
+	if err := json.Unmarshal(value, &raw); err != nil {
+		return nil, ErrSCIMInvalidValue
+	}
+	members := make([]types.SCIMGroupMember, 0, len(raw))
+	for _, r := range raw {
+		id, err := uuid.Parse(r.Value)
+		if err != nil {
+			return nil, ErrSCIMInvalidValue
+		}
+		members = append(members, types.SCIMGroupMember{UserID: id, Display: r.Display})
+	}
+	return members, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+	"github.com/google/uuid"
+)
+
+func patchOps(t *testing.T, raw string) []types.SCIMPatchOp {
+	t.Helper()
+	var ops []types.SCIMPatchOp
+	if err := json.Unmarshal([]byte(raw), &ops); err != nil {
+		t.Fatalf("unmarshal ops: %v", err)
+	}
+	return ops
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	base := types.SCIMUser{UserName: "jane@acme.com", GivenName: "Jane", Active: true, Role: types.RoleUser}
+
+	tests := []struct {
+		name  string
+		ops   string
+		check func(types.SCIMUser) bool
+	}{
+		{"okta deactivate", `[{"op":"replace","value":{"active":false}}]`,
+			func(u types.SCIMUser) bool { return !u.Active }},
+		{"azure string bool", `[{"op":"Replace","path":"active","value":"False"}]`,
+			func(u types.SCIMUser) bool { return !u.Active }},
+		{"qualified path", `[{"op":"replace","path":"urn:ietf:params:scim:schemas:core:2.0:User:name.familyName","value":"Doe"}]`,
+			func(u types.SCIMUser) bool { return u.FamilyName == "Doe" && u.GivenName == "Jane" }},
+		{"name object", `[{"op":"add","path":"name","value":{"familyName":"Doe"}}]`,
+			func(u types.SCIMUser) bool { return u.FamilyName == "Doe" && u.GivenName == "Jane" }},
+		{"primary role", `[{"op":"replace","path":"roles","value":[{"value":"user"},{"value":"admin","primary":true}]}]`,
+			func(u types.SCIMUser) bool { return u.Role == types.RoleAdmin }},
+		{"remove role resets", `[{"op":"replace","path":"roles","value":"admin"},{"op":"remove","path":"roles"}]`,
+			func(u types.SCIMUser) bool { return u.Role == types.RoleUser }},
+		{"derived attributes ignored", `[{"op":"replace","path":"emails[type eq \"work\"].value","value":"x@y.z"},{"op":"replace","path":"displayName","value":"J"}]`,
+			func(u types.SCIMUser) bool { return u == base }},
+		{"external id", `[{"op":"add","path":"externalId","value":"00u1"}]`,
+			func(u types.SCIMUser) bool { return u.ExternalID == "00u1" }},
+	}
+	for _, tt := range tests {
+		got, err := applyUserPatch(base, patchOps(t, tt.ops))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !tt.check(got) {
+			t.Errorf("%s: unexpected result %+v", tt.name, got)
+		}
+	}
+}
+
+func TestApplyUserPatchErrors(t *testing.T) {
+	base := types.SCIMUser{UserName: "jane@acme.com", Active: true}
+
+	tests := []struct {
+		ops  string
+		want error
+	}{
+		{`[{"op":"move","path":"active","value":true}]`, ErrSCIMInvalidPatch},
+		{`[{"op":"replace","path":"title","value":"CTO"}]`, ErrSCIMInvalidPatch},
+		{`[{"op":"remove"}]`, ErrSCIMInvalidPatch},
+		{`[{"op":"remove","path":"active"}]`, ErrSCIMMutability},
+		{`[{"op":"replace","path":"active","value":"maybe"}]`, ErrSCIMInvalidValue},
+	}
+	for _, tt := range tests {
+		if _, err := applyUserPatch(base, patchOps(t, tt.ops)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.ops, err, tt.want)
+		}
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	base := types.SCIMGroup{
+		DisplayName: "Engineering",
+		Members:     []types.SCIMGroupMember{{UserID: a}, {UserID: b}},
+	}
+
+	got, err := applyGroupPatch(base, patchOps(t, `[
+		{"op":"add","path":"members","value":[{"value":"`+b.String()+`"},{"value":"`+c.String()+`"}]},
+		{"op":"remove","path":"members[value eq \"`+a.String()+`\"]"},
+		{"op":"replace","value":{"displayName":"Platform","externalId":"grp-1"}}
+	]`))
+	if err != nil {
+		t.Fatalf("applyGroupPatch: %v", err)
+	}
+	if got.DisplayName != "Platform" || got.ExternalID != "grp-1" {
+		t.Errorf("unexpected attributes %+v", got)
+	}
+	if len(got.Members) != 2 || got.Members[0].UserID != b || got.Members[1].UserID != c {
+		t.Errorf("unexpected members %+v", got.Members)
+	}
+	if len(base.Members) != 2 || base.Members[0].UserID != a {
+		t.Errorf("input group was modified: %+v", base.Members)
+	}
+
+	got, err = applyGroupPatch(base, patchOps(t, `[{"op":"remove","path":"members"}]`))
+	if err != nil || len(got.Members) != 0 {
+		t.Errorf("remove all members: %+v, %v", got.Members, err)
+	}
+
+	got, err = applyGroupPatch(base, patchOps(t, `[{"op":"replace","path":"members","value":[{"value":"`+c.String()+`"}]}]`))
+	if err != nil || len(got.Members) != 1 || got.Members[0].UserID != c {
+		t.Errorf("replace members: %+v, %v", got.Members, err)
+	}
+
+	if _, err := applyGroupPatch(base, patchOps(t, `[{"op":"add","path":"members","value":[{"value":"not-a-uuid"}]}]`)); !errors.Is(err, ErrSCIMInvalidValue) {
+		t.Errorf("invalid member id: got %v", err)
+	}
+}
+
+func TestSCIMPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		start, count int
+		want         []int
+	}{
+		{1, 2, []int{1, 2}},
+		{4, 10, []int{4, 5}},
+		{0, 1, []int{1}},
+		{6, 1, []int{}},
+		{1, 0, []int{}},
+	}
+	for _, tt := range tests {
+		got := scimPage(items, tt.start, tt.count)
+		if len(got) != len(tt.want) {
+			t.Errorf("scimPage(%d, %d) = %v, want %v", tt.start, tt.count, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("scimPage(%d, %d) = %v, want %v", tt.start, tt.count, got, tt.want)
+			}
+		}
+	}
+}
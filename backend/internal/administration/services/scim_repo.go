@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const scimTokenColumns = `id, organization_id, description, created_by, last_used_at, revoked_at, created_at`
+
+// scimUserSelect lists users that are either members of the org or were
+// provisioned into it by SCIM. A provisioned user without a membership is
+// reported as inactive. Names set by the org's IdP take precedence over the
+// account's.
+const scimUserSelect = `SELECT ` + scimUserColumns + ` ` + scimUserFrom
+
+const scimUserColumns = `u.id, u.email, COALESCE(su.given_name, u.first_name), COALESCE(su.family_name, u.last_name),
+	COALESCE(su.external_id, ''),
+	m.id IS NOT NULL, su.user_id IS NOT NULL, COALESCE(r.key, ''), u.created_at,
+	GREATEST(u.updated_at, COALESCE(m.updated_at, u.updated_at), COALESCE(su.updated_at, u.updated_at))`
+
+const scimUserFrom = `FROM users u
+	LEFT JOIN org_memberships m ON m.user_id = u.id AND m.organization_id = $1
+	LEFT JOIN org_roles r ON r.id = m.role_id
+	LEFT JOIN scim_users su ON su.user_id = u.id AND su.organization_id = $1
+	WHERE (m.id IS NOT NULL OR su.user_id IS NOT NULL)`
+
+const scimGroupColumns = `id, name, external_id, created_at, updated_at`
+
+type pgxSCIMTokenRepository struct{}
+
+func NewSCIMTokenRepository() types.SCIMTokenRepository {
+	return &pgxSCIMTokenRepository{}
+}
+
+func scanSCIMToken(row pgx.Row) (*types.SCIMToken, error) {
+	var t types.SCIMToken
+	if err := row.Scan(&t.ID, &t.OrganizationID, &t.Description, &t.CreatedBy, &t.LastUsedAt, &t.RevokedAt, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *pgxSCIMTokenRepository) Create(ctx context.Context, db database.DBTX, orgID uuid.UUID, tokenHash, description string, createdBy uuid.UUID) (*types.SCIMToken, error) {
+	t, err := scanSCIMToken(db.QueryRow(ctx,
+		`INSERT INTO scim_tokens (organization_id, token_hash, description, created_by)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING `+scimTokenColumns,
+		orgID, tokenHash, description, createdBy))
+	if err != nil {
+		return nil, fmt.Errorf("create scim token: %w", err)
+	}
+	return t, nil
+}
+
+func (r *pgxSCIMTokenRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.SCIMToken, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+scimTokenColumns+` FROM scim_tokens
+		 WHERE organization_id = $1 AND revoked_at IS NULL
+		 ORDER BY created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*types.SCIMToken
+	for rows.Next() {
+		t, err := scanSCIMToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scim token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+func (r *pgxSCIMTokenRepository) Revoke(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx,
+		`UPDATE scim_tokens SET revoked_at = NOW()
+		 WHERE organization_id = $1 AND id = $2 AND revoked_at IS NULL`, orgID, id)
+	if err != nil {
+		return false, fmt.Errorf("revoke scim token: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *pgxSCIMTokenRepository) GetActiveByHash(ctx context.Context, db database.DBTX, hash string) (*types.SCIMToken, error) {
+	t, err := scanSCIMToken(db.QueryRow(ctx,
+		`SELECT `+scimTokenColumns+` FROM scim_tokens
+		 WHERE token_hash = $1 AND revoked_at IS NULL`, hash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get scim token: %w", err)
+	}
+	return t, nil
+}
+
+func (r *pgxSCIMTokenRepository) TouchLastUsed(ctx context.Context, db database.DBTX, id uuid.UUID) error {
+	_, err := db.Exec(ctx, `UPDATE scim_tokens SET last_used_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("touch scim token: %w", err)
+	}
+	return nil
+}
+
+type pgxSCIMRepository struct{}
+
+func NewSCIMRepository() types.SCIMRepository {
+	return &pgxSCIMRepository{}
+}
+
+func scanSCIMUser(row pgx.Row) (*types.SCIMUser, error) {
+	var u types.SCIMUser
+	err := row.Scan(&u.ID, &u.UserName, &u.GivenName, &u.FamilyName, &u.ExternalID, &u.Active, &u.Provisioned, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *pgxSCIMRepository) ListUsers(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.SCIMUser, error) {
+	rows, err := db.Query(ctx, scimUserSelect+` ORDER BY u.created_at ASC, u.id ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*types.SCIMUser
+	for rows.Next() {
+		u, err := scanSCIMUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scim user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// ListUsersPage returns one page of the org's SCIM users, oldest first, along
+// with the total number matching.
+func (r *pgxSCIMRepository) ListUsersPage(ctx context.Context, db database.DBTX, orgID uuid.UUID, params types.ListSCIMUsersParams) ([]*types.SCIMUser, int, error) {
+	where := scimUserFrom
+	args := []any{orgID}
+	if params.UserName != "" {
+		args = append(args, params.UserName)
+		where += fmt.Sprintf(` AND LOWER(u.email) = LOWER($%d)`, len(args))
+	}
+
+	var total int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count scim users: %w", err)
+	}
+	if params.Limit <= 0 || params.Offset >= total {
+		return nil, total, nil
+	}
+
+	args = append(args, params.Limit, params.Offset)
+	rows, err := db.Query(ctx,
+		`SELECT `+scimUserColumns+` `+where+fmt.Sprintf(` ORDER BY u.created_at ASC, u.id ASC LIMIT $%d OFFSET $%d`, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list scim users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*types.SCIMUser
+	for rows.Next() {
+		u, err := scanSCIMUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan scim user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, total, rows.Err()
+}
+
+func (r *pgxSCIMRepository) GetUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) (*types.SCIMUser, error) {
+	u, err := scanSCIMUser(db.QueryRow(ctx, scimUserSelect+` AND u.id = $2`, orgID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get scim user: %w", err)
+	}
+	return u, nil
+}
+
+func (r *pgxSCIMRepository) UpsertUserLink(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID, params types.SCIMUserLinkParams) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO scim_users (organization_id, user_id, external_id, given_name, family_name)
+		 VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+		 ON CONFLICT (organization_id, user_id)
+		 DO UPDATE SET external_id = EXCLUDED.external_id, given_name = EXCLUDED.given_name,
+		               family_name = EXCLUDED.family_name, updated_at = NOW()`,
+		orgID, userID, params.ExternalID, params.GivenName, params.FamilyName)
+	if err != nil {
+		return fmt.Errorf("upsert scim user: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxSCIMRepository) DeleteUserLink(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) error {
+	_, err := db.Exec(ctx, `DELETE FROM scim_users WHERE organization_id = $1 AND user_id = $2`, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("delete scim user: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxSCIMRepository) ListGroups(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.SCIMGroup, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+scimGroupColumns+` FROM teams WHERE organization_id = $1 ORDER BY created_at ASC, id ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*types.SCIMGroup
+	byID := make(map[uuid.UUID]*types.SCIMGroup)
+	for rows.Next() {
+		var g types.SCIMGroup
+		if err := rows.Scan(&g.ID, &g.DisplayName, &g.ExternalID, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan scim group: %w", err)
+		}
+		groups = append(groups, &g)
+		byID[g.ID] = &g
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scim groups: %w", err)
+	}
+
+	memberRows, err := db.Query(ctx,
+		`SELECT tm.team_id, tm.user_id, u.email
+		 FROM team_memberships tm
+		 JOIN users u ON u.id = tm.user_id
+		 WHERE tm.organization_id = $1
+		 ORDER BY tm.created_at ASC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim group members: %w", err)
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var teamID uuid.UUID
+		var m types.SCIMGroupMember
+		if err := memberRows.Scan(&teamID, &m.UserID, &m.Display); err != nil {
+			return nil, fmt.Errorf("scan scim group member: %w", err)
+		}
+		if g, ok := byID[teamID]; ok {
+			g.Members = append(g.Members, m)
+		}
+	}
+	return groups, nil
+}
+
+func (r *pgxSCIMRepository) GetGroup(ctx context.Context, db database.DBTX, orgID, teamID uuid.UUID) (*types.SCIMGroup, error) {
+	var g types.SCIMGroup
+	err := db.QueryRow(ctx,
+		`SELECT `+scimGroupColumns+` FROM teams WHERE organization_id = $1 AND id = $2`, orgID, teamID,
+	).Scan(&g.ID, &g.DisplayName, &g.ExternalID, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get scim group: %w", err)
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT tm.user_id, u.email
+		 FROM team_memberships tm
+		 JOIN users u ON u.id = tm.user_id
+		 WHERE tm.team_id = $1
+		 ORDER BY tm.created_at ASC`, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim group members: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m types.SCIMGroupMember
+		if err := rows.Scan(&m.UserID, &m.Display); err != nil {
+			return nil, fmt.Errorf("scan scim group member: %w", err)
+		}
+		g.Members = append(g.Members, m)
+	}
+	return &g, nil
+}
+
+func (r *pgxSCIMRepository) SetGroupExternalID(ctx context.Context, db database.DBTX, orgID, teamID uuid.UUID, externalID string) error {
+	_, err := db.Exec(ctx,
+		`UPDATE teams SET external_id = $3, updated_at = NOW() WHERE organization_id = $1 AND id = $2`,
+		orgID, teamID, externalID)
+	if err != nil {
+		return fmt.Errorf("set scim group external id: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	authtypes "agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSCIMTokenNotFound = errors.New("SCIM token not found")
+	ErrSCIMUnauthorized  = errors.New("invalid SCIM token")
+	ErrSCIMUserNotFound  = errors.New("SCIM user not found")
+	ErrSCIMUserExists    = errors.New("user is already provisioned in this organization")
+	ErrSCIMUserTaken     = errors.New("email belongs to an account this organization cannot claim")
+	ErrSCIMMutability    = errors.New("attribute cannot be modified")
+	ErrSCIMInvalidValue  = errors.New("invalid SCIM attribute value")
+	ErrSCIMInvalidPatch  = errors.New("invalid SCIM patch operation")
+)
+
+// scimUnusablePassword is stored as the password hash of users created by
+// SCIM. It is not a valid bcrypt hash, so password login always fails.
+const scimUnusablePassword = "!"
+
+// SCIMListParams are the filtering and pagination parameters of a SCIM list
+// request. StartIndex is 1-based.
+type SCIMListParams struct {
+	Filter     string
+	StartIndex int
+	Count      int
+}
+
+// SCIMService implements SCIM 2.0 provisioning for an org. Users map to the
+// global users table plus an org membership; groups map to teams.
+type SCIMService struct {
+	pool           *pgxpool.Pool
+	orgRepo        types.OrganizationRepository
+	tokenRepo      types.SCIMTokenRepository
+	scimRepo       types.SCIMRepository
+	membershipRepo types.MembershipRepository
+	roleRepo       types.RoleRepository
+	teamRepo       types.TeamRepository
+	domainRepo     types.DomainRepository
+	userRepo       authtypes.UserRepository
+	sessionRepo    authtypes.RefreshTokenRepository
+	eventBus       *events.Bus
+	auditLog       *audit.Log
+}
+
+func NewSCIMService(
+	pool *pgxpool.Pool,
+	orgRepo types.OrganizationRepository,
+	tokenRepo types.SCIMTokenRepository,
+	scimRepo types.SCIMRepository,
+	membershipRepo types.MembershipRepository,
+	roleRepo types.RoleRepository,
+	teamRepo types.TeamRepository,
+	domainRepo types.DomainRepository,
+	userRepo authtypes.UserRepository,
+	sessionRepo authtypes.RefreshTokenRepository,
+	eventBus *events.Bus,
+	auditLog *audit.Log,
+) *SCIMService {
+	return &SCIMService{
+		pool:           pool,
+		orgRepo:        orgRepo,
+		tokenRepo:      tokenRepo,
+		scimRepo:       scimRepo,
+		membershipRepo: membershipRepo,
+		roleRepo:       roleRepo,
+		teamRepo:       teamRepo,
+		domainRepo:     domainRepo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		eventBus:       eventBus,
+		auditLog:       auditLog,
+	}
+}
+
+// CreateToken issues a SCIM bearer token for an org. A token can provision
+// users into any role, so the actor must hold every permission.
+func (s *SCIMService) CreateToken(ctx context.Context, orgID, createdBy uuid.UUID, description string, actor types.PermissionSet) (*types.SCIMToken, string, error) {
+	if !actor.HasAll(types.AllPermissions()) {
+		return nil, "", ErrRoleEscalation
+	}
+	raw, hash, err := authservices.GenerateRandomToken()
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.tokenRepo.Create(ctx, s.pool, orgID, hash, strings.TrimSpace(description), createdBy)
+	if err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+func (s *SCIMService) ListTokens(ctx context.Context, orgID uuid.UUID) ([]*types.SCIMToken, error) {
+	return s.tokenRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+func (s *SCIMService) RevokeToken(ctx context.Context, orgID, tokenID uuid.UUID) error {
+	revoked, err := s.tokenRepo.Revoke(ctx, s.pool, orgID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSCIMTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate checks a raw bearer token against the org it is presented
+// for. Tokens of soft-deleted orgs are rejected.
+func (s *SCIMService) Authenticate(ctx context.Context, orgID uuid.UUID, rawToken string) (*types.SCIMToken, error) {
+	token, err := s.tokenRepo.GetActiveByHash(ctx, s.pool, authservices.HashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.OrganizationID != orgID {
+		return nil, ErrSCIMUnauthorized
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil || org.DeletedAt != nil {
+		return nil, ErrSCIMUnauthorized
+	}
+
+	if err := s.tokenRepo.TouchLastUsed(ctx, s.pool, token.ID); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// ListUsers returns the org's members and deprovisioned SCIM users matching
+// the filter, along with the total number of matches. Unfiltered listings and
+// userName lookups, which IdPs issue before every create, are paged in SQL;
+// other filters are matched in memory.
+func (s *SCIMService) ListUsers(ctx context.Context, orgID uuid.UUID, params SCIMListParams) ([]*types.SCIMUser, int, error) {
+	filter, err := parseOptionalFilter(params.Filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := types.ListSCIMUsersParams{Offset: max(params.StartIndex, 1) - 1, Limit: params.Count}
+	if filter != nil {
+		userName, ok := equalityValue(filter, "username")
+		if !ok {
+			return s.filterUsers(ctx, orgID, filter, params)
+		}
+		query.UserName = userName
+	}
+	return s.scimRepo.ListUsersPage(ctx, s.pool, orgID, query)
+}
+
+func (s *SCIMService) filterUsers(ctx context.Context, orgID uuid.UUID, filter SCIMFilter, params SCIMListParams) ([]*types.SCIMUser, int, error) {
+	users, err := s.scimRepo.ListUsers(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*types.SCIMUser
+	for _, u := range users {
+		if filter.Match(scimUserAttributes(u)) {
+			matched = append(matched, u)
+		}
+	}
+	return scimPage(matched, params.StartIndex, params.Count), len(matched), nil
+}
+
+func (s *SCIMService) GetUser(ctx context.Context, orgID, userID uuid.UUID) (*types.SCIMUser, error) {
+	u, err := s.scimRepo.GetUser(ctx, s.pool, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrSCIMUserNotFound
+	}
+	return u, nil
+}
+
+// CreateUser provisions a user into the org. An existing account with the
+// same email is linked rather than duplicated, but only if it is already a
+// member or its email domain is verified by the org: accounts are global, so
+// an IdP may not claim one just by knowing its address. Otherwise a new
+// account is created with its email marked verified, since the IdP vouches
+// for it.
+func (s *SCIMService) CreateUser(ctx context.Context, orgID uuid.UUID, desired types.SCIMUser) (*types.SCIMUser, error) {
+	email, err := normalizeSCIMUserName(desired.UserName)
+	if err != nil {
+		return nil, err
+	}
+	if desired.Role == "" {
+		desired.Role = types.RoleUser
+	}
+
+	var created *types.SCIMUser
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := s.checkRole(ctx, tx, orgID, desired.Role); err != nil {
+			return err
+		}
+
+		user, err := s.userRepo.GetByEmail(ctx, tx, email)
+		if err != nil {
+			return err
+		}
+		var member bool
+		if user != nil {
+			existing, err := s.scimRepo.GetUser(ctx, tx, orgID, user.ID)
+			if err != nil {
+				return err
+			}
+			if existing != nil && existing.Provisioned {
+				return ErrSCIMUserExists
+			}
+			member = existing != nil && existing.Active
+			if !member {
+				d, err := s.domainRepo.GetVerifiedByDomain(ctx, tx, emailDomain(email))
+				if err != nil {
+					return err
+				}
+				if d == nil || d.OrganizationID != orgID {
+					return ErrSCIMUserTaken
+				}
+			}
+		} else {
+			user, err = s.userRepo.Create(ctx, tx, authtypes.CreateUserParams{
+				Email:        email,
+				PasswordHash: scimUnusablePassword,
+				FirstName:    strings.TrimSpace(desired.GivenName),
+				LastName:     strings.TrimSpace(desired.FamilyName),
+			})
+			if database.IsUniqueViolation(err) {
+				return ErrSCIMUserExists
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := s.userRepo.MarkEmailVerified(ctx, tx, user.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.scimRepo.UpsertUserLink(ctx, tx, orgID, user.ID, types.SCIMUserLinkParams{
+			ExternalID: desired.ExternalID,
+			GivenName:  strings.TrimSpace(desired.GivenName),
+			FamilyName: strings.TrimSpace(desired.FamilyName),
+		}); err != nil {
+			return err
+		}
+		// Linking an existing member leaves their membership and role as
+		// they are.
+		if desired.Active && !member {
+			if err := s.addMember(ctx, tx, orgID, user.ID, desired.Role); err != nil {
+				return err
+			}
+		}
+
+		created, err = s.scimRepo.GetUser(ctx, tx, orgID, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ReplaceUser applies a full SCIM representation of a user. An empty Role
+// keeps the current role, as most IdPs don't send roles at all.
+func (s *SCIMService) ReplaceUser(ctx context.Context, orgID, userID uuid.UUID, desired types.SCIMUser) (*types.SCIMUser, error) {
+	return s.updateUser(ctx, orgID, userID, func(current types.SCIMUser) (types.SCIMUser, error) {
+		desired.ID = current.ID
+		if desired.Role == "" {
+			desired.Role = current.Role
+		}
+		return desired, nil
+	})
+}
+
+func (s *SCIMService) PatchUser(ctx context.Context, orgID, userID uuid.UUID, ops []types.SCIMPatchOp) (*types.SCIMUser, error) {
+	return s.updateUser(ctx, orgID, userID, func(current types.SCIMUser) (types.SCIMUser, error) {
+		return applyUserPatch(current, ops)
+	})
+}
+
+// DeleteUser deprovisions a user: their membership and provisioning link are
+// removed and their sessions revoked. The global user row is kept.
+func (s *SCIMService) DeleteUser(ctx context.Context, orgID, userID uuid.UUID) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.scimRepo.GetUser(ctx, tx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrSCIMUserNotFound
+		}
+		if current.Active {
+			if err := s.deactivate(ctx, tx, orgID, userID); err != nil {
+				return err
+			}
+		}
+		return s.scimRepo.DeleteUserLink(ctx, tx, orgID, userID)
+	})
+}
+
+// updateUser reads a user, computes the desired state with change and
+// reconciles the provisioning link and the membership in one transaction.
+// Names are stored on the link: the account's own name is shared across orgs
+// and only the user may change it.
+func (s *SCIMService) updateUser(ctx context.Context, orgID, userID uuid.UUID, change func(types.SCIMUser) (types.SCIMUser, error)) (*types.SCIMUser, error) {
+	var updated *types.SCIMUser
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.scimRepo.GetUser(ctx, tx, orgID, userID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrSCIMUserNotFound
+		}
+
+		desired, err := change(*current)
+		if err != nil {
+			return err
+		}
+		// userName is the account's email, which is shared across orgs.
+		if !strings.EqualFold(strings.TrimSpace(desired.UserName), current.UserName) {
+			return ErrSCIMMutability
+		}
+		if desired.Role == "" {
+			desired.Role = types.RoleUser
+		}
+
+		if err := s.scimRepo.UpsertUserLink(ctx, tx, orgID, userID, types.SCIMUserLinkParams{
+			ExternalID: desired.ExternalID,
+			GivenName:  strings.TrimSpace(desired.GivenName),
+			FamilyName: strings.TrimSpace(desired.FamilyName),
+		}); err != nil {
+			return err
+		}
+
+		switch {
+		case current.Active && !desired.Active:
+			if err := s.deactivate(ctx, tx, orgID, userID); err != nil {
+				return err
+			}
+		case !current.Active && desired.Active:
+			if err := s.checkRole(ctx, tx, orgID, desired.Role); err != nil {
+				return err
+			}
+			if err := s.addMember(ctx, tx, orgID, userID, desired.Role); err != nil {
+				return err
+			}
+		case desired.Active && desired.Role != current.Role:
+			if err := s.checkRole(ctx, tx, orgID, desired.Role); err != nil {
+				return err
+			}
+			if current.Role == types.RoleAdmin {
+				admins, err := s.membershipRepo.LockAdmins(ctx, tx, orgID)
+				if err != nil {
+					return err
+				}
+				if len(admins) <= 1 {
+					return ErrLastAdmin
+				}
+			}
+			if _, err := s.membershipRepo.UpdateRole(ctx, tx, userID, orgID, desired.Role); err != nil {
+				return err
+			}
+		}
+
+		updated, err = s.scimRepo.GetUser(ctx, tx, orgID, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// addMember gives a provisioned user a membership with role.
+func (s *SCIMService) addMember(ctx context.Context, tx pgx.Tx, orgID, userID uuid.UUID, role string) error {
+	m, err := s.membershipRepo.Create(ctx, tx, userID, orgID, role)
+	if err != nil {
+		return err
+	}
+	return s.eventBus.Publish(ctx, tx, events.MemberJoined{OrgID: orgID, UserID: userID, Role: m.Role, Via: events.ViaSCIM})
+}
+
+// deactivate removes a user's org membership, subject to the last-admin rule,
+// and revokes their refresh tokens. Their team memberships and grants in the
+// org go with the membership. Refresh tokens are not org-scoped, so the user
+// is signed out everywhere and must sign in again to reach other orgs.
+func (s *SCIMService) deactivate(ctx context.Context, tx pgx.Tx, orgID, userID uuid.UUID) error {
+	removed, err := removeMember(ctx, tx, s.membershipRepo, s.auditLog, orgID, userID, audit.ActionMemberRemoved, nil)
+	if err != nil {
+		return err
+	}
+	if err := s.sessionRepo.DeleteAllByUser(ctx, tx, userID); err != nil {
+		return err
+	}
+	return s.eventBus.Publish(ctx, tx, events.MemberRemoved{OrgID: orgID, UserID: userID, Role: removed.Role, Via: events.ViaSCIM})
+}
+
+func (s *SCIMService) checkRole(ctx context.Context, db database.DBTX, orgID uuid.UUID, key string) error {
+	role, err := s.roleRepo.GetByKey(ctx, db, orgID, key)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrSCIMInvalidValue
+	}
+	return nil
+}
+
+func (s *SCIMService) ListGroups(ctx context.Context, orgID uuid.UUID, params SCIMListParams) ([]*types.SCIMGroup, int, error) {
+	filter, err := parseOptionalFilter(params.Filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	groups, err := s.scimRepo.ListGroups(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*types.SCIMGroup
+	for _, g := range groups {
+		if filter == nil || filter.Match(scimGroupAttributes(g)) {
+			matched = append(matched, g)
+		}
+	}
+	return scimPage(matched, params.StartIndex, params.Count), len(matched), nil
+}
+
+func (s *SCIMService) GetGroup(ctx context.Context, orgID, groupID uuid.UUID) (*types.SCIMGroup, error) {
+	g, err := s.scimRepo.GetGroup(ctx, s.pool, orgID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, ErrTeamNotFound
+	}
+	return g, nil
+}
+
+// CreateGroup creates a team. Members must already be org members and join
+// with the member team role.
+func (s *SCIMService) CreateGroup(ctx context.Context, orgID uuid.UUID, desired types.SCIMGroup) (*types.SCIMGroup, error) {
+	name := strings.TrimSpace(desired.DisplayName)
+	if name == "" {
+		return nil, ErrSCIMInvalidValue
+	}
+
+	var created *types.SCIMGroup
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		team, err := s.teamRepo.Create(ctx, tx, types.CreateTeamParams{OrganizationID: orgID, Name: name})
+		if database.IsUniqueViolation(err) {
+			return ErrTeamNameTaken
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.scimRepo.SetGroupExternalID(ctx, tx, orgID, team.ID, desired.ExternalID); err != nil {
+			return err
+		}
+		if err := s.syncGroupMembers(ctx, tx, orgID, team.ID, nil, desired.Members); err != nil {
+			return err
+		}
+		created, err = s.scimRepo.GetGroup(ctx, tx, orgID, team.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *SCIMService) ReplaceGroup(ctx context.Context, orgID, groupID uuid.UUID, desired types.SCIMGroup) (*types.SCIMGroup, error) {
+	return s.updateGroup(ctx, orgID, groupID, func(current types.SCIMGroup) (types.SCIMGroup, error) {
+		desired.ID = current.ID
+		return desired, nil
+	})
+}
+
+func (s *SCIMService) PatchGroup(ctx context.Context, orgID, groupID uuid.UUID, ops []types.SCIMPatchOp) (*types.SCIMGroup, error) {
+	return s.updateGroup(ctx, orgID, groupID, func(current types.SCIMGroup) (types.SCIMGroup, error) {
+		return applyGroupPatch(current, ops)
+	})
+}
+
+func (s *SCIMService) DeleteGroup(ctx context.Context, orgID, groupID uuid.UUID) error {
+	team, err := s.teamRepo.GetByID(ctx, s.pool, orgID, groupID)
+	if err != nil {
+		return err
+	}
+	if team == nil {
+		return ErrTeamNotFound
+	}
+	return s.teamRepo.Delete(ctx, s.pool, orgID, groupID)
+}
+
+func (s *SCIMService) updateGroup(ctx context.Context, orgID, groupID uuid.UUID, change func(types.SCIMGroup) (types.SCIMGroup, error)) (*types.SCIMGroup, error) {
+	var updated *types.SCIMGroup
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.scimRepo.GetGroup(ctx, tx, orgID, groupID)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrTeamNotFound
+		}
+
+		desired, err := change(*current)
+		if err != nil {
+			return err
+		}
+		desired.DisplayName = strings.TrimSpace(desired.DisplayName)
+		if desired.DisplayName == "" {
+			return ErrSCIMInvalidValue
+		}
+
+		if desired.DisplayName != current.DisplayName {
+			team, err := s.teamRepo.GetByID(ctx, tx, orgID, groupID)
+			if err != nil {
+				return err
+			}
+			_, err = s.teamRepo.Update(ctx, tx, orgID, groupID, types.UpdateTeamParams{
+				Name:        desired.DisplayName,
+				Description: team.Description,
+			})
+			if database.IsUniqueViolation(err) {
+				return ErrTeamNameTaken
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if desired.ExternalID != current.ExternalID {
+			if err := s.scimRepo.SetGroupExternalID(ctx, tx, orgID, groupID, desired.ExternalID); err != nil {
+				return err
+			}
+		}
+		if err := s.syncGroupMembers(ctx, tx, orgID, groupID, current.Members, desired.Members); err != nil {
+			return err
+		}
+
+		updated, err = s.scimRepo.GetGroup(ctx, tx, orgID, groupID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// syncGroupMembers adds and removes team members so the team matches
+// desired. The IdP is authoritative, so the last-maintainer rule does not
+// apply.
+func (s *SCIMService) syncGroupMembers(ctx context.Context, tx pgx.Tx, orgID, teamID uuid.UUID, current, desired []types.SCIMGroupMember) error {
+	have := make(map[uuid.UUID]bool, len(current))
+	for _, m := range current {
+		have[m.UserID] = true
+	}
+	want := make(map[uuid.UUID]bool, len(desired))
+	for _, m := range desired {
+		want[m.UserID] = true
+	}
+
+	for id := range have {
+		if !want[id] {
+			if err := s.teamRepo.RemoveMember(ctx, tx, teamID, id); err != nil {
+				return err
+			}
+		}
+	}
+	for _, m := range desired {
+		if have[m.UserID] {
+			continue
+		}
+		have[m.UserID] = true
+		_, err := s.teamRepo.AddMember(ctx, tx, teamID, m.UserID, orgID, types.TeamRoleMember)
+		if database.IsForeignKeyViolation(err) {
+			return ErrMemberNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseOptionalFilter(filter string) (SCIMFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	return ParseSCIMFilter(filter)
+}
+
+func normalizeSCIMUserName(userName string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(userName))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrSCIMInvalidValue
+	}
+	return email, nil
+}
+
+// scimPage returns the page of items starting at the 1-based startIndex.
+func scimPage[T any](items []T, startIndex, count int) []T {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if startIndex > len(items) || count <= 0 {
+		return []T{}
+	}
+	end := startIndex - 1 + count
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[startIndex-1 : end]
+}
+
+func scimUserAttributes(u *types.SCIMUser) SCIMAttributes {
+	return SCIMAttributes{
+		"id":                {u.ID.String()},
+		"username":          {u.UserName},
+		"externalid":        {u.ExternalID},
+		"displayname":       {strings.TrimSpace(u.GivenName + " " + u.FamilyName)},
+		"name.givenname":    {u.GivenName},
+		"name.familyname":   {u.FamilyName},
+		"emails":            {u.UserName},
+		"emails.value":      {u.UserName},
+		"active":            {strconv.FormatBool(u.Active)},
+		"roles":             {u.Role},
+		"roles.value":       {u.Role},
+		"meta.created":      {u.CreatedAt.UTC().Format(time.RFC3339)},
+		"meta.lastmodified": {u.UpdatedAt.UTC().Format(time.RFC3339)},
+	}
+}
+
+func scimGroupAttributes(g *types.SCIMGroup) SCIMAttributes {
+	attrs := SCIMAttributes{
+		"id":                {g.ID.String()},
+		"displayname":       {g.DisplayName},
+		"externalid":        {g.ExternalID},
+		"meta.created":      {g.CreatedAt.UTC().Format(time.RFC3339)},
+		"meta.lastmodified": {g.UpdatedAt.UTC().Format(time.RFC3339)},
+	}
+	for _, m := range g.Members {
+		attrs["members"] = append(attrs["members"], m.UserID.String())
+		attrs["members.value"] = append(attrs["members.value"], m.UserID.String())
+		attrs["members.display"] = append(attrs["members.display"], m.Display)
+	}
+	return attrs
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	authtypes "agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func newTestSCIMService(pool *pgxpool.Pool) *SCIMService {
+	return NewSCIMService(
+		pool, NewOrganizationRepository(), NewSCIMTokenRepository(), NewSCIMRepository(),
+		NewMembershipRepository(), NewRoleRepository(), NewTeamRepository(), NewDomainRepository(),
+		authservices.NewUserRepository(), authservices.NewRefreshTokenRepository(), newTestBus(), audit.New(pool),
+	)
+}
+
+func TestSCIMDeactivateRemovesMembershipAndSessions(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	orgSvc := newTestOrgService(pool)
+	orgID, _, _ := setupTwoAdminOrg(t, pool, orgSvc)
+
+	tokenRepo := authservices.NewRefreshTokenRepository()
+	svc := newTestSCIMService(pool)
+	bus := newTestBus()
+	var removed []events.MemberRemoved
+	events.Subscribe(bus, "record", func(_ context.Context, _ database.DBTX, e events.MemberRemoved) error {
+		removed = append(removed, e)
+		return nil
+	})
+	svc.eventBus = bus
+
+	created, err := svc.CreateUser(ctx, orgID, types.SCIMUser{
+		UserName:   "scim-" + uuid.NewString() + "@example.com",
+		GivenName:  "Sam",
+		FamilyName: "Provisioned",
+		Active:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, created.ID)
+	})
+	if !created.Active || created.Role != "user" {
+		t.Fatalf("created user = %+v, want active user", created)
+	}
+
+	_, hash, err := authservices.GenerateRandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokenRepo.Create(ctx, pool, created.ID, hash, time.Now().Add(time.Hour), time.Now(), []string{authservices.AMRPassword}); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := patchOps(t, `[{"op":"replace","value":{"active":false,"name.givenName":"Samantha"}}]`)
+	updated, err := svc.PatchUser(ctx, orgID, created.ID, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Active {
+		t.Error("user still active after deactivation")
+	}
+	if updated.GivenName != "Samantha" {
+		t.Errorf("GivenName = %q, want Samantha", updated.GivenName)
+	}
+	// The IdP's names are the org's view; the account keeps its own.
+	if u, _ := authservices.NewUserRepository().GetByID(ctx, pool, created.ID); u == nil || u.FirstName != "Sam" {
+		t.Errorf("account = %+v, want first name Sam", u)
+	}
+
+	if m, _ := orgSvc.membershipRepo.GetByUserAndOrg(ctx, pool, created.ID, orgID); m != nil {
+		t.Error("membership not removed")
+	}
+	if rt, _ := tokenRepo.GetByHash(ctx, pool, hash); rt != nil {
+		t.Error("refresh token not revoked")
+	}
+	if len(removed) != 1 || removed[0].UserID != created.ID || removed[0].Via != events.ViaSCIM {
+		t.Errorf("MemberRemoved events = %+v, want one via scim", removed)
+	}
+
+	// The deactivated user stays visible to the IdP, and the account survives
+	// deletion.
+	if _, err := svc.GetUser(ctx, orgID, created.ID); err != nil {
+		t.Errorf("GetUser after deactivation: %v", err)
+	}
+	if err := svc.DeleteUser(ctx, orgID, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetUser(ctx, orgID, created.ID); err != ErrSCIMUserNotFound {
+		t.Errorf("GetUser after delete = %v, want ErrSCIMUserNotFound", err)
+	}
+	if u, _ := authservices.NewUserRepository().GetByID(ctx, pool, created.ID); u == nil {
+		t.Error("global user row was deleted")
+	}
+}
+
+func TestSCIMCreateUserLinksOnlyClaimableAccounts(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	orgSvc := newTestOrgService(pool)
+	orgID, _, member := setupTwoAdminOrg(t, pool, orgSvc)
+	svc := newTestSCIMService(pool)
+	bus := newTestBus()
+	var joined []events.MemberJoined
+	events.Subscribe(bus, "record", func(_ context.Context, _ database.DBTX, e events.MemberJoined) error {
+		joined = append(joined, e)
+		return nil
+	})
+	svc.eventBus = bus
+	userRepo := authservices.NewUserRepository()
+
+	// An account outside the org cannot be claimed by email alone.
+	outsider := createTestUser(t, pool)
+	if _, err := svc.CreateUser(ctx, orgID, types.SCIMUser{UserName: outsider.Email, Active: true}); !errors.Is(err, ErrSCIMUserTaken) {
+		t.Fatalf("CreateUser(outsider) = %v, want ErrSCIMUserTaken", err)
+	}
+	if m, _ := orgSvc.membershipRepo.GetByUserAndOrg(ctx, pool, outsider.ID, orgID); m != nil {
+		t.Error("outsider was added to the org")
+	}
+
+	// Existing members are linked without touching their membership.
+	m, err := userRepo.GetByID(ctx, pool, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linked, err := svc.CreateUser(ctx, orgID, types.SCIMUser{UserName: m.Email, ExternalID: "ext-1", Active: true})
+	if err != nil {
+		t.Fatalf("CreateUser(member): %v", err)
+	}
+	if linked.ID != member || linked.Role != types.RoleAdmin || !linked.Provisioned {
+		t.Errorf("linked = %+v, want the admin member, provisioned", linked)
+	}
+	if _, err := svc.CreateUser(ctx, orgID, types.SCIMUser{UserName: m.Email, Active: true}); !errors.Is(err, ErrSCIMUserExists) {
+		t.Errorf("second CreateUser(member) = %v, want ErrSCIMUserExists", err)
+	}
+
+	// Accounts under a domain the org has verified can be claimed.
+	domain := "scim-" + uuid.NewString() + ".example.com"
+	d, err := NewDomainRepository().Create(ctx, pool, orgID, domain, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE org_domains SET verified_at = NOW() WHERE id = $1`, d.ID); err != nil {
+		t.Fatal(err)
+	}
+	employee, err := userRepo.Create(ctx, pool, authtypes.CreateUserParams{
+		Email:        "jane@" + domain,
+		PasswordHash: "x",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, employee.ID)
+	})
+	claimed, err := svc.CreateUser(ctx, orgID, types.SCIMUser{UserName: employee.Email, Active: true})
+	if err != nil {
+		t.Fatalf("CreateUser(verified domain): %v", err)
+	}
+	if claimed.ID != employee.ID || !claimed.Active {
+		t.Errorf("claimed = %+v, want the existing account, active", claimed)
+	}
+	// Linking the existing member added no membership; claiming did.
+	if len(joined) != 1 || joined[0].UserID != employee.ID || joined[0].Via != events.ViaSCIM {
+		t.Errorf("MemberJoined events = %+v, want one via scim", joined)
+	}
+}
+
+func TestSCIMListUsersPagesAndFilters(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	orgID, _, _ := setupTwoAdminOrg(t, pool, newTestOrgService(pool))
+	svc := newTestSCIMService(pool)
+
+	var emails []string
+	for range 3 {
+		u, err := svc.CreateUser(ctx, orgID, types.SCIMUser{UserName: "scim-" + uuid.NewString() + "@example.com", Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, u.ID)
+		})
+		emails = append(emails, u.UserName)
+	}
+
+	tests := []struct {
+		name      string
+		params    SCIMListParams
+		wantLen   int
+		wantTotal int
+	}{
+		{"page", SCIMListParams{StartIndex: 2, Count: 2}, 2, 5},
+		{"past the end", SCIMListParams{StartIndex: 6, Count: 2}, 0, 5},
+		{"count only", SCIMListParams{StartIndex: 1, Count: 0}, 0, 5},
+		{"userName", SCIMListParams{Filter: `userName eq "` + strings.ToUpper(emails[1]) + `"`, StartIndex: 1, Count: 10}, 1, 1},
+		{"in memory", SCIMListParams{Filter: `userName sw "scim-"`, StartIndex: 3, Count: 10}, 1, 3},
+	}
+	for _, tt := range tests {
+		users, total, err := svc.ListUsers(ctx, orgID, tt.params)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(users) != tt.wantLen || total != tt.wantTotal {
+			t.Errorf("%s: got %d users of %d, want %d of %d", tt.name, len(users), total, tt.wantLen, tt.wantTotal)
+		}
+	}
+}
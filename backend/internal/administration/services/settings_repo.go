@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const orgSettingsColumns = `organization_id, version, settings, updated_by, updated_at, mfa_required_at`
+
+type pgxOrgSettingsRepository struct{}
+
+func NewOrgSettingsRepository() types.OrgSettingsRepository {
+	return &pgxOrgSettingsRepository{}
+}
+
+func scanOrgSettings(row pgx.Row) (*types.OrgSettingsRecord, error) {
+	var rec types.OrgSettingsRecord
+	if err := row.Scan(&rec.OrganizationID, &rec.Version, &rec.Values, &rec.UpdatedBy, &rec.UpdatedAt, &rec.MFARequiredAt); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *pgxOrgSettingsRepository) Get(ctx context.Context, db database.DBTX, orgID uuid.UUID) (*types.OrgSettingsRecord, error) {
+	rec, err := scanOrgSettings(db.QueryRow(ctx,
+		`SELECT `+orgSettingsColumns+` FROM org_settings WHERE organization_id = $1`, orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get org settings: %w", err)
+	}
+	return rec, nil
+}
+
+// GetForUpdate locks the org's settings row, creating an empty version 0 row
+// first if the org has never saved settings.
+func (r *pgxOrgSettingsRepository) GetForUpdate(ctx context.Context, db database.DBTX, orgID uuid.UUID) (*types.OrgSettingsRecord, error) {
+	_, err := db.Exec(ctx,
+		`INSERT INTO org_settings (organization_id) VALUES ($1) ON CONFLICT (organization_id) DO NOTHING`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("init org settings: %w", err)
+	}
+	rec, err := scanOrgSettings(db.QueryRow(ctx,
+		`SELECT `+orgSettingsColumns+` FROM org_settings WHERE organization_id = $1 FOR UPDATE`, orgID))
+	if err != nil {
+		return nil, fmt.Errorf("lock org settings: %w", err)
+	}
+	return rec, nil
+}
+
+// Save replaces the settings document, bumps its version and records the new
+// version in the history table. It stamps mfa_required_at when requireMfa is
+// switched on and clears it when switched off. The row must exist; see
+// GetForUpdate.
+func (r *pgxOrgSettingsRepository) Save(ctx context.Context, db database.DBTX, orgID uuid.UUID, values map[string]json.RawMessage, updatedBy uuid.UUID) (*types.OrgSettingsRecord, error) {
+	rec, err := scanOrgSettings(db.QueryRow(ctx,
+		`WITH s AS (
+		     UPDATE org_settings
+		     SET version = version + 1, settings = $2, updated_by = $3, updated_at = NOW(),
+		         mfa_required_at = CASE
+		             WHEN COALESCE(($2::jsonb->>'`+types.SettingRequireMFA+`')::boolean, false)
+		             THEN COALESCE(mfa_required_at, NOW())
+		         END
+		     WHERE organization_id = $1
+		     RETURNING `+orgSettingsColumns+`
+		 ), v AS (
+		     INSERT INTO org_settings_versions (organization_id, version, settings, updated_by, created_at)
+		     SELECT organization_id, version, settings, updated_by, updated_at FROM s
+		 )
+		 SELECT `+orgSettingsColumns+` FROM s`,
+		orgID, values, updatedBy))
+	if err != nil {
+		return nil, fmt.Errorf("save org settings: %w", err)
+	}
+	return rec, nil
+}
+
+func (r *pgxOrgSettingsRepository) ListVersions(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.OrgSettingsRecord, error) {
+	rows, err := db.Query(ctx,
+		`SELECT organization_id, version, settings, updated_by, created_at, NULL::timestamptz
+		 FROM org_settings_versions
+		 WHERE organization_id = $1
+		 ORDER BY version DESC`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list org settings versions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*types.OrgSettingsRecord
+	for rows.Next() {
+		rec, err := scanOrgSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan org settings version: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// MinMaxSessionHoursForUser returns the strictest session lifetime cap among
+// the live orgs a user belongs to, or nil if none sets one.
+func (r *pgxOrgSettingsRepository) MinMaxSessionHoursForUser(ctx context.Context, db database.DBTX, userID uuid.UUID) (*int, error) {
+	var hours *int
+	err := db.QueryRow(ctx,
+		`SELECT MIN((s.settings->>'`+types.SettingMaxSessionHours+`')::int)
+		 FROM org_settings s
+		 JOIN org_memberships m ON m.organization_id = s.organization_id
+		 JOIN organizations o ON o.id = s.organization_id
+		 WHERE m.user_id = $1 AND o.deleted_at IS NULL`, userID,
+	).Scan(&hours)
+	if err != nil {
+		return nil, fmt.Errorf("get session cap: %w", err)
+	}
+	return hours, nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrSettingsVersionConflict = errors.New("settings were changed since they were read")
+
+var validBrandColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// SettingsValidationError reports invalid values in a settings update, keyed
+// by setting.
+type SettingsValidationError struct {
+	Fields map[string]string
+}
+
+func (e *SettingsValidationError) Error() string {
+	return "invalid organization settings"
+}
+
+type SettingsService struct {
+	pool         *pgxpool.Pool
+	settingsRepo types.OrgSettingsRepository
+	roleRepo     types.RoleRepository
+	auditLog     *audit.Log
+}
+
+func NewSettingsService(pool *pgxpool.Pool, settingsRepo types.OrgSettingsRepository, roleRepo types.RoleRepository, auditLog *audit.Log) *SettingsService {
+	return &SettingsService{
+		pool:         pool,
+		settingsRepo: settingsRepo,
+		roleRepo:     roleRepo,
+		auditLog:     auditLog,
+	}
+}
+
+// Get returns an org's effective settings and their version. Orgs that have
+// never saved settings are at version 0.
+func (s *SettingsService) Get(ctx context.Context, orgID uuid.UUID) (*types.VersionedOrgSettings, error) {
+	rec, err := s.settingsRepo.Get(ctx, s.pool, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return versionedSettings(rec)
+}
+
+// Effective returns an org's effective settings, read through db so callers
+// can use them inside a transaction.
+func (s *SettingsService) Effective(ctx context.Context, db database.DBTX, orgID uuid.UUID) (types.OrgSettings, error) {
+	rec, err := s.settingsRepo.Get(ctx, db, orgID)
+	if err != nil {
+		return types.OrgSettings{}, err
+	}
+	if rec == nil {
+		return types.DefaultOrgSettings(), nil
+	}
+	return effectiveSettings(rec.Values)
+}
+
+// Update applies a JSON merge patch to an org's settings: each key sets a
+// setting and null resets it to its default. If expectedVersion is set and
+// the stored version differs, ErrSettingsVersionConflict is returned.
+func (s *SettingsService) Update(ctx context.Context, orgID, actorID uuid.UUID, patch map[string]json.RawMessage, expectedVersion *int) (*types.VersionedOrgSettings, error) {
+	normalized, err := validateSettingsPatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *types.VersionedOrgSettings
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		rec, err := s.settingsRepo.GetForUpdate(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != nil && *expectedVersion != rec.Version {
+			return ErrSettingsVersionConflict
+		}
+
+		if raw, ok := normalized[types.SettingDefaultInviteRole]; ok && raw != nil {
+			var key string
+			if err := json.Unmarshal(raw, &key); err != nil {
+				return err
+			}
+			role, err := s.roleRepo.GetByKey(ctx, tx, orgID, key)
+			if err != nil {
+				return err
+			}
+			if role == nil {
+				return &SettingsValidationError{Fields: map[string]string{
+					types.SettingDefaultInviteRole: "Role does not exist in this organization",
+				}}
+			}
+		}
+
+		values := make(map[string]json.RawMessage, len(rec.Values)+len(normalized))
+		for k, v := range rec.Values {
+			values[k] = v
+		}
+		for k, v := range normalized {
+			if v == nil {
+				delete(values, k)
+			} else {
+				values[k] = v
+			}
+		}
+
+		saved, err := s.settingsRepo.Save(ctx, tx, orgID, values, actorID)
+		if err != nil {
+			return err
+		}
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        actorID,
+			Action:         audit.ActionSettingsUpdated,
+			TargetType:     audit.TargetOrganization,
+			TargetID:       orgID,
+			Before:         rec.Values,
+			After:          saved.Values,
+			Metadata:       map[string]any{"version": saved.Version},
+		})
+		if err != nil {
+			return err
+		}
+		updated, err = versionedSettings(saved)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// ListVersions returns the org's saved settings documents, newest first.
+func (s *SettingsService) ListVersions(ctx context.Context, orgID uuid.UUID) ([]*types.OrgSettingsRecord, error) {
+	return s.settingsRepo.ListVersions(ctx, s.pool, orgID)
+}
+
+// MaxSessionLifetime returns the strictest session lifetime cap set by the
+// orgs a user belongs to, or 0 if none sets one. It implements the auth
+// domain's SessionPolicy.
+func (s *SettingsService) MaxSessionLifetime(ctx context.Context, db database.DBTX, userID uuid.UUID) (time.Duration, error) {
+	hours, err := s.settingsRepo.MinMaxSessionHoursForUser(ctx, db, userID)
+	if err != nil {
+		return 0, err
+	}
+	if hours == nil {
+		return 0, nil
+	}
+	return time.Duration(*hours) * time.Hour, nil
+}
+
+func versionedSettings(rec *types.OrgSettingsRecord) (*types.VersionedOrgSettings, error) {
+	if rec == nil {
+		return &types.VersionedOrgSettings{Settings: types.DefaultOrgSettings()}, nil
+	}
+	settings, err := effectiveSettings(rec.Values)
+	if err != nil {
+		return nil, err
+	}
+	updatedAt := rec.UpdatedAt
+	return &types.VersionedOrgSettings{
+		Version:   rec.Version,
+		Settings:  settings,
+		UpdatedBy: rec.UpdatedBy,
+		UpdatedAt: &updatedAt,
+	}, nil
+}
+
+// effectiveSettings overlays stored values on the schema defaults.
+func effectiveSettings(values map[string]json.RawMessage) (types.OrgSettings, error) {
+	settings := types.DefaultOrgSettings()
+	if len(values) == 0 {
+		return settings, nil
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return settings, fmt.Errorf("encode org settings: %w", err)
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return settings, fmt.Errorf("decode org settings: %w", err)
+	}
+	return settings, nil
+}
+
+// validateSettingsPatch checks a merge patch against the settings schema and
+// returns it with values normalized. Keys set to null map to a nil value.
+func validateSettingsPatch(patch map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	out := make(map[string]json.RawMessage, len(patch))
+	fields := make(map[string]string)
+	for key, raw := range patch {
+		def, ok := types.LookupSetting(key)
+		if !ok {
+			fields[key] = "Unknown setting"
+			continue
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			out[key] = nil
+			continue
+		}
+		value, msg := normalizeSetting(def, raw)
+		if msg != "" {
+			fields[key] = msg
+			continue
+		}
+		out[key] = value
+	}
+	if len(fields) > 0 {
+		return nil, &SettingsValidationError{Fields: fields}
+	}
+	return out, nil
+}
+
+// normalizeSetting decodes a value as its schema type, applies the schema's
+// bounds and any per-setting normalization, and re-encodes it. A non-empty
+// message describes why the value is invalid.
+func normalizeSetting(def types.SettingDefinition, raw json.RawMessage) (json.RawMessage, string) {
+	var value any
+	switch def.Type {
+	case types.SettingTypeString:
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, "Must be a string"
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, "Must not be empty"
+		}
+		if len(def.Enum) > 0 && !slices.Contains(def.Enum, v) {
+			return nil, "Must be one of " + strings.Join(def.Enum, ", ")
+		}
+		switch def.Key {
+		case types.SettingBrandColor:
+			if !validBrandColor.MatchString(v) {
+				return nil, "Must be a color like #1a2b3c"
+			}
+			v = strings.ToLower(v)
+		case types.SettingBrandLogoURL:
+			if u, err := url.Parse(v); err != nil || u.Scheme != "https" || u.Host == "" || len(v) > 2048 {
+				return nil, "Must be an https URL"
+			}
+		}
+		value = v
+	case types.SettingTypeInteger:
+		var v int
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, "Must be an integer"
+		}
+		if v < def.Min || v > def.Max {
+			return nil, fmt.Sprintf("Must be between %d and %d", def.Min, def.Max)
+		}
+		value = v
+	case types.SettingTypeBoolean:
+		var v bool
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, "Must be a boolean"
+		}
+		value = v
+	case types.SettingTypeStringList:
+		var v []string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, "Must be a list of strings"
+		}
+		if len(v) > def.Max {
+			return nil, fmt.Sprintf("Must have at most %d entries", def.Max)
+		}
+		if def.Key == types.SettingAllowedInviteDomains {
+			domains, msg := normalizeDomainList(v)
+			if msg != "" {
+				return nil, msg
+			}
+			v = domains
+		}
+		value = v
+	default:
+		return nil, "Unsupported setting type"
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, "Invalid value"
+	}
+	return out, ""
+}
+
+func normalizeDomainList(domains []string) ([]string, string) {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+		if len(d) > 253 || !validDomain.MatchString(d) {
+			return nil, fmt.Sprintf("%q is not a valid domain", d)
+		}
+		if !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	return out, ""
+}
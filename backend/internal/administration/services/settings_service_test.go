@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+)
+
+func settingsPatch(t *testing.T, raw string) map[string]json.RawMessage {
+	t.Helper()
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &patch); err != nil {
+		t.Fatalf("unmarshal patch: %v", err)
+	}
+	return patch
+}
+
+func TestValidateSettingsPatch(t *testing.T) {
+	patch := settingsPatch(t, `{
+		"defaultInviteRole": "  admin ",
+		"inviteTtlHours": 48,
+		"allowedInviteDomains": ["Acme.com.", "acme.com", "example.org"],
+		"requireMfa": true,
+		"maxSessionHours": null
+	}`)
+
+	out, err := validateSettingsPatch(patch)
+	if err != nil {
+		t.Fatalf("validateSettingsPatch: %v", err)
+	}
+
+	want := map[string]string{
+		types.SettingDefaultInviteRole:    `"admin"`,
+		types.SettingInviteTTLHours:       `48`,
+		types.SettingAllowedInviteDomains: `["acme.com","example.org"]`,
+		types.SettingRequireMFA:           `true`,
+	}
+	for key, value := range want {
+		if string(out[key]) != value {
+			t.Errorf("%s = %s, want %s", key, out[key], value)
+		}
+	}
+	if v, ok := out[types.SettingMaxSessionHours]; !ok || v != nil {
+		t.Errorf("maxSessionHours = %s, want reset", v)
+	}
+}
+
+func TestValidateSettingsPatchErrors(t *testing.T) {
+	patch := settingsPatch(t, `{
+		"colour": "blue",
+		"defaultInviteRole": "",
+		"inviteTtlHours": 0,
+		"allowedInviteDomains": ["not a domain"],
+		"requireMfa": "yes",
+		"maxSessionHours": 1.5
+	}`)
+
+	_, err := validateSettingsPatch(patch)
+	var validationErr *SettingsValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want SettingsValidationError", err)
+	}
+	for _, key := range []string{
+		"colour",
+		types.SettingDefaultInviteRole,
+		types.SettingInviteTTLHours,
+		types.SettingAllowedInviteDomains,
+		types.SettingRequireMFA,
+		types.SettingMaxSessionHours,
+	} {
+		if validationErr.Fields[key] == "" {
+			t.Errorf("no error for %s", key)
+		}
+	}
+}
+
+func TestEffectiveSettings(t *testing.T) {
+	settings, err := effectiveSettings(nil)
+	if err != nil {
+		t.Fatalf("effectiveSettings: %v", err)
+	}
+	if settings.DefaultInviteRole != types.RoleUser || settings.RequireMFA || settings.MaxSessionHours != nil {
+		t.Errorf("defaults = %+v", settings)
+	}
+
+	settings, err = effectiveSettings(settingsPatch(t, `{"requireMfa": true, "maxSessionHours": 12}`))
+	if err != nil {
+		t.Fatalf("effectiveSettings: %v", err)
+	}
+	if !settings.RequireMFA || settings.MaxSessionHours == nil || *settings.MaxSessionHours != 12 {
+		t.Errorf("settings = %+v", settings)
+	}
+	if settings.DefaultInviteRole != types.RoleUser {
+		t.Errorf("defaultInviteRole = %q, want default", settings.DefaultInviteRole)
+	}
+}
+
+func TestValidateSettingsPatchEmailBranding(t *testing.T) {
+	out, err := validateSettingsPatch(settingsPatch(t, `{
+		"emailLocale": "de",
+		"brandColor": "#FF6600",
+		"brandLogoUrl": "https://cdn.example.com/logo.png"
+	}`))
+	if err != nil {
+		t.Fatalf("validateSettingsPatch: %v", err)
+	}
+	if string(out[types.SettingBrandColor]) != `"#ff6600"` {
+		t.Errorf("brandColor = %s, want lowercased", out[types.SettingBrandColor])
+	}
+
+	_, err = validateSettingsPatch(settingsPatch(t, `{
+		"emailLocale": "xx",
+		"brandColor": "orange",
+		"brandLogoUrl": "http://cdn.example.com/logo.png"
+	}`))
+	var validationErr *SettingsValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want SettingsValidationError", err)
+	}
+	for _, key := range []string{types.SettingEmailLocale, types.SettingBrandColor, types.SettingBrandLogoURL} {
+		if validationErr.Fields[key] == "" {
+			t.Errorf("no error for %s", key)
+		}
+	}
+}
+
+func TestEmailBranding(t *testing.T) {
+	settings, err := effectiveSettings(settingsPatch(t, `{"emailLocale": "de", "brandColor": "#ff6600"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	brand := settings.EmailBranding("Acme")
+	if settings.EmailLocale != "de" || brand.Name != "Acme" || brand.PrimaryColor != "#ff6600" || brand.LogoURL != "" {
+		t.Errorf("locale = %q, branding = %+v", settings.EmailLocale, brand)
+	}
+	if types.DefaultOrgSettings().EmailLocale != "en" {
+		t.Error("default emailLocale is not en")
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	minSlugLength = 3
+	maxSlugLength = 48
+	// maxBaseSlugLength leaves room for a "-" plus 8 hex chars of collision suffix.
+	maxBaseSlugLength = maxSlugLength - 9
+	maxSlugAttempts   = 5
+)
+
+var (
+	ErrSlugInvalid  = errors.New("slug must be 3-48 lowercase letters, digits or single hyphens")
+	ErrSlugReserved = errors.New("slug is reserved")
+	ErrSlugTaken    = errors.New("slug is already in use")
+)
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+	validSlug       = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// reservedSlugs collide with frontend routes or are otherwise misleading.
+var reservedSlugs = map[string]struct{}{
+	"admin":         {},
+	"agenteur":      {},
+	"api":           {},
+	"app":           {},
+	"auth":          {},
+	"by-slug":       {},
+	"dashboard":     {},
+	"help":          {},
+	"invitations":   {},
+	"login":         {},
+	"logout":        {},
+	"me":            {},
+	"new":           {},
+	"organizations": {},
+	"settings":      {},
+	"signup":        {},
+	"static":        {},
+	"support":       {},
+	"system":        {},
+	"www":           {},
+}
+
+// ValidateSlug checks a user-chosen slug against the format rules and the
+// reserved word list.
+func ValidateSlug(slug string) error {
+	if len(slug) < minSlugLength || len(slug) > maxSlugLength || !validSlug.MatchString(slug) {
+		return ErrSlugInvalid
+	}
+	if _, ok := reservedSlugs[slug]; ok {
+		return ErrSlugReserved
+	}
+	return nil
+}
+
+func generateSlug(name string) string {
+	slug := strings.ToLower(name)
+	slug = nonAlphanumeric.ReplaceAllString(slug, "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > maxBaseSlugLength {
+		slug = strings.TrimRight(slug[:maxBaseSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "org"
+	}
+	return slug
+}
+
+// slugCandidate returns the slug to try on the given attempt. The first attempt
+// uses the base slug as-is when it is valid; later attempts (or invalid bases,
+// e.g. reserved words or too short) get a random suffix.
+func slugCandidate(base string, attempt int) (string, error) {
+	if attempt == 0 && ValidateSlug(base) == nil {
+		return base, nil
+	}
+	suffix, err := randomSuffix()
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + suffix, nil
+}
+
+func randomSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
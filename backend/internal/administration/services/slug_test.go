@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSlug(t *testing.T) {
+	cases := []struct {
+		slug string
+		want error
+	}{
+		{"acme", nil},
+		{"acme-labs-2", nil},
+		{"ab", ErrSlugInvalid},
+		{strings.Repeat("a", 49), ErrSlugInvalid},
+		{"Acme", ErrSlugInvalid},
+		{"acme--labs", ErrSlugInvalid},
+		{"-acme", ErrSlugInvalid},
+		{"acme_labs", ErrSlugInvalid},
+		{"admin", ErrSlugReserved},
+		{"by-slug", ErrSlugReserved},
+	}
+	for _, c := range cases {
+		if got := ValidateSlug(c.slug); !errors.Is(got, c.want) {
+			t.Errorf("ValidateSlug(%q) = %v, want %v", c.slug, got, c.want)
+		}
+	}
+}
+
+func TestGenerateSlug(t *testing.T) {
+	cases := map[string]string{
+		"Acme Inc.":   "acme-inc",
+		"  --Foo  ":   "foo",
+		"!!!":         "org",
+		"Ünïcode Co":  "n-code-co",
+		"a b c d e f": "a-b-c-d-e-f",
+	}
+	for name, want := range cases {
+		if got := generateSlug(name); got != want {
+			t.Errorf("generateSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	long := generateSlug(strings.Repeat("word ", 30))
+	if len(long) > maxBaseSlugLength || strings.HasSuffix(long, "-") {
+		t.Fatalf("expected truncated slug without trailing hyphen, got %q", long)
+	}
+}
+
+func TestSlugCandidate(t *testing.T) {
+	first, err := slugCandidate("acme", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "acme" {
+		t.Fatalf("expected base slug on first attempt, got %q", first)
+	}
+
+	second, err := slugCandidate("acme", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(second, "acme-") || ValidateSlug(second) != nil {
+		t.Fatalf("expected valid suffixed slug, got %q", second)
+	}
+
+	reserved, err := slugCandidate("admin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reserved == "admin" || ValidateSlug(reserved) != nil {
+		t.Fatalf("expected reserved base to be suffixed, got %q", reserved)
+	}
+
+	short, err := slugCandidate("ab", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValidateSlug(short) != nil {
+		t.Fatalf("expected short base to be suffixed into a valid slug, got %q", short)
+	}
+}
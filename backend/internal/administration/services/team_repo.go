@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const teamColumns = `t.id, t.organization_id, t.name, t.description,
+	(SELECT COUNT(*) FROM team_memberships tm WHERE tm.team_id = t.id),
+	t.created_at, t.updated_at`
+
+const teamMembershipColumns = `team_id, user_id, organization_id, role, created_at, updated_at`
+
+type pgxTeamRepository struct{}
+
+func NewTeamRepository() types.TeamRepository {
+	return &pgxTeamRepository{}
+}
+
+func scanTeam(row pgx.Row) (*types.Team, error) {
+	var t types.Team
+	if err := row.Scan(&t.ID, &t.OrganizationID, &t.Name, &t.Description, &t.MemberCount, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func scanTeamMembership(row pgx.Row) (*types.TeamMembership, error) {
+	var m types.TeamMembership
+	if err := row.Scan(&m.TeamID, &m.UserID, &m.OrganizationID, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *pgxTeamRepository) Create(ctx context.Context, db database.DBTX, params types.CreateTeamParams) (*types.Team, error) {
+	team, err := scanTeam(db.QueryRow(ctx,
+		`WITH t AS (
+		     INSERT INTO teams (organization_id, name, description)
+		     VALUES ($1, $2, $3)
+		     RETURNING *
+		 )
+		 SELECT `+teamColumns+` FROM t`,
+		params.OrganizationID, params.Name, params.Description))
+	if err != nil {
+		return nil, fmt.Errorf("create team: %w", err)
+	}
+	return team, nil
+}
+
+func (r *pgxTeamRepository) GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.Team, error) {
+	team, err := scanTeam(db.QueryRow(ctx,
+		`SELECT `+teamColumns+` FROM teams t WHERE t.organization_id = $1 AND t.id = $2`, orgID, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get team: %w", err)
+	}
+	return team, nil
+}
+
+func (r *pgxTeamRepository) ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.Team, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+teamColumns+` FROM teams t WHERE t.organization_id = $1 ORDER BY LOWER(t.name)`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("list teams: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []*types.Team
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+	return teams, nil
+}
+
+func (r *pgxTeamRepository) Update(ctx context.Context, db database.DBTX, orgID, id uuid.UUID, params types.UpdateTeamParams) (*types.Team, error) {
+	team, err := scanTeam(db.QueryRow(ctx,
+		`WITH t AS (
+		     UPDATE teams SET name = $3, description = $4, updated_at = NOW()
+		     WHERE organization_id = $1 AND id = $2
+		     RETURNING *
+		 )
+		 SELECT `+teamColumns+` FROM t`,
+		orgID, id, params.Name, params.Description))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update team: %w", err)
+	}
+	return team, nil
+}
+
+func (r *pgxTeamRepository) Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) error {
+	tag, err := db.Exec(ctx, `DELETE FROM teams WHERE organization_id = $1 AND id = $2`, orgID, id)
+	if err != nil {
+		return fmt.Errorf("delete team: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team not found")
+	}
+	return nil
+}
+
+func (r *pgxTeamRepository) AddMember(ctx context.Context, db database.DBTX, teamID, userID, orgID uuid.UUID, role string) (*types.TeamMembership, error) {
+	m, err := scanTeamMembership(db.QueryRow(ctx,
+		`INSERT INTO team_memberships (team_id, user_id, organization_id, role)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING `+teamMembershipColumns,
+		teamID, userID, orgID, role))
+	if err != nil {
+		return nil, fmt.Errorf("add team member: %w", err)
+	}
+	return m, nil
+}
+
+func (r *pgxTeamRepository) GetMember(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) (*types.TeamMembership, error) {
+	m, err := scanTeamMembership(db.QueryRow(ctx,
+		`SELECT `+teamMembershipColumns+` FROM team_memberships WHERE team_id = $1 AND user_id = $2`,
+		teamID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get team member: %w", err)
+	}
+	return m, nil
+}
+
+// GetMemberForUpdate is GetMember with a row lock. Must be called inside a
+// transaction.
+func (r *pgxTeamRepository) GetMemberForUpdate(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) (*types.TeamMembership, error) {
+	m, err := scanTeamMembership(db.QueryRow(ctx,
+		`SELECT `+teamMembershipColumns+` FROM team_memberships WHERE team_id = $1 AND user_id = $2 FOR UPDATE`,
+		teamID, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get team member for update: %w", err)
+	}
+	return m, nil
+}
+
+func (r *pgxTeamRepository) ListMembers(ctx context.Context, db database.DBTX, teamID uuid.UUID) ([]*types.TeamMemberWithUser, error) {
+	rows, err := db.Query(ctx,
+		`SELECT u.id, u.email, u.first_name, u.last_name, tm.role, tm.created_at
+		 FROM team_memberships tm
+		 JOIN users u ON u.id = tm.user_id
+		 WHERE tm.team_id = $1
+		 ORDER BY tm.created_at ASC`, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("list team members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*types.TeamMemberWithUser
+	for rows.Next() {
+		var m types.TeamMemberWithUser
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FirstName, &m.LastName, &m.Role, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("scan team member: %w", err)
+		}
+		members = append(members, &m)
+	}
+	return members, nil
+}
+
+func (r *pgxTeamRepository) ListByUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) ([]*types.TeamWithRole, error) {
+	rows, err := db.Query(ctx,
+		`SELECT t.id, t.name, t.description, tm.role, tm.created_at
+		 FROM team_memberships tm
+		 JOIN teams t ON t.id = tm.team_id
+		 WHERE tm.organization_id = $1 AND tm.user_id = $2
+		 ORDER BY LOWER(t.name)`, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list teams by user: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []*types.TeamWithRole
+	for rows.Next() {
+		var t types.TeamWithRole
+		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Role, &t.JoinedAt); err != nil {
+			return nil, fmt.Errorf("scan team with role: %w", err)
+		}
+		teams = append(teams, &t)
+	}
+	return teams, nil
+}
+
+func (r *pgxTeamRepository) UpdateMemberRole(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID, role string) (*types.TeamMembership, error) {
+	m, err := scanTeamMembership(db.QueryRow(ctx,
+		`UPDATE team_memberships SET role = $3, updated_at = NOW()
+		 WHERE team_id = $1 AND user_id = $2
+		 RETURNING `+teamMembershipColumns,
+		teamID, userID, role))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("update team member role: %w", err)
+	}
+	return m, nil
+}
+
+func (r *pgxTeamRepository) RemoveMember(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) error {
+	tag, err := db.Exec(ctx,
+		`DELETE FROM team_memberships WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("remove team member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("team member not found")
+	}
+	return nil
+}
+
+// LockMaintainers locks the team's maintainer rows and returns their user IDs,
+// in a stable order. Must be called inside a transaction.
+func (r *pgxTeamRepository) LockMaintainers(ctx context.Context, db database.DBTX, teamID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx,
+		`SELECT user_id FROM team_memberships
+		 WHERE team_id = $1 AND role = 'maintainer'
+		 ORDER BY user_id
+		 FOR UPDATE`, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("lock maintainers: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan maintainer: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
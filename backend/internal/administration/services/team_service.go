@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrTeamNameTaken      = errors.New("a team with this name already exists")
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	ErrAlreadyTeamMember  = errors.New("user is already a member of this team")
+	ErrLastMaintainer     = errors.New("cannot remove the last maintainer from the team")
+)
+
+type TeamService struct {
+	pool           *pgxpool.Pool
+	teamRepo       types.TeamRepository
+	membershipRepo types.MembershipRepository
+	auditLog       *audit.Log
+}
+
+func NewTeamService(pool *pgxpool.Pool, teamRepo types.TeamRepository, membershipRepo types.MembershipRepository, auditLog *audit.Log) *TeamService {
+	return &TeamService{
+		pool:           pool,
+		teamRepo:       teamRepo,
+		membershipRepo: membershipRepo,
+		auditLog:       auditLog,
+	}
+}
+
+// Create creates a team. If the creator is a member of the org they become
+// its first maintainer; superadmins acting on an org they don't belong to
+// create an empty team.
+func (s *TeamService) Create(ctx context.Context, orgID, creatorID uuid.UUID, params types.CreateTeamParams) (*types.Team, error) {
+	params.OrganizationID = orgID
+
+	var team *types.Team
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		team, err = s.teamRepo.Create(ctx, tx, params)
+		if database.IsUniqueViolation(err) {
+			return ErrTeamNameTaken
+		}
+		if err != nil {
+			return err
+		}
+		err = s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamCreated,
+			TargetType:     audit.TargetTeam,
+			TargetID:       team.ID,
+			After:          teamSnapshot(team),
+		})
+		if err != nil {
+			return err
+		}
+
+		membership, err := s.membershipRepo.GetByUserAndOrg(ctx, tx, creatorID, orgID)
+		if err != nil {
+			return err
+		}
+		if membership == nil {
+			return nil
+		}
+		if _, err := s.teamRepo.AddMember(ctx, tx, team.ID, creatorID, orgID, types.TeamRoleMaintainer); err != nil {
+			return err
+		}
+		team.MemberCount = 1
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *TeamService) List(ctx context.Context, orgID uuid.UUID) ([]*types.Team, error) {
+	return s.teamRepo.ListByOrg(ctx, s.pool, orgID)
+}
+
+func (s *TeamService) Get(ctx context.Context, orgID, teamID uuid.UUID) (*types.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, s.pool, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+func (s *TeamService) Update(ctx context.Context, orgID, teamID uuid.UUID, params types.UpdateTeamParams) (*types.Team, error) {
+	existing, err := s.Get(ctx, orgID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var team *types.Team
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		team, err = s.teamRepo.Update(ctx, tx, orgID, teamID, params)
+		if err != nil {
+			return err
+		}
+		if team == nil {
+			return ErrTeamNotFound
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamUpdated,
+			TargetType:     audit.TargetTeam,
+			TargetID:       teamID,
+			Before:         teamSnapshot(existing),
+			After:          teamSnapshot(team),
+		})
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrTeamNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// Delete removes a team. Its memberships and permission grants are removed
+// with it.
+func (s *TeamService) Delete(ctx context.Context, orgID, teamID uuid.UUID) error {
+	team, err := s.Get(ctx, orgID, teamID)
+	if err != nil {
+		return err
+	}
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := s.teamRepo.Delete(ctx, tx, orgID, teamID); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamDeleted,
+			TargetType:     audit.TargetTeam,
+			TargetID:       teamID,
+			Before:         teamSnapshot(team),
+		})
+	})
+}
+
+func (s *TeamService) ListMembers(ctx context.Context, orgID, teamID uuid.UUID) ([]*types.TeamMemberWithUser, error) {
+	if _, err := s.Get(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+	return s.teamRepo.ListMembers(ctx, s.pool, teamID)
+}
+
+// ListUserTeams returns the teams a member belongs to in an org.
+func (s *TeamService) ListUserTeams(ctx context.Context, orgID, userID uuid.UUID) ([]*types.TeamWithRole, error) {
+	return s.teamRepo.ListByUser(ctx, s.pool, orgID, userID)
+}
+
+// AddMember adds an org member to a team. Returns ErrMemberNotFound if the
+// user is not a member of the org.
+func (s *TeamService) AddMember(ctx context.Context, orgID, teamID, userID uuid.UUID, role string) (*types.TeamMembership, error) {
+	if _, err := s.Get(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+
+	var m *types.TeamMembership
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		var err error
+		m, err = s.teamRepo.AddMember(ctx, tx, teamID, userID, orgID, role)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamMemberAdded,
+			TargetType:     audit.TargetTeam,
+			TargetID:       teamID,
+			After:          memberSnapshot{Role: role},
+			Metadata:       map[string]any{"userId": userID},
+		})
+	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrAlreadyTeamMember
+	}
+	if database.IsForeignKeyViolation(err) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UpdateMemberRole changes a team member's role. Demoting the last maintainer
+// is rejected with ErrLastMaintainer; maintainer rows are locked so concurrent
+// demotions are serialized, as in OrgService.UpdateMemberRole.
+func (s *TeamService) UpdateMemberRole(ctx context.Context, orgID, teamID, userID uuid.UUID, role string) (*types.TeamMembership, error) {
+	if _, err := s.Get(ctx, orgID, teamID); err != nil {
+		return nil, err
+	}
+
+	var membership *types.TeamMembership
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		maintainers, err := s.teamRepo.LockMaintainers(ctx, tx, teamID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := s.teamRepo.GetMemberForUpdate(ctx, tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return ErrTeamMemberNotFound
+		}
+
+		if existing.Role == types.TeamRoleMaintainer && role != types.TeamRoleMaintainer && len(maintainers) <= 1 {
+			return ErrLastMaintainer
+		}
+
+		membership, err = s.teamRepo.UpdateMemberRole(ctx, tx, teamID, userID, role)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamMemberRoleUpdated,
+			TargetType:     audit.TargetTeam,
+			TargetID:       teamID,
+			Before:         memberSnapshot{Role: existing.Role},
+			After:          memberSnapshot{Role: role},
+			Metadata:       map[string]any{"userId": userID},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// RemoveMember removes a member from a team, subject to the same
+// last-maintainer rule as UpdateMemberRole. Removing someone from the org
+// removes them from all of its teams regardless of this rule.
+func (s *TeamService) RemoveMember(ctx context.Context, orgID, teamID, userID uuid.UUID) error {
+	if _, err := s.Get(ctx, orgID, teamID); err != nil {
+		return err
+	}
+
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		maintainers, err := s.teamRepo.LockMaintainers(ctx, tx, teamID)
+		if err != nil {
+			return err
+		}
+
+		membership, err := s.teamRepo.GetMemberForUpdate(ctx, tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if membership == nil {
+			return ErrTeamMemberNotFound
+		}
+
+		if membership.Role == types.TeamRoleMaintainer && len(maintainers) <= 1 {
+			return ErrLastMaintainer
+		}
+
+		if err := s.teamRepo.RemoveMember(ctx, tx, teamID, userID); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionTeamMemberRemoved,
+			TargetType:     audit.TargetTeam,
+			TargetID:       teamID,
+			Before:         memberSnapshot{Role: membership.Role},
+			Metadata:       map[string]any{"userId": userID},
+		})
+	})
+}
+
+// CanManage reports whether a user may manage a team: either through the
+// teams.manage permission or as one of its maintainers.
+func (s *TeamService) CanManage(ctx context.Context, teamID, userID uuid.UUID, perms types.PermissionSet) (bool, error) {
+	if perms.Has(types.PermTeamsManage) {
+		return true, nil
+	}
+	m, err := s.teamRepo.GetMember(ctx, s.pool, teamID, userID)
+	if err != nil {
+		return false, err
+	}
+	return m != nil && m.Role == types.TeamRoleMaintainer, nil
+}
+
+// teamAuditState is the part of a team recorded in audit events.
+type teamAuditState struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func teamSnapshot(t *types.Team) teamAuditState {
+	return teamAuditState{Name: t.Name, Description: t.Description}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"github.com/google/uuid"
+)
+
+func TestTeamRemoveMemberConcurrentMaintainersKeepOneMaintainer(t *testing.T) {
+	pool := testPool(t)
+	orgSvc := newTestOrgService(pool)
+	teamSvc := NewTeamService(pool, NewTeamRepository(), NewMembershipRepository(), audit.New(pool))
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		orgID, a, b := setupTwoAdminOrg(t, pool, orgSvc)
+		team, err := teamSvc.Create(ctx, orgID, a, types.CreateTeamParams{Name: "Team " + uuid.NewString()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := teamSvc.AddMember(ctx, orgID, team.ID, b, types.TeamRoleMaintainer); err != nil {
+			t.Fatal(err)
+		}
+
+		err1, err2 := runConcurrently(
+			func() error { return teamSvc.RemoveMember(ctx, orgID, team.ID, a) },
+			func() error { return teamSvc.RemoveMember(ctx, orgID, team.ID, b) },
+		)
+
+		members, err := teamSvc.ListMembers(ctx, orgID, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(members) != 1 || members[0].Role != types.TeamRoleMaintainer {
+			t.Fatalf("expected exactly 1 maintainer, got %d members (errors: %v, %v)", len(members), err1, err2)
+		}
+		if !errors.Is(err1, ErrLastMaintainer) && !errors.Is(err2, ErrLastMaintainer) {
+			t.Fatalf("expected one ErrLastMaintainer, got %v, %v", err1, err2)
+		}
+	}
+}
+
+func TestOrgRemoveMemberRemovesTeamMemberships(t *testing.T) {
+	pool := testPool(t)
+	orgSvc := newTestOrgService(pool)
+	teamSvc := NewTeamService(pool, NewTeamRepository(), NewMembershipRepository(), audit.New(pool))
+	ctx := context.Background()
+
+	orgID, a, b := setupTwoAdminOrg(t, pool, orgSvc)
+	team, err := teamSvc.Create(ctx, orgID, a, types.CreateTeamParams{Name: "Team " + uuid.NewString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := teamSvc.AddMember(ctx, orgID, team.ID, b, types.TeamRoleMember); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := orgSvc.RemoveMember(ctx, orgID, b, adminActor); err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := teamSvc.ListUserTeams(ctx, orgID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != 0 {
+		t.Fatalf("expected removed member to have no teams, got %d", len(teams))
+	}
+	if _, err := teamSvc.AddMember(ctx, orgID, team.ID, b, types.TeamRoleMember); !errors.Is(err, ErrMemberNotFound) {
+		t.Fatalf("expected ErrMemberNotFound adding a non-member, got %v", err)
+	}
+}
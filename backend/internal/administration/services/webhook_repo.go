@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookEndpointColumns = `id, organization_id, url, description, event_types, secret, enabled,
+	consecutive_failures, disabled_reason, disabled_at, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, last_attempt_at, created_at`
+
+const webhookAttemptColumns = `id, delivery_id, request_headers, request_body, response_status, response_headers,
+	response_body, error, duration_ms, created_at`
+
+type pgxWebhookRepository struct{}
+
+func NewWebhookRepository() types.WebhookRepository {
+	return &pgxWebhookRepository{}
+}
+
+func scanWebhookEndpoint(row pgx.Row) (*types.WebhookEndpoint, error) {
+	var e types.WebhookEndpoint
+	err := row.Scan(&e.ID, &e.OrganizationID, &e.URL, &e.Description, &e.EventTypes, &e.Secret, &e.Enabled,
+		&e.ConsecutiveFailures, &e.DisabledReason, &e.DisabledAt, &e.CreatedBy, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*types.WebhookDelivery, error) {
+	var d types.WebhookDelivery
+	err := row.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.LastAttemptAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *pgxWebhookRepository) getEndpoint(ctx context.Context, db database.DBTX, query string, args ...any) (*types.WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(db.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+func (r *pgxWebhookRepository) listEndpoints(ctx context.Context, db database.DBTX, query string, args ...any) ([]*types.WebhookEndpoint, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := []*types.WebhookEndpoint{}
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, rows.Err()
+}
+
+func (r *pgxWebhookRepository) LockOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) error {
+	if _, err := db.Exec(ctx, `SELECT 1 FROM organizations WHERE id = $1 FOR UPDATE`, orgID); err != nil {
+		return fmt.Errorf("lock organization: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxWebhookRepository) CreateEndpoint(ctx context.Context, db database.DBTX, orgID, actorID uuid.UUID, params types.CreateWebhookEndpointParams, secret string) (*types.WebhookEndpoint, error) {
+	var createdBy *uuid.UUID
+	if actorID != uuid.Nil {
+		createdBy = &actorID
+	}
+	e, err := scanWebhookEndpoint(db.QueryRow(ctx,
+		`INSERT INTO webhook_endpoints (organization_id, url, description, event_types, secret, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING `+webhookEndpointColumns,
+		orgID, params.URL, params.Description, params.EventTypes, secret, createdBy))
+	if err != nil {
+		return nil, fmt.Errorf("create webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+func (r *pgxWebhookRepository) GetEndpoint(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*types.WebhookEndpoint, error) {
+	return r.getEndpoint(ctx, db,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE organization_id = $1 AND id = $2`, orgID, id)
+}
+
+func (r *pgxWebhookRepository) GetEndpointForUpdate(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.WebhookEndpoint, error) {
+	return r.getEndpoint(ctx, db,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *pgxWebhookRepository) ListEndpoints(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*types.WebhookEndpoint, error) {
+	return r.listEndpoints(ctx, db,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE organization_id = $1 ORDER BY created_at`, orgID)
+}
+
+func (r *pgxWebhookRepository) ListSubscribed(ctx context.Context, db database.DBTX, orgID uuid.UUID, eventType string) ([]*types.WebhookEndpoint, error) {
+	return r.listEndpoints(ctx, db,
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+		 WHERE organization_id = $1 AND enabled AND (event_types = '{}' OR $2 = ANY(event_types))
+		 ORDER BY created_at`,
+		orgID, eventType)
+}
+
+func (r *pgxWebhookRepository) UpdateEndpoint(ctx context.Context, db database.DBTX, e *types.WebhookEndpoint) (*types.WebhookEndpoint, error) {
+	updated, err := scanWebhookEndpoint(db.QueryRow(ctx,
+		`UPDATE webhook_endpoints
+		 SET url = $2, description = $3, event_types = $4, enabled = $5, consecutive_failures = $6,
+		     disabled_reason = $7, disabled_at = $8, updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING `+webhookEndpointColumns,
+		e.ID, e.URL, e.Description, e.EventTypes, e.Enabled, e.ConsecutiveFailures, e.DisabledReason, e.DisabledAt))
+	if err != nil {
+		return nil, fmt.Errorf("update webhook endpoint: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *pgxWebhookRepository) DeleteEndpoint(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM webhook_endpoints WHERE organization_id = $1 AND id = $2`, orgID, id)
+	if err != nil {
+		return false, fmt.Errorf("delete webhook endpoint: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *pgxWebhookRepository) CreateDelivery(ctx context.Context, db database.DBTX, endpointID, eventID uuid.UUID, eventType string, payload json.RawMessage) (*types.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(db.QueryRow(ctx,
+		`INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING `+webhookDeliveryColumns,
+		endpointID, eventID, eventType, payload))
+	if err != nil {
+		return nil, fmt.Errorf("create webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxWebhookRepository) GetDelivery(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(db.QueryRow(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+func (r *pgxWebhookRepository) ListDeliveries(ctx context.Context, db database.DBTX, endpointID uuid.UUID, limit, offset int) ([]*types.WebhookDelivery, int, error) {
+	var total int
+	err := db.QueryRow(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE endpoint_id = $1`, endpointID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count webhook deliveries: %w", err)
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		 WHERE endpoint_id = $1
+		 ORDER BY created_at DESC, id
+		 LIMIT $2 OFFSET $3`,
+		endpointID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*types.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, total, rows.Err()
+}
+
+func (r *pgxWebhookRepository) SetDeliveryStatus(ctx context.Context, db database.DBTX, id uuid.UUID, status string) error {
+	if _, err := db.Exec(ctx, `UPDATE webhook_deliveries SET status = $2 WHERE id = $1`, id, status); err != nil {
+		return fmt.Errorf("update webhook delivery status: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxWebhookRepository) RecordAttempt(ctx context.Context, db database.DBTX, a *types.WebhookDeliveryAttempt, status string) error {
+	err := db.QueryRow(ctx,
+		`INSERT INTO webhook_delivery_attempts (delivery_id, request_headers, request_body, response_status, response_headers,
+		   response_body, error, duration_ms)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		a.DeliveryID, a.RequestHeaders, a.RequestBody, a.ResponseStatus, a.ResponseHeaders,
+		a.ResponseBody, a.Error, a.DurationMs).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert webhook delivery attempt: %w", err)
+	}
+	_, err = db.Exec(ctx,
+		`UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, last_attempt_at = $3 WHERE id = $1`,
+		a.DeliveryID, status, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxWebhookRepository) ListAttempts(ctx context.Context, db database.DBTX, deliveryID uuid.UUID) ([]*types.WebhookDeliveryAttempt, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+webhookAttemptColumns+` FROM webhook_delivery_attempts WHERE delivery_id = $1 ORDER BY created_at`,
+		deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook delivery attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []*types.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var a types.WebhookDeliveryAttempt
+		err := rows.Scan(&a.ID, &a.DeliveryID, &a.RequestHeaders, &a.RequestBody, &a.ResponseStatus, &a.ResponseHeaders,
+			&a.ResponseBody, &a.Error, &a.DurationMs, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery attempt: %w", err)
+		}
+		attempts = append(attempts, &a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *pgxWebhookRepository) PurgeDeliveries(ctx context.Context, db database.DBTX, before time.Time) (int64, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM webhook_deliveries WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge webhook deliveries: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
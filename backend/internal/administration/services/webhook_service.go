@@ -0,0 +1,642 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	authservices "agenteur.ai/api/internal/auth/services"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDisabled         = errors.New("webhook endpoint is disabled")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrInvalidWebhookEvent     = errors.New("unknown webhook event type")
+	ErrTooManyWebhooks         = fmt.Errorf("an organization may have at most %d webhook endpoints", maxWebhookEndpoints)
+	ErrWebhookSignature        = errors.New("webhook signature does not match")
+	ErrWebhookTimestamp        = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// WebhookOutboxKind is the kind of outbox messages that deliver a webhook.
+const WebhookOutboxKind = "webhook"
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the endpoint's secret. Receivers
+// should recompute it and reject requests whose timestamp is too old, which
+// stops a captured request from being replayed later; VerifyWebhookSignature
+// does both.
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	maxWebhookEndpoints              = 20
+	maxWebhookDescriptionLength      = 200
+	webhookTimeout                   = 10 * time.Second
+	webhookSecretPrefix              = "whsec_"
+	webhookUserAgent                 = "Agenteur-Webhooks/1.0"
+	maxWebhookResponseBodyStoredSize = 16 << 10
+)
+
+// WebhookService manages an org's webhook endpoints and delivers events to
+// them. Events are published inside the transaction that causes them and
+// delivered through the outbox, so an endpoint hears about a change only if
+// it commits, and failed deliveries are retried with backoff.
+type WebhookService struct {
+	pool         *pgxpool.Pool
+	webhookRepo  types.WebhookRepository
+	outbox       *outbox.Outbox
+	auditLog     *audit.Log
+	client       *http.Client
+	disableAfter int
+	now          func() time.Time
+}
+
+// NewWebhookService creates a service that disables an endpoint after
+// disableAfter consecutive failed attempts. Unless allowPrivateNetworks is
+// set, deliveries to loopback, private and link-local addresses are refused,
+// so endpoints can't be used to reach internal services.
+func NewWebhookService(pool *pgxpool.Pool, webhookRepo types.WebhookRepository, messageOutbox *outbox.Outbox, auditLog *audit.Log, disableAfter int, allowPrivateNetworks bool) *WebhookService {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !allowPrivateNetworks {
+		dialer.Control = refusePrivateAddresses
+	}
+	return &WebhookService{
+		pool:        pool,
+		webhookRepo: webhookRepo,
+		outbox:      messageOutbox,
+		auditLog:    auditLog,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// No proxy: through one, the only dial would be to the proxy and
+			// the address check would never see the receiver's address.
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// A redirect could point anywhere; receivers must answer at
+			// the configured URL.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		disableAfter: disableAfter,
+		now:          time.Now,
+	}
+}
+
+// refusePrivateAddresses is a dialer control that rejects connections to
+// addresses that aren't publicly routable. It runs after DNS resolution, so
+// a public name resolving to a private address is refused too.
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() {
+		return fmt.Errorf("webhook address %s is not publicly routable", ip)
+	}
+	return nil
+}
+
+// Publish queues eventType for every enabled endpoint of the org that
+// subscribes to it. db should be the transaction making the change the event
+// reports.
+func (s *WebhookService) Publish(ctx context.Context, db database.DBTX, orgID uuid.UUID, eventType string, data any) error {
+	endpoints, err := s.webhookRepo.ListSubscribed(ctx, db, orgID, eventType)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode %s webhook payload: %w", eventType, err)
+	}
+	eventID := uuid.New()
+	for _, e := range endpoints {
+		d, err := s.webhookRepo.CreateDelivery(ctx, db, e.ID, eventID, eventType, payload)
+		if err != nil {
+			return err
+		}
+		if err := s.enqueue(ctx, db, e, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemberJoined handles events.MemberJoined by publishing member.joined. Like
+// the other event handlers below, it runs in the transaction that made the
+// change.
+func (s *WebhookService) MemberJoined(ctx context.Context, db database.DBTX, e events.MemberJoined) error {
+	return s.Publish(ctx, db, e.OrgID, types.WebhookMemberJoined, types.WebhookMemberData{UserID: e.UserID, Role: e.Role, Via: e.Via})
+}
+
+// MemberRemoved publishes member.removed.
+func (s *WebhookService) MemberRemoved(ctx context.Context, db database.DBTX, e events.MemberRemoved) error {
+	return s.Publish(ctx, db, e.OrgID, types.WebhookMemberRemoved, types.WebhookMemberData{UserID: e.UserID, Role: e.Role, Via: e.Via})
+}
+
+// InvitationAccepted publishes invitation.accepted.
+func (s *WebhookService) InvitationAccepted(ctx context.Context, db database.DBTX, e events.InvitationAccepted) error {
+	return s.Publish(ctx, db, e.OrgID, types.WebhookInvitationAccepted, types.WebhookInvitationData{
+		InvitationID: e.InvitationID,
+		Email:        e.Email,
+		Role:         e.Role,
+		UserID:       e.UserID,
+	})
+}
+
+// OrgUpdated publishes org.updated.
+func (s *WebhookService) OrgUpdated(ctx context.Context, db database.DBTX, e events.OrgUpdated) error {
+	return s.Publish(ctx, db, e.OrgID, types.WebhookOrgUpdated, types.WebhookOrgData{ID: e.OrgID, Name: e.Name, Slug: e.Slug})
+}
+
+type webhookMessage struct {
+	OrgID      uuid.UUID `json:"orgId"`
+	DeliveryID uuid.UUID `json:"deliveryId"`
+}
+
+func (s *WebhookService) enqueue(ctx context.Context, db database.DBTX, e *types.WebhookEndpoint, d *types.WebhookDelivery) error {
+	summary := fmt.Sprintf("%s webhook to %s", d.EventType, e.URL)
+	return s.outbox.Enqueue(ctx, db, WebhookOutboxKind, summary, webhookMessage{OrgID: e.OrganizationID, DeliveryID: d.ID})
+}
+
+// Deliver is the outbox handler for webhook messages. It POSTs the delivery
+// and logs the attempt. A failure is returned so the outbox retries it,
+// unless the endpoint has been disabled, by this failure or before.
+func (s *WebhookService) Deliver(ctx context.Context, payload json.RawMessage) error {
+	var msg webhookMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return outbox.Permanent(fmt.Errorf("decode webhook message: %w", err))
+	}
+	// Deleted endpoints take their deliveries with them, and old
+	// deliveries are purged: there is nothing left to send.
+	d, err := s.webhookRepo.GetDelivery(ctx, s.pool, msg.DeliveryID)
+	if err != nil || d == nil {
+		return err
+	}
+	e, err := s.webhookRepo.GetEndpoint(ctx, s.pool, msg.OrgID, d.EndpointID)
+	if err != nil || e == nil {
+		return err
+	}
+	if !e.Enabled {
+		return outbox.Permanent(ErrWebhookDisabled)
+	}
+
+	attempt := s.send(ctx, e, d)
+
+	var disabled bool
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		e, err := s.webhookRepo.GetEndpointForUpdate(ctx, tx, d.EndpointID)
+		if err != nil || e == nil {
+			return err
+		}
+		status := types.WebhookDeliveryFailed
+		if attempt.Succeeded() {
+			status = types.WebhookDeliverySucceeded
+		}
+		if err := s.webhookRepo.RecordAttempt(ctx, tx, attempt, status); err != nil {
+			return err
+		}
+		return s.recordOutcome(ctx, tx, e, attempt, &disabled)
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case attempt.Succeeded():
+		return nil
+	case disabled:
+		return outbox.Permanent(ErrWebhookDisabled)
+	case attempt.Error != nil:
+		return errors.New(*attempt.Error)
+	default:
+		return fmt.Errorf("webhook endpoint returned %d", *attempt.ResponseStatus)
+	}
+}
+
+// recordOutcome updates the endpoint's failure count after an attempt and
+// disables it when the count reaches the limit.
+func (s *WebhookService) recordOutcome(ctx context.Context, tx pgx.Tx, e *types.WebhookEndpoint, attempt *types.WebhookDeliveryAttempt, disabled *bool) error {
+	if attempt.Succeeded() {
+		if e.ConsecutiveFailures == 0 {
+			return nil
+		}
+		e.ConsecutiveFailures = 0
+		_, err := s.webhookRepo.UpdateEndpoint(ctx, tx, e)
+		return err
+	}
+
+	e.ConsecutiveFailures++
+	if e.Enabled && e.ConsecutiveFailures >= s.disableAfter {
+		reason := fmt.Sprintf("Disabled after %d consecutive failed deliveries", e.ConsecutiveFailures)
+		now := s.now()
+		e.Enabled, e.DisabledReason, e.DisabledAt = false, &reason, &now
+		*disabled = true
+	}
+	if _, err := s.webhookRepo.UpdateEndpoint(ctx, tx, e); err != nil {
+		return err
+	}
+	if !*disabled {
+		return nil
+	}
+	return s.auditLog.Record(ctx, tx, audit.Entry{
+		OrganizationID: e.OrganizationID,
+		Action:         audit.ActionWebhookDisabled,
+		TargetType:     audit.TargetWebhook,
+		TargetID:       e.ID,
+		Before:         webhookAuditState{URL: e.URL, EventTypes: e.EventTypes, Enabled: true},
+		After:          webhookSnapshot(e),
+		Metadata:       map[string]any{"consecutiveFailures": e.ConsecutiveFailures},
+	})
+}
+
+// webhookBody is the JSON POSTed for an event.
+type webhookBody struct {
+	ID             uuid.UUID       `json:"id"`
+	Type           string          `json:"type"`
+	OrganizationID uuid.UUID       `json:"organizationId"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// send makes one signed request for d and returns the attempt to log.
+func (s *WebhookService) send(ctx context.Context, e *types.WebhookEndpoint, d *types.WebhookDelivery) *types.WebhookDeliveryAttempt {
+	attempt := &types.WebhookDeliveryAttempt{DeliveryID: d.ID}
+	fail := func(err error) *types.WebhookDeliveryAttempt {
+		msg := err.Error()
+		attempt.Error = &msg
+		return attempt
+	}
+
+	body, err := json.Marshal(webhookBody{
+		ID:             d.EventID,
+		Type:           d.EventType,
+		OrganizationID: e.OrganizationID,
+		CreatedAt:      d.CreatedAt,
+		Data:           d.Payload,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("encode webhook body: %w", err))
+	}
+	attempt.RequestBody = string(body)
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	attempt.RequestHeaders = map[string]string{
+		"Content-Type":         "application/json",
+		"User-Agent":           webhookUserAgent,
+		WebhookIDHeader:        d.ID.String(),
+		WebhookEventHeader:     d.EventType,
+		WebhookTimestampHeader: timestamp,
+		WebhookSignatureHeader: SignWebhook(e.Secret, timestamp, body),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return fail(fmt.Errorf("build webhook request: %w", err))
+	}
+	for k, v := range attempt.RequestHeaders {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	attempt.DurationMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBodyStoredSize))
+	attempt.DurationMs = int(time.Since(start).Milliseconds())
+	attempt.ResponseStatus = &resp.StatusCode
+	attempt.ResponseHeaders = make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		attempt.ResponseHeaders[k] = resp.Header.Get(k)
+	}
+	bodyText := strings.ToValidUTF8(string(respBody), "�")
+	attempt.ResponseBody = &bodyText
+	if err != nil {
+		return fail(fmt.Errorf("read webhook response: %w", err))
+	}
+	return attempt
+}
+
+// SignWebhook returns the signature header value for a request body sent at
+// timestamp, in Unix seconds.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a received webhook the way receivers should:
+// the signature must match the body and timestamp, and the timestamp must be
+// within tolerance of now.
+func VerifyWebhookSignature(secret, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	if !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body))) {
+		return ErrWebhookSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookTimestamp
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return ErrWebhookTimestamp
+	}
+	return nil
+}
+
+// WebhookEndpointWithSecret is a newly created endpoint and the secret its
+// deliveries are signed with, which is not shown again.
+type WebhookEndpointWithSecret struct {
+	*types.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, orgID uuid.UUID) ([]*types.WebhookEndpoint, error) {
+	return s.webhookRepo.ListEndpoints(ctx, s.pool, orgID)
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, orgID, id uuid.UUID) (*types.WebhookEndpoint, error) {
+	e, err := s.webhookRepo.GetEndpoint(ctx, s.pool, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return e, nil
+}
+
+// CreateEndpoint adds an endpoint with a generated signing secret.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, orgID, actorID uuid.UUID, params types.CreateWebhookEndpointParams) (*WebhookEndpointWithSecret, error) {
+	var err error
+	if params.URL, err = normalizeWebhookURL(params.URL); err != nil {
+		return nil, err
+	}
+	if params.EventTypes, err = normalizeWebhookEventTypes(params.EventTypes); err != nil {
+		return nil, err
+	}
+	params.Description = truncateDescription(params.Description)
+
+	raw, _, err := authservices.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	secret := webhookSecretPrefix + raw
+
+	var created *types.WebhookEndpoint
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		// Concurrent creates queue here so both can't pass the limit.
+		if err := s.webhookRepo.LockOrg(ctx, tx, orgID); err != nil {
+			return err
+		}
+		existing, err := s.webhookRepo.ListEndpoints(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if len(existing) >= maxWebhookEndpoints {
+			return ErrTooManyWebhooks
+		}
+		created, err = s.webhookRepo.CreateEndpoint(ctx, tx, orgID, actorID, params, secret)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			ActorID:        actorID,
+			Action:         audit.ActionWebhookCreated,
+			TargetType:     audit.TargetWebhook,
+			TargetID:       created.ID,
+			After:          webhookSnapshot(created),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookEndpointWithSecret{WebhookEndpoint: created, Secret: secret}, nil
+}
+
+// UpdateEndpoint changes an endpoint's settings.
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, orgID, id uuid.UUID, params types.UpdateWebhookEndpointParams) (*types.WebhookEndpoint, error) {
+	var updated *types.WebhookEndpoint
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.webhookRepo.GetEndpoint(ctx, tx, orgID, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrWebhookNotFound
+		}
+		e := *current
+		if params.URL != nil {
+			if e.URL, err = normalizeWebhookURL(*params.URL); err != nil {
+				return err
+			}
+		}
+		if params.Description != nil {
+			e.Description = truncateDescription(*params.Description)
+		}
+		if params.EventTypes != nil {
+			if e.EventTypes, err = normalizeWebhookEventTypes(*params.EventTypes); err != nil {
+				return err
+			}
+		}
+		if params.Enabled != nil && *params.Enabled != e.Enabled {
+			e.Enabled = *params.Enabled
+			if e.Enabled {
+				e.ConsecutiveFailures, e.DisabledReason, e.DisabledAt = 0, nil, nil
+			} else {
+				reason, now := "Disabled by an admin", s.now()
+				e.DisabledReason, e.DisabledAt = &reason, &now
+			}
+		}
+
+		updated, err = s.webhookRepo.UpdateEndpoint(ctx, tx, &e)
+		if err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionWebhookUpdated,
+			TargetType:     audit.TargetWebhook,
+			TargetID:       id,
+			Before:         webhookSnapshot(current),
+			After:          webhookSnapshot(updated),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteEndpoint removes an endpoint and its delivery log.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, orgID, id uuid.UUID) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.webhookRepo.GetEndpoint(ctx, tx, orgID, id)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return ErrWebhookNotFound
+		}
+		if _, err := s.webhookRepo.DeleteEndpoint(ctx, tx, orgID, id); err != nil {
+			return err
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			OrganizationID: orgID,
+			Action:         audit.ActionWebhookDeleted,
+			TargetType:     audit.TargetWebhook,
+			TargetID:       id,
+			Before:         webhookSnapshot(current),
+		})
+	})
+}
+
+// ListDeliveries returns a page of an endpoint's deliveries, newest first,
+// and the total number.
+func (s *WebhookService) ListDeliveries(ctx context.Context, orgID, endpointID uuid.UUID, page, perPage int) ([]*types.WebhookDelivery, int, error) {
+	if _, err := s.GetEndpoint(ctx, orgID, endpointID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.ListDeliveries(ctx, s.pool, endpointID, perPage, (page-1)*perPage)
+}
+
+// GetDelivery returns a delivery with its attempt log.
+func (s *WebhookService) GetDelivery(ctx context.Context, orgID, endpointID, deliveryID uuid.UUID) (*types.WebhookDelivery, error) {
+	d, err := s.getDelivery(ctx, s.pool, orgID, endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.AttemptLog, err = s.webhookRepo.ListAttempts(ctx, s.pool, d.ID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *WebhookService) getDelivery(ctx context.Context, db database.DBTX, orgID, endpointID, deliveryID uuid.UUID) (*types.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(ctx, orgID, endpointID); err != nil {
+		return nil, err
+	}
+	d, err := s.webhookRepo.GetDelivery(ctx, db, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || d.EndpointID != endpointID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return d, nil
+}
+
+// Redeliver queues a delivery to be sent again straight away, whatever its
+// status. The endpoint must be enabled.
+func (s *WebhookService) Redeliver(ctx context.Context, orgID, endpointID, deliveryID uuid.UUID) (*types.WebhookDelivery, error) {
+	var d *types.WebhookDelivery
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		e, err := s.webhookRepo.GetEndpoint(ctx, tx, orgID, endpointID)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrWebhookNotFound
+		}
+		if !e.Enabled {
+			return ErrWebhookDisabled
+		}
+		if d, err = s.getDelivery(ctx, tx, orgID, endpointID, deliveryID); err != nil {
+			return err
+		}
+		if err := s.webhookRepo.SetDeliveryStatus(ctx, tx, d.ID, types.WebhookDeliveryPending); err != nil {
+			return err
+		}
+		d.Status = types.WebhookDeliveryPending
+		return s.enqueue(ctx, tx, e, d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// PurgeDeliveries deletes deliveries created before the cutoff, with their
+// attempts.
+func (s *WebhookService) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	return s.webhookRepo.PurgeDeliveries(ctx, s.pool, before)
+}
+
+func normalizeWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", ErrInvalidWebhookURL
+	}
+	return u.String(), nil
+}
+
+// normalizeWebhookEventTypes validates and deduplicates event types. An
+// empty list subscribes to every event.
+func normalizeWebhookEventTypes(eventTypes []string) ([]string, error) {
+	out := []string{}
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if !types.IsValidWebhookEventType(t) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidWebhookEvent, t)
+		}
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func truncateDescription(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxWebhookDescriptionLength {
+		s = strings.ToValidUTF8(s[:maxWebhookDescriptionLength], "")
+	}
+	return s
+}
+
+// webhookAuditState is the part of an endpoint recorded in the audit log.
+// The secret is left out.
+type webhookAuditState struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Enabled    bool     `json:"enabled"`
+}
+
+func webhookSnapshot(e *types.WebhookEndpoint) webhookAuditState {
+	return webhookAuditState{URL: e.URL, EventTypes: e.EventTypes, Enabled: e.Enabled}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/outbox"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"org.updated"}`)
+	ts := "1700000000"
+	sig := SignWebhook("whsec_test", ts, body)
+
+	if err := VerifyWebhookSignature("whsec_test", ts, sig, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	cases := []struct {
+		name            string
+		secret, ts, sig string
+		body            []byte
+		at              time.Time
+		want            error
+	}{
+		{"wrong secret", "whsec_other", ts, sig, body, now, ErrWebhookSignature},
+		{"edited body", "whsec_test", ts, sig, []byte(`{"type":"org.deleted"}`), now, ErrWebhookSignature},
+		{"edited timestamp", "whsec_test", "1700000001", sig, body, now, ErrWebhookSignature},
+		{"replayed later", "whsec_test", ts, sig, body, now.Add(10 * time.Minute), ErrWebhookTimestamp},
+	}
+	for _, c := range cases {
+		if err := VerifyWebhookSignature(c.secret, c.ts, c.sig, c.body, c.at, 5*time.Minute); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", c.name, err, c.want)
+		}
+	}
+}
+
+func TestNormalizeWebhookInput(t *testing.T) {
+	for _, raw := range []string{"ftp://example.com/hook", "/hook", "https://user:pw@example.com/hook", "https://"} {
+		if _, err := normalizeWebhookURL(raw); !errors.Is(err, ErrInvalidWebhookURL) {
+			t.Errorf("normalizeWebhookURL(%q) err = %v", raw, err)
+		}
+	}
+	if u, err := normalizeWebhookURL(" https://example.com/hook "); err != nil || u != "https://example.com/hook" {
+		t.Errorf("normalizeWebhookURL = %q, %v", u, err)
+	}
+
+	got, err := normalizeWebhookEventTypes([]string{types.WebhookOrgUpdated, types.WebhookMemberJoined, types.WebhookOrgUpdated})
+	if err != nil || strings.Join(got, ",") != "member.joined,org.updated" {
+		t.Errorf("normalizeWebhookEventTypes = %v, %v", got, err)
+	}
+	if got, err := normalizeWebhookEventTypes(nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("normalizeWebhookEventTypes(nil) = %#v, %v", got, err)
+	}
+	if _, err := normalizeWebhookEventTypes([]string{"org.deleted"}); !errors.Is(err, ErrInvalidWebhookEvent) {
+		t.Errorf("unknown event type err = %v", err)
+	}
+}
+
+func TestRefusePrivateAddresses(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:80", "10.1.2.3:443", "192.168.0.1:443", "169.254.169.254:80", "[::1]:443", "[::ffff:10.0.0.1]:443", "0.0.0.0:80"} {
+		if err := refusePrivateAddresses("tcp", addr, nil); err == nil {
+			t.Errorf("%s was allowed", addr)
+		}
+	}
+	if err := refusePrivateAddresses("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("public address refused: %v", err)
+	}
+}
+
+func TestWebhookClientBypassesProxies(t *testing.T) {
+	svc := NewWebhookService(nil, nil, nil, nil, 1, false)
+	if svc.client.Transport.(*http.Transport).Proxy != nil {
+		t.Fatal("webhook client uses a proxy, which would hide the receiver's address from the dialer")
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits.Add(1) }))
+	defer srv.Close()
+	if resp, err := svc.client.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback receiver succeeded")
+	}
+	if hits.Load() != 0 {
+		t.Errorf("loopback receiver got %d requests", hits.Load())
+	}
+}
+
+func newTestWebhookService(pool *pgxpool.Pool, disableAfter int) *WebhookService {
+	return NewWebhookService(pool, NewWebhookRepository(), outbox.New(pool, 5), audit.New(pool), disableAfter, true)
+}
+
+// publishTestEvent publishes an event in its own transaction, as a service
+// would, and returns the new deliveries' outbox payloads.
+func publishTestEvent(t *testing.T, pool *pgxpool.Pool, svc *WebhookService, orgID uuid.UUID, eventType string) []json.RawMessage {
+	t.Helper()
+	ctx := context.Background()
+	before := time.Now()
+	err := database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		return svc.Publish(ctx, tx, orgID, eventType, types.WebhookOrgData{ID: orgID, Name: "Hooks"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := pool.Query(ctx,
+		`SELECT d.id FROM webhook_deliveries d JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		 WHERE e.organization_id = $1 AND d.event_type = $2 AND d.created_at >= $3`, orgID, eventType, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var payloads []json.RawMessage
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(webhookMessage{OrgID: orgID, DeliveryID: id})
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestWebhookDeliveryRetriesLogsAndDisables(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	owner := createTestUser(t, pool)
+	org, err := newTestOrgService(pool).Create(ctx, owner.ID, "Webhook Test "+uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM organizations WHERE id = $1`, org.ID)
+	})
+
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	var secret atomic.Value
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := VerifyWebhookSignature(secret.Load().(string), r.Header.Get(WebhookTimestampHeader),
+			r.Header.Get(WebhookSignatureHeader), body, time.Now(), 5*time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		received.Add(1)
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte("receiver says hi"))
+	}))
+	defer server.Close()
+
+	svc := newTestWebhookService(pool, 2)
+	created, err := svc.CreateEndpoint(ctx, org.ID, owner.ID, types.CreateWebhookEndpointParams{
+		URL:        server.URL,
+		EventTypes: []string{types.WebhookOrgUpdated},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Store(created.Secret)
+
+	if got := publishTestEvent(t, pool, svc, org.ID, types.WebhookMemberJoined); len(got) != 0 {
+		t.Fatalf("unsubscribed event created %d deliveries", len(got))
+	}
+	payloads := publishTestEvent(t, pool, svc, org.ID, types.WebhookOrgUpdated)
+	if len(payloads) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(payloads))
+	}
+
+	// The first failure is retried; the second reaches the limit.
+	if err := svc.Deliver(ctx, payloads[0]); err == nil || errors.Is(err, ErrWebhookDisabled) {
+		t.Fatalf("first failure: err = %v, want a retryable error", err)
+	}
+	if err := svc.Deliver(ctx, payloads[0]); !errors.Is(err, ErrWebhookDisabled) {
+		t.Fatalf("second failure: err = %v, want ErrWebhookDisabled", err)
+	}
+	endpoint, err := svc.GetEndpoint(ctx, org.ID, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.Enabled || endpoint.DisabledReason == nil || endpoint.ConsecutiveFailures != 2 {
+		t.Errorf("endpoint = %+v, want it disabled after 2 failures", endpoint)
+	}
+
+	deliveries, total, err := svc.ListDeliveries(ctx, org.ID, created.ID, 1, 20)
+	if err != nil || total != 1 {
+		t.Fatalf("ListDeliveries = %d, %v", total, err)
+	}
+	delivery, err := svc.GetDelivery(ctx, org.ID, created.ID, deliveries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != types.WebhookDeliveryFailed || delivery.Attempts != 2 || len(delivery.AttemptLog) != 2 {
+		t.Fatalf("delivery = %+v, want 2 failed attempts", delivery)
+	}
+	a := delivery.AttemptLog[0]
+	if a.ResponseStatus == nil || *a.ResponseStatus != http.StatusInternalServerError ||
+		a.ResponseBody == nil || *a.ResponseBody != "receiver says hi" || !strings.Contains(a.RequestBody, `"org.updated"`) {
+		t.Errorf("attempt = %+v", a)
+	}
+
+	if _, err := svc.Redeliver(ctx, org.ID, created.ID, delivery.ID); !errors.Is(err, ErrWebhookDisabled) {
+		t.Errorf("Redeliver to a disabled endpoint: err = %v", err)
+	}
+	enabled := true
+	if _, err := svc.UpdateEndpoint(ctx, org.ID, created.ID, types.UpdateWebhookEndpointParams{Enabled: &enabled}); err != nil {
+		t.Fatal(err)
+	}
+	status.Store(http.StatusNoContent)
+	if _, err := svc.Redeliver(ctx, org.ID, created.ID, delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Deliver(ctx, payloads[0]); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+	delivery, _ = svc.GetDelivery(ctx, org.ID, created.ID, delivery.ID)
+	endpoint, _ = svc.GetEndpoint(ctx, org.ID, created.ID)
+	if delivery.Status != types.WebhookDeliverySucceeded || endpoint.ConsecutiveFailures != 0 || received.Load() != 3 {
+		t.Errorf("after redelivery: delivery = %+v, endpoint = %+v, received = %d", delivery, endpoint, received.Load())
+	}
+}
@@ -0,0 +1,55 @@
+package types
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Join policies for a verified domain.
+const (
+	JoinPolicyOff      = "off"
+	JoinPolicyAutoJoin = "auto_join"
+	JoinPolicyRequest  = "request"
+)
+
+// IsValidJoinPolicy reports whether policy is a known join policy.
+func IsValidJoinPolicy(policy string) bool {
+	return policy == JoinPolicyOff || policy == JoinPolicyAutoJoin || policy == JoinPolicyRequest
+}
+
+// OrgDomain is an email domain claimed by an organization. It takes effect
+// once verified through a DNS TXT challenge.
+type OrgDomain struct {
+	ID                uuid.UUID  `json:"id"`
+	OrganizationID    uuid.UUID  `json:"organizationId"`
+	Domain            string     `json:"domain"`
+	VerificationToken string     `json:"verificationToken"`
+	VerifiedAt        *time.Time `json:"verifiedAt,omitempty"`
+	JoinPolicy        string     `json:"joinPolicy"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// JoinRequest is a request from a user with a matching verified domain to
+// join an org whose join policy is "request".
+type JoinRequest struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	UserID         uuid.UUID  `json:"userId"`
+	Email          string     `json:"email"`
+	FirstName      string     `json:"firstName"`
+	LastName       string     `json:"lastName"`
+	EmailVerified  bool       `json:"emailVerified"`
+	Status         string     `json:"status"`
+	DecidedBy      *uuid.UUID `json:"decidedBy,omitempty"`
+	DecidedAt      *time.Time `json:"decidedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it; tests
+// substitute a fake.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
@@ -6,6 +6,17 @@ import (
 	"github.com/google/uuid"
 )
 
+// Invitation statuses.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationExpired  = "expired"
+	InvitationRevoked  = "revoked"
+)
+
+// InvitationStatuses lists every invitation status.
+var InvitationStatuses = []string{InvitationPending, InvitationAccepted, InvitationExpired, InvitationRevoked}
+
 type Invitation struct {
 	ID             uuid.UUID `json:"id"`
 	OrganizationID uuid.UUID `json:"organizationId"`
@@ -34,3 +45,12 @@ type CreateInvitationParams struct {
 	Role           string
 	ExpiresAt      time.Time
 }
+
+// ListInvitationsParams filters and paginates an org's invitations. An empty
+// Statuses matches every status; Search matches the email case-insensitively.
+type ListInvitationsParams struct {
+	Statuses []string
+	Search   string
+	Page     int
+	PerPage  int
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation import job statuses.
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// Invitation import row statuses. Rows start pending or invalid; the worker
+// settles every pending row as created, skipped or failed.
+const (
+	ImportRowPending        = "pending"
+	ImportRowInvalid        = "invalid"
+	ImportRowCreated        = "created"
+	ImportRowSkippedMember  = "skipped_member"
+	ImportRowSkippedPending = "skipped_pending"
+	ImportRowFailed         = "failed"
+)
+
+// MaxInvitationImportRows bounds the rows in one import.
+const MaxInvitationImportRows = 5000
+
+// InvitationImportInput is one submitted email and role pair. An empty role
+// uses the org's default invite role.
+type InvitationImportInput struct {
+	Line  int    `json:"-"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// InvitationImportRow is one row of an import and its outcome. Line is the
+// row's line in a submitted CSV, or its 1-based position in a JSON list.
+type InvitationImportRow struct {
+	Line         int        `json:"line"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	Status       string     `json:"status"`
+	Message      string     `json:"message,omitempty"`
+	InvitationID *uuid.UUID `json:"invitationId,omitempty"`
+}
+
+// InvitationImportJob is a bulk invitation import.
+type InvitationImportJob struct {
+	ID             uuid.UUID             `json:"id"`
+	OrganizationID uuid.UUID             `json:"organizationId"`
+	CreatedBy      uuid.UUID             `json:"createdBy"`
+	InviterName    string                `json:"-"`
+	OrgName        string                `json:"-"`
+	Status         string                `json:"status"`
+	Rows           []InvitationImportRow `json:"rows"`
+	Error          string                `json:"error,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+	CompletedAt    *time.Time            `json:"completedAt,omitempty"`
+}
+
+// ImportProgress counts an import's rows by outcome.
+type ImportProgress struct {
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Created   int `json:"created"`
+	Skipped   int `json:"skipped"`
+	Invalid   int `json:"invalid"`
+	Failed    int `json:"failed"`
+}
+
+// Progress counts the job's rows by outcome. Every row that is no longer
+// pending counts as processed.
+func (j *InvitationImportJob) Progress() ImportProgress {
+	p := ImportProgress{Total: len(j.Rows)}
+	for _, row := range j.Rows {
+		switch row.Status {
+		case ImportRowPending:
+			continue
+		case ImportRowCreated:
+			p.Created++
+		case ImportRowSkippedMember, ImportRowSkippedPending:
+			p.Skipped++
+		case ImportRowInvalid:
+			p.Invalid++
+		case ImportRowFailed:
+			p.Failed++
+		}
+		p.Processed++
+	}
+	return p
+}
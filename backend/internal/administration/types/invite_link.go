@@ -0,0 +1,61 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invite link limits.
+const (
+	MaxInviteLinkUses         = 10000
+	DefaultInviteLinkTTLHours = 168
+	MaxInviteLinkTTLHours     = 30 * 24
+)
+
+// InviteLink is a shareable link that anyone may use to join an org, up to
+// MaxUses times. AllowedDomain, when set, limits it to verified email
+// addresses at that domain.
+type InviteLink struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	TokenHash      string     `json:"-"`
+	Role           string     `json:"role"`
+	MaxUses        int        `json:"maxUses"`
+	UseCount       int        `json:"useCount"`
+	AllowedDomain  *string    `json:"allowedDomain"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	DisabledAt     *time.Time `json:"disabledAt"`
+	CreatedBy      uuid.UUID  `json:"createdBy"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// Usable reports whether the link can still be used to join at now.
+func (l *InviteLink) Usable(now time.Time) bool {
+	return l.DisabledAt == nil && now.Before(l.ExpiresAt) && l.UseCount < l.MaxUses
+}
+
+// InviteLinkWithOrg is a join with the org name for display.
+type InviteLinkWithOrg struct {
+	InviteLink
+	OrganizationName string `json:"organizationName"`
+}
+
+// InviteLinkUse records a user who joined through an invite link.
+type InviteLinkUse struct {
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+type CreateInviteLinkParams struct {
+	OrganizationID uuid.UUID
+	CreatedBy      uuid.UUID
+	TokenHash      string
+	Role           string
+	MaxUses        int
+	AllowedDomain  *string
+	ExpiresAt      time.Time
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxIPAllowlistEntries bounds the size of an org's IP allowlist.
+const MaxIPAllowlistEntries = 100
+
+// IPAllowlistEntry is an address range allowed to access an org. An org with
+// no entries allows every address.
+type IPAllowlistEntry struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organizationId"`
+	CIDR           netip.Prefix `json:"cidr"`
+	Description    string       `json:"description"`
+	CreatedBy      *uuid.UUID   `json:"createdBy,omitempty"`
+	CreatedAt      time.Time    `json:"createdAt"`
+}
+
+// IPAllowlistEntryParams describes an entry in a replacement allowlist.
+type IPAllowlistEntryParams struct {
+	CIDR        netip.Prefix
+	Description string
+}
+
+// IPAllowlistInput is an unparsed allowlist entry as submitted by a client.
+// CIDR may also be a bare IP address.
+type IPAllowlistInput struct {
+	CIDR        string `json:"cidr"`
+	Description string `json:"description"`
+}
@@ -10,6 +10,7 @@ type OrgMembership struct {
 	ID             uuid.UUID `json:"id"`
 	UserID         uuid.UUID `json:"userId"`
 	OrganizationID uuid.UUID `json:"organizationId"`
+	RoleID         uuid.UUID `json:"roleId"`
 	Role           string    `json:"role"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFAComplianceReport lists the members of an org who have not set up
+// multi-factor authentication, and when each must have done so.
+type MFAComplianceReport struct {
+	RequireMFA          bool                  `json:"requireMfa"`
+	RequiredSince       *time.Time            `json:"requiredSince"`
+	GracePeriodDays     int                   `json:"gracePeriodDays"`
+	NonCompliantMembers []*NonCompliantMember `json:"nonCompliantMembers"`
+}
+
+// NonCompliantMember is a member without a confirmed second factor. Deadline
+// is nil while the org does not require MFA.
+type NonCompliantMember struct {
+	UserID    uuid.UUID  `json:"userId"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Role      string     `json:"role"`
+	JoinedAt  time.Time  `json:"joinedAt"`
+	Deadline  *time.Time `json:"deadline"`
+	Overdue   bool       `json:"overdue"`
+}
@@ -0,0 +1,139 @@
+package types
+
+import "sort"
+
+// Permission keys. Built-in and custom roles are expressed as sets of these.
+const (
+	PermOrgUpdate         = "org.update"
+	PermOrgDelete         = "org.delete"
+	PermMembersUpdate     = "members.update"
+	PermMembersRemove     = "members.remove"
+	PermInvitationsCreate = "invitations.create"
+	PermRolesManage       = "roles.manage"
+	PermTeamsManage       = "teams.manage"
+	PermDomainsManage     = "domains.manage"
+	PermSCIMManage        = "scim.manage"
+	PermIPAllowlistManage = "ip_allowlist.manage"
+	PermAuditRead         = "audit.read"
+	PermWebhooksManage    = "webhooks.manage"
+	PermAgentsRead        = "agents.read"
+	PermAgentsManage      = "agents.manage"
+	PermAgentsDeploy      = "agents.deploy"
+	PermDeploymentsRead   = "deployments.read"
+	PermDeploymentsManage = "deployments.manage"
+	PermSecretsRead       = "secrets.read"
+	PermSecretsManage     = "secrets.manage"
+)
+
+// Permission describes an entry in the permission catalogue.
+type Permission struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalogue lists every permission that can be granted.
+var PermissionCatalogue = []Permission{
+	{PermOrgUpdate, "Update organization details"},
+	{PermOrgDelete, "Delete and restore the organization"},
+	{PermMembersUpdate, "Change member roles and transfer ownership"},
+	{PermMembersRemove, "Remove members from the organization"},
+	{PermInvitationsCreate, "Invite new members and approve join requests"},
+	{PermRolesManage, "Create, edit and delete custom roles and permission grants"},
+	{PermTeamsManage, "Create, edit and delete any team and manage its members"},
+	{PermDomainsManage, "Verify email domains and set their join policy"},
+	{PermSCIMManage, "Manage SCIM provisioning tokens"},
+	{PermIPAllowlistManage, "Restrict the IP addresses that can access the organization"},
+	{PermAuditRead, "View the organization's audit log"},
+	{PermWebhooksManage, "Manage outbound webhooks and view their deliveries"},
+	{PermAgentsRead, "View agents"},
+	{PermAgentsManage, "Create, edit and delete agents"},
+	{PermAgentsDeploy, "Deploy agents"},
+	{PermDeploymentsRead, "View deployments"},
+	{PermDeploymentsManage, "Manage and roll back deployments"},
+	{PermSecretsRead, "View secret names and metadata"},
+	{PermSecretsManage, "Create, rotate and delete secrets"},
+}
+
+// Built-in role keys. Their permissions are defined here rather than in the
+// database so new catalogue entries reach existing orgs without a migration.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+var builtinRolePermissions = map[string][]string{
+	RoleUser: {PermAgentsRead, PermAgentsDeploy, PermDeploymentsRead},
+}
+
+// IsBuiltinRole reports whether key names a built-in role.
+func IsBuiltinRole(key string) bool {
+	return key == RoleAdmin || key == RoleUser
+}
+
+// BuiltinRolePermissions returns the permissions of a built-in role. The admin
+// role holds every permission in the catalogue.
+func BuiltinRolePermissions(key string) []string {
+	if key == RoleAdmin {
+		return AllPermissions()
+	}
+	return builtinRolePermissions[key]
+}
+
+// AllPermissions returns every permission key in the catalogue.
+func AllPermissions() []string {
+	keys := make([]string, len(PermissionCatalogue))
+	for i, p := range PermissionCatalogue {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+// IsValidPermission reports whether key is in the catalogue.
+func IsValidPermission(key string) bool {
+	for _, p := range PermissionCatalogue {
+		if p.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is a set of permission keys.
+type PermissionSet map[string]struct{}
+
+func NewPermissionSet(keys ...string) PermissionSet {
+	s := make(PermissionSet, len(keys))
+	s.Add(keys...)
+	return s
+}
+
+func (s PermissionSet) Add(keys ...string) {
+	for _, k := range keys {
+		s[k] = struct{}{}
+	}
+}
+
+func (s PermissionSet) Has(key string) bool {
+	_, ok := s[key]
+	return ok
+}
+
+// HasAll reports whether s contains every key.
+func (s PermissionSet) HasAll(keys []string) bool {
+	for _, k := range keys {
+		if !s.Has(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns the permissions in s, sorted.
+func (s PermissionSet) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,8 +2,11 @@ package types
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/mailer"
 	"github.com/google/uuid"
 )
 
@@ -12,18 +15,89 @@ type OrganizationRepository interface {
 	Create(ctx context.Context, db database.DBTX, params CreateOrgParams) (*Organization, error)
 	GetByID(ctx context.Context, db database.DBTX, id uuid.UUID) (*Organization, error)
 	GetBySlug(ctx context.Context, db database.DBTX, slug string) (*Organization, error)
+	GetByHistoricalSlug(ctx context.Context, db database.DBTX, slug string) (*Organization, error)
+	LockSlug(ctx context.Context, db database.DBTX, slug string) error
+	SlugInUse(ctx context.Context, db database.DBTX, slug string, excludeOrgID uuid.UUID) (bool, error)
+	AddSlugHistory(ctx context.Context, db database.DBTX, orgID uuid.UUID, slug string) error
+	DeleteSlugHistory(ctx context.Context, db database.DBTX, orgID uuid.UUID, slug string) error
 	Update(ctx context.Context, db database.DBTX, id uuid.UUID, params UpdateOrgParams) (*Organization, error)
 	ListAll(ctx context.Context, db database.DBTX) ([]*Organization, error)
+	SoftDelete(ctx context.Context, db database.DBTX, id uuid.UUID) (*Organization, error)
+	Restore(ctx context.Context, db database.DBTX, id uuid.UUID, deletedAfter time.Time) (*Organization, error)
+	PurgeDeleted(ctx context.Context, db database.DBTX, deletedBefore time.Time) (int64, error)
 }
 
 // MembershipRepository defines org membership data access methods.
 type MembershipRepository interface {
 	Create(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID, role string) (*OrgMembership, error)
 	GetByUserAndOrg(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) (*OrgMembership, error)
+	GetByUserAndOrgForUpdate(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) (*OrgMembership, error)
 	ListByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) ([]*OrgWithRole, error)
 	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*MemberWithUser, error)
+	// ListWithoutMFA lists the org's members who have no confirmed TOTP
+	// factor.
+	ListWithoutMFA(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*MemberWithUser, error)
+	UpdateRole(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID, role string) (*OrgMembership, error)
 	Delete(ctx context.Context, db database.DBTX, userID, orgID uuid.UUID) error
 	CountAdmins(ctx context.Context, db database.DBTX, orgID uuid.UUID) (int, error)
+	LockAdmins(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// RoleRepository defines org role data access methods. Lookups are scoped to
+// an org and include the built-in roles.
+type RoleRepository interface {
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*Role, error)
+	GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*Role, error)
+	GetByKey(ctx context.Context, db database.DBTX, orgID uuid.UUID, key string) (*Role, error)
+	Create(ctx context.Context, db database.DBTX, params CreateRoleParams) (*Role, error)
+	Update(ctx context.Context, db database.DBTX, id uuid.UUID, params UpdateRoleParams) (*Role, error)
+	Delete(ctx context.Context, db database.DBTX, id uuid.UUID) error
+}
+
+// TeamRepository defines team and team membership data access methods.
+type TeamRepository interface {
+	Create(ctx context.Context, db database.DBTX, params CreateTeamParams) (*Team, error)
+	GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*Team, error)
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*Team, error)
+	Update(ctx context.Context, db database.DBTX, orgID, id uuid.UUID, params UpdateTeamParams) (*Team, error)
+	Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) error
+	AddMember(ctx context.Context, db database.DBTX, teamID, userID, orgID uuid.UUID, role string) (*TeamMembership, error)
+	GetMember(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) (*TeamMembership, error)
+	GetMemberForUpdate(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) (*TeamMembership, error)
+	ListMembers(ctx context.Context, db database.DBTX, teamID uuid.UUID) ([]*TeamMemberWithUser, error)
+	ListByUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) ([]*TeamWithRole, error)
+	UpdateMemberRole(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID, role string) (*TeamMembership, error)
+	RemoveMember(ctx context.Context, db database.DBTX, teamID, userID uuid.UUID) error
+	LockMaintainers(ctx context.Context, db database.DBTX, teamID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// PermissionGrantRepository defines team and individual permission grant
+// data access methods.
+type PermissionGrantRepository interface {
+	Create(ctx context.Context, db database.DBTX, params CreateGrantParams) (*PermissionGrant, error)
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*PermissionGrant, error)
+	// Delete removes a grant and returns it, or nil if there is none.
+	Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*PermissionGrant, error)
+	ListPermissionsForUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) ([]string, error)
+}
+
+// DomainRepository defines org domain data access methods.
+type DomainRepository interface {
+	Create(ctx context.Context, db database.DBTX, orgID uuid.UUID, domain, verificationToken string) (*OrgDomain, error)
+	GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*OrgDomain, error)
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*OrgDomain, error)
+	GetVerifiedByDomain(ctx context.Context, db database.DBTX, domain string) (*OrgDomain, error)
+	MarkVerified(ctx context.Context, db database.DBTX, id uuid.UUID) (*OrgDomain, error)
+	UpdateJoinPolicy(ctx context.Context, db database.DBTX, orgID, id uuid.UUID, policy string) (*OrgDomain, error)
+	Delete(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error)
+}
+
+// JoinRequestRepository defines org join request data access methods.
+type JoinRequestRepository interface {
+	CreatePending(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) error
+	ListPendingByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*JoinRequest, error)
+	GetByIDForUpdate(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*JoinRequest, error)
+	Decide(ctx context.Context, db database.DBTX, id uuid.UUID, status string, decidedBy uuid.UUID) (*JoinRequest, error)
 }
 
 // InvitationRepository defines invitation data access methods.
@@ -31,10 +105,118 @@ type InvitationRepository interface {
 	Create(ctx context.Context, db database.DBTX, params CreateInvitationParams) (*Invitation, error)
 	GetByTokenHash(ctx context.Context, db database.DBTX, hash string) (*InvitationWithOrg, error)
 	GetPendingByEmailAndOrg(ctx context.Context, db database.DBTX, email string, orgID uuid.UUID) (*Invitation, error)
+	// List returns a page of an org's invitations, newest first, and the
+	// total number matching params.
+	List(ctx context.Context, db database.DBTX, orgID uuid.UUID, params ListInvitationsParams) ([]*InvitationWithOrg, int, error)
+	GetByIDForUpdate(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*Invitation, error)
+	// Reissue replaces an invitation's token and expiry and makes it pending
+	// again.
+	Reissue(ctx context.Context, db database.DBTX, id uuid.UUID, tokenHash string, expiresAt time.Time) (*Invitation, error)
+	// ExpirePending marks pending invitations that expired before now as
+	// expired and returns how many it changed.
+	ExpirePending(ctx context.Context, db database.DBTX, now time.Time) (int64, error)
 	UpdateStatus(ctx context.Context, db database.DBTX, id uuid.UUID, status string) error
+	RevokePendingByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) (int64, error)
 }
 
-// EmailService defines the interface for sending emails.
+// InviteLinkRepository defines invite link data access methods.
+type InviteLinkRepository interface {
+	Create(ctx context.Context, db database.DBTX, params CreateInviteLinkParams) (*InviteLink, error)
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*InviteLink, error)
+	GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*InviteLink, error)
+	// GetByTokenHash returns the link with the token hash, or nil if there is
+	// none or its org is deleted.
+	GetByTokenHash(ctx context.Context, db database.DBTX, hash string) (*InviteLinkWithOrg, error)
+	Disable(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*InviteLink, error)
+	// Consume takes one use of a usable link and reports whether it could.
+	Consume(ctx context.Context, db database.DBTX, id uuid.UUID) (bool, error)
+	RecordUse(ctx context.Context, db database.DBTX, linkID, userID uuid.UUID) error
+	ListUses(ctx context.Context, db database.DBTX, linkID uuid.UUID) ([]*InviteLinkUse, error)
+}
+
+// InvitationImportRepository defines bulk invitation import job data access
+// methods.
+type InvitationImportRepository interface {
+	Create(ctx context.Context, db database.DBTX, orgID, createdBy uuid.UUID, inviterName, orgName string, rows []InvitationImportRow) (*InvitationImportJob, error)
+	GetByID(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*InvitationImportJob, error)
+	// ClaimNext marks the oldest pending job, or a running job not updated
+	// since staleBefore, as running and returns it. It returns nil if there
+	// is none.
+	ClaimNext(ctx context.Context, db database.DBTX, staleBefore time.Time) (*InvitationImportJob, error)
+	UpdateRows(ctx context.Context, db database.DBTX, id uuid.UUID, rows []InvitationImportRow) error
+	Finish(ctx context.Context, db database.DBTX, id uuid.UUID, status, errMsg string) error
+}
+
+// EmailService sends templated emails. The email is queued through db and
+// only sent once that transaction commits.
 type EmailService interface {
-	SendInvitation(ctx context.Context, to, inviterName, orgName, inviteURL string) error
+	Send(ctx context.Context, db database.DBTX, email mailer.Email) error
+}
+
+// SCIMTokenRepository defines SCIM bearer token data access methods.
+type SCIMTokenRepository interface {
+	Create(ctx context.Context, db database.DBTX, orgID uuid.UUID, tokenHash, description string, createdBy uuid.UUID) (*SCIMToken, error)
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*SCIMToken, error)
+	Revoke(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error)
+	GetActiveByHash(ctx context.Context, db database.DBTX, hash string) (*SCIMToken, error)
+	TouchLastUsed(ctx context.Context, db database.DBTX, id uuid.UUID) error
+}
+
+// SCIMRepository defines the read models and provisioning links behind the
+// SCIM Users and Groups resources.
+type SCIMRepository interface {
+	ListUsers(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*SCIMUser, error)
+	ListUsersPage(ctx context.Context, db database.DBTX, orgID uuid.UUID, params ListSCIMUsersParams) ([]*SCIMUser, int, error)
+	GetUser(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) (*SCIMUser, error)
+	UpsertUserLink(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID, params SCIMUserLinkParams) error
+	DeleteUserLink(ctx context.Context, db database.DBTX, orgID, userID uuid.UUID) error
+	ListGroups(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*SCIMGroup, error)
+	GetGroup(ctx context.Context, db database.DBTX, orgID, teamID uuid.UUID) (*SCIMGroup, error)
+	SetGroupExternalID(ctx context.Context, db database.DBTX, orgID, teamID uuid.UUID, externalID string) error
+}
+
+// OrgSettingsRepository defines org settings data access methods.
+type OrgSettingsRepository interface {
+	Get(ctx context.Context, db database.DBTX, orgID uuid.UUID) (*OrgSettingsRecord, error)
+	GetForUpdate(ctx context.Context, db database.DBTX, orgID uuid.UUID) (*OrgSettingsRecord, error)
+	Save(ctx context.Context, db database.DBTX, orgID uuid.UUID, values map[string]json.RawMessage, updatedBy uuid.UUID) (*OrgSettingsRecord, error)
+	ListVersions(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*OrgSettingsRecord, error)
+	MinMaxSessionHoursForUser(ctx context.Context, db database.DBTX, userID uuid.UUID) (*int, error)
+}
+
+// IPAllowlistRepository defines org IP allowlist data access methods.
+type IPAllowlistRepository interface {
+	ListByOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*IPAllowlistEntry, error)
+	// Replace swaps the org's allowlist for entries.
+	Replace(ctx context.Context, db database.DBTX, orgID, actorID uuid.UUID, entries []IPAllowlistEntryParams) ([]*IPAllowlistEntry, error)
+}
+
+// WebhookRepository defines webhook endpoint and delivery data access
+// methods.
+type WebhookRepository interface {
+	// LockOrg locks the org's row for the rest of the transaction, so
+	// endpoint counts can't change under the caller.
+	LockOrg(ctx context.Context, db database.DBTX, orgID uuid.UUID) error
+	CreateEndpoint(ctx context.Context, db database.DBTX, orgID, actorID uuid.UUID, params CreateWebhookEndpointParams, secret string) (*WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (*WebhookEndpoint, error)
+	// GetEndpointForUpdate locks the endpoint for the rest of the
+	// transaction.
+	GetEndpointForUpdate(ctx context.Context, db database.DBTX, id uuid.UUID) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, db database.DBTX, orgID uuid.UUID) ([]*WebhookEndpoint, error)
+	// ListSubscribed lists the org's enabled endpoints that receive
+	// eventType.
+	ListSubscribed(ctx context.Context, db database.DBTX, orgID uuid.UUID, eventType string) ([]*WebhookEndpoint, error)
+	// UpdateEndpoint saves the endpoint's settings and failure state.
+	UpdateEndpoint(ctx context.Context, db database.DBTX, e *WebhookEndpoint) (*WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, db database.DBTX, orgID, id uuid.UUID) (bool, error)
+
+	CreateDelivery(ctx context.Context, db database.DBTX, endpointID, eventID uuid.UUID, eventType string, payload json.RawMessage) (*WebhookDelivery, error)
+	GetDelivery(ctx context.Context, db database.DBTX, id uuid.UUID) (*WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, db database.DBTX, endpointID uuid.UUID, limit, offset int) ([]*WebhookDelivery, int, error)
+	SetDeliveryStatus(ctx context.Context, db database.DBTX, id uuid.UUID, status string) error
+	// RecordAttempt logs an attempt and updates its delivery's status and
+	// attempt count.
+	RecordAttempt(ctx context.Context, db database.DBTX, a *WebhookDeliveryAttempt, status string) error
+	ListAttempts(ctx context.Context, db database.DBTX, deliveryID uuid.UUID) ([]*WebhookDeliveryAttempt, error)
+	PurgeDeliveries(ctx context.Context, db database.DBTX, before time.Time) (int64, error)
 }
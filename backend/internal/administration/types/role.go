@@ -0,0 +1,48 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is either a built-in role (OrganizationID nil) or a custom role defined
+// by an organization.
+type Role struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
+	Key            string     `json:"key"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	Permissions    []string   `json:"permissions"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// IsBuiltin reports whether the role is shared by all organizations.
+func (r *Role) IsBuiltin() bool {
+	return r.OrganizationID == nil
+}
+
+// EffectivePermissions returns the role's permissions, resolving built-in
+// roles from the catalogue.
+func (r *Role) EffectivePermissions() []string {
+	if r.IsBuiltin() {
+		return BuiltinRolePermissions(r.Key)
+	}
+	return r.Permissions
+}
+
+type CreateRoleParams struct {
+	OrganizationID uuid.UUID
+	Key            string
+	Name           string
+	Description    string
+	Permissions    []string
+}
+
+type UpdateRoleParams struct {
+	Name        string
+	Description string
+	Permissions []string
+}
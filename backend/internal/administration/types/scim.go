@@ -0,0 +1,76 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SCIMToken is an org-scoped bearer credential for the SCIM provisioning
+// API. Only the token's hash is stored.
+type SCIMToken struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	Description    string     `json:"description"`
+	CreatedBy      *uuid.UUID `json:"createdBy,omitempty"`
+	LastUsedAt     *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt      *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// SCIMUser is a user as seen by an org's identity provider: the global user
+// row, the org membership (Active) and the provisioning link (ExternalID).
+// Provisioned reports whether the provisioning link exists.
+type SCIMUser struct {
+	ID          uuid.UUID
+	UserName    string
+	GivenName   string
+	FamilyName  string
+	ExternalID  string
+	Active      bool
+	Provisioned bool
+	Role        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ListSCIMUsersParams narrows and paginates an org's SCIM users. A non-empty
+// UserName matches that email case-insensitively; a Limit of zero returns
+// only the total.
+type ListSCIMUsersParams struct {
+	UserName string
+	Offset   int
+	Limit    int
+}
+
+// SCIMUserLinkParams are the org-scoped attributes of a provisioning link.
+// Empty names fall back to the account's own.
+type SCIMUserLinkParams struct {
+	ExternalID string
+	GivenName  string
+	FamilyName string
+}
+
+// SCIMGroup is a team as seen by an org's identity provider.
+type SCIMGroup struct {
+	ID          uuid.UUID
+	DisplayName string
+	ExternalID  string
+	Members     []SCIMGroupMember
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type SCIMGroupMember struct {
+	UserID  uuid.UUID
+	Display string
+}
+
+// SCIMPatchOp is a single operation of a SCIM PATCH request (RFC 7644
+// section 3.5.2).
+type SCIMPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"agenteur.ai/api/internal/mailer"
+
+	"github.com/google/uuid"
+)
+
+// Org setting keys.
+const (
+	SettingDefaultInviteRole    = "defaultInviteRole"
+	SettingInviteTTLHours       = "inviteTtlHours"
+	SettingAllowedInviteDomains = "allowedInviteDomains"
+	SettingRequireMFA           = "requireMfa"
+	SettingMFAGracePeriodDays   = "mfaGracePeriodDays"
+	SettingMaxSessionHours      = "maxSessionHours"
+	SettingEmailLocale          = "emailLocale"
+	SettingBrandColor           = "brandColor"
+	SettingBrandLogoURL         = "brandLogoUrl"
+)
+
+// SettingType is the JSON type of a setting's value.
+type SettingType string
+
+const (
+	SettingTypeString     SettingType = "string"
+	SettingTypeInteger    SettingType = "integer"
+	SettingTypeBoolean    SettingType = "boolean"
+	SettingTypeStringList SettingType = "string[]"
+)
+
+// SettingDefinition describes one org setting. For integers Min and Max
+// bound the value; for lists Max bounds the number of entries. Enum, when
+// set, lists the values a string may take.
+type SettingDefinition struct {
+	Key         string      `json:"key"`
+	Type        SettingType `json:"type"`
+	Description string      `json:"description"`
+	Default     any         `json:"default"`
+	Min         int         `json:"min,omitempty"`
+	Max         int         `json:"max,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+}
+
+// OrgSettingsSchema defines every org setting. Settings documents are
+// validated against it, and it is served to clients so they can render
+// settings forms.
+var OrgSettingsSchema = []SettingDefinition{
+	{Key: SettingDefaultInviteRole, Type: SettingTypeString, Default: RoleUser,
+		Description: "Role given to invitations that don't specify one"},
+	{Key: SettingInviteTTLHours, Type: SettingTypeInteger, Default: nil, Min: 1, Max: 720,
+		Description: "Hours before an invitation expires; unset uses the platform default"},
+	{Key: SettingAllowedInviteDomains, Type: SettingTypeStringList, Default: []string{}, Max: 50,
+		Description: "Email domains invitations may be sent to; empty allows any domain"},
+	{Key: SettingRequireMFA, Type: SettingTypeBoolean, Default: false,
+		Description: "Require members to use multi-factor authentication"},
+	{Key: SettingMFAGracePeriodDays, Type: SettingTypeInteger, Default: 0, Min: 0, Max: 90,
+		Description: "Days members may keep signing in without MFA after it becomes required or after they join"},
+	{Key: SettingMaxSessionHours, Type: SettingTypeInteger, Default: nil, Min: 1, Max: 8760,
+		Description: "Maximum hours a member's session lasts before they must sign in again; unset means no cap"},
+	{Key: SettingEmailLocale, Type: SettingTypeString, Default: mailer.DefaultLocale, Enum: mailer.Locales,
+		Description: "Language of emails sent on the organization's behalf"},
+	{Key: SettingBrandColor, Type: SettingTypeString, Default: nil,
+		Description: "Accent color of the organization's emails, as #rrggbb; unset uses the product color"},
+	{Key: SettingBrandLogoURL, Type: SettingTypeString, Default: nil,
+		Description: "HTTPS URL of a logo shown at the top of the organization's emails; unset shows its name"},
+}
+
+// LookupSetting returns the definition of a setting key.
+func LookupSetting(key string) (SettingDefinition, bool) {
+	for _, def := range OrgSettingsSchema {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return SettingDefinition{}, false
+}
+
+// OrgSettings are an org's effective settings: its stored values over the
+// schema defaults. Its JSON field names are the setting keys.
+type OrgSettings struct {
+	DefaultInviteRole    string   `json:"defaultInviteRole"`
+	InviteTTLHours       *int     `json:"inviteTtlHours"`
+	AllowedInviteDomains []string `json:"allowedInviteDomains"`
+	RequireMFA           bool     `json:"requireMfa"`
+	MFAGracePeriodDays   int      `json:"mfaGracePeriodDays"`
+	MaxSessionHours      *int     `json:"maxSessionHours"`
+	EmailLocale          string   `json:"emailLocale"`
+	BrandColor           *string  `json:"brandColor"`
+	BrandLogoURL         *string  `json:"brandLogoUrl"`
+}
+
+// DefaultOrgSettings returns the settings of an org that has set nothing.
+func DefaultOrgSettings() OrgSettings {
+	return OrgSettings{
+		DefaultInviteRole:    RoleUser,
+		AllowedInviteDomains: []string{},
+		EmailLocale:          mailer.DefaultLocale,
+	}
+}
+
+// EmailBranding returns the branding for emails sent on behalf of the org.
+func (s OrgSettings) EmailBranding(orgName string) mailer.Branding {
+	b := mailer.Branding{Name: orgName}
+	if s.BrandColor != nil {
+		b.PrimaryColor = *s.BrandColor
+	}
+	if s.BrandLogoURL != nil {
+		b.LogoURL = *s.BrandLogoURL
+	}
+	return b
+}
+
+// OrgSettingsRecord is a stored settings document. Values holds only the
+// settings the org has set explicitly. MFARequiredAt is when requireMfa was
+// last switched on, and is nil while it is off; it is not kept in history.
+type OrgSettingsRecord struct {
+	OrganizationID uuid.UUID                  `json:"organizationId"`
+	Version        int                        `json:"version"`
+	Values         map[string]json.RawMessage `json:"values"`
+	UpdatedBy      *uuid.UUID                 `json:"updatedBy,omitempty"`
+	UpdatedAt      time.Time                  `json:"updatedAt"`
+	MFARequiredAt  *time.Time                 `json:"mfaRequiredAt,omitempty"`
+}
+
+// VersionedOrgSettings are an org's effective settings with the version of
+// the document they were read from.
+type VersionedOrgSettings struct {
+	Version   int
+	Settings  OrgSettings
+	UpdatedBy *uuid.UUID
+	UpdatedAt *time.Time
+}
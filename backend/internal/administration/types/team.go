@@ -0,0 +1,85 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team roles. Maintainers manage their team's details and membership.
+const (
+	TeamRoleMaintainer = "maintainer"
+	TeamRoleMember     = "member"
+)
+
+// IsValidTeamRole reports whether role is a team role.
+func IsValidTeamRole(role string) bool {
+	return role == TeamRoleMaintainer || role == TeamRoleMember
+}
+
+type Team struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	MemberCount    int       `json:"memberCount"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type CreateTeamParams struct {
+	OrganizationID uuid.UUID
+	Name           string
+	Description    string
+}
+
+type UpdateTeamParams struct {
+	Name        string
+	Description string
+}
+
+type TeamMembership struct {
+	TeamID         uuid.UUID `json:"teamId"`
+	UserID         uuid.UUID `json:"userId"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// TeamMemberWithUser is a join of team_memberships + users for member listing.
+type TeamMemberWithUser struct {
+	UserID    uuid.UUID `json:"userId"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+// TeamWithRole is a join of teams + team_memberships for a user's team listing.
+type TeamWithRole struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joinedAt"`
+}
+
+// PermissionGrant grants a permission to a team or to an individual member,
+// on top of their org role. Exactly one of TeamID and UserID is set.
+type PermissionGrant struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organizationId"`
+	TeamID         *uuid.UUID `json:"teamId,omitempty"`
+	UserID         *uuid.UUID `json:"userId,omitempty"`
+	Permission     string     `json:"permission"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type CreateGrantParams struct {
+	OrganizationID uuid.UUID
+	TeamID         *uuid.UUID
+	UserID         *uuid.UUID
+	Permission     string
+}
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types.
+const (
+	WebhookMemberJoined       = "member.joined"
+	WebhookMemberRemoved      = "member.removed"
+	WebhookInvitationAccepted = "invitation.accepted"
+	WebhookOrgUpdated         = "org.updated"
+)
+
+// WebhookEventTypes lists every event type an endpoint can subscribe to.
+var WebhookEventTypes = []string{
+	WebhookMemberJoined,
+	WebhookMemberRemoved,
+	WebhookInvitationAccepted,
+	WebhookOrgUpdated,
+}
+
+// IsValidWebhookEventType reports whether t is in WebhookEventTypes.
+func IsValidWebhookEventType(t string) bool {
+	for _, et := range WebhookEventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookMemberData is the data of member.joined and member.removed events.
+// Via says how the member joined or left: "invitation", "invite_link",
+// "domain", "join_request", "scim", "removed" or "left".
+type WebhookMemberData struct {
+	UserID uuid.UUID `json:"userId"`
+	Role   string    `json:"role"`
+	Via    string    `json:"via"`
+}
+
+// WebhookInvitationData is the data of invitation.accepted events.
+type WebhookInvitationData struct {
+	InvitationID uuid.UUID `json:"invitationId"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	UserID       uuid.UUID `json:"userId"`
+}
+
+// WebhookOrgData is the data of org.updated events.
+type WebhookOrgData struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
+}
+
+// Webhook delivery statuses. A failed delivery is retried with backoff until
+// it succeeds or runs out of attempts.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEndpoint is a URL an org's events are POSTed to. An endpoint with
+// no event types receives every event.
+type WebhookEndpoint struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organizationId"`
+	URL            string    `json:"url"`
+	Description    string    `json:"description"`
+	EventTypes     []string  `json:"eventTypes"`
+	// Secret signs deliveries. It is only shown when the endpoint is
+	// created.
+	Secret  string `json:"-"`
+	Enabled bool   `json:"enabled"`
+	// ConsecutiveFailures counts failed attempts since the last success;
+	// the endpoint is disabled when it reaches the configured limit.
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledReason      *string    `json:"disabledReason"`
+	DisabledAt          *time.Time `json:"disabledAt"`
+	CreatedBy           *uuid.UUID `json:"createdBy,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+}
+
+// Subscribes reports whether the endpoint receives events of eventType.
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookEndpointParams describes a new endpoint.
+type CreateWebhookEndpointParams struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"eventTypes"`
+}
+
+// UpdateWebhookEndpointParams changes an endpoint. Nil fields are left
+// unchanged. Enabling a disabled endpoint clears its failure count.
+type UpdateWebhookEndpointParams struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	EventTypes  *[]string `json:"eventTypes"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// WebhookDelivery is one event sent to one endpoint. EventID is shared by
+// the deliveries of the same event to different endpoints, so receivers can
+// deduplicate on it.
+type WebhookDelivery struct {
+	ID            uuid.UUID       `json:"id"`
+	EndpointID    uuid.UUID       `json:"endpointId"`
+	EventID       uuid.UUID       `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastAttemptAt *time.Time      `json:"lastAttemptAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	// AttemptLog is only loaded for a single delivery.
+	AttemptLog []*WebhookDeliveryAttempt `json:"attemptLog,omitempty"`
+}
+
+// WebhookDeliveryAttempt records one HTTP request made for a delivery and
+// the response, or the error if there was none.
+type WebhookDeliveryAttempt struct {
+	ID              uuid.UUID         `json:"id"`
+	DeliveryID      uuid.UUID         `json:"deliveryId"`
+	RequestHeaders  map[string]string `json:"requestHeaders"`
+	RequestBody     string            `json:"requestBody"`
+	ResponseStatus  *int              `json:"responseStatus"`
+	ResponseHeaders map[string]string `json:"responseHeaders"`
+	ResponseBody    *string           `json:"responseBody"`
+	Error           *string           `json:"error"`
+	DurationMs      int               `json:"durationMs"`
+	CreatedAt       time.Time         `json:"createdAt"`
+}
+
+// Succeeded reports whether the attempt got a 2xx response.
+func (a *WebhookDeliveryAttempt) Succeeded() bool {
+	return a.ResponseStatus != nil && *a.ResponseStatus >= 200 && *a.ResponseStatus < 300
+}
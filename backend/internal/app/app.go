@@ -2,19 +2,29 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	adminhandlers "agenteur.ai/api/internal/administration/handlers"
 	adminservices "agenteur.ai/api/internal/administration/services"
+	admintypes "agenteur.ai/api/internal/administration/types"
+	"agenteur.ai/api/internal/audit"
 	authhandlers "agenteur.ai/api/internal/auth/handlers"
 	authservices "agenteur.ai/api/internal/auth/services"
 	"agenteur.ai/api/internal/config"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/jobs"
+	"agenteur.ai/api/internal/mailer"
 	"agenteur.ai/api/internal/middleware"
+	"agenteur.ai/api/internal/outbox"
+	"agenteur.ai/api/internal/scheduler"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -24,6 +34,28 @@ type App struct {
 	Config *config.Config
 	Server *http.Server
 	DB     *pgxpool.Pool
+	Logger *slog.Logger
+
+	// schedules and jobQueue's workers run while the server is up and are
+	// drained on shutdown.
+	schedules *scheduler.Scheduler
+	jobQueue  *jobs.Queue
+	// eventBus's after-commit handlers are drained on shutdown.
+	eventBus *events.Bus
+	// mailTransport is closed on shutdown.
+	mailTransport mailer.Transport
+}
+
+// Background job kinds.
+const (
+	jobExpireInvitations  = "expire-invitations"
+	jobPurgeRefreshTokens = "purge-refresh-tokens"
+)
+
+// every returns a schedule spec firing at a fixed interval. Schedules fire at
+// most once a second, so shorter intervals are rounded up.
+func every(d time.Duration) string {
+	return "@every " + max(d, time.Second).Round(time.Second).String()
 }
 
 func NewApp() *App {
@@ -43,46 +75,317 @@ func NewApp() *App {
 		"env", cfg.Env,
 	)
 
-	// Auth domain
+	// Administration repositories, the domain join policy and org settings are
+	// built first: the auth service applies the join policy on signup and
+	// verification, and caps sessions by org settings.
 	userRepo := authservices.NewUserRepository()
+	orgRepo := adminservices.NewOrganizationRepository()
+	membershipRepo := adminservices.NewMembershipRepository()
+	invitationRepo := adminservices.NewInvitationRepository()
+	invitationImportRepo := adminservices.NewInvitationImportRepository()
+	inviteLinkRepo := adminservices.NewInviteLinkRepository()
+	roleRepo := adminservices.NewRoleRepository()
+	teamRepo := adminservices.NewTeamRepository()
+	grantRepo := adminservices.NewPermissionGrantRepository()
+	domainRepo := adminservices.NewDomainRepository()
+	joinRequestRepo := adminservices.NewJoinRequestRepository()
+	scimTokenRepo := adminservices.NewSCIMTokenRepository()
+	scimRepo := adminservices.NewSCIMRepository()
+	settingsRepo := adminservices.NewOrgSettingsRepository()
+	ipAllowlistRepo := adminservices.NewIPAllowlistRepository()
+	webhookRepo := adminservices.NewWebhookRepository()
+	auditLog := audit.New(pool)
+	if cfg.AuditSigningKey != "" {
+		key, err := audit.ParseSigningKey(cfg.AuditSigningKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		auditLog.SetSigningKey(key)
+	}
+	mailTransport, err := newMailTransport(cfg)
+	if err != nil {
+		log.Fatal("email setup failed:", err)
+	}
+	auditSinks, err := newAuditSinks(cfg)
+	if err != nil {
+		log.Fatal("audit sink setup failed:", err)
+	}
+	emailTemplates, err := mailer.LoadTemplates()
+	if err != nil {
+		log.Fatal("load email templates:", err)
+	}
+	// Emails are queued in the outbox with the change that causes them and
+	// delivered by the dispatch-outbox task.
+	messageOutbox := outbox.New(pool, cfg.OutboxMaxAttempts)
+	messageOutbox.Handle(outbox.KindEmail, outbox.EmailHandler(mailer.NewMailer(mailTransport, emailTemplates, cfg.EmailFrom)))
+	emailService := outbox.NewEmailQueue(messageOutbox)
+	// Org webhooks are queued the same way, one message per delivery.
+	webhookService := adminservices.NewWebhookService(
+		pool, webhookRepo, messageOutbox, auditLog, cfg.WebhookDisableAfterFailures, cfg.WebhookAllowPrivateNetworks,
+	)
+	messageOutbox.Handle(adminservices.WebhookOutboxKind, webhookService.Deliver)
+	// Services publish domain events on the bus; their side effects are
+	// subscribed below.
+	eventBus := events.New(logger)
+	domainService := adminservices.NewDomainService(pool, domainRepo, joinRequestRepo, membershipRepo, eventBus, auditLog, net.DefaultResolver)
+	settingsService := adminservices.NewSettingsService(pool, settingsRepo, roleRepo, auditLog)
+
+	// Auth domain
 	tokenRepo := authservices.NewRefreshTokenRepository()
-	authService := authservices.NewAuthService(pool, userRepo, tokenRepo, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.BcryptCost)
-	userService := authservices.NewUserService(pool, userRepo)
+	verificationRepo := authservices.NewEmailVerificationRepository()
+	totpRepo := authservices.NewTOTPRepository()
+	authService := authservices.NewAuthService(
+		pool, userRepo, tokenRepo, verificationRepo, totpRepo, emailService, domainService, settingsService, eventBus,
+		cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.BcryptCost,
+		cfg.EmailVerificationBaseURL, cfg.EmailVerificationTTL,
+	)
+	userService := authservices.NewUserService(pool, userRepo, auditLog)
 	authMiddleware := authhandlers.NewAuthMiddleware(cfg.JWTSecret)
 	secureCookies := cfg.Env != "local"
-	authHandler := authhandlers.NewAuthHandler(authService, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, secureCookies)
+	authCookies := authhandlers.NewAuthCookies(cfg.AccessTokenTTL, cfg.RefreshTokenTTL, secureCookies)
+	authHandler := authhandlers.NewAuthHandler(authService, cfg.JWTSecret, authCookies)
 	userHandler := authhandlers.NewUserHandler(userService)
 
 	// Administration domain
-	orgRepo := adminservices.NewOrganizationRepository()
-	membershipRepo := adminservices.NewMembershipRepository()
-	invitationRepo := adminservices.NewInvitationRepository()
-	emailService := adminservices.NewConsoleEmailService()
-	orgService := adminservices.NewOrgService(pool, orgRepo, membershipRepo)
-	invitationService := adminservices.NewInvitationService(pool, invitationRepo, membershipRepo, emailService, userRepo, cfg.InviteBaseURL, cfg.InviteTokenTTL)
-	orgHandler := adminhandlers.NewOrgHandler(orgService)
-	invitationHandler := adminhandlers.NewInvitationHandler(invitationService, orgService)
+	roleService := adminservices.NewRoleService(pool, roleRepo, grantRepo, auditLog)
+	teamService := adminservices.NewTeamService(pool, teamRepo, membershipRepo, auditLog)
+	orgService := adminservices.NewOrgService(pool, orgRepo, membershipRepo, invitationRepo, roleRepo, eventBus, auditLog, cfg.OrgDeletionGracePeriod)
+	invitationService := adminservices.NewInvitationService(
+		pool, invitationRepo, invitationImportRepo, membershipRepo, eventBus, userRepo, authService, roleService, settingsService, auditLog,
+		cfg.InviteBaseURL, cfg.InviteTokenTTL,
+	)
+	scimService := adminservices.NewSCIMService(
+		pool, orgRepo, scimTokenRepo, scimRepo, membershipRepo, roleRepo, teamRepo, domainRepo, userRepo, tokenRepo, eventBus, auditLog,
+	)
+	inviteLinkService := adminservices.NewInviteLinkService(
+		pool, inviteLinkRepo, membershipRepo, userRepo, roleService, settingsService, eventBus, auditLog, cfg.InviteLinkBaseURL,
+	)
+	notificationService := adminservices.NewNotificationService(emailService, membershipRepo, settingsService)
+	mfaPolicyService := adminservices.NewMFAPolicyService(pool, settingsRepo, membershipRepo)
+	ipAllowlistService := adminservices.NewIPAllowlistService(pool, ipAllowlistRepo, auditLog)
+
+	// Emails and webhooks are written in the transaction that publishes
+	// their event, so they are sent only if it commits. After-commit
+	// handlers run in the background and their failures are only logged.
+	events.Subscribe(eventBus, "invitation-email", notificationService.InvitationSent)
+	events.Subscribe(eventBus, "org-deleted-email", notificationService.OrgDeleted)
+	events.Subscribe(eventBus, "webhooks", webhookService.MemberJoined)
+	events.Subscribe(eventBus, "webhooks", webhookService.MemberRemoved)
+	events.Subscribe(eventBus, "webhooks", webhookService.InvitationAccepted)
+	events.Subscribe(eventBus, "webhooks", webhookService.OrgUpdated)
+	events.SubscribeAfterCommit(eventBus, "activity-log", func(ctx context.Context, e events.UserSignedUp) error {
+		logger.Info("user signed up", "userId", e.UserID, "verified", e.Verified)
+		return nil
+	})
+	events.SubscribeAfterCommit(eventBus, "activity-log", func(ctx context.Context, e events.OrgCreated) error {
+		logger.Info("organization created", "orgId", e.OrgID, "createdBy", e.CreatedBy)
+		return nil
+	})
+
+	orgHandler := adminhandlers.NewOrgHandler(orgService, roleService)
+	invitationHandler := adminhandlers.NewInvitationHandler(invitationService, orgService, authCookies)
+	roleHandler := adminhandlers.NewRoleHandler(roleService)
+	teamHandler := adminhandlers.NewTeamHandler(teamService)
+	domainHandler := adminhandlers.NewDomainHandler(domainService)
+	scimHandler := adminhandlers.NewSCIMHandler(scimService, cfg.SCIMBaseURL)
+	settingsHandler := adminhandlers.NewSettingsHandler(settingsService)
+	mfaHandler := adminhandlers.NewMFAHandler(mfaPolicyService)
+	ipAllowlistHandler := adminhandlers.NewIPAllowlistHandler(ipAllowlistService)
+	webhookHandler := adminhandlers.NewWebhookHandler(webhookService)
+	inviteLinkHandler := adminhandlers.NewInviteLinkHandler(inviteLinkService)
 	adminHandler := adminhandlers.NewAdminHandler(pool, userService)
-	roleMW := adminhandlers.NewRoleMiddleware(pool, membershipRepo, userRepo)
+	auditHandler := adminhandlers.NewAuditHandler(auditLog)
+	jobQueue := jobs.New(pool, cfg.JobWorkers, cfg.JobPollInterval)
+	jobs.Handle(jobQueue, jobExpireInvitations, func(ctx context.Context, _ struct{}) error {
+		n, err := invitationService.ExpireStale(ctx)
+		if n > 0 {
+			logger.Info("expired invitations", "count", n)
+		}
+		return err
+	})
+	jobs.Handle(jobQueue, jobPurgeRefreshTokens, func(ctx context.Context, _ struct{}) error {
+		n, err := authService.PurgeExpiredRefreshTokens(ctx)
+		if n > 0 {
+			logger.Info("purged expired refresh tokens", "count", n)
+		}
+		return err
+	})
+
+	// Recurring schedules fire on one replica per slot.
+	schedules := scheduler.New(pool, cfg.SchedulerLocation)
+	for _, sc := range []struct {
+		name, spec string
+		task       scheduler.Task
+	}{
+		{"dispatch-outbox", every(cfg.OutboxDispatchInterval), func(ctx context.Context) error {
+			_, err := messageOutbox.Dispatch(ctx)
+			return err
+		}},
+		{"process-invite-imports", every(cfg.InviteImportInterval), func(ctx context.Context) error {
+			n, err := invitationService.ProcessImports(ctx)
+			if n > 0 {
+				logger.Info("processed invitation imports", "count", n)
+			}
+			return err
+		}},
+		{jobExpireInvitations, every(cfg.InviteExpiryInterval), enqueueJob(jobQueue, pool, jobExpireInvitations)},
+		{jobPurgeRefreshTokens, every(cfg.RefreshTokenCleanupInterval), enqueueJob(jobQueue, pool, jobPurgeRefreshTokens)},
+		{"purge-deleted-orgs", every(cfg.OrgPurgeInterval), func(ctx context.Context) error {
+			n, err := orgService.PurgeDeleted(ctx)
+			if n > 0 {
+				logger.Info("purged deleted organizations", "count", n)
+			}
+			return err
+		}},
+		{"purge-outbox", "@hourly", func(ctx context.Context) error {
+			n, err := messageOutbox.PurgeDelivered(ctx, time.Now().Add(-cfg.OutboxRetention))
+			if n > 0 {
+				logger.Info("purged delivered outbox messages", "count", n)
+			}
+			return err
+		}},
+		{"purge-webhook-deliveries", "@daily", func(ctx context.Context) error {
+			n, err := webhookService.PurgeDeliveries(ctx, time.Now().Add(-cfg.WebhookDeliveryRetention))
+			if n > 0 {
+				logger.Info("purged webhook deliveries", "count", n)
+			}
+			return err
+		}},
+		{"purge-jobs", "@hourly", func(ctx context.Context) error {
+			n, err := jobQueue.PurgeFinished(ctx, time.Now().Add(-cfg.JobRetention))
+			if n > 0 {
+				logger.Info("purged finished jobs", "count", n)
+			}
+			return err
+		}},
+		{"purge-schedule-runs", "@daily", func(ctx context.Context) error {
+			_, err := schedules.PurgeHistory(ctx, time.Now().Add(-cfg.ScheduleRunRetention))
+			return err
+		}},
+	} {
+		if err := schedules.Register(sc.name, sc.spec, sc.task); err != nil {
+			log.Fatal("register schedule:", err)
+		}
+	}
+	if cfg.AuditSigningKey != "" {
+		err := schedules.Register("checkpoint-audit-chains", "@daily", func(ctx context.Context) error {
+			n, err := auditLog.Checkpoint(ctx)
+			if n > 0 {
+				logger.Info("signed audit chain checkpoints", "count", n)
+			}
+			return err
+		})
+		if err != nil {
+			log.Fatal("register schedule:", err)
+		}
+	} else {
+		logger.Warn("AUDIT_SIGNING_KEY not set; audit chains will not be checkpointed")
+	}
+	if len(auditSinks) > 0 {
+		auditStreamer := audit.NewStreamer(pool, auditSinks...)
+		err := schedules.Register("stream-audit-events", every(cfg.AuditStreamInterval), func(ctx context.Context) error {
+			_, err := auditStreamer.Deliver(ctx)
+			return err
+		})
+		if err != nil {
+			log.Fatal("register schedule:", err)
+		}
+	}
+
+	emailTemplateHandler := adminhandlers.NewEmailTemplateHandler(emailTemplates)
+	jobHandler := adminhandlers.NewJobHandler(jobQueue)
+	scheduleHandler := adminhandlers.NewScheduleHandler(schedules)
+	outboxHandler := adminhandlers.NewOutboxHandler(messageOutbox)
+	roleMW := adminhandlers.NewRoleMiddleware(pool, orgRepo, membershipRepo, userRepo, roleService, mfaPolicyService, ipAllowlistService)
 
 	server := &http.Server{
-		Addr:    cfg.Port,
+		Addr: cfg.Port,
 		Handler: NewRouter(&RouterDeps{
-			Config:            cfg,
-			Logger:            logger,
-			AuthMiddleware:    authMiddleware,
-			RoleMiddleware:    roleMW,
-			AuthHandler:       authHandler,
-			UserHandler:       userHandler,
-			OrgHandler:        orgHandler,
-			InvitationHandler: invitationHandler,
-			AdminHandler:      adminHandler,
+			Config:               cfg,
+			Logger:               logger,
+			AuthMiddleware:       authMiddleware,
+			RoleMiddleware:       roleMW,
+			AuthHandler:          authHandler,
+			UserHandler:          userHandler,
+			OrgHandler:           orgHandler,
+			InvitationHandler:    invitationHandler,
+			RoleHandler:          roleHandler,
+			TeamHandler:          teamHandler,
+			DomainHandler:        domainHandler,
+			SCIMHandler:          scimHandler,
+			SettingsHandler:      settingsHandler,
+			MFAHandler:           mfaHandler,
+			IPAllowlistHandler:   ipAllowlistHandler,
+			WebhookHandler:       webhookHandler,
+			InviteLinkHandler:    inviteLinkHandler,
+			AdminHandler:         adminHandler,
+			EmailTemplateHandler: emailTemplateHandler,
+			OutboxHandler:        outboxHandler,
+			JobHandler:           jobHandler,
+			ScheduleHandler:      scheduleHandler,
+			AuditHandler:         auditHandler,
 		}),
 	}
 	return &App{
 		Config: cfg,
 		Server: server,
 		DB:     pool,
+		Logger: logger,
+
+		schedules:     schedules,
+		jobQueue:      jobQueue,
+		eventBus:      eventBus,
+		mailTransport: mailTransport,
+	}
+}
+
+// enqueueJob returns a schedule task that queues a job of the given kind.
+// The kind is also the job's unique key, so a run is skipped while the
+// previous job is still queued or running.
+func enqueueJob(queue *jobs.Queue, pool *pgxpool.Pool, kind string) scheduler.Task {
+	return func(ctx context.Context) error {
+		_, err := queue.Enqueue(ctx, pool, jobs.EnqueueParams{Kind: kind, Payload: struct{}{}, UniqueKey: kind})
+		return err
+	}
+}
+
+// newAuditSinks builds the audit event sinks configured in cfg.
+func newAuditSinks(cfg *config.Config) ([]audit.Sink, error) {
+	var sinks []audit.Sink
+	if cfg.AuditSyslogAddr != "" {
+		sink, err := audit.NewSyslogSink(cfg.AuditSyslogNetwork, cfg.AuditSyslogAddr, "agenteur-api")
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if cfg.AuditWebhookURL != "" {
+		if cfg.AuditWebhookSecret == "" {
+			return nil, fmt.Errorf("AUDIT_WEBHOOK_SECRET is required with AUDIT_WEBHOOK_URL")
+		}
+		sinks = append(sinks, audit.NewWebhookSink(cfg.AuditWebhookURL, cfg.AuditWebhookSecret))
+	}
+	return sinks, nil
+}
+
+// newMailTransport builds the email transport selected by cfg.EmailBackend.
+func newMailTransport(cfg *config.Config) (mailer.Transport, error) {
+	switch cfg.EmailBackend {
+	case "console":
+		return mailer.NewConsoleTransport(), nil
+	case "smtp":
+		return mailer.NewSMTPTransport(mailer.SMTPConfig{
+			Host:        cfg.SMTPHost,
+			Port:        cfg.SMTPPort,
+			Username:    cfg.SMTPUsername,
+			Password:    cfg.SMTPPassword,
+			Auth:        cfg.SMTPAuth,
+			TLS:         cfg.SMTPTLS,
+			Timeout:     cfg.SMTPTimeout,
+			IdleTimeout: cfg.SMTPIdleTimeout,
+		})
+	default:
+		return nil, fmt.Errorf("unknown email backend %q", cfg.EmailBackend)
 	}
 }
 
@@ -92,6 +395,9 @@ func (a *App) Start() error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	a.jobQueue.Start()
+	a.schedules.Start()
+
 	errCh := make(chan error, 1)
 	go func() {
 		errCh <- a.Server.ListenAndServe()
@@ -102,29 +408,59 @@ func (a *App) Start() error {
 		return err
 	case <-quit:
 		log.Println("shutting down gracefully...")
-		a.DB.Close()
 		ctx, cancel := context.WithTimeout(context.Background(), 10*1e9) // 10s
 		defer cancel()
-		return a.Server.Shutdown(ctx)
+		err := a.Server.Shutdown(ctx)
+
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), a.Config.JobDrainTimeout)
+		defer cancelDrain()
+		if derr := a.schedules.Shutdown(drainCtx); derr != nil {
+			log.Println("scheduled runs still going at shutdown were cancelled")
+		}
+		if derr := a.jobQueue.Shutdown(drainCtx); derr != nil {
+			log.Println("jobs still running at shutdown were cancelled")
+		}
+		if derr := a.eventBus.Shutdown(drainCtx); derr != nil {
+			log.Println("event handlers still running at shutdown were abandoned")
+		}
+		a.mailTransport.Close()
+		a.DB.Close()
+		return err
 	}
 }
 
 type RouterDeps struct {
-	Config            *config.Config
-	Logger            *slog.Logger
-	AuthMiddleware    *authhandlers.AuthMiddleware
-	RoleMiddleware    *adminhandlers.RoleMiddleware
-	AuthHandler       *authhandlers.AuthHandler
-	UserHandler       *authhandlers.UserHandler
-	OrgHandler        *adminhandlers.OrgHandler
-	InvitationHandler *adminhandlers.InvitationHandler
-	AdminHandler      *adminhandlers.AdminHandler
+	Config               *config.Config
+	Logger               *slog.Logger
+	AuthMiddleware       *authhandlers.AuthMiddleware
+	RoleMiddleware       *adminhandlers.RoleMiddleware
+	AuthHandler          *authhandlers.AuthHandler
+	UserHandler          *authhandlers.UserHandler
+	OrgHandler           *adminhandlers.OrgHandler
+	InvitationHandler    *adminhandlers.InvitationHandler
+	RoleHandler          *adminhandlers.RoleHandler
+	TeamHandler          *adminhandlers.TeamHandler
+	DomainHandler        *adminhandlers.DomainHandler
+	SCIMHandler          *adminhandlers.SCIMHandler
+	SettingsHandler      *adminhandlers.SettingsHandler
+	MFAHandler           *adminhandlers.MFAHandler
+	IPAllowlistHandler   *adminhandlers.IPAllowlistHandler
+	WebhookHandler       *adminhandlers.WebhookHandler
+	InviteLinkHandler    *adminhandlers.InviteLinkHandler
+	AdminHandler         *adminhandlers.AdminHandler
+	EmailTemplateHandler *adminhandlers.EmailTemplateHandler
+	OutboxHandler        *adminhandlers.OutboxHandler
+	JobHandler           *adminhandlers.JobHandler
+	ScheduleHandler      *adminhandlers.ScheduleHandler
+	AuditHandler         *adminhandlers.AuditHandler
 }
 
 func NewRouter(deps *RouterDeps) http.Handler {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID())
+	r.Use(middleware.ClientIP(deps.Config.TrustedProxies))
+	r.Use(middleware.UserAgent())
 	r.Use(middleware.RequestLogger(deps.Logger))
 	r.Use(middleware.CORS(deps.Config.CORSAllowedOrigins))
 
@@ -140,9 +476,12 @@ func NewRouter(deps *RouterDeps) http.Handler {
 		api.Post("/auth/login", deps.AuthHandler.Login)
 		api.Post("/auth/refresh", deps.AuthHandler.Refresh)
 		api.Post("/auth/logout", deps.AuthHandler.Logout)
+		api.Post("/auth/verify-email", deps.AuthHandler.VerifyEmail)
 
 		// Public invitation view (token is the auth)
 		api.Get("/invitations/{token}", deps.InvitationHandler.GetByToken)
+		api.Post("/invitations/{token}/signup", deps.InvitationHandler.SignupAndAccept)
+		api.Get("/invite-links/{token}", deps.InviteLinkHandler.GetByToken)
 
 		// Authenticated routes
 		api.Group(func(authenticated chi.Router) {
@@ -151,13 +490,25 @@ func NewRouter(deps *RouterDeps) http.Handler {
 			// User routes
 			authenticated.Get("/users/me", deps.UserHandler.GetMe)
 			authenticated.Put("/users/me", deps.UserHandler.UpdateMe)
+			authenticated.Post("/auth/verify-email/resend", deps.AuthHandler.ResendVerification)
+			authenticated.Get("/auth/mfa", deps.AuthHandler.GetMFA)
+			authenticated.Post("/auth/mfa/totp", deps.AuthHandler.SetupTOTP)
+			authenticated.Post("/auth/mfa/totp/confirm", deps.AuthHandler.ConfirmTOTP)
+			authenticated.Delete("/auth/mfa/totp", deps.AuthHandler.DisableTOTP)
 
 			// Accept invitation (authenticated)
 			authenticated.Post("/invitations/{token}/accept", deps.InvitationHandler.Accept)
+			authenticated.Post("/invite-links/{token}/accept", deps.InviteLinkHandler.Accept)
 
 			// Organization routes
 			authenticated.Post("/organizations", deps.OrgHandler.Create)
 			authenticated.Get("/organizations", deps.OrgHandler.List)
+			authenticated.Get("/organizations/by-slug/{slug}", deps.OrgHandler.GetBySlug)
+			authenticated.Get("/permissions", deps.RoleHandler.ListPermissions)
+
+			// Restore is reachable while the org is soft-deleted
+			authenticated.With(deps.RoleMiddleware.RequireDeletedOrgMember, deps.RoleMiddleware.RequirePermission(admintypes.PermOrgDelete)).
+				Post("/organizations/{orgID}/restore", deps.OrgHandler.Restore)
 
 			// Superadmin routes
 			authenticated.Route("/admin", func(adminRouter chi.Router) {
@@ -165,26 +516,154 @@ func NewRouter(deps *RouterDeps) http.Handler {
 
 				adminRouter.Get("/users", deps.AdminHandler.ListUsers)
 				adminRouter.Put("/users/{userID}/superadmin", deps.AdminHandler.ToggleSuperadmin)
+				adminRouter.Get("/email-templates", deps.EmailTemplateHandler.List)
+				adminRouter.Get("/email-templates/{name}/preview", deps.EmailTemplateHandler.Preview)
+				adminRouter.Get("/outbox", deps.OutboxHandler.List)
+				adminRouter.Post("/outbox/{messageID}/replay", deps.OutboxHandler.Replay)
+				adminRouter.Get("/jobs/stats", deps.JobHandler.Stats)
+				adminRouter.Get("/schedules", deps.ScheduleHandler.List)
+				adminRouter.Get("/schedules/{name}/runs", deps.ScheduleHandler.ListRuns)
+				adminRouter.Post("/schedules/{name}/run", deps.ScheduleHandler.Run)
+				adminRouter.Get("/audit-log", deps.AuditHandler.List)
+				adminRouter.Get("/audit-log/export", deps.AuditHandler.Export)
+				adminRouter.Get("/audit-log/verify", deps.AuditHandler.Verify)
 			})
 
 			// Org-scoped routes (require membership)
 			authenticated.Route("/organizations/{orgID}", func(orgRouter chi.Router) {
 				orgRouter.Use(deps.RoleMiddleware.RequireOrgMember)
 
+				requirePerm := deps.RoleMiddleware.RequirePermission
+
 				orgRouter.Get("/", deps.OrgHandler.Get)
 				orgRouter.Get("/members", deps.OrgHandler.ListMembers)
+				orgRouter.Post("/leave", deps.OrgHandler.Leave)
+				orgRouter.Get("/members/{userID}/teams", deps.TeamHandler.ListUserTeams)
+				orgRouter.Get("/roles", deps.RoleHandler.List)
+				orgRouter.Get("/settings", deps.SettingsHandler.Get)
+				orgRouter.Get("/settings/schema", deps.SettingsHandler.Schema)
+
+				orgRouter.With(requirePerm(admintypes.PermOrgUpdate)).Put("/", deps.OrgHandler.Update)
+				orgRouter.With(requirePerm(admintypes.PermOrgDelete)).Delete("/", deps.OrgHandler.Delete)
+				orgRouter.With(requirePerm(admintypes.PermOrgUpdate)).Patch("/settings", deps.SettingsHandler.Update)
+				orgRouter.With(requirePerm(admintypes.PermOrgUpdate)).Get("/settings/versions", deps.SettingsHandler.ListVersions)
+				orgRouter.With(requirePerm(admintypes.PermOrgUpdate)).Get("/mfa/compliance", deps.MFAHandler.ComplianceReport)
+				orgRouter.With(requirePerm(admintypes.PermAuditRead)).Get("/audit-log", deps.AuditHandler.ListOrg)
+				orgRouter.With(requirePerm(admintypes.PermAuditRead)).Get("/audit-log/export", deps.AuditHandler.ExportOrg)
+				orgRouter.With(requirePerm(admintypes.PermAuditRead)).Get("/audit-log/verify", deps.AuditHandler.VerifyOrg)
+				orgRouter.With(requirePerm(admintypes.PermMembersUpdate)).Put("/members/{userID}", deps.OrgHandler.UpdateMemberRole)
+				orgRouter.With(requirePerm(admintypes.PermMembersRemove)).Delete("/members/{userID}", deps.OrgHandler.RemoveMember)
+				orgRouter.With(requirePerm(admintypes.PermMembersUpdate)).Post("/transfer-ownership", deps.OrgHandler.TransferOwnership)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Get("/invitations", deps.InvitationHandler.List)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Post("/invitations", deps.InvitationHandler.Create)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Delete("/invitations/{invitationID}", deps.InvitationHandler.Revoke)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Post("/invitations/{invitationID}/resend", deps.InvitationHandler.Resend)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Post("/invitations/bulk", deps.InvitationHandler.CreateImport)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Get("/invite-links", deps.InviteLinkHandler.List)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Post("/invite-links", deps.InviteLinkHandler.Create)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Delete("/invite-links/{linkID}", deps.InviteLinkHandler.Disable)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Get("/invite-links/{linkID}/members", deps.InviteLinkHandler.ListUses)
+				orgRouter.With(requirePerm(admintypes.PermInvitationsCreate)).Get("/invitations/bulk/{jobID}", deps.InvitationHandler.GetImport)
+
+				orgRouter.Group(func(rolesRouter chi.Router) {
+					rolesRouter.Use(requirePerm(admintypes.PermRolesManage))
 
-				// Admin-only org actions
-				orgRouter.Group(func(adminRouter chi.Router) {
-					adminRouter.Use(deps.RoleMiddleware.RequireOrgAdmin)
+					rolesRouter.Post("/roles", deps.RoleHandler.Create)
+					rolesRouter.Put("/roles/{roleID}", deps.RoleHandler.Update)
+					rolesRouter.Delete("/roles/{roleID}", deps.RoleHandler.Delete)
+					rolesRouter.Get("/grants", deps.RoleHandler.ListGrants)
+					rolesRouter.Post("/grants", deps.RoleHandler.CreateGrant)
+					rolesRouter.Delete("/grants/{grantID}", deps.RoleHandler.DeleteGrant)
+				})
+
+				orgRouter.Group(func(domainsRouter chi.Router) {
+					domainsRouter.Use(requirePerm(admintypes.PermDomainsManage))
+
+					domainsRouter.Get("/domains", deps.DomainHandler.List)
+					domainsRouter.Post("/domains", deps.DomainHandler.Add)
+					domainsRouter.Post("/domains/{domainID}/verify", deps.DomainHandler.Verify)
+					domainsRouter.Put("/domains/{domainID}", deps.DomainHandler.Update)
+					domainsRouter.Delete("/domains/{domainID}", deps.DomainHandler.Delete)
+				})
+
+				orgRouter.Group(func(scimRouter chi.Router) {
+					scimRouter.Use(requirePerm(admintypes.PermSCIMManage))
+
+					scimRouter.Get("/scim-tokens", deps.SCIMHandler.ListTokens)
+					scimRouter.Post("/scim-tokens", deps.SCIMHandler.CreateToken)
+					scimRouter.Delete("/scim-tokens/{tokenID}", deps.SCIMHandler.RevokeToken)
+				})
+
+				orgRouter.Group(func(ipRouter chi.Router) {
+					ipRouter.Use(requirePerm(admintypes.PermIPAllowlistManage))
+
+					ipRouter.Get("/ip-allowlist", deps.IPAllowlistHandler.List)
+					ipRouter.Put("/ip-allowlist", deps.IPAllowlistHandler.Replace)
+				})
+
+				orgRouter.Group(func(webhooksRouter chi.Router) {
+					webhooksRouter.Use(requirePerm(admintypes.PermWebhooksManage))
 
-					adminRouter.Put("/", deps.OrgHandler.Update)
-					adminRouter.Delete("/members/{userID}", deps.OrgHandler.RemoveMember)
-					adminRouter.Post("/invitations", deps.InvitationHandler.Create)
+					webhooksRouter.Get("/webhooks", deps.WebhookHandler.List)
+					webhooksRouter.Post("/webhooks", deps.WebhookHandler.Create)
+					webhooksRouter.Get("/webhooks/event-types", deps.WebhookHandler.EventTypes)
+					webhooksRouter.Get("/webhooks/{webhookID}", deps.WebhookHandler.Get)
+					webhooksRouter.Patch("/webhooks/{webhookID}", deps.WebhookHandler.Update)
+					webhooksRouter.Delete("/webhooks/{webhookID}", deps.WebhookHandler.Delete)
+					webhooksRouter.Get("/webhooks/{webhookID}/deliveries", deps.WebhookHandler.ListDeliveries)
+					webhooksRouter.Get("/webhooks/{webhookID}/deliveries/{deliveryID}", deps.WebhookHandler.GetDelivery)
+					webhooksRouter.Post("/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver", deps.WebhookHandler.Redeliver)
+				})
+
+				orgRouter.Group(func(joinRouter chi.Router) {
+					joinRouter.Use(requirePerm(admintypes.PermInvitationsCreate))
+
+					joinRouter.Get("/join-requests", deps.DomainHandler.ListJoinRequests)
+					joinRouter.Post("/join-requests/{requestID}/approve", deps.DomainHandler.ApproveJoinRequest)
+					joinRouter.Post("/join-requests/{requestID}/reject", deps.DomainHandler.RejectJoinRequest)
+				})
+
+				// Team maintainers manage their own team; the handler checks
+				// for teams.manage or maintainer membership.
+				orgRouter.Route("/teams", func(teamsRouter chi.Router) {
+					teamsRouter.Get("/", deps.TeamHandler.List)
+					teamsRouter.With(requirePerm(admintypes.PermTeamsManage)).Post("/", deps.TeamHandler.Create)
+					teamsRouter.Get("/{teamID}", deps.TeamHandler.Get)
+					teamsRouter.Put("/{teamID}", deps.TeamHandler.Update)
+					teamsRouter.Delete("/{teamID}", deps.TeamHandler.Delete)
+					teamsRouter.Get("/{teamID}/members", deps.TeamHandler.ListMembers)
+					teamsRouter.Post("/{teamID}/members", deps.TeamHandler.AddMember)
+					teamsRouter.Put("/{teamID}/members/{userID}", deps.TeamHandler.UpdateMemberRole)
+					teamsRouter.Delete("/{teamID}/members/{userID}", deps.TeamHandler.RemoveMember)
 				})
 			})
 		})
 	})
 
+	// SCIM provisioning, authenticated with an org-scoped bearer token
+	r.Route("/scim/v2/{orgID}", func(scim chi.Router) {
+		scim.Use(deps.SCIMHandler.Authenticate)
+
+		scim.Get("/ServiceProviderConfig", deps.SCIMHandler.ServiceProviderConfig)
+		scim.Get("/Schemas", deps.SCIMHandler.ListSchemas)
+		scim.Get("/Schemas/{id}", deps.SCIMHandler.GetSchema)
+		scim.Get("/ResourceTypes", deps.SCIMHandler.ListResourceTypes)
+		scim.Get("/ResourceTypes/{id}", deps.SCIMHandler.GetResourceType)
+
+		scim.Get("/Users", deps.SCIMHandler.ListUsers)
+		scim.Post("/Users", deps.SCIMHandler.CreateUser)
+		scim.Get("/Users/{id}", deps.SCIMHandler.GetUser)
+		scim.Put("/Users/{id}", deps.SCIMHandler.ReplaceUser)
+		scim.Patch("/Users/{id}", deps.SCIMHandler.PatchUser)
+		scim.Delete("/Users/{id}", deps.SCIMHandler.DeleteUser)
+
+		scim.Get("/Groups", deps.SCIMHandler.ListGroups)
+		scim.Post("/Groups", deps.SCIMHandler.CreateGroup)
+		scim.Get("/Groups/{id}", deps.SCIMHandler.GetGroup)
+		scim.Put("/Groups/{id}", deps.SCIMHandler.ReplaceGroup)
+		scim.Patch("/Groups/{id}", deps.SCIMHandler.PatchGroup)
+		scim.Delete("/Groups/{id}", deps.SCIMHandler.DeleteGroup)
+	})
+
 	return r
 }
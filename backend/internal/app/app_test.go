@@ -6,11 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	adminhandlers "agenteur.ai/api/internal/administration/handlers"
 	authhandlers "agenteur.ai/api/internal/auth/handlers"
 	"agenteur.ai/api/internal/config"
 	imiddleware "agenteur.ai/api/internal/middleware"
+	"agenteur.ai/api/internal/scheduler"
 )
 
 func testRouter() http.Handler {
@@ -21,7 +23,7 @@ func testRouter() http.Handler {
 		JWTSecret:          "test-secret",
 	}
 	authMW := authhandlers.NewAuthMiddleware(cfg.JWTSecret)
-	roleMW := adminhandlers.NewRoleMiddleware(nil, nil, nil)
+	roleMW := adminhandlers.NewRoleMiddleware(nil, nil, nil, nil, nil, nil, nil)
 	return NewRouter(&RouterDeps{
 		Config:         cfg,
 		Logger:         logger,
@@ -58,3 +60,35 @@ func TestNewRouterCORSPreflight(t *testing.T) {
 		t.Fatalf("expected status 204, got %d", res.Code)
 	}
 }
+
+func TestNewMailTransport(t *testing.T) {
+	if _, err := newMailTransport(&config.Config{EmailBackend: "console"}); err != nil {
+		t.Fatalf("console: %v", err)
+	}
+	if _, err := newMailTransport(&config.Config{EmailBackend: "smtp", SMTPHost: "smtp.example.com"}); err != nil {
+		t.Fatalf("smtp: %v", err)
+	}
+	if _, err := newMailTransport(&config.Config{EmailBackend: "smtp"}); err == nil {
+		t.Error("smtp without host: expected error")
+	}
+	if _, err := newMailTransport(&config.Config{EmailBackend: "carrier-pigeon"}); err == nil {
+		t.Error("unknown backend: expected error")
+	}
+}
+
+func TestEveryRoundsToWholeSeconds(t *testing.T) {
+	cases := map[time.Duration]string{
+		500 * time.Millisecond:  "@every 1s",
+		2 * time.Second:         "@every 2s",
+		1500 * time.Millisecond: "@every 2s",
+		15 * time.Minute:        "@every 15m0s",
+	}
+	for d, want := range cases {
+		if got := every(d); got != want {
+			t.Errorf("every(%v) = %q, want %q", d, got, want)
+		}
+		if _, err := scheduler.Parse(every(d), time.UTC); err != nil {
+			t.Errorf("every(%v) does not parse: %v", d, err)
+		}
+	}
+}
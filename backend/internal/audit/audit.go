@@ -0,0 +1,273 @@
+// Package audit keeps an append-only log of changes to organizations, their
+// members and users. Events are written through the caller's transaction, so
+// a change and its event commit together or not at all. The actor, client
+// IP, user agent and request ID are taken from the request context.
+//
+// Each organization's events form a hash chain, checkpointed daily with an
+// Ed25519 signature, so edits and deletions can be detected with Verify.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Actions.
+const (
+	ActionOrgCreated              = "org.created"
+	ActionOrgUpdated              = "org.updated"
+	ActionOrgDeleted              = "org.deleted"
+	ActionOrgRestored             = "org.restored"
+	ActionOrgOwnershipTransferred = "org.ownership_transferred"
+	ActionIPAllowlistUpdated      = "org.ip_allowlist.updated"
+	ActionSettingsUpdated         = "org.settings.updated"
+	ActionMemberRemoved           = "member.removed"
+	ActionMemberLeft              = "member.left"
+	ActionMemberRoleUpdated       = "member.role_updated"
+	ActionInvitationCreated       = "invitation.created"
+	ActionInvitationResent        = "invitation.resent"
+	ActionInvitationRevoked       = "invitation.revoked"
+	ActionInvitationAccepted      = "invitation.accepted"
+	ActionInviteLinkCreated       = "invite_link.created"
+	ActionInviteLinkDisabled      = "invite_link.disabled"
+	ActionInviteLinkAccepted      = "invite_link.accepted"
+	ActionRoleCreated             = "role.created"
+	ActionRoleUpdated             = "role.updated"
+	ActionRoleDeleted             = "role.deleted"
+	ActionGrantCreated            = "grant.created"
+	ActionGrantDeleted            = "grant.deleted"
+	ActionTeamCreated             = "team.created"
+	ActionTeamUpdated             = "team.updated"
+	ActionTeamDeleted             = "team.deleted"
+	ActionTeamMemberAdded         = "team.member_added"
+	ActionTeamMemberRoleUpdated   = "team.member_role_updated"
+	ActionTeamMemberRemoved       = "team.member_removed"
+	ActionDomainAdded             = "domain.added"
+	ActionDomainVerified          = "domain.verified"
+	ActionDomainJoinPolicyUpdated = "domain.join_policy_updated"
+	ActionDomainDeleted           = "domain.deleted"
+	ActionJoinRequestApproved     = "join_request.approved"
+	ActionJoinRequestRejected     = "join_request.rejected"
+	ActionWebhookCreated          = "webhook.created"
+	ActionWebhookUpdated          = "webhook.updated"
+	ActionWebhookDeleted          = "webhook.deleted"
+	ActionWebhookDisabled         = "webhook.disabled"
+	ActionUserSuperadminGranted   = "user.superadmin_granted"
+	ActionUserSuperadminRevoked   = "user.superadmin_revoked"
+)
+
+// Target types.
+const (
+	TargetOrganization = "organization"
+	TargetUser         = "user"
+	TargetInvitation   = "invitation"
+	TargetInviteLink   = "invite_link"
+	TargetRole         = "role"
+	TargetGrant        = "grant"
+	TargetTeam         = "team"
+	TargetDomain       = "domain"
+	TargetJoinRequest  = "join_request"
+	TargetWebhook      = "webhook"
+)
+
+// Event is a recorded audit event.
+type Event struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID *uuid.UUID `json:"organizationId"`
+	ActorID        *uuid.UUID `json:"actorId"`
+	Action         string     `json:"action"`
+	TargetType     string     `json:"targetType"`
+	TargetID       *uuid.UUID `json:"targetId"`
+	// Changes maps each changed field to its before and after values.
+	Changes   map[string]Change `json:"changes"`
+	Metadata  json.RawMessage   `json:"metadata"`
+	IPAddress *netip.Addr       `json:"ipAddress"`
+	UserAgent string            `json:"userAgent"`
+	RequestID string            `json:"requestId"`
+	CreatedAt time.Time         `json:"createdAt"`
+	// ChainSeq is the event's position in its organization's hash chain,
+	// and Hash covers its contents and PrevHash, the hash before it. All
+	// three are zero for events recorded before chaining began.
+	ChainSeq int64  `json:"chainSeq,omitempty"`
+	PrevHash string `json:"prevHash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+
+	// txid is the writing transaction, which orders events for sinks.
+	txid string
+}
+
+// Change is a field's value before and after a change. A nil side means the
+// field did not exist, as when something is created or deleted.
+type Change struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Entry describes an event to record. Before and After are snapshots of the
+// target that marshal to JSON objects; either may be nil. Zero IDs are
+// stored as NULL, and ActorID defaults to the actor in the context.
+type Entry struct {
+	OrganizationID uuid.UUID
+	ActorID        uuid.UUID
+	Action         string
+	TargetType     string
+	TargetID       uuid.UUID
+	Before, After  any
+	Metadata       any
+}
+
+// Filter narrows a listing. Zero fields match everything. Action matches
+// the action itself or, given a prefix such as "member", every action under
+// it.
+type Filter struct {
+	OrganizationID uuid.UUID
+	ActorID        uuid.UUID
+	Action         string
+	TargetType     string
+	TargetID       uuid.UUID
+	Since, Until   time.Time
+}
+
+type actorContextKey string
+
+const actorKey actorContextKey = "audit_actor"
+
+// WithActor returns a context whose audit events are attributed to userID.
+func WithActor(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey, userID)
+}
+
+// ActorFrom returns the actor set by WithActor, or uuid.Nil.
+func ActorFrom(ctx context.Context) uuid.UUID {
+	id, _ := ctx.Value(actorKey).(uuid.UUID)
+	return id
+}
+
+// Log records and lists audit events.
+type Log struct {
+	pool       *pgxpool.Pool
+	repo       *repository
+	signingKey ed25519.PrivateKey
+}
+
+func New(pool *pgxpool.Pool) *Log {
+	return &Log{pool: pool, repo: &repository{}}
+}
+
+// Record writes an event through db, which should be the transaction making
+// the change.
+func (l *Log) Record(ctx context.Context, db database.DBTX, e Entry) error {
+	changes, err := Diff(e.Before, e.After)
+	if err != nil {
+		return fmt.Errorf("diff audit snapshots: %w", err)
+	}
+	metadata := []byte("{}")
+	if e.Metadata != nil {
+		if metadata, err = json.Marshal(e.Metadata); err != nil {
+			return fmt.Errorf("encode audit metadata: %w", err)
+		}
+	}
+	if e.ActorID == uuid.Nil {
+		e.ActorID = ActorFrom(ctx)
+	}
+
+	ev := &Event{
+		ID:             uuid.New(),
+		OrganizationID: optionalID(e.OrganizationID),
+		ActorID:        optionalID(e.ActorID),
+		Action:         e.Action,
+		TargetType:     e.TargetType,
+		TargetID:       optionalID(e.TargetID),
+		Changes:        changes,
+		Metadata:       metadata,
+		UserAgent:      middleware.GetUserAgent(ctx),
+		RequestID:      middleware.GetRequestID(ctx),
+		// Postgres keeps microseconds, and the hash must match what is
+		// read back.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if ip := middleware.GetClientIP(ctx); ip.IsValid() {
+		ev.IPAddress = &ip
+	}
+	// Appending to the chain takes a lock held until commit, which a bare
+	// pool can't do.
+	if pool, ok := db.(*pgxpool.Pool); ok {
+		return database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+			return l.repo.Insert(ctx, tx, ev)
+		})
+	}
+	return l.repo.Insert(ctx, db, ev)
+}
+
+// List returns a page of events matching f, newest first, and the total
+// number matching.
+func (l *Log) List(ctx context.Context, f Filter, page, perPage int) ([]*Event, int, error) {
+	return l.repo.List(ctx, l.pool, f, perPage, (page-1)*perPage)
+}
+
+// Diff compares two snapshots field by field and returns the fields whose
+// JSON values differ. A nil snapshot has no fields.
+func Diff(before, after any) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for k, bv := range b {
+		if av, ok := a[k]; !ok || !jsonEqual(bv, av) {
+			changes[k] = Change{Before: bv, After: a[k]}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = Change{After: av}
+		}
+	}
+	return changes, nil
+}
+
+func fields(snapshot any) (map[string]json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("snapshot is not a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,237 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestDiff(t *testing.T) {
+	type org struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+	changes, err := Diff(org{"Acme", "acme"}, org{"Acme Inc", "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || string(changes["name"].Before) != `"Acme"` || string(changes["name"].After) != `"Acme Inc"` {
+		t.Errorf("changes = %+v, want only name", changes)
+	}
+
+	changes, err = Diff(nil, org{"Acme", "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes["slug"].Before != nil || string(changes["slug"].After) != `"acme"` {
+		t.Errorf("create changes = %+v", changes)
+	}
+
+	changes, err = Diff(map[string]any{"role": "admin"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes["role"].After != nil {
+		t.Errorf("delete changes = %+v", changes)
+	}
+
+	if _, err := Diff([]string{"a"}, nil); err == nil {
+		t.Error("expected an error for a non-object snapshot")
+	}
+}
+
+// These tests need a migrated Postgres database and are skipped unless
+// TEST_DATABASE_URL is set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// requestContext returns the context a handler sees for a request from
+// 203.0.113.7 by actor.
+func requestContext(t *testing.T, actor uuid.UUID) context.Context {
+	var ctx context.Context
+	h := middleware.RequestID()(middleware.ClientIP(nil)(middleware.UserAgent()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = WithActor(r.Context(), actor)
+		}))))
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = "203.0.113.7:4711"
+	req.Header.Set("User-Agent", "audit-test")
+	req.Header.Set(middleware.RequestIDHeader, "req-"+uuid.NewString())
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return ctx
+}
+
+func TestRecordCapturesRequestAndCommitsWithChange(t *testing.T) {
+	pool := testPool(t)
+	log := New(pool)
+	actor, orgID, target := uuid.New(), uuid.New(), uuid.New()
+	ctx := requestContext(t, actor)
+
+	err := database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		return log.Record(ctx, tx, Entry{
+			OrganizationID: orgID,
+			Action:         ActionMemberRoleUpdated,
+			TargetType:     TargetUser,
+			TargetID:       target,
+			Before:         map[string]string{"role": "user"},
+			After:          map[string]string{"role": "admin"},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An event recorded in a rolled-back transaction disappears with it.
+	rollback := errors.New("rollback")
+	err = database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		if err := log.Record(ctx, tx, Entry{OrganizationID: orgID, Action: ActionMemberRemoved}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatal(err)
+	}
+
+	events, total, err := log.List(ctx, Filter{OrganizationID: orgID}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("total = %d, want 1", total)
+	}
+	e := events[0]
+	if e.ActorID == nil || *e.ActorID != actor || e.TargetID == nil || *e.TargetID != target {
+		t.Errorf("actor/target = %v/%v", e.ActorID, e.TargetID)
+	}
+	if e.IPAddress == nil || e.IPAddress.String() != "203.0.113.7" || e.UserAgent != "audit-test" || e.RequestID != middleware.GetRequestID(ctx) {
+		t.Errorf("request metadata = %v %q %q", e.IPAddress, e.UserAgent, e.RequestID)
+	}
+	if c := e.Changes["role"]; string(c.Before) != `"user"` || string(c.After) != `"admin"` {
+		t.Errorf("changes = %+v", e.Changes)
+	}
+
+	if _, err := pool.Exec(ctx, `UPDATE audit_events SET action = 'tampered' WHERE id = $1`, e.ID); err == nil {
+		t.Error("expected audit events to be append-only")
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	log := New(pool)
+	orgID, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	for _, e := range []Entry{
+		{OrganizationID: orgID, ActorID: alice, Action: ActionMemberRemoved},
+		{OrganizationID: orgID, ActorID: alice, Action: ActionMemberRoleUpdated},
+		{OrganizationID: orgID, ActorID: bob, Action: ActionOrgUpdated},
+		{OrganizationID: uuid.New(), ActorID: alice, Action: ActionMemberRemoved},
+	} {
+		if err := log.Record(ctx, pool, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"org", Filter{OrganizationID: orgID}, 3},
+		{"action prefix", Filter{OrganizationID: orgID, Action: "member"}, 2},
+		{"exact action", Filter{OrganizationID: orgID, Action: ActionOrgUpdated}, 1},
+		{"actor across orgs", Filter{ActorID: alice}, 3},
+		{"actor in org", Filter{OrganizationID: orgID, ActorID: bob}, 1},
+	}
+	for _, c := range cases {
+		_, total, err := log.List(ctx, c.filter, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != c.want {
+			t.Errorf("%s: total = %d, want %d", c.name, total, c.want)
+		}
+	}
+}
+
+// recordingSink keeps the events sent to it for one org, failing while err
+// is set.
+type recordingSink struct {
+	name   string
+	orgID  uuid.UUID
+	err    error
+	events []*Event
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(ctx context.Context, events []*Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, e := range events {
+		if e.OrganizationID != nil && *e.OrganizationID == s.orgID {
+			s.events = append(s.events, e)
+		}
+	}
+	return nil
+}
+
+func TestStreamerResumesFromCursor(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	log := New(pool)
+	orgID := uuid.New()
+	sink := &recordingSink{name: "test:" + uuid.NewString(), orgID: orgID, err: errors.New("collector down")}
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM audit_sink_cursors WHERE sink = $1`, sink.name)
+	})
+
+	for _, action := range []string{ActionOrgCreated, ActionOrgUpdated} {
+		if err := log.Record(ctx, pool, Entry{OrganizationID: orgID, Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A failed send leaves the cursor where it was.
+	if _, err := NewStreamer(pool, sink).Deliver(ctx); err == nil {
+		t.Fatal("expected the failing sink's error")
+	}
+	sink.err = nil
+	if _, err := NewStreamer(pool, sink).Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.events) != 2 || sink.events[0].Action != ActionOrgCreated || sink.events[1].Action != ActionOrgUpdated {
+		t.Fatalf("delivered %+v, want created then updated", sink.events)
+	}
+
+	// A restarted streamer only sends what it hasn't delivered yet.
+	if err := log.Record(ctx, pool, Entry{OrganizationID: orgID, Action: ActionOrgDeleted}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStreamer(pool, sink).Deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.events) != 3 || sink.events[2].Action != ActionOrgDeleted {
+		t.Fatalf("delivered %+v, want deleted appended once", sink.events)
+	}
+}
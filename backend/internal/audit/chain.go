@@ -0,0 +1,299 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GlobalChain is the chain of events that belong to no organization, such
+// as superadmin grants.
+var GlobalChain = uuid.Nil
+
+// Checkpoint is a signed copy of a chain's head at some point in time.
+type Checkpoint struct {
+	ID       uuid.UUID `json:"id"`
+	ChainID  uuid.UUID `json:"chainId"`
+	ChainSeq int64     `json:"chainSeq"`
+	Hash     string    `json:"hash"`
+	// KeyID identifies the key that signed it; see KeyID.
+	KeyID     string    `json:"keyId"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Verification is the result of walking a chain.
+type Verification struct {
+	ChainID uuid.UUID `json:"chainId"`
+	Valid   bool      `json:"valid"`
+	// Events is how many events were checked before the walk ended.
+	Events   int64  `json:"events"`
+	HeadSeq  int64  `json:"headSeq"`
+	HeadHash string `json:"headHash"`
+	// Checkpoints counts the checkpoints whose signature was checked, and
+	// UnverifiedCheckpoints those signed by another key, or all of them
+	// when no key is configured. Both are checked against the chain.
+	Checkpoints           int         `json:"checkpoints"`
+	UnverifiedCheckpoints int         `json:"unverifiedCheckpoints"`
+	Break                 *ChainBreak `json:"break,omitempty"`
+}
+
+// ChainBreak is the first broken link found in a chain.
+type ChainBreak struct {
+	Seq     int64      `json:"seq"`
+	EventID *uuid.UUID `json:"eventId,omitempty"`
+	Reason  string     `json:"reason"`
+}
+
+// errChainBroken stops a walk at the first broken link.
+var errChainBroken = errors.New("audit chain broken")
+
+// chainOf returns the chain an event of orgID belongs to.
+func chainOf(orgID *uuid.UUID) uuid.UUID {
+	if orgID == nil {
+		return GlobalChain
+	}
+	return *orgID
+}
+
+// chainRecord is what an event's hash covers.
+type chainRecord struct {
+	ChainSeq       int64             `json:"chainSeq"`
+	PrevHash       string            `json:"prevHash"`
+	ID             uuid.UUID         `json:"id"`
+	OrganizationID *uuid.UUID        `json:"organizationId"`
+	ActorID        *uuid.UUID        `json:"actorId"`
+	Action         string            `json:"action"`
+	TargetType     string            `json:"targetType"`
+	TargetID       *uuid.UUID        `json:"targetId"`
+	Changes        map[string]Change `json:"changes"`
+	Metadata       json.RawMessage   `json:"metadata"`
+	IPAddress      string            `json:"ipAddress"`
+	UserAgent      string            `json:"userAgent"`
+	RequestID      string            `json:"requestId"`
+	CreatedAt      string            `json:"createdAt"`
+}
+
+// hashEvent returns the hex SHA-256 of e's canonical JSON, hashed like auth
+// tokens. The JSON includes the chain position and the previous hash, which
+// links each event to the one before it.
+func hashEvent(e *Event) (string, error) {
+	rec := chainRecord{
+		ChainSeq:       e.ChainSeq,
+		PrevHash:       e.PrevHash,
+		ID:             e.ID,
+		OrganizationID: e.OrganizationID,
+		ActorID:        e.ActorID,
+		Action:         e.Action,
+		TargetType:     e.TargetType,
+		TargetID:       e.TargetID,
+		Changes:        e.Changes,
+		Metadata:       e.Metadata,
+		UserAgent:      e.UserAgent,
+		RequestID:      e.RequestID,
+		CreatedAt:      e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if e.IPAddress != nil {
+		rec.IPAddress = e.IPAddress.String()
+	}
+	canonical, err := canonicalJSON(rec)
+	if err != nil {
+		return "", fmt.Errorf("encode audit event for hashing: %w", err)
+	}
+	h := sha256.Sum256(canonical)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// canonicalJSON encodes v compactly with object keys sorted at every level.
+// Postgres stores changes and metadata as JSONB, which reorders keys and
+// drops whitespace, so the hash must not depend on either.
+func canonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// ParseSigningKey decodes a base64-encoded 32-byte Ed25519 seed.
+func ParseSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode audit signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit signing key is %d bytes, want %d", len(seed), ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// KeyID returns a short fingerprint of a public key, recorded with each
+// checkpoint so checkpoints signed before a key rotation are recognized.
+func KeyID(pub ed25519.PublicKey) string {
+	h := sha256.Sum256(pub)
+	return hex.EncodeToString(h[:8])
+}
+
+// checkpointMessage is the data a checkpoint signs.
+func checkpointMessage(c *Checkpoint) []byte {
+	return fmt.Appendf(nil, "audit-checkpoint\n%s\n%d\n%s\n%s",
+		c.ChainID, c.ChainSeq, c.Hash, c.CreatedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// SetSigningKey sets the key checkpoints are signed and verified with. It
+// must be called before checkpointing or verifying starts.
+func (l *Log) SetSigningKey(key ed25519.PrivateKey) {
+	l.signingKey = key
+}
+
+// Checkpoint signs the head of every chain that has grown since its last
+// checkpoint and returns how many checkpoints were written.
+func (l *Log) Checkpoint(ctx context.Context) (int, error) {
+	if l.signingKey == nil {
+		return 0, errors.New("audit signing key not configured")
+	}
+	heads, err := l.repo.ListUncheckpointedHeads(ctx, l.pool)
+	if err != nil {
+		return 0, err
+	}
+	keyID := KeyID(l.signingKey.Public().(ed25519.PublicKey))
+	for i, c := range heads {
+		c.KeyID = keyID
+		c.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(l.signingKey, checkpointMessage(c)))
+		if err := l.repo.InsertCheckpoint(ctx, l.pool, c); err != nil {
+			return i, err
+		}
+	}
+	return len(heads), nil
+}
+
+// Chains returns the ID of every chain: organization IDs, and GlobalChain
+// if any event belongs to no organization.
+func (l *Log) Chains(ctx context.Context) ([]uuid.UUID, error) {
+	return l.repo.ListChains(ctx, l.pool)
+}
+
+// Verify walks a chain from its first event, recomputing each hash and
+// checking it against the next event's link and the chain's checkpoints.
+// It stops at the first broken link, which the result describes.
+func (l *Log) Verify(ctx context.Context, chainID uuid.UUID) (*Verification, error) {
+	checkpoints, err := l.repo.ListCheckpoints(ctx, l.pool, chainID)
+	if err != nil {
+		return nil, err
+	}
+	var pub ed25519.PublicKey
+	if l.signingKey != nil {
+		pub = l.signingKey.Public().(ed25519.PublicKey)
+	}
+	v := newChainVerifier(chainID, checkpoints, pub)
+	err = l.repo.EachInChain(ctx, l.pool, chainID, v.add)
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+	return v.finish(), nil
+}
+
+// chainVerifier checks a chain's events one at a time, in order.
+type chainVerifier struct {
+	result      Verification
+	checkpoints map[int64][]*Checkpoint
+	maxCheckSeq int64
+}
+
+// newChainVerifier checks the checkpoint signatures up front. Checkpoints
+// signed with another key, or all of them when pub is nil, are only checked
+// against the chain.
+func newChainVerifier(chainID uuid.UUID, checkpoints []*Checkpoint, pub ed25519.PublicKey) *chainVerifier {
+	v := &chainVerifier{
+		result:      Verification{ChainID: chainID, Valid: true},
+		checkpoints: make(map[int64][]*Checkpoint),
+	}
+	keyID := ""
+	if pub != nil {
+		keyID = KeyID(pub)
+	}
+	for _, c := range checkpoints {
+		v.checkpoints[c.ChainSeq] = append(v.checkpoints[c.ChainSeq], c)
+		v.maxCheckSeq = max(v.maxCheckSeq, c.ChainSeq)
+		if pub == nil || c.KeyID != keyID {
+			v.result.UnverifiedCheckpoints++
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(c.Signature)
+		if err != nil || !ed25519.Verify(pub, checkpointMessage(c), sig) {
+			v.fail(c.ChainSeq, nil, fmt.Sprintf("checkpoint %s has an invalid signature", c.ID))
+			continue
+		}
+		v.result.Checkpoints++
+	}
+	return v
+}
+
+func (v *chainVerifier) add(e *Event) error {
+	if v.result.Break != nil {
+		return errChainBroken
+	}
+	want := v.result.HeadSeq + 1
+	switch {
+	case e.ChainSeq != want:
+		v.fail(want, nil, fmt.Sprintf("event %d is missing; the chain continues at %d", want, e.ChainSeq))
+	case e.PrevHash != v.result.HeadHash:
+		v.fail(e.ChainSeq, &e.ID, "previous hash does not match the event before it")
+	}
+	if v.result.Break != nil {
+		return errChainBroken
+	}
+
+	hash, err := hashEvent(e)
+	if err != nil {
+		return err
+	}
+	if hash != e.Hash {
+		v.fail(e.ChainSeq, &e.ID, "event contents do not match its hash")
+		return errChainBroken
+	}
+	for _, c := range v.checkpoints[e.ChainSeq] {
+		if c.Hash != e.Hash {
+			v.fail(e.ChainSeq, &e.ID, fmt.Sprintf("checkpoint %s does not match the event's hash", c.ID))
+			return errChainBroken
+		}
+	}
+	v.result.Events++
+	v.result.HeadSeq = e.ChainSeq
+	v.result.HeadHash = e.Hash
+	return nil
+}
+
+// finish reports a chain shorter than its latest checkpoint, as when the
+// newest events were deleted.
+func (v *chainVerifier) finish() *Verification {
+	if v.result.Break == nil && v.maxCheckSeq > v.result.HeadSeq {
+		v.fail(v.result.HeadSeq+1, nil,
+			fmt.Sprintf("the chain ends at event %d but a checkpoint covers event %d", v.result.HeadSeq, v.maxCheckSeq))
+	}
+	return &v.result
+}
+
+func (v *chainVerifier) fail(seq int64, eventID *uuid.UUID, reason string) {
+	if v.result.Break != nil {
+		return
+	}
+	v.result.Valid = false
+	v.result.Break = &ChainBreak{Seq: seq, EventID: eventID, Reason: reason}
+}
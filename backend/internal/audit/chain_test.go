@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// testChain builds n linked events as Insert would.
+func testChain(t *testing.T, n int) []*Event {
+	t.Helper()
+	var events []*Event
+	prev := ""
+	for i := range n {
+		e := testEvent(ActionMemberRoleUpdated)
+		e.ChainSeq = int64(i + 1)
+		e.PrevHash = prev
+		var err error
+		if e.Hash, err = hashEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		prev = e.Hash
+		events = append(events, e)
+	}
+	return events
+}
+
+func signedCheckpoint(key ed25519.PrivateKey, e *Event) *Checkpoint {
+	c := &Checkpoint{
+		ID:        uuid.New(),
+		ChainSeq:  e.ChainSeq,
+		Hash:      e.Hash,
+		KeyID:     KeyID(key.Public().(ed25519.PublicKey)),
+		CreatedAt: time.Now(),
+	}
+	c.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpointMessage(c)))
+	return c
+}
+
+func verifyEvents(checkpoints []*Checkpoint, pub ed25519.PublicKey, events []*Event) *Verification {
+	v := newChainVerifier(uuid.Nil, checkpoints, pub)
+	for _, e := range events {
+		if v.add(e) != nil {
+			break
+		}
+	}
+	return v.finish()
+}
+
+func TestHashIgnoresJSONBKeyOrderAndWhitespace(t *testing.T) {
+	e := testEvent(ActionOrgUpdated)
+	e.Metadata = json.RawMessage(`{"b": 1, "a": {"y": "<", "x": 2.50}}`)
+	want, err := hashEvent(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Metadata = json.RawMessage(`{"a":{"x":2.50,"y":"<"},"b":1}`)
+	if got, _ := hashEvent(e); got != want {
+		t.Errorf("hash changed with key order: %s != %s", got, want)
+	}
+	e.Action = ActionOrgDeleted
+	if got, _ := hashEvent(e); got == want {
+		t.Error("hash did not change with the action")
+	}
+}
+
+func TestVerifierAcceptsIntactChain(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	events := testChain(t, 3)
+	result := verifyEvents([]*Checkpoint{signedCheckpoint(key, events[1])}, key.Public().(ed25519.PublicKey), events)
+	if !result.Valid || result.Events != 3 || result.HeadSeq != 3 || result.HeadHash != events[2].Hash || result.Checkpoints != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestVerifierReportsFirstBrokenLink(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	pub := key.Public().(ed25519.PublicKey)
+	otherKey := ed25519.NewKeyFromSeed([]byte(strings.Repeat("k", ed25519.SeedSize)))
+
+	cases := []struct {
+		name       string
+		tamper     func(events []*Event) ([]*Event, []*Checkpoint)
+		seq        int64
+		reasonPart string
+	}{
+		{"edited", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			ev[1].Action = ActionOrgDeleted
+			return ev, nil
+		}, 2, "contents"},
+		{"edited and rehashed", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			ev[1].Action = ActionOrgDeleted
+			ev[1].Hash, _ = hashEvent(ev[1])
+			return ev, nil
+		}, 3, "previous hash"},
+		{"deleted", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			return []*Event{ev[0], ev[2], ev[3]}, nil
+		}, 2, "missing"},
+		{"truncated", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			return ev[:2], []*Checkpoint{signedCheckpoint(key, ev[3])}
+		}, 3, "checkpoint covers event 4"},
+		{"rewritten from the start", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			return testChain(t, 4), []*Checkpoint{signedCheckpoint(key, ev[2])}
+		}, 3, "does not match"},
+		{"forged checkpoint", func(ev []*Event) ([]*Event, []*Checkpoint) {
+			c := signedCheckpoint(otherKey, ev[2])
+			c.KeyID = KeyID(pub)
+			return ev, []*Checkpoint{c}
+		}, 3, "invalid signature"},
+	}
+	for _, c := range cases {
+		events, checkpoints := c.tamper(testChain(t, 4))
+		result := verifyEvents(checkpoints, pub, events)
+		if result.Valid || result.Break == nil {
+			t.Errorf("%s: chain reported valid", c.name)
+			continue
+		}
+		if result.Break.Seq != c.seq || !strings.Contains(result.Break.Reason, c.reasonPart) {
+			t.Errorf("%s: break = %+v, want seq %d mentioning %q", c.name, result.Break, c.seq, c.reasonPart)
+		}
+	}
+}
+
+func TestVerifierCountsCheckpointsOfOtherKeysAsUnverified(t *testing.T) {
+	events := testChain(t, 2)
+	old := ed25519.NewKeyFromSeed([]byte(strings.Repeat("o", ed25519.SeedSize)))
+	current := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	result := verifyEvents([]*Checkpoint{signedCheckpoint(old, events[1])}, current.Public().(ed25519.PublicKey), events)
+	if !result.Valid || result.Checkpoints != 0 || result.UnverifiedCheckpoints != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	key, err := ParseSigningKey(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(ed25519.NewKeyFromSeed(seed)) {
+		t.Error("key does not match its seed")
+	}
+	if _, err := ParseSigningKey(base64.StdEncoding.EncodeToString(seed[:16])); err == nil {
+		t.Error("expected an error for a short seed")
+	}
+}
+
+func TestChainSurvivesConcurrentWritesAndDetectsTampering(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	log := New(pool)
+	log.SetSigningKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	orgID := uuid.New()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- log.Record(ctx, pool, Entry{OrganizationID: orgID, Action: ActionMemberRemoved, Metadata: map[string]any{"n": 1.5}})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := log.Checkpoint(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := log.Verify(ctx, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Events != 10 || result.Checkpoints != 1 {
+		t.Fatalf("result = %+v, want 10 valid events and a checkpoint", result)
+	}
+
+	// Edit an event behind the append-only trigger's back.
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE audit_events SET action = 'tampered' WHERE chain_id = $1 AND chain_seq = 4`, orgID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `ALTER TABLE audit_events ENABLE TRIGGER audit_events_append_only`)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = log.Verify(ctx, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.Break.Seq != 4 || result.Events != 3 {
+		t.Errorf("result = %+v, want a break at event 4", result)
+	}
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testEvent(action string) *Event {
+	orgID, target := uuid.New(), uuid.New()
+	ip := netip.MustParseAddr("203.0.113.7")
+	return &Event{
+		ID:             uuid.New(),
+		OrganizationID: &orgID,
+		Action:         action,
+		TargetType:     TargetUser,
+		TargetID:       &target,
+		Changes:        map[string]Change{"role": {Before: json.RawMessage(`"user"`), After: json.RawMessage(`"admin"`)}},
+		Metadata:       json.RawMessage(`{}`),
+		IPAddress:      &ip,
+		UserAgent:      "curl/8.0, with a comma",
+		RequestID:      "req-1",
+		CreatedAt:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestNDJSONEncoderWritesOneEventPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(FormatNDJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*Event{testEvent(ActionMemberRoleUpdated), testEvent(ActionMemberRemoved)}
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var got Event
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != events[1].ID || got.Action != ActionMemberRemoved {
+		t.Errorf("second line = %+v", got)
+	}
+}
+
+func TestCSVEncoderQuotesAndEncodesChanges(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := testEvent(ActionMemberRoleUpdated)
+	if err := enc.Encode(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != strings.Join(csvHeader, ",") {
+		t.Fatalf("records = %q", records)
+	}
+	row := records[1]
+	if row[0] != e.ID.String() || row[1] != "2026-03-01T12:00:00Z" || row[3] != "" || row[9] != "203.0.113.7" {
+		t.Errorf("row = %q", row)
+	}
+	if row[7] != `{"role":{"before":"user","after":"admin"}}` || row[10] != e.UserAgent {
+		t.Errorf("changes/user agent = %q / %q", row[7], row[10])
+	}
+}
+
+func TestCSVEncoderWritesHeaderForEmptyExport(t *testing.T) {
+	var buf bytes.Buffer
+	enc, _ := NewEncoder(FormatCSV, &buf)
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(csvHeader, ",") {
+		t.Errorf("empty export = %q", got)
+	}
+}
+
+func TestNewEncoderRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewEncoder("xml", &bytes.Buffer{}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("err = %v, want ErrUnknownFormat", err)
+	}
+}
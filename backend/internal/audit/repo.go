@@ -0,0 +1,308 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const eventColumns = `id, organization_id, actor_id, action, target_type, target_id, changes, metadata, ip_address, user_agent, request_id, created_at,
+	COALESCE(chain_seq, 0), COALESCE(prev_hash, ''), COALESCE(hash, ''), txid::text`
+
+type repository struct{}
+
+func scanEvent(row pgx.Row) (*Event, error) {
+	var e Event
+	err := row.Scan(&e.ID, &e.OrganizationID, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.Changes, &e.Metadata,
+		&e.IPAddress, &e.UserAgent, &e.RequestID, &e.CreatedAt,
+		&e.ChainSeq, &e.PrevHash, &e.Hash, &e.txid)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Insert appends e to its chain. db must be a transaction: the chain's
+// advisory lock is held until it ends, so concurrent events are chained one
+// after the other in commit order.
+func (r *repository) Insert(ctx context.Context, db database.DBTX, e *Event) error {
+	chainID := chainOf(e.OrganizationID)
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_chain:' || $1::text))`, chainID); err != nil {
+		return fmt.Errorf("lock audit chain: %w", err)
+	}
+	err := db.QueryRow(ctx,
+		`SELECT chain_seq, hash FROM audit_events
+		 WHERE chain_id = $1 AND chain_seq IS NOT NULL
+		 ORDER BY chain_seq DESC LIMIT 1`,
+		chainID).Scan(&e.ChainSeq, &e.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("get audit chain head: %w", err)
+	}
+	e.ChainSeq++
+	if e.Hash, err = hashEvent(e); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx,
+		`INSERT INTO audit_events (id, organization_id, actor_id, action, target_type, target_id, changes, metadata, ip_address,
+		   user_agent, request_id, created_at, chain_seq, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		e.ID, e.OrganizationID, e.ActorID, e.Action, e.TargetType, e.TargetID, e.Changes, e.Metadata, e.IPAddress,
+		e.UserAgent, e.RequestID, e.CreatedAt, e.ChainSeq, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("insert audit event: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, db database.DBTX, f Filter, limit, offset int) ([]*Event, int, error) {
+	where, args := filterClause(f)
+
+	var total int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_events WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count audit events: %w", err)
+	}
+
+	args = append(args, limit, offset)
+	rows, err := db.Query(ctx,
+		`SELECT `+eventColumns+` FROM audit_events WHERE `+where+
+			fmt.Sprintf(` ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d`, len(args)-1, len(args)),
+		args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
+
+// Each calls fn for every event matching f, oldest first, without holding
+// them all in memory.
+func (r *repository) Each(ctx context.Context, db database.DBTX, f Filter, fn func(*Event) error) error {
+	where, args := filterClause(f)
+	rows, err := db.Query(ctx, `SELECT `+eventColumns+` FROM audit_events WHERE `+where+` ORDER BY created_at, id`, args...)
+	if err != nil {
+		return fmt.Errorf("list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit event: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LockCursor locks the sink's cursor, creating it at the start of the log
+// the first time. It returns nil if another streamer holds the lock.
+func (r *repository) LockCursor(ctx context.Context, tx pgx.Tx, sink string) (*cursor, error) {
+	if _, err := tx.Exec(ctx, `INSERT INTO audit_sink_cursors (sink) VALUES ($1) ON CONFLICT DO NOTHING`, sink); err != nil {
+		return nil, fmt.Errorf("create audit sink cursor: %w", err)
+	}
+	var c cursor
+	err := tx.QueryRow(ctx,
+		`SELECT last_txid::text, last_id FROM audit_sink_cursors WHERE sink = $1 FOR UPDATE SKIP LOCKED`,
+		sink).Scan(&c.txid, &c.id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock audit sink cursor: %w", err)
+	}
+	return &c, nil
+}
+
+// ListAfter returns up to limit events past c in cursor order. Only events
+// written by transactions older than every running one are returned, as no
+// further event can appear before them.
+func (r *repository) ListAfter(ctx context.Context, db database.DBTX, c *cursor, limit int) ([]*Event, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+eventColumns+` FROM audit_events
+		 WHERE (txid, id) > ($1::text::xid8, $2)
+		   AND txid < pg_snapshot_xmin(pg_current_snapshot())
+		 ORDER BY txid, id
+		 LIMIT $3`,
+		c.txid, c.id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list audit events after cursor: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// AdvanceCursor moves the sink's cursor to the last event delivered.
+func (r *repository) AdvanceCursor(ctx context.Context, db database.DBTX, sink string, last *Event, delivered int) error {
+	_, err := db.Exec(ctx,
+		`UPDATE audit_sink_cursors
+		 SET last_txid = $2::text::xid8, last_id = $3, delivered = delivered + $4, updated_at = NOW()
+		 WHERE sink = $1`,
+		sink, last.txid, last.ID, delivered)
+	if err != nil {
+		return fmt.Errorf("advance audit sink cursor: %w", err)
+	}
+	return nil
+}
+
+// EachInChain calls fn for every chained event of chainID in chain order.
+func (r *repository) EachInChain(ctx context.Context, db database.DBTX, chainID uuid.UUID, fn func(*Event) error) error {
+	rows, err := db.Query(ctx,
+		`SELECT `+eventColumns+` FROM audit_events
+		 WHERE chain_id = $1 AND chain_seq IS NOT NULL
+		 ORDER BY chain_seq`,
+		chainID)
+	if err != nil {
+		return fmt.Errorf("list audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("scan audit event: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListChains returns the ID of every chain.
+func (r *repository) ListChains(ctx context.Context, db database.DBTX) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx, `SELECT DISTINCT chain_id FROM audit_events WHERE chain_seq IS NOT NULL ORDER BY chain_id`)
+	if err != nil {
+		return nil, fmt.Errorf("list audit chains: %w", err)
+	}
+	defer rows.Close()
+
+	var chains []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan audit chain: %w", err)
+		}
+		chains = append(chains, id)
+	}
+	return chains, rows.Err()
+}
+
+// ListUncheckpointedHeads returns the head of every chain that has grown
+// since its last checkpoint.
+func (r *repository) ListUncheckpointedHeads(ctx context.Context, db database.DBTX) ([]*Checkpoint, error) {
+	rows, err := db.Query(ctx,
+		`SELECT h.chain_id, h.chain_seq, h.hash
+		 FROM (
+		     SELECT DISTINCT ON (chain_id) chain_id, chain_seq, hash
+		     FROM audit_events
+		     WHERE chain_seq IS NOT NULL
+		     ORDER BY chain_id, chain_seq DESC
+		 ) h
+		 WHERE NOT EXISTS (
+		     SELECT 1 FROM audit_checkpoints c WHERE c.chain_id = h.chain_id AND c.chain_seq >= h.chain_seq
+		 )`)
+	if err != nil {
+		return nil, fmt.Errorf("list audit chain heads: %w", err)
+	}
+	defer rows.Close()
+
+	var heads []*Checkpoint
+	for rows.Next() {
+		var c Checkpoint
+		if err := rows.Scan(&c.ChainID, &c.ChainSeq, &c.Hash); err != nil {
+			return nil, fmt.Errorf("scan audit chain head: %w", err)
+		}
+		heads = append(heads, &c)
+	}
+	return heads, rows.Err()
+}
+
+const checkpointColumns = `id, chain_id, chain_seq, hash, key_id, signature, created_at`
+
+func (r *repository) InsertCheckpoint(ctx context.Context, db database.DBTX, c *Checkpoint) error {
+	err := db.QueryRow(ctx,
+		`INSERT INTO audit_checkpoints (chain_id, chain_seq, hash, key_id, signature, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id`,
+		c.ChainID, c.ChainSeq, c.Hash, c.KeyID, c.Signature, c.CreatedAt).Scan(&c.ID)
+	if err != nil {
+		return fmt.Errorf("insert audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ListCheckpoints returns a chain's checkpoints, oldest first.
+func (r *repository) ListCheckpoints(ctx context.Context, db database.DBTX, chainID uuid.UUID) ([]*Checkpoint, error) {
+	rows, err := db.Query(ctx,
+		`SELECT `+checkpointColumns+` FROM audit_checkpoints WHERE chain_id = $1 ORDER BY chain_seq, created_at`, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("list audit checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []*Checkpoint
+	for rows.Next() {
+		var c Checkpoint
+		if err := rows.Scan(&c.ID, &c.ChainID, &c.ChainSeq, &c.Hash, &c.KeyID, &c.Signature, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan audit checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, &c)
+	}
+	return checkpoints, rows.Err()
+}
+
+func filterClause(f Filter) (string, []any) {
+	where := `TRUE`
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where += fmt.Sprintf(` AND `+cond, len(args))
+	}
+	if f.OrganizationID != uuid.Nil {
+		add(`organization_id = $%d`, f.OrganizationID)
+	}
+	if f.ActorID != uuid.Nil {
+		add(`actor_id = $%d`, f.ActorID)
+	}
+	if f.Action != "" {
+		add(`(action = $%[1]d OR starts_with(action, $%[1]d || '.'))`, f.Action)
+	}
+	if f.TargetType != "" {
+		add(`target_type = $%d`, f.TargetType)
+	}
+	if f.TargetID != uuid.Nil {
+		add(`target_id = $%d`, f.TargetID)
+	}
+	if !f.Since.IsZero() {
+		add(`created_at >= $%d`, f.Since)
+	}
+	if !f.Until.IsZero() {
+		add(`created_at < $%d`, f.Until)
+	}
+	return where, args
+}
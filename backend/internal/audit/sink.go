@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Sink is an external system, such as a SIEM, that audit events are
+// streamed to.
+type Sink interface {
+	// Name identifies the sink's delivery cursor. A sink with a new name
+	// starts again from the oldest event.
+	Name() string
+	// Send delivers a batch of events in order. An error means the whole
+	// batch is retried, so a sink may see an event more than once.
+	Send(ctx context.Context, events []*Event) error
+}
+
+const (
+	// syslogPriority is facility 13 (log audit) at severity 5 (notice).
+	syslogPriority = 13*8 + 5
+	// sinkTimeout bounds a single delivery when the context has no deadline.
+	sinkTimeout = 30 * time.Second
+	// syslogMaxUDPMessage is the largest message sent over UDP, the size RFC
+	// 5426 asks receivers to try to support.
+	syslogMaxUDPMessage = 8192
+)
+
+// SyslogSink sends events to a syslog collector as RFC 5424 messages, one
+// per event, with the event as JSON in the message body. Over TCP messages
+// are framed by octet counting (RFC 6587); over UDP each is a datagram, and
+// an event too large for one is sent without its changes and metadata and
+// marked "truncated", rather than failing the batch on every retry.
+type SyslogSink struct {
+	network  string
+	addr     string
+	hostname string
+	appName  string
+}
+
+// NewSyslogSink creates a sink sending to addr over network, "udp" or
+// "tcp", with appName as the syslog APP-NAME.
+func NewSyslogSink(network, addr, appName string) (*SyslogSink, error) {
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("syslog network %q: must be udp or tcp", network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{network: network, addr: addr, hostname: hostname, appName: appName}, nil
+}
+
+func (s *SyslogSink) Name() string { return "syslog:" + s.network + "://" + s.addr }
+
+func (s *SyslogSink) Send(ctx context.Context, events []*Event) error {
+	ctx, cancel := withSinkTimeout(ctx)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.network, s.addr)
+	if err != nil {
+		return fmt.Errorf("dial syslog: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	for _, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			return err
+		}
+		if s.network == "udp" && len(msg) > syslogMaxUDPMessage {
+			if msg, err = s.formatTruncated(e); err != nil {
+				return err
+			}
+		}
+		if s.network == "tcp" {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := conn.Write(msg); err != nil {
+			return fmt.Errorf("write syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+// format renders e as an RFC 5424 message. The MSGID is the event's action,
+// so collectors can route on it without parsing the body, which starts with
+// a byte order mark to mark it as UTF-8.
+func (s *SyslogSink) format(e *Event) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode audit event: %w", err)
+	}
+	return s.message(e, body), nil
+}
+
+// formatTruncated renders e without its changes and metadata, marked as
+// truncated, and cuts the message to fit a UDP datagram if it is still too
+// long. The event ID lets a collector look up the full event.
+func (s *SyslogSink) formatTruncated(e *Event) ([]byte, error) {
+	short := *e
+	short.Changes, short.Metadata = nil, nil
+	body, err := json.Marshal(struct {
+		*Event
+		Truncated bool `json:"truncated"`
+	}{&short, true})
+	if err != nil {
+		return nil, fmt.Errorf("encode audit event: %w", err)
+	}
+	msg := s.message(e, body)
+	return msg[:min(len(msg), syslogMaxUDPMessage)], nil
+}
+
+func (s *SyslogSink) message(e *Event, body []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s - %s - \ufeff",
+		syslogPriority,
+		e.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogField(s.hostname, 255),
+		syslogField(s.appName, 48),
+		syslogField(e.Action, 32))
+	b.Write(body)
+	return b.Bytes()
+}
+
+// syslogField makes v a valid RFC 5424 header field: printable US-ASCII
+// without spaces, at most max characters, and "-" when empty.
+func syslogField(v string, max int) string {
+	out := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(out) < max; i++ {
+		if c := v[i]; c > ' ' && c < 127 {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return "-"
+	}
+	return string(out)
+}
+
+// Webhook signature headers. The signature is the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the shared secret, so receivers
+// can reject forged and replayed deliveries.
+const (
+	WebhookTimestampHeader = "X-Audit-Timestamp"
+	WebhookSignatureHeader = "X-Audit-Signature"
+)
+
+// WebhookSink POSTs batches of events as JSON, {"events": [...]}, signed
+// with a shared secret.
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: sinkTimeout},
+		now:    time.Now,
+	}
+}
+
+func (s *WebhookSink) Name() string { return "webhook:" + s.url }
+
+func (s *WebhookSink) Send(ctx context.Context, events []*Event) error {
+	body, err := json.Marshal(map[string]any{"events": events})
+	if err != nil {
+		return fmt.Errorf("encode audit events: %w", err)
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(s.secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post audit webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// WebhookSignature returns the signature header value for a delivery.
+func WebhookSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func withSinkTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, sinkTimeout)
+}
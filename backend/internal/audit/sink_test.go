@@ -0,0 +1,204 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseSyslog splits an RFC 5424 message into its header fields and the
+// JSON event in its body.
+func parseSyslog(t *testing.T, msg string) ([]string, *Event) {
+	t.Helper()
+	header, body, ok := strings.Cut(msg, " - \ufeff")
+	if !ok {
+		t.Fatalf("message without structured data and BOM: %q", msg)
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatalf("body %q: %v", body, err)
+	}
+	return strings.Split(header, " "), &e
+}
+
+func TestSyslogSinkSendsRFC5424OverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), "agenteur api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*Event{testEvent(ActionMemberRoleUpdated), testEvent(ActionOrgUpdated)}
+	if err := sink.Send(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 8192)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i, want := range events {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields, got := parseSyslog(t, string(buf[:n]))
+		if len(fields) != 6 {
+			t.Fatalf("header fields = %q", fields)
+		}
+		if fields[0] != "<109>1" || fields[1] != "2026-03-01T12:00:00.000000Z" || fields[3] != "agenteurapi" ||
+			fields[4] != "-" || fields[5] != want.Action {
+			t.Errorf("message %d header = %q", i, fields)
+		}
+		if got.ID != want.ID {
+			t.Errorf("message %d carries event %s, want %s", i, got.ID, want.ID)
+		}
+	}
+}
+
+func TestSyslogSinkTruncatesOversizedUDPMessages(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := testEvent(ActionOrgUpdated)
+	e.Metadata = json.RawMessage(`{"note":"` + strings.Repeat("x", 70000) + `"}`)
+	if err := sink.Send(context.Background(), []*Event{e}); err != nil {
+		t.Fatalf("Send: %v, want the oversized event sent truncated", err)
+	}
+
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n > syslogMaxUDPMessage {
+		t.Errorf("datagram is %d bytes, want at most %d", n, syslogMaxUDPMessage)
+	}
+	_, body, _ := strings.Cut(string(buf[:n]), " - \ufeff")
+	var got struct {
+		ID        string          `json:"id"`
+		Metadata  json.RawMessage `json:"metadata"`
+		Truncated bool            `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("body %q: %v", body, err)
+	}
+	if got.ID != e.ID.String() || !got.Truncated || string(got.Metadata) != "null" {
+		t.Errorf("body = %s, want the event marked truncated without its metadata", body)
+	}
+}
+
+func TestSyslogSinkFramesByOctetCountOverTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []string
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				break
+			}
+			msgs = append(msgs, string(msg))
+		}
+		received <- msgs
+	}()
+
+	sink, err := NewSyslogSink("tcp", ln.Addr().String(), "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []*Event{testEvent(ActionMemberRemoved), testEvent(ActionInvitationCreated), testEvent(ActionRoleDeleted)}
+	if err := sink.Send(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msgs := <-received:
+		if len(msgs) != len(events) {
+			t.Fatalf("got %d messages, want %d", len(msgs), len(events))
+		}
+		for i, msg := range msgs {
+			if _, got := parseSyslog(t, msg); got.ID != events[i].ID {
+				t.Errorf("message %d carries event %s, want %s", i, got.ID, events[i].ID)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("syslog listener received nothing")
+	}
+}
+
+func TestNewSyslogSinkRejectsUnknownNetwork(t *testing.T) {
+	if _, err := NewSyslogSink("unix", "/dev/log", "api"); err == nil {
+		t.Error("expected an error for a unix socket")
+	}
+}
+
+func TestWebhookSinkSignsBatches(t *testing.T) {
+	secret := []byte("s3cret")
+	var got struct {
+		Events []*Event `json:"events"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get(WebhookTimestampHeader)
+		if r.Header.Get(WebhookSignatureHeader) != WebhookSignature(secret, ts, body) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, string(secret))
+	events := []*Event{testEvent(ActionOrgCreated), testEvent(ActionOrgUpdated)}
+	if err := sink.Send(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Events) != 2 || got.Events[0].ID != events[0].ID || got.Events[1].Action != ActionOrgUpdated {
+		t.Errorf("received %+v", got.Events)
+	}
+
+	// A receiver with another secret rejects the delivery, which fails.
+	forged := NewWebhookSink(srv.URL, "wrong")
+	if err := forged.Send(context.Background(), events); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("err = %v, want a 401 failure", err)
+	}
+}
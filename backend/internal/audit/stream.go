@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// streamBatchSize is how many events are sent to a sink at a time.
+const streamBatchSize = 200
+
+// cursor is the last event delivered to a sink.
+type cursor struct {
+	txid string
+	id   uuid.UUID
+}
+
+// Streamer delivers audit events to sinks. Each sink has a durable cursor
+// that advances in the same transaction that locks it, so delivery resumes
+// where it stopped after a restart or a failed send, and replicas never
+// stream to the same sink at once. Delivery is at least once: a batch sent
+// just before a crash is sent again.
+type Streamer struct {
+	pool      *pgxpool.Pool
+	repo      *repository
+	sinks     []Sink
+	batchSize int
+}
+
+func NewStreamer(pool *pgxpool.Pool, sinks ...Sink) *Streamer {
+	return &Streamer{pool: pool, repo: &repository{}, sinks: sinks, batchSize: streamBatchSize}
+}
+
+// Deliver sends every sink the events it hasn't seen yet and returns how
+// many were delivered. A failing sink doesn't hold up the others; its
+// error is returned once they have all been tried.
+func (s *Streamer) Deliver(ctx context.Context) (int, error) {
+	delivered := 0
+	var errs []error
+	for _, sink := range s.sinks {
+		n, err := s.deliver(ctx, sink)
+		delivered += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stream audit events to %s: %w", sink.Name(), err))
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+func (s *Streamer) deliver(ctx context.Context, sink Sink) (int, error) {
+	delivered := 0
+	for {
+		n := 0
+		err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+			c, err := s.repo.LockCursor(ctx, tx, sink.Name())
+			if err != nil || c == nil {
+				return err
+			}
+			events, err := s.repo.ListAfter(ctx, tx, c, s.batchSize)
+			if err != nil || len(events) == 0 {
+				return err
+			}
+			if err := sink.Send(ctx, events); err != nil {
+				return err
+			}
+			n = len(events)
+			return s.repo.AdvanceCursor(ctx, tx, sink.Name(), events[len(events)-1], n)
+		})
+		if err != nil {
+			return delivered, err
+		}
+		delivered += n
+		if n < s.batchSize {
+			return delivered, nil
+		}
+	}
+}
@@ -14,20 +14,16 @@ import (
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
 type AuthHandler struct {
-	authService    *services.AuthService
-	jwtSecret      string
-	accessTokenTTL time.Duration
-	refreshTokenTTL time.Duration
-	secureCookies  bool
+	authService *services.AuthService
+	jwtSecret   string
+	cookies     *AuthCookies
 }
 
-func NewAuthHandler(authService *services.AuthService, jwtSecret string, accessTTL, refreshTTL time.Duration, secureCookies bool) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, jwtSecret string, cookies *AuthCookies) *AuthHandler {
 	return &AuthHandler{
-		authService:     authService,
-		jwtSecret:       jwtSecret,
-		accessTokenTTL:  accessTTL,
-		refreshTokenTTL: refreshTTL,
-		secureCookies:   secureCookies,
+		authService: authService,
+		jwtSecret:   jwtSecret,
+		cookies:     cookies,
 	}
 }
 
@@ -41,15 +37,36 @@ type signupRequest struct {
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	MFACode  string `json:"mfaCode"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type mfaStatusResponse struct {
+	TOTPEnabled bool     `json:"totpEnabled"`
+	SessionMFA  bool     `json:"sessionMfa"`
+	SessionAMR  []string `json:"sessionAmr"`
+}
+
+type totpSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
 }
 
 type userResponse struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	FirstName    string `json:"firstName"`
-	LastName     string `json:"lastName"`
-	IsSuperadmin bool   `json:"isSuperadmin"`
-	CreatedAt    string `json:"createdAt"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	IsSuperadmin  bool   `json:"isSuperadmin"`
+	EmailVerified bool   `json:"emailVerified"`
+	CreatedAt     string `json:"createdAt"`
 }
 
 func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
@@ -84,14 +101,15 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.setAuthCookies(w, accessJWT, rawRefresh)
+	h.cookies.Set(w, accessJWT, rawRefresh)
 	httputil.JSON(w, http.StatusCreated, userResponse{
-		ID:           user.ID.String(),
-		Email:        user.Email,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsSuperadmin: user.IsSuperadmin,
-		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -102,24 +120,33 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, rawRefresh, accessJWT, err := h.authService.Login(r.Context(), req.Email, req.Password)
+	user, rawRefresh, accessJWT, err := h.authService.Login(r.Context(), req.Email, req.Password, req.MFACode)
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
 			httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid email or password")
 			return
 		}
+		if errors.Is(err, services.ErrMFACodeRequired) {
+			httputil.Error(w, http.StatusUnauthorized, "MFA_CODE_REQUIRED", "An authenticator code is required")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			httputil.Error(w, http.StatusUnauthorized, "INVALID_MFA_CODE", "Invalid authenticator code")
+			return
+		}
 		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
 		return
 	}
 
-	h.setAuthCookies(w, accessJWT, rawRefresh)
+	h.cookies.Set(w, accessJWT, rawRefresh)
 	httputil.JSON(w, http.StatusOK, userResponse{
-		ID:           user.ID.String(),
-		Email:        user.Email,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsSuperadmin: user.IsSuperadmin,
-		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -140,14 +167,15 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.setAuthCookies(w, accessJWT, rawRefresh)
+	h.cookies.Set(w, accessJWT, rawRefresh)
 	httputil.JSON(w, http.StatusOK, userResponse{
-		ID:           user.ID.String(),
-		Email:        user.Email,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsSuperadmin: user.IsSuperadmin,
-		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -169,48 +197,190 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.clearAuthCookies(w)
+	h.cookies.Clear(w)
 	httputil.JSON(w, http.StatusOK, map[string]string{"message": "logged out"})
 }
 
-func (h *AuthHandler) setAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    accessToken,
-		Path:     "/",
-		MaxAge:   int(h.accessTokenTTL.Seconds()),
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
-	})
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Path:     "/api/auth",
-		MaxAge:   int(h.refreshTokenTTL.Seconds()),
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
+// VerifyEmail redeems an email verification token. The token is the auth, so
+// this route is public.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if req.Token == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"token": "Token is required"})
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerification) {
+			httputil.Error(w, http.StatusBadRequest, "INVALID_TOKEN", "Invalid or expired verification token")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, userResponse{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
-func (h *AuthHandler) clearAuthCookies(w http.ResponseWriter) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
+// ResendVerification mails the authenticated user a new verification link.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	claims := GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	if err := h.authService.ResendVerification(r.Context(), claims.UserID); err != nil {
+		if errors.Is(err, services.ErrAlreadyVerified) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Email already verified")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "verification email sent"})
+}
+
+// GetMFA reports whether the user has TOTP enabled and whether the current
+// session was authenticated with MFA.
+func (h *AuthHandler) GetMFA(w http.ResponseWriter, r *http.Request) {
+	claims := GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	factor, err := h.authService.GetTOTP(r.Context(), claims.UserID)
+	if err != nil {
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	amr := claims.AMR
+	if amr == nil {
+		amr = []string{}
+	}
+	httputil.JSON(w, http.StatusOK, mfaStatusResponse{
+		TOTPEnabled: factor.Confirmed(),
+		SessionMFA:  claims.HasMFA(),
+		SessionAMR:  amr,
 	})
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/api/auth",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
+}
+
+// SetupTOTP starts TOTP enrolment and returns the secret to add to an
+// authenticator app. Calling it again replaces an unconfirmed secret.
+func (h *AuthHandler) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	secret, uri, err := h.authService.SetupTOTP(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+			httputil.Error(w, http.StatusConflict, "CONFLICT", "Authenticator app already enabled")
+			return
+		}
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, totpSetupResponse{Secret: secret, OTPAuthURL: uri})
+}
+
+// ConfirmTOTP enables the pending TOTP factor and re-issues the session's
+// tokens as MFA-authenticated.
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if req.Code == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"code": "Code is required"})
+		return
+	}
+
+	var currentRefresh string
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		currentRefresh = cookie.Value
+	}
+
+	user, rawRefresh, accessJWT, err := h.authService.ConfirmTOTP(r.Context(), claims.UserID, req.Code, currentRefresh)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	h.cookies.Set(w, accessJWT, rawRefresh)
+	httputil.JSON(w, http.StatusOK, userResponse{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
+
+// DisableTOTP removes the user's TOTP factor. The body must carry a current
+// code.
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	claims := GetUserClaims(r.Context())
+	if claims == nil {
+		httputil.Error(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.Error(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON body")
+		return
+	}
+	if req.Code == "" {
+		httputil.ValidationError(w, "Validation failed", map[string]string{"code": "Code is required"})
+		return
+	}
+
+	if err := h.authService.DisableTOTP(r.Context(), claims.UserID, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	httputil.JSON(w, http.StatusOK, map[string]string{"message": "authenticator app disabled"})
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		httputil.ValidationError(w, "Validation failed", map[string]string{"code": "Invalid authenticator code"})
+	case errors.Is(err, services.ErrMFANotEnabled):
+		httputil.Error(w, http.StatusNotFound, "NOT_FOUND", "No authenticator app set up")
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		httputil.Error(w, http.StatusConflict, "CONFLICT", "Authenticator app already enabled")
+	default:
+		httputil.Error(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	}
+}
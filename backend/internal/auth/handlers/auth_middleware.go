@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"agenteur.ai/api/internal/audit"
 	"agenteur.ai/api/internal/auth/services"
 	"agenteur.ai/api/internal/httputil"
 )
@@ -22,7 +23,8 @@ func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
 }
 
 // Authenticate reads the access_token cookie, validates the JWT, and stores
-// claims in context for downstream handlers.
+// claims in context for downstream handlers. The user is also recorded as
+// the actor of any audit events the request causes.
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cookie, err := r.Cookie("access_token")
@@ -38,6 +40,7 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), claimsKey, claims)
+		ctx = audit.WithActor(ctx, claims.UserID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+)
+
+// AuthCookies writes the access and refresh token cookies. Handlers in other
+// domains that start sessions use it so every session gets the same cookies.
+type AuthCookies struct {
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	secure          bool
+}
+
+func NewAuthCookies(accessTTL, refreshTTL time.Duration, secure bool) *AuthCookies {
+	return &AuthCookies{
+		accessTokenTTL:  accessTTL,
+		refreshTokenTTL: refreshTTL,
+		secure:          secure,
+	}
+}
+
+// Set stores a session's tokens in cookies.
+func (c *AuthCookies) Set(w http.ResponseWriter, accessToken, refreshToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "access_token",
+		Value:    accessToken,
+		Path:     "/",
+		MaxAge:   int(c.accessTokenTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/api/auth",
+		MaxAge:   int(c.refreshTokenTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Clear expires both token cookies.
+func (c *AuthCookies) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "access_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/api/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
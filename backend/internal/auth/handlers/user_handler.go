@@ -37,12 +37,13 @@ func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	}
 
 	httputil.JSON(w, http.StatusOK, userResponse{
-		ID:           user.ID.String(),
-		Email:        user.Email,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsSuperadmin: user.IsSuperadmin,
-		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
 
@@ -69,11 +70,12 @@ func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
 	}
 
 	httputil.JSON(w, http.StatusOK, userResponse{
-		ID:           user.ID.String(),
-		Email:        user.Email,
-		FirstName:    user.FirstName,
-		LastName:     user.LastName,
-		IsSuperadmin: user.IsSuperadmin,
-		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		IsSuperadmin:  user.IsSuperadmin,
+		EmailVerified: user.EmailVerified(),
+		CreatedAt:     user.CreatedAt.Format(time.RFC3339),
 	})
 }
@@ -8,7 +8,11 @@ import (
 	"time"
 
 	"agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/events"
+	"agenteur.ai/api/internal/mailer"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,75 +20,275 @@ var (
 	ErrInvalidCredentials  = errors.New("invalid email or password")
 	ErrEmailExists         = errors.New("email already registered")
 	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrInvalidVerification = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified     = errors.New("email already verified")
+	ErrMFACodeRequired     = errors.New("multi-factor authentication code required")
+	ErrInvalidMFACode      = errors.New("invalid multi-factor authentication code")
+	ErrMFAAlreadyEnabled   = errors.New("multi-factor authentication already enabled")
+	ErrMFANotEnabled       = errors.New("multi-factor authentication not enabled")
 )
 
+// totpIssuer names the account in authenticator apps.
+const totpIssuer = "Agenteur"
+
+// expiredTokenBatchSize is how many expired refresh tokens are deleted per
+// statement, keeping each delete short.
+const expiredTokenBatchSize = 1000
+
 type AuthService struct {
-	pool            *pgxpool.Pool
-	userRepo        types.UserRepository
-	tokenRepo       types.RefreshTokenRepository
-	jwtSecret       string
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
-	bcryptCost      int
+	pool             *pgxpool.Pool
+	userRepo         types.UserRepository
+	tokenRepo        types.RefreshTokenRepository
+	verificationRepo types.EmailVerificationRepository
+	totpRepo         types.TOTPRepository
+	emailSender      types.EmailSender
+	joinPolicy       types.OrgJoinPolicy
+	sessionPolicy    types.SessionPolicy
+	eventBus         events.Publisher
+	jwtSecret        string
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
+	bcryptCost       int
+	verifyBaseURL    string
+	verifyTokenTTL   time.Duration
 }
 
+// NewAuthService creates the auth service. verifyBaseURL is the frontend page
+// that receives email verification tokens as its last path segment. joinPolicy
+// may be nil, in which case no org is joined automatically; sessionPolicy may
+// be nil, in which case sessions are not capped beyond refreshTokenTTL.
 func NewAuthService(
 	pool *pgxpool.Pool,
 	userRepo types.UserRepository,
 	tokenRepo types.RefreshTokenRepository,
+	verificationRepo types.EmailVerificationRepository,
+	totpRepo types.TOTPRepository,
+	emailSender types.EmailSender,
+	joinPolicy types.OrgJoinPolicy,
+	sessionPolicy types.SessionPolicy,
+	eventBus events.Publisher,
 	jwtSecret string,
 	accessTokenTTL time.Duration,
 	refreshTokenTTL time.Duration,
 	bcryptCost int,
+	verifyBaseURL string,
+	verifyTokenTTL time.Duration,
 ) *AuthService {
 	return &AuthService{
-		pool:            pool,
-		userRepo:        userRepo,
-		tokenRepo:       tokenRepo,
-		jwtSecret:       jwtSecret,
-		accessTokenTTL:  accessTokenTTL,
-		refreshTokenTTL: refreshTokenTTL,
-		bcryptCost:      bcryptCost,
+		pool:             pool,
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		verificationRepo: verificationRepo,
+		totpRepo:         totpRepo,
+		emailSender:      emailSender,
+		joinPolicy:       joinPolicy,
+		sessionPolicy:    sessionPolicy,
+		eventBus:         eventBus,
+		jwtSecret:        jwtSecret,
+		accessTokenTTL:   accessTokenTTL,
+		refreshTokenTTL:  refreshTokenTTL,
+		bcryptCost:       bcryptCost,
+		verifyBaseURL:    verifyBaseURL,
+		verifyTokenTTL:   verifyTokenTTL,
 	}
 }
 
 // Signup creates a new user and returns the user, raw refresh token, and access JWT.
+// The user, their verification token and any org join the join policy allows
+// are written in one transaction.
 func (s *AuthService) Signup(ctx context.Context, email, password, firstName, lastName string) (*types.User, string, string, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
-	existing, err := s.userRepo.GetByEmail(ctx, s.pool, email)
+	hash, err := HashPassword(password, s.bcryptCost)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("check existing user: %w", err)
+		return nil, "", "", fmt.Errorf("hash password: %w", err)
 	}
-	if existing != nil {
-		return nil, "", "", ErrEmailExists
+
+	var user *types.User
+	err = database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		existing, err := s.userRepo.GetByEmail(ctx, tx, email)
+		if err != nil {
+			return fmt.Errorf("check existing user: %w", err)
+		}
+		if existing != nil {
+			return ErrEmailExists
+		}
+
+		user, err = s.userRepo.Create(ctx, tx, types.CreateUserParams{
+			Email:        email,
+			PasswordHash: hash,
+			FirstName:    firstName,
+			LastName:     lastName,
+		})
+		if database.IsUniqueViolation(err) {
+			return ErrEmailExists
+		}
+		if err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+
+		if err := s.sendVerificationEmail(ctx, tx, user); err != nil {
+			return err
+		}
+		if err := s.eventBus.Publish(ctx, tx, events.UserSignedUp{UserID: user.ID, Email: user.Email}); err != nil {
+			return err
+		}
+		return s.applyJoinPolicy(ctx, tx, user)
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	rawRefresh, accessJWT, err := s.generateTokens(ctx, s.pool, user, time.Now(), []string{AMRPassword})
+	if err != nil {
+		return nil, "", "", err
 	}
 
+	return user, rawRefresh, accessJWT, nil
+}
+
+// CreateVerifiedUser creates a user whose email address is already verified,
+// for flows where they proved control of the mailbox another way, such as by
+// following an emailed invitation. It writes through the caller's
+// transaction and applies the org join policy; ErrEmailExists is returned if
+// the address is taken.
+func (s *AuthService) CreateVerifiedUser(ctx context.Context, tx database.DBTX, email, password, firstName, lastName string) (*types.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
 	hash, err := HashPassword(password, s.bcryptCost)
 	if err != nil {
-		return nil, "", "", fmt.Errorf("hash password: %w", err)
+		return nil, fmt.Errorf("hash password: %w", err)
 	}
 
-	user, err := s.userRepo.Create(ctx, s.pool, types.CreateUserParams{
+	existing, err := s.userRepo.GetByEmail(ctx, tx, email)
+	if err != nil {
+		return nil, fmt.Errorf("check existing user: %w", err)
+	}
+	if existing != nil {
+		return nil, ErrEmailExists
+	}
+
+	user, err := s.userRepo.Create(ctx, tx, types.CreateUserParams{
 		Email:        email,
 		PasswordHash: hash,
 		FirstName:    firstName,
 		LastName:     lastName,
 	})
+	if database.IsUniqueViolation(err) {
+		return nil, ErrEmailExists
+	}
 	if err != nil {
-		return nil, "", "", fmt.Errorf("create user: %w", err)
+		return nil, fmt.Errorf("create user: %w", err)
 	}
 
-	rawRefresh, accessJWT, err := s.generateTokens(ctx, user)
+	user, err = s.userRepo.MarkEmailVerified(ctx, tx, user.ID)
 	if err != nil {
-		return nil, "", "", err
+		return nil, err
+	}
+	if err := s.eventBus.Publish(ctx, tx, events.UserSignedUp{UserID: user.ID, Email: user.Email, Verified: true}); err != nil {
+		return nil, err
+	}
+	if err := s.applyJoinPolicy(ctx, tx, user); err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	return user, rawRefresh, accessJWT, nil
+// StartSession issues tokens for a new password session, storing the refresh
+// token through db so it commits with the caller's transaction.
+func (s *AuthService) StartSession(ctx context.Context, db database.DBTX, user *types.User) (string, string, error) {
+	return s.generateTokens(ctx, db, user, time.Now(), []string{AMRPassword})
+}
+
+// VerifyEmail redeems an email verification token, marks the user's email as
+// verified and applies the org join policy, all in one transaction.
+func (s *AuthService) VerifyEmail(ctx context.Context, rawToken string) (*types.User, error) {
+	var user *types.User
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		token, err := s.verificationRepo.GetByHashForUpdate(ctx, tx, HashToken(rawToken))
+		if err != nil {
+			return err
+		}
+		if token == nil || time.Now().After(token.ExpiresAt) {
+			return ErrInvalidVerification
+		}
+
+		user, err = s.userRepo.MarkEmailVerified(ctx, tx, token.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrInvalidVerification
+		}
+
+		if err := s.verificationRepo.DeleteAllByUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return s.applyJoinPolicy(ctx, tx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ResendVerification replaces any outstanding verification tokens for the
+// user and mails a new one.
+func (s *AuthService) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, s.pool, userID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if user == nil {
+		return ErrInvalidCredentials
+	}
+	if user.EmailVerified() {
+		return ErrAlreadyVerified
+	}
+
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := s.verificationRepo.DeleteAllByUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return s.sendVerificationEmail(ctx, tx, user)
+	})
+}
+
+// sendVerificationEmail creates a verification token for user and queues the
+// email with its link through db.
+func (s *AuthService) sendVerificationEmail(ctx context.Context, db database.DBTX, user *types.User) error {
+	raw, hash, err := GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(s.verifyTokenTTL)
+	if _, err := s.verificationRepo.Create(ctx, db, user.ID, hash, expiresAt); err != nil {
+		return err
+	}
+	return s.emailSender.Send(ctx, db, mailer.Email{
+		To:       user.Email,
+		Template: mailer.TemplateEmailVerification,
+		Data: mailer.EmailVerificationData{
+			VerifyURL: s.verifyBaseURL + "/" + raw,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+func (s *AuthService) applyJoinPolicy(ctx context.Context, tx database.DBTX, user *types.User) error {
+	if s.joinPolicy == nil {
+		return nil
+	}
+	if err := s.joinPolicy.ApplyJoinPolicy(ctx, tx, user); err != nil {
+		return fmt.Errorf("apply join policy: %w", err)
+	}
+	return nil
 }
 
 // Login authenticates a user and returns the user, raw refresh token, and access JWT.
-func (s *AuthService) Login(ctx context.Context, email, password string) (*types.User, string, string, error) {
+// Users with a confirmed TOTP factor must also pass a valid mfaCode; without
+// one ErrMFACodeRequired is returned.
+func (s *AuthService) Login(ctx context.Context, email, password, mfaCode string) (*types.User, string, string, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
 	user, err := s.userRepo.GetByEmail(ctx, s.pool, email)
@@ -99,7 +303,22 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*types
 		return nil, "", "", ErrInvalidCredentials
 	}
 
-	rawRefresh, accessJWT, err := s.generateTokens(ctx, user)
+	amr := []string{AMRPassword}
+	factor, err := s.totpRepo.GetByUser(ctx, s.pool, user.ID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if factor.Confirmed() {
+		if mfaCode == "" {
+			return nil, "", "", ErrMFACodeRequired
+		}
+		if err := s.verifyTOTP(ctx, user.ID, mfaCode); err != nil {
+			return nil, "", "", err
+		}
+		amr = append(amr, AMROTP, AMRMFA)
+	}
+
+	rawRefresh, accessJWT, err := s.generateTokens(ctx, s.pool, user, time.Now(), amr)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -112,6 +331,20 @@ func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
 	return s.tokenRepo.DeleteAllByUser(ctx, s.pool, userID)
 }
 
+// PurgeExpiredRefreshTokens deletes refresh tokens that have expired and
+// returns how many it deleted.
+func (s *AuthService) PurgeExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	now := time.Now()
+	var total int64
+	for {
+		n, err := s.tokenRepo.DeleteExpired(ctx, s.pool, now, expiredTokenBatchSize)
+		total += n
+		if err != nil || n < expiredTokenBatchSize {
+			return total, err
+		}
+	}
+}
+
 // Refresh rotates refresh tokens and issues a new access JWT.
 func (s *AuthService) Refresh(ctx context.Context, refreshTokenRaw string) (*types.User, string, string, error) {
 	hash := HashToken(refreshTokenRaw)
@@ -135,7 +368,7 @@ func (s *AuthService) Refresh(ctx context.Context, refreshTokenRaw string) (*typ
 		return nil, "", "", ErrInvalidRefreshToken
 	}
 
-	rawRefresh, accessJWT, err := s.generateTokens(ctx, user)
+	rawRefresh, accessJWT, err := s.generateTokens(ctx, s.pool, user, storedToken.SessionStartedAt, storedToken.AMR)
 	if err != nil {
 		return nil, "", "", err
 	}
@@ -143,21 +376,180 @@ func (s *AuthService) Refresh(ctx context.Context, refreshTokenRaw string) (*typ
 	return user, rawRefresh, accessJWT, nil
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, user *types.User) (string, string, error) {
+// generateTokens issues a refresh token for the session begun at
+// sessionStartedAt with the authentication methods amr, plus an access JWT.
+// The refresh token is stored through db.
+func (s *AuthService) generateTokens(ctx context.Context, db database.DBTX, user *types.User, sessionStartedAt time.Time, amr []string) (string, string, error) {
+	expiresAt, err := s.refreshExpiry(ctx, db, user.ID, sessionStartedAt)
+	if err != nil {
+		return "", "", err
+	}
+
 	rawRefresh, refreshHash, err := GenerateRandomToken()
 	if err != nil {
 		return "", "", fmt.Errorf("generate refresh token: %w", err)
 	}
 
-	_, err = s.tokenRepo.Create(ctx, s.pool, user.ID, refreshHash, time.Now().Add(s.refreshTokenTTL))
+	_, err = s.tokenRepo.Create(ctx, db, user.ID, refreshHash, expiresAt, sessionStartedAt, amr)
 	if err != nil {
 		return "", "", fmt.Errorf("store refresh token: %w", err)
 	}
 
-	accessJWT, err := GenerateAccessToken(user.ID, user.Email, user.IsSuperadmin, s.jwtSecret, s.accessTokenTTL)
+	accessJWT, err := GenerateAccessToken(user.ID, user.Email, user.IsSuperadmin, amr, s.jwtSecret, s.accessTokenTTL)
 	if err != nil {
 		return "", "", fmt.Errorf("generate access token: %w", err)
 	}
 
 	return rawRefresh, accessJWT, nil
 }
+
+// refreshExpiry returns when a new refresh token expires: after the refresh
+// TTL, but no later than the session lifetime cap of the user's orgs allows.
+// A session already past its cap gets ErrInvalidRefreshToken.
+func (s *AuthService) refreshExpiry(ctx context.Context, db database.DBTX, userID uuid.UUID, sessionStartedAt time.Time) (time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.refreshTokenTTL)
+	if s.sessionPolicy == nil {
+		return expiresAt, nil
+	}
+
+	maxLifetime, err := s.sessionPolicy.MaxSessionLifetime(ctx, db, userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get session policy: %w", err)
+	}
+	if maxLifetime <= 0 {
+		return expiresAt, nil
+	}
+	sessionEnd := sessionStartedAt.Add(maxLifetime)
+	if !sessionEnd.After(now) {
+		return time.Time{}, ErrInvalidRefreshToken
+	}
+	if sessionEnd.Before(expiresAt) {
+		expiresAt = sessionEnd
+	}
+	return expiresAt, nil
+}
+
+// GetTOTP returns the user's TOTP factor, or nil if they have none.
+func (s *AuthService) GetTOTP(ctx context.Context, userID uuid.UUID) (*types.TOTPFactor, error) {
+	return s.totpRepo.GetByUser(ctx, s.pool, userID)
+}
+
+// SetupTOTP starts TOTP enrolment: it stores a new unconfirmed secret and
+// returns it with the otpauth:// URI to show as a QR code. The factor is not
+// required at login until ConfirmTOTP succeeds.
+func (s *AuthService) SetupTOTP(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	user, err := s.userRepo.GetByID(ctx, s.pool, userID)
+	if err != nil {
+		return "", "", fmt.Errorf("get user: %w", err)
+	}
+	if user == nil {
+		return "", "", ErrInvalidCredentials
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	factor, err := s.totpRepo.UpsertPending(ctx, s.pool, userID, secret)
+	if err != nil {
+		return "", "", err
+	}
+	if factor == nil {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	return secret, TOTPURI(totpIssuer, user.Email, secret), nil
+}
+
+// ConfirmTOTP confirms a pending TOTP factor with a code from the
+// authenticator and upgrades the caller's session to MFA. If currentRefresh
+// is the user's refresh token it is rotated so the session keeps its start
+// time; otherwise a new session begins.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID uuid.UUID, code, currentRefresh string) (*types.User, string, string, error) {
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		factor, err := s.totpRepo.GetByUserForUpdate(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if factor == nil {
+			return ErrMFANotEnabled
+		}
+		if factor.Confirmed() {
+			return ErrMFAAlreadyEnabled
+		}
+		step, ok := ValidateTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		return s.totpRepo.Confirm(ctx, tx, userID, step)
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, s.pool, userID)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("get user: %w", err)
+	}
+	if user == nil {
+		return nil, "", "", ErrInvalidCredentials
+	}
+
+	sessionStartedAt := time.Now()
+	if currentRefresh != "" {
+		hash := HashToken(currentRefresh)
+		stored, err := s.tokenRepo.GetByHash(ctx, s.pool, hash)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("get refresh token: %w", err)
+		}
+		if stored != nil && stored.UserID == userID && time.Now().Before(stored.ExpiresAt) {
+			if err := s.tokenRepo.DeleteByHash(ctx, s.pool, hash); err != nil {
+				return nil, "", "", fmt.Errorf("delete old refresh token: %w", err)
+			}
+			sessionStartedAt = stored.SessionStartedAt
+		}
+	}
+
+	rawRefresh, accessJWT, err := s.generateTokens(ctx, s.pool, user, sessionStartedAt, []string{AMRPassword, AMROTP, AMRMFA})
+	if err != nil {
+		return nil, "", "", err
+	}
+	return user, rawRefresh, accessJWT, nil
+}
+
+// DisableTOTP removes the user's TOTP factor. A valid code is required so a
+// stolen session alone cannot turn MFA off.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		factor, err := s.totpRepo.GetByUserForUpdate(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !factor.Confirmed() {
+			return ErrMFANotEnabled
+		}
+		if _, ok := ValidateTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep); !ok {
+			return ErrInvalidMFACode
+		}
+		return s.totpRepo.Delete(ctx, tx, userID)
+	})
+}
+
+// verifyTOTP checks a code against the user's confirmed factor and records
+// its time step so it cannot be reused.
+func (s *AuthService) verifyTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	return database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		factor, err := s.totpRepo.GetByUserForUpdate(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !factor.Confirmed() {
+			return ErrMFANotEnabled
+		}
+		step, ok := ValidateTOTP(factor.Secret, code, time.Now(), factor.LastUsedStep)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		return s.totpRepo.SetLastUsedStep(ctx, tx, userID, step)
+	})
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+)
+
+type fixedSessionPolicy time.Duration
+
+func (p fixedSessionPolicy) MaxSessionLifetime(context.Context, database.DBTX, uuid.UUID) (time.Duration, error) {
+	return time.Duration(p), nil
+}
+
+func TestRefreshExpirySessionCap(t *testing.T) {
+	ctx := context.Background()
+	uid := uuid.New()
+	now := time.Now()
+
+	s := &AuthService{refreshTokenTTL: 7 * 24 * time.Hour}
+	expiresAt, err := s.refreshExpiry(ctx, nil, uid, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiresAt.Sub(now) < 7*24*time.Hour-time.Minute {
+		t.Fatalf("expected uncapped expiry, got %s", expiresAt.Sub(now))
+	}
+
+	s.sessionPolicy = fixedSessionPolicy(8 * time.Hour)
+	started := now.Add(-2 * time.Hour)
+	expiresAt, err = s.refreshExpiry(ctx, nil, uid, started)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiresAt.Equal(started.Add(8 * time.Hour)) {
+		t.Fatalf("expected expiry at session cap, got %s", expiresAt)
+	}
+
+	_, err = s.refreshExpiry(ctx, nil, uid, now.Add(-9*time.Hour))
+	if !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected ErrInvalidRefreshToken for expired session, got %v", err)
+	}
+}
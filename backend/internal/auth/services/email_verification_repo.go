@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type pgxEmailVerificationRepository struct{}
+
+func NewEmailVerificationRepository() types.EmailVerificationRepository {
+	return &pgxEmailVerificationRepository{}
+}
+
+func (r *pgxEmailVerificationRepository) Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*types.EmailVerificationToken, error) {
+	var t types.EmailVerificationToken
+	err := db.QueryRow(ctx,
+		`INSERT INTO email_verification_tokens (user_id, token_hash, expires_at)
+		 VALUES ($1, $2, $3)
+		 RETURNING id, user_id, token_hash, expires_at, created_at`,
+		userID, tokenHash, expiresAt,
+	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create email verification token: %w", err)
+	}
+	return &t, nil
+}
+
+// GetByHashForUpdate looks up a token and locks it so it can only be redeemed
+// once. Must be called inside a transaction.
+func (r *pgxEmailVerificationRepository) GetByHashForUpdate(ctx context.Context, db database.DBTX, hash string) (*types.EmailVerificationToken, error) {
+	var t types.EmailVerificationToken
+	err := db.QueryRow(ctx,
+		`SELECT id, user_id, token_hash, expires_at, created_at
+		 FROM email_verification_tokens WHERE token_hash = $1
+		 FOR UPDATE`, hash,
+	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get email verification token by hash: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *pgxEmailVerificationRepository) DeleteAllByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) error {
+	_, err := db.Exec(ctx, `DELETE FROM email_verification_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete email verification tokens by user: %w", err)
+	}
+	return nil
+}
@@ -2,22 +2,38 @@ package services
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Authentication method references (RFC 8176) recorded in the amr claim.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
 // TokenClaims holds custom JWT claims for access tokens.
 type TokenClaims struct {
 	jwt.RegisteredClaims
 	UserID       uuid.UUID `json:"uid"`
 	Email        string    `json:"email"`
 	IsSuperadmin bool      `json:"is_superadmin"`
+	AMR          []string  `json:"amr,omitempty"`
+}
+
+// HasMFA reports whether the session was authenticated with more than one
+// factor.
+func (c *TokenClaims) HasMFA() bool {
+	return slices.Contains(c.AMR, AMRMFA)
 }
 
-// GenerateAccessToken creates a signed HS256 JWT with the given claims.
-func GenerateAccessToken(userID uuid.UUID, email string, isSuperadmin bool, secret string, ttl time.Duration) (string, error) {
+// GenerateAccessToken creates a signed HS256 JWT with the given claims. amr
+// lists the methods the session was authenticated with.
+func GenerateAccessToken(userID uuid.UUID, email string, isSuperadmin bool, amr []string, secret string, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := TokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -28,6 +44,7 @@ func GenerateAccessToken(userID uuid.UUID, email string, isSuperadmin bool, secr
 		UserID:       userID,
 		Email:        email,
 		IsSuperadmin: isSuperadmin,
+		AMR:          amr,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
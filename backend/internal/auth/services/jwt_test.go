@@ -11,7 +11,7 @@ const testSecret = "test-secret-key-for-testing-only!"
 
 func TestJWTGenerateAndValidate(t *testing.T) {
 	uid := uuid.New()
-	token, err := GenerateAccessToken(uid, "test@example.com", false, testSecret, 15*time.Minute)
+	token, err := GenerateAccessToken(uid, "test@example.com", false, nil, testSecret, 15*time.Minute)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -36,7 +36,7 @@ func TestJWTGenerateAndValidate(t *testing.T) {
 
 func TestJWTExpiredToken(t *testing.T) {
 	uid := uuid.New()
-	token, err := GenerateAccessToken(uid, "test@example.com", false, testSecret, -1*time.Second)
+	token, err := GenerateAccessToken(uid, "test@example.com", false, nil, testSecret, -1*time.Second)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -49,7 +49,7 @@ func TestJWTExpiredToken(t *testing.T) {
 
 func TestJWTWrongSecret(t *testing.T) {
 	uid := uuid.New()
-	token, err := GenerateAccessToken(uid, "test@example.com", false, testSecret, 15*time.Minute)
+	token, err := GenerateAccessToken(uid, "test@example.com", false, nil, testSecret, 15*time.Minute)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -62,7 +62,7 @@ func TestJWTWrongSecret(t *testing.T) {
 
 func TestJWTParseUnvalidatedExpired(t *testing.T) {
 	uid := uuid.New()
-	token, err := GenerateAccessToken(uid, "test@example.com", false, testSecret, -1*time.Second)
+	token, err := GenerateAccessToken(uid, "test@example.com", false, nil, testSecret, -1*time.Second)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -78,7 +78,7 @@ func TestJWTParseUnvalidatedExpired(t *testing.T) {
 
 func TestJWTParseUnvalidatedWrongSecret(t *testing.T) {
 	uid := uuid.New()
-	token, err := GenerateAccessToken(uid, "test@example.com", false, testSecret, 15*time.Minute)
+	token, err := GenerateAccessToken(uid, "test@example.com", false, nil, testSecret, 15*time.Minute)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -88,3 +88,30 @@ func TestJWTParseUnvalidatedWrongSecret(t *testing.T) {
 		t.Fatal("expected wrong secret to fail even unvalidated parse")
 	}
 }
+
+func TestJWTAMRClaim(t *testing.T) {
+	uid := uuid.New()
+	token, err := GenerateAccessToken(uid, "test@example.com", false, []string{AMRPassword}, testSecret, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateAccessToken(token, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.HasMFA() {
+		t.Fatal("expected password-only session to lack MFA")
+	}
+
+	token, err = GenerateAccessToken(uid, "test@example.com", false, []string{AMRPassword, AMROTP, AMRMFA}, testSecret, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = ValidateAccessToken(token, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.HasMFA() {
+		t.Fatalf("expected MFA session, got amr %v", claims.AMR)
+	}
+}
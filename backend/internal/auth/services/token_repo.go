@@ -17,14 +17,14 @@ func NewRefreshTokenRepository() types.RefreshTokenRepository {
 	return &pgxRefreshTokenRepository{}
 }
 
-func (r *pgxRefreshTokenRepository) Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*types.RefreshToken, error) {
+func (r *pgxRefreshTokenRepository) Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt, sessionStartedAt time.Time, amr []string) (*types.RefreshToken, error) {
 	var t types.RefreshToken
 	err := db.QueryRow(ctx,
-		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
-		 VALUES ($1, $2, $3)
-		 RETURNING id, user_id, token_hash, expires_at, created_at`,
-		userID, tokenHash, expiresAt,
-	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt)
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at, session_started_at, amr)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, user_id, token_hash, expires_at, session_started_at, amr, created_at`,
+		userID, tokenHash, expiresAt, sessionStartedAt, amr,
+	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.SessionStartedAt, &t.AMR, &t.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("create refresh token: %w", err)
 	}
@@ -34,9 +34,9 @@ func (r *pgxRefreshTokenRepository) Create(ctx context.Context, db database.DBTX
 func (r *pgxRefreshTokenRepository) GetByHash(ctx context.Context, db database.DBTX, hash string) (*types.RefreshToken, error) {
 	var t types.RefreshToken
 	err := db.QueryRow(ctx,
-		`SELECT id, user_id, token_hash, expires_at, created_at
+		`SELECT id, user_id, token_hash, expires_at, session_started_at, amr, created_at
 		 FROM refresh_tokens WHERE token_hash = $1`, hash,
-	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt)
+	).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.SessionStartedAt, &t.AMR, &t.CreatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -61,3 +61,14 @@ func (r *pgxRefreshTokenRepository) DeleteAllByUser(ctx context.Context, db data
 	}
 	return nil
 }
+
+func (r *pgxRefreshTokenRepository) DeleteExpired(ctx context.Context, db database.DBTX, before time.Time, limit int) (int64, error) {
+	tag, err := db.Exec(ctx,
+		`DELETE FROM refresh_tokens WHERE id IN (
+		     SELECT id FROM refresh_tokens WHERE expires_at < $1 LIMIT $2
+		 )`, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired refresh tokens: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
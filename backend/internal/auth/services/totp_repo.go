@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const totpColumns = `user_id, secret, confirmed_at, last_used_step, created_at`
+
+type pgxTOTPRepository struct{}
+
+func NewTOTPRepository() types.TOTPRepository {
+	return &pgxTOTPRepository{}
+}
+
+func scanTOTPFactor(row pgx.Row) (*types.TOTPFactor, error) {
+	var f types.TOTPFactor
+	if err := row.Scan(&f.UserID, &f.Secret, &f.ConfirmedAt, &f.LastUsedStep, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *pgxTOTPRepository) GetByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) (*types.TOTPFactor, error) {
+	f, err := scanTOTPFactor(db.QueryRow(ctx,
+		`SELECT `+totpColumns+` FROM user_totp WHERE user_id = $1`, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get totp factor: %w", err)
+	}
+	return f, nil
+}
+
+// GetByUserForUpdate locks the user's factor so a code can only be used once.
+// Must be called inside a transaction.
+func (r *pgxTOTPRepository) GetByUserForUpdate(ctx context.Context, db database.DBTX, userID uuid.UUID) (*types.TOTPFactor, error) {
+	f, err := scanTOTPFactor(db.QueryRow(ctx,
+		`SELECT `+totpColumns+` FROM user_totp WHERE user_id = $1 FOR UPDATE`, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lock totp factor: %w", err)
+	}
+	return f, nil
+}
+
+func (r *pgxTOTPRepository) UpsertPending(ctx context.Context, db database.DBTX, userID uuid.UUID, secret string) (*types.TOTPFactor, error) {
+	f, err := scanTOTPFactor(db.QueryRow(ctx,
+		`INSERT INTO user_totp (user_id, secret)
+		 VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE
+		 SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		 WHERE user_totp.confirmed_at IS NULL
+		 RETURNING `+totpColumns,
+		userID, secret))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("upsert pending totp factor: %w", err)
+	}
+	return f, nil
+}
+
+func (r *pgxTOTPRepository) Confirm(ctx context.Context, db database.DBTX, userID uuid.UUID, step int64) error {
+	_, err := db.Exec(ctx,
+		`UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2 WHERE user_id = $1`, userID, step)
+	if err != nil {
+		return fmt.Errorf("confirm totp factor: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxTOTPRepository) SetLastUsedStep(ctx context.Context, db database.DBTX, userID uuid.UUID, step int64) error {
+	_, err := db.Exec(ctx,
+		`UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1`, userID, step)
+	if err != nil {
+		return fmt.Errorf("set totp last used step: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxTOTPRepository) Delete(ctx context.Context, db database.DBTX, userID uuid.UUID) error {
+	_, err := db.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("delete totp factor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for SHA-1, truncated to six digits.
+func TestTOTPCodeRFCVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, totpStep(time.Unix(tt.unix, 0))); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := totpEncoding.DecodeString(secret)
+	now := time.Now()
+	step := totpStep(now)
+
+	if got, ok := ValidateTOTP(secret, totpCode(key, step), now, 0); !ok || got != step {
+		t.Fatalf("expected current code to validate at step %d, got %d %v", step, got, ok)
+	}
+	if _, ok := ValidateTOTP(secret, totpCode(key, step-1), now, 0); !ok {
+		t.Fatal("expected previous code to validate within skew")
+	}
+	if _, ok := ValidateTOTP(secret, totpCode(key, step-2), now, 0); ok {
+		t.Fatal("expected code outside skew to fail")
+	}
+	if _, ok := ValidateTOTP(secret, totpCode(key, step), now, step); ok {
+		t.Fatal("expected replayed code to fail")
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now, 0); ok {
+		t.Fatal("expected short code to fail")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Agenteur", "jane@acme.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Agenteur:jane@acme.com?") || !strings.Contains(uri, "secret=ABC") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+}
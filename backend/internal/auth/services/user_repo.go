@@ -22,9 +22,9 @@ func (r *pgxUserRepository) Create(ctx context.Context, db database.DBTX, params
 	err := db.QueryRow(ctx,
 		`INSERT INTO users (email, password_hash, first_name, last_name)
 		 VALUES ($1, $2, $3, $4)
-		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at`,
+		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at`,
 		params.Email, params.PasswordHash, params.FirstName, params.LastName,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("create user: %w", err)
 	}
@@ -34,9 +34,9 @@ func (r *pgxUserRepository) Create(ctx context.Context, db database.DBTX, params
 func (r *pgxUserRepository) GetByID(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.User, error) {
 	var u types.User
 	err := db.QueryRow(ctx,
-		`SELECT id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at
+		`SELECT id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at
 		 FROM users WHERE id = $1`, id,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -49,9 +49,9 @@ func (r *pgxUserRepository) GetByID(ctx context.Context, db database.DBTX, id uu
 func (r *pgxUserRepository) GetByEmail(ctx context.Context, db database.DBTX, email string) (*types.User, error) {
 	var u types.User
 	err := db.QueryRow(ctx,
-		`SELECT id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at
+		`SELECT id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at
 		 FROM users WHERE LOWER(email) = LOWER($1)`, email,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -66,9 +66,9 @@ func (r *pgxUserRepository) Update(ctx context.Context, db database.DBTX, id uui
 	err := db.QueryRow(ctx,
 		`UPDATE users SET first_name = $2, last_name = $3, updated_at = NOW()
 		 WHERE id = $1
-		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at`,
+		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at`,
 		id, params.FirstName, params.LastName,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("update user: %w", err)
 	}
@@ -98,12 +98,12 @@ func (r *pgxUserRepository) ListAll(ctx context.Context, db database.DBTX, page,
 	var args []any
 	if search != "" {
 		like := "%" + strings.ToLower(search) + "%"
-		query = `SELECT id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at
+		query = `SELECT id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at
 		         FROM users WHERE LOWER(email) LIKE $1 OR LOWER(first_name) LIKE $1 OR LOWER(last_name) LIKE $1
 		         ORDER BY created_at DESC LIMIT $2 OFFSET $3`
 		args = []any{like, perPage, offset}
 	} else {
-		query = `SELECT id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at
+		query = `SELECT id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at
 		         FROM users ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 		args = []any{perPage, offset}
 	}
@@ -117,7 +117,7 @@ func (r *pgxUserRepository) ListAll(ctx context.Context, db database.DBTX, page,
 	var users []*types.User
 	for rows.Next() {
 		var u types.User
-		if err := rows.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt); err != nil {
 			return nil, 0, fmt.Errorf("scan user: %w", err)
 		}
 		users = append(users, &u)
@@ -125,14 +125,33 @@ func (r *pgxUserRepository) ListAll(ctx context.Context, db database.DBTX, page,
 	return users, total, nil
 }
 
+// MarkEmailVerified records that the user proved ownership of their email
+// address. Already-verified users keep their original timestamp.
+func (r *pgxUserRepository) MarkEmailVerified(ctx context.Context, db database.DBTX, id uuid.UUID) (*types.User, error) {
+	var u types.User
+	err := db.QueryRow(ctx,
+		`UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		 WHERE id = $1
+		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at`,
+		id,
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("mark email verified: %w", err)
+	}
+	return &u, nil
+}
+
 func (r *pgxUserRepository) SetSuperadmin(ctx context.Context, db database.DBTX, id uuid.UUID, isSuperadmin bool) (*types.User, error) {
 	var u types.User
 	err := db.QueryRow(ctx,
 		`UPDATE users SET is_superadmin = $2, updated_at = NOW()
 		 WHERE id = $1
-		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, created_at, updated_at`,
+		 RETURNING id, email, password_hash, first_name, last_name, is_superadmin, email_verified_at, created_at, updated_at`,
 		id, isSuperadmin,
-	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.CreatedAt, &u.UpdatedAt)
+	).Scan(&u.ID, &u.Email, &u.PasswordHash, &u.FirstName, &u.LastName, &u.IsSuperadmin, &u.EmailVerifiedAt, &u.CreatedAt, &u.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
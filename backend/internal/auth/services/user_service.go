@@ -4,18 +4,22 @@ import (
 	"context"
 	"fmt"
 
+	"agenteur.ai/api/internal/audit"
 	"agenteur.ai/api/internal/auth/types"
+	"agenteur.ai/api/internal/database"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserService struct {
 	pool     *pgxpool.Pool
 	userRepo types.UserRepository
+	auditLog *audit.Log
 }
 
-func NewUserService(pool *pgxpool.Pool, userRepo types.UserRepository) *UserService {
-	return &UserService{pool: pool, userRepo: userRepo}
+func NewUserService(pool *pgxpool.Pool, userRepo types.UserRepository, auditLog *audit.Log) *UserService {
+	return &UserService{pool: pool, userRepo: userRepo, auditLog: auditLog}
 }
 
 func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*types.User, error) {
@@ -38,6 +42,39 @@ func (s *UserService) ListAll(ctx context.Context, page, perPage int, search str
 	return s.userRepo.ListAll(ctx, s.pool, page, perPage, search)
 }
 
+// SetSuperadmin grants or revokes superadmin access and records the change.
+// It returns nil if the user does not exist.
 func (s *UserService) SetSuperadmin(ctx context.Context, id uuid.UUID, isSuperadmin bool) (*types.User, error) {
-	return s.userRepo.SetSuperadmin(ctx, s.pool, id, isSuperadmin)
+	var user *types.User
+	err := database.WithTx(ctx, s.pool, func(tx pgx.Tx) error {
+		current, err := s.userRepo.GetByID(ctx, tx, id)
+		if err != nil || current == nil {
+			return err
+		}
+		user, err = s.userRepo.SetSuperadmin(ctx, tx, id, isSuperadmin)
+		if err != nil || current.IsSuperadmin == isSuperadmin {
+			return err
+		}
+		action := audit.ActionUserSuperadminRevoked
+		if isSuperadmin {
+			action = audit.ActionUserSuperadminGranted
+		}
+		return s.auditLog.Record(ctx, tx, audit.Entry{
+			Action:     action,
+			TargetType: audit.TargetUser,
+			TargetID:   id,
+			Before:     superadminSnapshot{current.IsSuperadmin},
+			After:      superadminSnapshot{isSuperadmin},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// superadminSnapshot is the part of a user recorded in superadmin audit
+// events.
+type superadminSnapshot struct {
+	IsSuperadmin bool `json:"isSuperadmin"`
 }
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPFactor is a user's TOTP authenticator. It is pending until the user
+// confirms it with a valid code, and only confirmed factors are required at
+// login.
+type TOTPFactor struct {
+	UserID       uuid.UUID  `json:"userId"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmedAt"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// Confirmed reports whether the factor has been confirmed.
+func (f *TOTPFactor) Confirmed() bool {
+	return f != nil && f.ConfirmedAt != nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/mailer"
 	"github.com/google/uuid"
 )
 
@@ -16,12 +17,57 @@ type UserRepository interface {
 	Update(ctx context.Context, db database.DBTX, id uuid.UUID, params UpdateUserParams) (*User, error)
 	ListAll(ctx context.Context, db database.DBTX, page, perPage int, search string) ([]*User, int, error)
 	SetSuperadmin(ctx context.Context, db database.DBTX, id uuid.UUID, isSuperadmin bool) (*User, error)
+	MarkEmailVerified(ctx context.Context, db database.DBTX, id uuid.UUID) (*User, error)
 }
 
 // RefreshTokenRepository defines refresh token data access methods.
 type RefreshTokenRepository interface {
-	Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*RefreshToken, error)
+	Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt, sessionStartedAt time.Time, amr []string) (*RefreshToken, error)
 	GetByHash(ctx context.Context, db database.DBTX, hash string) (*RefreshToken, error)
 	DeleteByHash(ctx context.Context, db database.DBTX, hash string) error
 	DeleteAllByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) error
+	// DeleteExpired deletes up to limit tokens that expired before the given
+	// time and returns how many it deleted.
+	DeleteExpired(ctx context.Context, db database.DBTX, before time.Time, limit int) (int64, error)
+}
+
+// EmailVerificationRepository defines email verification token data access methods.
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, db database.DBTX, userID uuid.UUID, tokenHash string, expiresAt time.Time) (*EmailVerificationToken, error)
+	GetByHashForUpdate(ctx context.Context, db database.DBTX, hash string) (*EmailVerificationToken, error)
+	DeleteAllByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) error
+}
+
+// TOTPRepository defines TOTP authenticator data access methods.
+type TOTPRepository interface {
+	GetByUser(ctx context.Context, db database.DBTX, userID uuid.UUID) (*TOTPFactor, error)
+	GetByUserForUpdate(ctx context.Context, db database.DBTX, userID uuid.UUID) (*TOTPFactor, error)
+	// UpsertPending stores a new unconfirmed secret, replacing any other
+	// unconfirmed one. It returns nil if the user has a confirmed factor.
+	UpsertPending(ctx context.Context, db database.DBTX, userID uuid.UUID, secret string) (*TOTPFactor, error)
+	Confirm(ctx context.Context, db database.DBTX, userID uuid.UUID, step int64) error
+	SetLastUsedStep(ctx context.Context, db database.DBTX, userID uuid.UUID, step int64) error
+	Delete(ctx context.Context, db database.DBTX, userID uuid.UUID) error
+}
+
+// EmailSender sends templated emails, such as verification links. The email
+// is queued through db and only sent once that transaction commits.
+type EmailSender interface {
+	Send(ctx context.Context, db database.DBTX, email mailer.Email) error
+}
+
+// OrgJoinPolicy applies organization auto-join rules to a user inside the
+// caller's transaction. It is implemented by the administration domain and
+// injected so that auth does not depend on it.
+type OrgJoinPolicy interface {
+	ApplyJoinPolicy(ctx context.Context, tx database.DBTX, user *User) error
+}
+
+// SessionPolicy caps session lifetimes according to the settings of the orgs
+// a user belongs to. It is implemented by the administration domain and
+// reads through db so callers can see memberships made in their transaction.
+type SessionPolicy interface {
+	// MaxSessionLifetime returns the longest a user's session may last, or 0
+	// for no cap.
+	MaxSessionLifetime(ctx context.Context, db database.DBTX, userID uuid.UUID) (time.Duration, error)
 }
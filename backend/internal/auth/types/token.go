@@ -6,7 +6,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// RefreshToken is one link in a session's chain of rotated refresh tokens.
+// SessionStartedAt is the time of the login that began the chain, and AMR the
+// authentication methods it used.
 type RefreshToken struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           uuid.UUID `json:"userId"`
+	TokenHash        string    `json:"-"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	SessionStartedAt time.Time `json:"sessionStartedAt"`
+	AMR              []string  `json:"amr"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// EmailVerificationToken is a single-use token mailed to a user to prove they
+// own their email address.
+type EmailVerificationToken struct {
 	ID        uuid.UUID `json:"id"`
 	UserID    uuid.UUID `json:"userId"`
 	TokenHash string    `json:"-"`
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/netip"
 	"os"
 	"strconv"
 	"strings"
@@ -14,12 +15,101 @@ type Config struct {
 	Port               string
 	DatabaseURL        string
 	CORSAllowedOrigins []string
-	JWTSecret          string
-	AccessTokenTTL     time.Duration
-	RefreshTokenTTL    time.Duration
-	InviteBaseURL      string
-	InviteTokenTTL     time.Duration
-	BcryptCost         int
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header
+	// is believed when resolving client IPs.
+	TrustedProxies  []netip.Prefix
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	InviteBaseURL   string
+	InviteTokenTTL  time.Duration
+	// InviteLinkBaseURL is the frontend page multi-use invite link tokens
+	// are appended to.
+	InviteLinkBaseURL string
+	// InviteImportInterval is how often bulk invitation imports are picked up.
+	InviteImportInterval time.Duration
+	// InviteExpiryInterval is how often past-due invitations are marked
+	// expired.
+	InviteExpiryInterval time.Duration
+	BcryptCost           int
+
+	EmailVerificationBaseURL string
+	EmailVerificationTTL     time.Duration
+
+	// EmailBackend selects how email is sent: "console" logs it, "smtp"
+	// delivers it through the SMTP server below.
+	EmailBackend string
+	// EmailFrom is the sender address, e.g. "Agenteur <no-reply@agenteur.ai>".
+	EmailFrom    string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPAuth is the auth mechanism: plain, login or cram-md5.
+	SMTPAuth string
+	// SMTPTLS is the TLS mode: starttls, tls (implicit) or none.
+	SMTPTLS         string
+	SMTPTimeout     time.Duration
+	SMTPIdleTimeout time.Duration
+
+	// OutboxDispatchInterval is how often queued side effects, such as
+	// emails, are delivered.
+	OutboxDispatchInterval time.Duration
+	// OutboxMaxAttempts is how many times delivery is tried before a
+	// message is dead-lettered.
+	OutboxMaxAttempts int
+	// OutboxRetention is how long delivered messages are kept.
+	OutboxRetention time.Duration
+
+	// JobWorkers is how many background jobs run at once.
+	JobWorkers int
+	// JobPollInterval is how often idle workers check for due jobs.
+	JobPollInterval time.Duration
+	// JobDrainTimeout is how long shutdown waits for running jobs.
+	JobDrainTimeout time.Duration
+	// JobRetention is how long finished jobs are kept.
+	JobRetention time.Duration
+	// RefreshTokenCleanupInterval is how often expired refresh tokens are
+	// deleted.
+	RefreshTokenCleanupInterval time.Duration
+
+	// SchedulerLocation is the time zone cron schedules are evaluated in,
+	// unless a schedule names its own.
+	SchedulerLocation *time.Location
+	// ScheduleRunRetention is how long schedule run history is kept.
+	ScheduleRunRetention time.Duration
+
+	// AuditStreamInterval is how often new audit events are streamed to the
+	// configured sinks.
+	AuditStreamInterval time.Duration
+	// AuditSyslogAddr is the host:port of a syslog collector that receives
+	// audit events, over AuditSyslogNetwork (udp or tcp). Empty disables it.
+	AuditSyslogAddr    string
+	AuditSyslogNetwork string
+	// AuditWebhookURL receives audit events as signed JSON POSTs. Empty
+	// disables it.
+	AuditWebhookURL    string
+	AuditWebhookSecret string
+	// AuditSigningKey is the base64 Ed25519 seed the daily audit chain
+	// checkpoints are signed with. Empty disables checkpoints.
+	AuditSigningKey string
+
+	// WebhookDisableAfterFailures is how many consecutive failed deliveries
+	// disable an org's webhook endpoint.
+	WebhookDisableAfterFailures int
+	// WebhookDeliveryRetention is how long webhook deliveries and their
+	// attempt logs are kept.
+	WebhookDeliveryRetention time.Duration
+	// WebhookAllowPrivateNetworks allows webhook endpoints on loopback and
+	// private addresses, for local development.
+	WebhookAllowPrivateNetworks bool
+
+	// SCIMBaseURL is the public URL of the SCIM API root, used for resource
+	// locations.
+	SCIMBaseURL string
+
+	OrgDeletionGracePeriod time.Duration
+	OrgPurgeInterval       time.Duration
 }
 
 func Load() *Config {
@@ -36,16 +126,99 @@ func Load() *Config {
 		port = ":8080"
 	}
 	corsAllowedOrigins := parseCSVEnv("CORS_ALLOWED_ORIGINS")
+	trustedProxies := parsePrefixes(parseCSVEnv("TRUSTED_PROXIES"))
 
 	accessTTL := parseDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
 	refreshTTL := parseDuration("REFRESH_TOKEN_TTL", 168*time.Hour)
 	inviteTTL := parseDuration("INVITE_TOKEN_TTL", 72*time.Hour)
+	inviteImportInterval := parseDuration("INVITE_IMPORT_INTERVAL", 5*time.Second)
+	inviteExpiryInterval := parseDuration("INVITE_EXPIRY_INTERVAL", 15*time.Minute)
+	orgDeletionGrace := parseDuration("ORG_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	orgPurgeInterval := parseDuration("ORG_PURGE_INTERVAL", time.Hour)
+	emailVerificationTTL := parseDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	smtpTimeout := parseDuration("SMTP_TIMEOUT", 30*time.Second)
+	smtpIdleTimeout := parseDuration("SMTP_IDLE_TIMEOUT", 30*time.Second)
+	outboxDispatchInterval := parseDuration("OUTBOX_DISPATCH_INTERVAL", 2*time.Second)
+	outboxRetention := parseDuration("OUTBOX_RETENTION", 7*24*time.Hour)
+	jobPollInterval := parseDuration("JOB_POLL_INTERVAL", time.Second)
+	jobDrainTimeout := parseDuration("JOB_DRAIN_TIMEOUT", 30*time.Second)
+	jobRetention := parseDuration("JOB_RETENTION", 7*24*time.Hour)
+	refreshTokenCleanupInterval := parseDuration("REFRESH_TOKEN_CLEANUP_INTERVAL", time.Hour)
+	scheduleRunRetention := parseDuration("SCHEDULE_RUN_RETENTION", 30*24*time.Hour)
+	auditStreamInterval := parseDuration("AUDIT_STREAM_INTERVAL", 10*time.Second)
+	webhookDeliveryRetention := parseDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour)
 
 	inviteBaseURL := os.Getenv("INVITE_BASE_URL")
 	if inviteBaseURL == "" {
 		inviteBaseURL = "http://localhost:5173/invitations"
 	}
 
+	inviteLinkBaseURL := os.Getenv("INVITE_LINK_BASE_URL")
+	if inviteLinkBaseURL == "" {
+		inviteLinkBaseURL = "http://localhost:5173/join"
+	}
+
+	emailVerificationBaseURL := os.Getenv("EMAIL_VERIFICATION_BASE_URL")
+	if emailVerificationBaseURL == "" {
+		emailVerificationBaseURL = "http://localhost:5173/verify-email"
+	}
+
+	emailBackend := os.Getenv("EMAIL_BACKEND")
+	if emailBackend == "" {
+		emailBackend = "console"
+	}
+
+	emailFrom := os.Getenv("EMAIL_FROM")
+	if emailFrom == "" {
+		emailFrom = "Agenteur <no-reply@localhost>"
+	}
+
+	smtpPort := 0
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			smtpPort = n
+		}
+	}
+
+	outboxMaxAttempts := 8
+	if v := os.Getenv("OUTBOX_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			outboxMaxAttempts = n
+		}
+	}
+
+	jobWorkers := 4
+	if v := os.Getenv("JOB_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			jobWorkers = n
+		}
+	}
+
+	schedulerLocation := time.UTC
+	if v := os.Getenv("SCHEDULER_TIMEZONE"); v != "" {
+		if loc, err := time.LoadLocation(v); err == nil {
+			schedulerLocation = loc
+		}
+	}
+
+	auditSyslogNetwork := os.Getenv("AUDIT_SYSLOG_NETWORK")
+	if auditSyslogNetwork == "" {
+		auditSyslogNetwork = "udp"
+	}
+
+	webhookDisableAfter := 20
+	if v := os.Getenv("WEBHOOK_DISABLE_AFTER_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			webhookDisableAfter = n
+		}
+	}
+	webhookAllowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"))
+
+	scimBaseURL := os.Getenv("SCIM_BASE_URL")
+	if scimBaseURL == "" {
+		scimBaseURL = "http://localhost:8080/scim/v2"
+	}
+
 	bcryptCost := 12
 	if v := os.Getenv("BCRYPT_COST"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
@@ -54,16 +227,63 @@ func Load() *Config {
 	}
 
 	return &Config{
-		Env:                env,
-		Port:               port,
-		DatabaseURL:        os.Getenv("DATABASE_URL"),
-		CORSAllowedOrigins: corsAllowedOrigins,
-		JWTSecret:          os.Getenv("JWT_SECRET"),
-		AccessTokenTTL:     accessTTL,
-		RefreshTokenTTL:    refreshTTL,
-		InviteBaseURL:      inviteBaseURL,
-		InviteTokenTTL:     inviteTTL,
-		BcryptCost:         bcryptCost,
+		Env:                  env,
+		Port:                 port,
+		DatabaseURL:          os.Getenv("DATABASE_URL"),
+		CORSAllowedOrigins:   corsAllowedOrigins,
+		TrustedProxies:       trustedProxies,
+		JWTSecret:            os.Getenv("JWT_SECRET"),
+		AccessTokenTTL:       accessTTL,
+		RefreshTokenTTL:      refreshTTL,
+		InviteBaseURL:        inviteBaseURL,
+		InviteTokenTTL:       inviteTTL,
+		InviteLinkBaseURL:    inviteLinkBaseURL,
+		InviteImportInterval: inviteImportInterval,
+		InviteExpiryInterval: inviteExpiryInterval,
+		BcryptCost:           bcryptCost,
+
+		EmailVerificationBaseURL: emailVerificationBaseURL,
+		EmailVerificationTTL:     emailVerificationTTL,
+
+		EmailBackend:    emailBackend,
+		EmailFrom:       emailFrom,
+		SMTPHost:        os.Getenv("SMTP_HOST"),
+		SMTPPort:        smtpPort,
+		SMTPUsername:    os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:    os.Getenv("SMTP_PASSWORD"),
+		SMTPAuth:        os.Getenv("SMTP_AUTH"),
+		SMTPTLS:         os.Getenv("SMTP_TLS"),
+		SMTPTimeout:     smtpTimeout,
+		SMTPIdleTimeout: smtpIdleTimeout,
+
+		OutboxDispatchInterval: outboxDispatchInterval,
+		OutboxMaxAttempts:      outboxMaxAttempts,
+		OutboxRetention:        outboxRetention,
+
+		JobWorkers:                  jobWorkers,
+		JobPollInterval:             jobPollInterval,
+		JobDrainTimeout:             jobDrainTimeout,
+		JobRetention:                jobRetention,
+		RefreshTokenCleanupInterval: refreshTokenCleanupInterval,
+
+		SchedulerLocation:    schedulerLocation,
+		ScheduleRunRetention: scheduleRunRetention,
+
+		AuditStreamInterval: auditStreamInterval,
+		AuditSyslogAddr:     os.Getenv("AUDIT_SYSLOG_ADDR"),
+		AuditSyslogNetwork:  auditSyslogNetwork,
+		AuditWebhookURL:     os.Getenv("AUDIT_WEBHOOK_URL"),
+		AuditWebhookSecret:  os.Getenv("AUDIT_WEBHOOK_SECRET"),
+		AuditSigningKey:     os.Getenv("AUDIT_SIGNING_KEY"),
+
+		WebhookDisableAfterFailures: webhookDisableAfter,
+		WebhookDeliveryRetention:    webhookDeliveryRetention,
+		WebhookAllowPrivateNetworks: webhookAllowPrivate,
+
+		SCIMBaseURL: scimBaseURL,
+
+		OrgDeletionGracePeriod: orgDeletionGrace,
+		OrgPurgeInterval:       orgPurgeInterval,
 	}
 }
 
@@ -101,3 +321,19 @@ func parseCSVEnv(key string) []string {
 
 	return out
 }
+
+// parsePrefixes parses CIDR ranges or bare IP addresses, skipping invalid
+// entries. A bare address becomes a single-address prefix.
+func parsePrefixes(values []string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, v := range values {
+		if p, err := netip.ParsePrefix(v); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		if ip, err := netip.ParseAddr(v); err == nil {
+			out = append(out, netip.PrefixFrom(ip, ip.BitLen()))
+		}
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseCSVEnv(t *testing.T) {
@@ -38,3 +39,118 @@ func TestLoadParsesCORSAllowedOrigins(t *testing.T) {
 		t.Fatalf("CORSAllowedOrigins mismatch: got %v, want %v", cfg.CORSAllowedOrigins, want)
 	}
 }
+
+func TestLoadParsesOrgDeletionSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("ORG_DELETION_GRACE_PERIOD", "48h")
+	t.Setenv("ORG_PURGE_INTERVAL", "")
+
+	cfg := Load()
+
+	if cfg.OrgDeletionGracePeriod != 48*time.Hour {
+		t.Fatalf("OrgDeletionGracePeriod: got %v, want 48h", cfg.OrgDeletionGracePeriod)
+	}
+	if cfg.OrgPurgeInterval != time.Hour {
+		t.Fatalf("OrgPurgeInterval: got %v, want default 1h", cfg.OrgPurgeInterval)
+	}
+}
+
+func TestLoadParsesEmailSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("EMAIL_BACKEND", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "465")
+	t.Setenv("SMTP_TLS", "tls")
+	t.Setenv("SMTP_TIMEOUT", "10s")
+	t.Setenv("EMAIL_FROM", "")
+
+	cfg := Load()
+
+	if cfg.EmailBackend != "smtp" || cfg.SMTPHost != "smtp.example.com" || cfg.SMTPPort != 465 || cfg.SMTPTLS != "tls" {
+		t.Fatalf("SMTP settings: got %+v", cfg)
+	}
+	if cfg.SMTPTimeout != 10*time.Second {
+		t.Fatalf("SMTPTimeout: got %v, want 10s", cfg.SMTPTimeout)
+	}
+	if cfg.EmailFrom == "" {
+		t.Fatal("EmailFrom: want default")
+	}
+}
+
+func TestLoadParsesOutboxSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "0")
+	t.Setenv("OUTBOX_DISPATCH_INTERVAL", "500ms")
+
+	cfg := Load()
+
+	if cfg.OutboxMaxAttempts != 8 {
+		t.Fatalf("OutboxMaxAttempts: got %d, want default 8", cfg.OutboxMaxAttempts)
+	}
+	if cfg.OutboxDispatchInterval != 500*time.Millisecond {
+		t.Fatalf("OutboxDispatchInterval: got %v, want 500ms", cfg.OutboxDispatchInterval)
+	}
+}
+
+func TestLoadParsesJobSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("JOB_WORKERS", "-1")
+	t.Setenv("JOB_DRAIN_TIMEOUT", "1m")
+
+	cfg := Load()
+
+	if cfg.JobWorkers != 4 {
+		t.Fatalf("JobWorkers: got %d, want default 4", cfg.JobWorkers)
+	}
+	if cfg.JobDrainTimeout != time.Minute {
+		t.Fatalf("JobDrainTimeout: got %v, want 1m", cfg.JobDrainTimeout)
+	}
+}
+
+func TestLoadParsesAuditStreamSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("AUDIT_STREAM_INTERVAL", "30s")
+	t.Setenv("AUDIT_SYSLOG_ADDR", "siem.example.com:514")
+	t.Setenv("AUDIT_SYSLOG_NETWORK", "")
+
+	cfg := Load()
+
+	if cfg.AuditStreamInterval != 30*time.Second {
+		t.Fatalf("AuditStreamInterval: got %v, want 30s", cfg.AuditStreamInterval)
+	}
+	if cfg.AuditSyslogAddr != "siem.example.com:514" || cfg.AuditSyslogNetwork != "udp" {
+		t.Fatalf("syslog: got %s://%s, want default udp", cfg.AuditSyslogNetwork, cfg.AuditSyslogAddr)
+	}
+}
+
+func TestLoadParsesWebhookSettings(t *testing.T) {
+	t.Setenv("ENV", "dev")
+	t.Setenv("WEBHOOK_DISABLE_AFTER_FAILURES", "0")
+	t.Setenv("WEBHOOK_DELIVERY_RETENTION", "48h")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "true")
+
+	cfg := Load()
+
+	if cfg.WebhookDisableAfterFailures != 20 {
+		t.Fatalf("WebhookDisableAfterFailures: got %d, want default 20", cfg.WebhookDisableAfterFailures)
+	}
+	if cfg.WebhookDeliveryRetention != 48*time.Hour {
+		t.Fatalf("WebhookDeliveryRetention: got %v, want 48h", cfg.WebhookDeliveryRetention)
+	}
+	if !cfg.WebhookAllowPrivateNetworks {
+		t.Fatal("WebhookAllowPrivateNetworks: got false, want true")
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	got := parsePrefixes([]string{"10.1.2.3/8", "192.168.1.5", "::1", "not-an-ip"})
+	want := []string{"10.0.0.0/8", "192.168.1.5/32", "::1/128"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d prefixes, got %v", len(want), got)
+	}
+	for i, p := range got {
+		if p.String() != want[i] {
+			t.Fatalf("prefix %d: expected %s, got %s", i, want[i], p)
+		}
+	}
+}
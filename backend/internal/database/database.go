@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"sync"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -15,16 +16,55 @@ type DBTX interface {
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
+// commitHooks holds the AfterCommit callbacks of transactions started by
+// WithTx, keyed by pgx.Tx.
+var commitHooks sync.Map
+
+type txHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
 // WithTx runs fn inside a database transaction. If fn returns an error or
-// panics, the transaction is rolled back; otherwise it is committed.
+// panics, the transaction is rolled back; otherwise it is committed and the
+// callbacks registered with AfterCommit run.
 func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
 	tx, err := pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	hooks := &txHooks{}
+	commitHooks.Store(tx, hooks)
+	defer commitHooks.Delete(tx)
 	defer tx.Rollback(ctx) // no-op after commit
 	if err := fn(tx); err != nil {
 		return err
 	}
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	hooks.mu.Lock()
+	fns := hooks.fns
+	hooks.mu.Unlock()
+	for _, f := range fns {
+		f()
+	}
+	return nil
+}
+
+// AfterCommit runs f once the transaction db belongs to commits, and never
+// if it rolls back. When db is the pool, its writes are already committed
+// and f runs straight away; so it does for a transaction not started by
+// WithTx, whose commit can't be observed.
+func AfterCommit(db DBTX, f func()) {
+	if tx, ok := db.(pgx.Tx); ok {
+		if v, ok := commitHooks.Load(tx); ok {
+			hooks := v.(*txHooks)
+			hooks.mu.Lock()
+			hooks.fns = append(hooks.fns, f)
+			hooks.mu.Unlock()
+			return
+		}
+	}
+	f()
 }
@@ -0,0 +1,151 @@
+// Package events is an in-process bus for domain events. Services publish
+// typed events describing what changed, and side effects such as emails and
+// webhooks subscribe to them when the app is wired up, rather than being
+// called from every service method.
+//
+// A handler registered with Subscribe runs synchronously, inside the
+// publisher's transaction: its writes commit or roll back with the change,
+// and its error fails the publish. A handler registered with
+// SubscribeAfterCommit runs on its own goroutine once the transaction has
+// committed; its errors and panics are logged and never reach the publisher
+// or other handlers.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+)
+
+// afterCommitTimeout bounds a single after-commit handler run.
+const afterCommitTimeout = time.Minute
+
+// Event is a domain event. EventName must not depend on the value, as it
+// is called on the zero value to route subscriptions.
+type Event interface {
+	EventName() string
+}
+
+// Publisher publishes events. db is the transaction making the change the
+// event describes.
+type Publisher interface {
+	Publish(ctx context.Context, db database.DBTX, e Event) error
+}
+
+type syncHandler struct {
+	name string
+	fn   func(ctx context.Context, db database.DBTX, e Event) error
+}
+
+type afterCommitHandler struct {
+	name string
+	fn   func(ctx context.Context, e Event) error
+}
+
+// Bus dispatches published events to their subscribers. Subscribe before
+// publishing starts; subscriptions are not safe to add concurrently with
+// Publish.
+type Bus struct {
+	logger      *slog.Logger
+	handlers    map[string][]syncHandler
+	afterCommit map[string][]afterCommitHandler
+	wg          sync.WaitGroup
+}
+
+// New creates a Bus that logs after-commit handler failures to logger.
+func New(logger *slog.Logger) *Bus {
+	return &Bus{
+		logger:      logger,
+		handlers:    make(map[string][]syncHandler),
+		afterCommit: make(map[string][]afterCommitHandler),
+	}
+}
+
+// Subscribe registers fn to run inside the publisher's transaction for every
+// event of type E. name identifies the subscriber in errors.
+func Subscribe[E Event](b *Bus, name string, fn func(ctx context.Context, db database.DBTX, e E) error) {
+	var zero E
+	kind := zero.EventName()
+	b.handlers[kind] = append(b.handlers[kind], syncHandler{
+		name: name,
+		fn: func(ctx context.Context, db database.DBTX, e Event) error {
+			return fn(ctx, db, e.(E))
+		},
+	})
+}
+
+// SubscribeAfterCommit registers fn to run in the background for every event
+// of type E, once the transaction it was published in commits. name
+// identifies the subscriber in logs.
+func SubscribeAfterCommit[E Event](b *Bus, name string, fn func(ctx context.Context, e E) error) {
+	var zero E
+	kind := zero.EventName()
+	b.afterCommit[kind] = append(b.afterCommit[kind], afterCommitHandler{
+		name: name,
+		fn: func(ctx context.Context, e Event) error {
+			return fn(ctx, e.(E))
+		},
+	})
+}
+
+// Publish runs e's synchronous handlers in order through db, stopping at the
+// first error, and schedules its after-commit handlers.
+func (b *Bus) Publish(ctx context.Context, db database.DBTX, e Event) error {
+	kind := e.EventName()
+	for _, h := range b.handlers[kind] {
+		if err := h.fn(ctx, db, e); err != nil {
+			return fmt.Errorf("%s handler %s: %w", kind, h.name, err)
+		}
+	}
+
+	handlers := b.afterCommit[kind]
+	if len(handlers) == 0 {
+		return nil
+	}
+	// The request may be over by the time the handlers run; keep its values
+	// for logging but not its deadline.
+	ctx = context.WithoutCancel(ctx)
+	database.AfterCommit(db, func() {
+		for _, h := range handlers {
+			b.wg.Add(1)
+			go b.runAfterCommit(ctx, h, e)
+		}
+	})
+	return nil
+}
+
+func (b *Bus) runAfterCommit(ctx context.Context, h afterCommitHandler, e Event) {
+	defer b.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event handler panicked", "event", e.EventName(), "handler", h.name, "panic", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, afterCommitTimeout)
+	defer cancel()
+	if err := h.fn(ctx, e); err != nil {
+		b.logger.Error("event handler failed", "event", e.EventName(), "handler", h.name, "error", err)
+	}
+}
+
+// Shutdown waits for running after-commit handlers to finish, or for ctx to
+// be done.
+func (b *Bus) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
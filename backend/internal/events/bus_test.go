@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockedBuffer collects log output written from handler goroutines.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
+
+func TestPublishRunsSyncHandlersInOrderAndStopsAtError(t *testing.T) {
+	b := New(slog.New(slog.DiscardHandler))
+	var calls []string
+	Subscribe(b, "first", func(_ context.Context, _ database.DBTX, e OrgCreated) error {
+		calls = append(calls, "first:"+e.Name)
+		return nil
+	})
+	boom := errors.New("boom")
+	Subscribe(b, "second", func(_ context.Context, _ database.DBTX, _ OrgCreated) error {
+		calls = append(calls, "second")
+		return boom
+	})
+	Subscribe(b, "third", func(_ context.Context, _ database.DBTX, _ OrgCreated) error {
+		calls = append(calls, "third")
+		return nil
+	})
+	Subscribe(b, "other", func(_ context.Context, _ database.DBTX, _ OrgDeleted) error {
+		calls = append(calls, "other")
+		return nil
+	})
+
+	err := b.Publish(context.Background(), nil, OrgCreated{Name: "Acme"})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if !strings.Contains(err.Error(), "second") {
+		t.Errorf("err = %q, want the failing handler named", err)
+	}
+	if strings.Join(calls, ",") != "first:Acme,second" {
+		t.Errorf("calls = %v", calls)
+	}
+}
+
+func TestAfterCommitHandlerFailuresAreIsolated(t *testing.T) {
+	var logs lockedBuffer
+	b := New(slog.New(slog.NewTextHandler(&logs, nil)))
+	var ran atomic.Int32
+	SubscribeAfterCommit(b, "fails", func(context.Context, UserSignedUp) error {
+		return errors.New("smtp down")
+	})
+	SubscribeAfterCommit(b, "panics", func(context.Context, UserSignedUp) error {
+		panic("nil map")
+	})
+	SubscribeAfterCommit(b, "works", func(_ context.Context, e UserSignedUp) error {
+		if e.Email == "a@example.com" {
+			ran.Add(1)
+		}
+		return nil
+	})
+
+	// Outside a transaction the handlers are scheduled straight away.
+	if err := b.Publish(context.Background(), nil, UserSignedUp{Email: "a@example.com"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if ran.Load() != 1 {
+		t.Errorf("working handler ran %d times, want 1", ran.Load())
+	}
+	out := logs.String()
+	for _, want := range []string{"handler=fails", "smtp down", "handler=panics", "nil map"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// These tests need a migrated Postgres database and are skipped unless
+// TEST_DATABASE_URL is set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestAfterCommitHandlersRunOnlyOnCommit(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	b := New(slog.New(slog.DiscardHandler))
+	var mu sync.Mutex
+	var seen []uuid.UUID
+	SubscribeAfterCommit(b, "record", func(_ context.Context, e OrgCreated) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, e.OrgID)
+		return nil
+	})
+
+	committed, rolledBack := uuid.New(), uuid.New()
+	err := database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		if err := b.Publish(ctx, tx, OrgCreated{OrgID: committed}); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(seen) != 0 {
+			t.Error("handler ran before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rollback := errors.New("rollback")
+	err = database.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		if err := b.Publish(ctx, tx, OrgCreated{OrgID: rolledBack}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("err = %v", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := b.Shutdown(shutdownCtx); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 1 || seen[0] != committed {
+		t.Errorf("seen = %v, want only %v", seen, committed)
+	}
+}
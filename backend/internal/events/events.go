@@ -0,0 +1,111 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How a member joined or left an organization.
+const (
+	ViaInvitation  = "invitation"
+	ViaInviteLink  = "invite_link"
+	ViaDomain      = "domain"
+	ViaJoinRequest = "join_request"
+	ViaSCIM        = "scim"
+	ViaRemoved     = "removed"
+	ViaLeft        = "left"
+)
+
+// UserSignedUp is published when an account is created. Verified is true
+// when the email address was proven another way, as by an invitation.
+type UserSignedUp struct {
+	UserID   uuid.UUID
+	Email    string
+	Verified bool
+}
+
+func (UserSignedUp) EventName() string { return "user.signed_up" }
+
+// OrgCreated is published when a user creates an organization, of which
+// they are the first admin.
+type OrgCreated struct {
+	OrgID     uuid.UUID
+	Name      string
+	Slug      string
+	CreatedBy uuid.UUID
+}
+
+func (OrgCreated) EventName() string { return "org.created" }
+
+// OrgUpdated is published when an organization's name or slug changes.
+type OrgUpdated struct {
+	OrgID uuid.UUID
+	Name  string
+	Slug  string
+}
+
+func (OrgUpdated) EventName() string { return "org.updated" }
+
+// OrgDeleted is published when an organization is soft-deleted. It can be
+// restored until RestoreBy.
+type OrgDeleted struct {
+	OrgID     uuid.UUID
+	Name      string
+	RestoreBy time.Time
+}
+
+func (OrgDeleted) EventName() string { return "org.deleted" }
+
+// MemberJoined is published when a user becomes a member of an
+// organization; Via is ViaInvitation, ViaInviteLink, ViaDomain,
+// ViaJoinRequest or ViaSCIM.
+type MemberJoined struct {
+	OrgID  uuid.UUID
+	UserID uuid.UUID
+	Role   string
+	Via    string
+}
+
+func (MemberJoined) EventName() string { return "member.joined" }
+
+// MemberRemoved is published when a membership ends; Via is ViaRemoved,
+// ViaLeft or ViaSCIM.
+type MemberRemoved struct {
+	OrgID  uuid.UUID
+	UserID uuid.UUID
+	Role   string
+	Via    string
+}
+
+func (MemberRemoved) EventName() string { return "member.removed" }
+
+// InvitationSent is published when an invitation is created or resent.
+// InviteURL carries the invitation's token, so it must not be logged. The
+// only stored copy is in the invitation email's outbox message, whose
+// payload is never exposed. That message is purged once the outbox retention
+// period has passed after delivery, and the token stops working once the
+// invitation expires, is resent or is revoked.
+type InvitationSent struct {
+	InvitationID uuid.UUID
+	OrgID        uuid.UUID
+	Email        string
+	Role         string
+	ExpiresAt    time.Time
+	InviterName  string
+	OrgName      string
+	InviteURL    string
+}
+
+func (InvitationSent) EventName() string { return "invitation.sent" }
+
+// InvitationAccepted is published when a user accepts an invitation.
+type InvitationAccepted struct {
+	InvitationID uuid.UUID
+	OrgID        uuid.UUID
+	Email        string
+	Role         string
+	UserID       uuid.UUID
+}
+
+func (InvitationAccepted) EventName() string { return "invitation.accepted" }
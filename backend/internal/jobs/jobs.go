@@ -0,0 +1,171 @@
+// Package jobs runs background work from a Postgres-backed queue. Jobs are
+// enqueued with a kind and a JSON payload, optionally scheduled for later,
+// prioritized or deduplicated by a unique key. A pool of workers claims due
+// jobs with FOR UPDATE SKIP LOCKED, so any number of API instances can share
+// the queue, and retries failures with exponential backoff.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	// DefaultMaxAttempts is used when EnqueueParams.MaxAttempts is zero.
+	DefaultMaxAttempts = 5
+	// jobTimeout bounds a single run of a job.
+	jobTimeout = 5 * time.Minute
+	// claimLease is how long a claimed job is locked. It outlasts jobTimeout
+	// so a job is only claimed again if its worker died.
+	claimLease = jobTimeout + time.Minute
+	// Retry delays start at backoffBase and double per attempt up to
+	// backoffMax.
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Job is a unit of background work.
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"-"`
+	Priority    int             `json:"priority"`
+	Status      string          `json:"status"`
+	UniqueKey   *string         `json:"uniqueKey"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"`
+	LastError   *string         `json:"lastError"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	FinishedAt  *time.Time      `json:"finishedAt"`
+}
+
+// EnqueueParams describes a job to enqueue.
+type EnqueueParams struct {
+	Kind    string
+	Payload any
+	// Priority orders due jobs; higher runs first.
+	Priority int
+	// RunAt delays the job; the zero value runs it as soon as possible.
+	RunAt time.Time
+	// UniqueKey, if set, skips enqueuing while another job with the same key
+	// is queued or running.
+	UniqueKey   string
+	MaxAttempts int
+}
+
+// KindStats summarizes the jobs of one kind.
+type KindStats struct {
+	Kind      string `json:"kind"`
+	Queued    int    `json:"queued"`
+	Running   int    `json:"running"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	// OldestDueAt is when the longest-waiting due job became due, showing
+	// how far behind the workers are.
+	OldestDueAt *time.Time `json:"oldestDueAt"`
+}
+
+// Handler runs one job's payload. Returning an error retries the job later
+// until it runs out of attempts.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Queue enqueues jobs and runs them on a pool of workers.
+type Queue struct {
+	pool         *pgxpool.Pool
+	repo         *repository
+	workers      int
+	pollInterval time.Duration
+	handlers     map[string]Handler
+	now          func() time.Time
+
+	stop       chan struct{}
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// New creates a Queue run by the given number of workers, each polling for
+// due jobs every pollInterval while the queue is idle.
+func New(pool *pgxpool.Pool, workers int, pollInterval time.Duration) *Queue {
+	return &Queue{
+		pool:         pool,
+		repo:         &repository{},
+		workers:      workers,
+		pollInterval: pollInterval,
+		handlers:     make(map[string]Handler),
+		now:          time.Now,
+	}
+}
+
+// Handle registers fn to run jobs of the given kind, decoding their payloads
+// as T. It must be called before Start.
+func Handle[T any](q *Queue, kind string, fn func(ctx context.Context, payload T) error) {
+	q.handlers[kind] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("decode %s payload: %w", kind, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Enqueue adds a job through db, so a job enqueued in a transaction only
+// runs if it commits. It reports false if the job was skipped because its
+// unique key is taken.
+func (q *Queue) Enqueue(ctx context.Context, db database.DBTX, params EnqueueParams) (bool, error) {
+	payload, err := json.Marshal(params.Payload)
+	if err != nil {
+		return false, fmt.Errorf("encode %s payload: %w", params.Kind, err)
+	}
+	if params.RunAt.IsZero() {
+		params.RunAt = q.now()
+	}
+	if params.MaxAttempts == 0 {
+		params.MaxAttempts = DefaultMaxAttempts
+	}
+	return q.repo.Insert(ctx, db, params, payload)
+}
+
+// Stats returns job counts per kind.
+func (q *Queue) Stats(ctx context.Context) ([]KindStats, error) {
+	return q.repo.Stats(ctx, q.pool)
+}
+
+// Workers returns the size of the worker pool.
+func (q *Queue) Workers() int {
+	return q.workers
+}
+
+// PurgeFinished deletes jobs that finished before the given time and returns
+// how many it removed.
+func (q *Queue) PurgeFinished(ctx context.Context, before time.Time) (int64, error) {
+	return q.repo.DeleteFinished(ctx, q.pool, before)
+}
+
+// backoff returns the delay before retrying after the given number of
+// attempts, with up to 20% jitter so failures don't retry in lockstep.
+func backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts && d < backoffMax; i++ {
+		d *= 2
+	}
+	d = min(d, backoffMax)
+	return d + time.Duration(rand.Int64N(int64(d)/5+1))
+}
@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{40, time.Hour},
+	}
+	for _, c := range cases {
+		for range 20 {
+			got := backoff(c.attempts)
+			if got < c.want || got > c.want+c.want/5 {
+				t.Fatalf("backoff(%d) = %v, want %v plus up to 20%%", c.attempts, got, c.want)
+			}
+		}
+	}
+}
+
+func TestHandleDecodesPayload(t *testing.T) {
+	q := New(nil, 1, time.Second)
+	type payload struct{ OrgID string }
+	var got payload
+	Handle(q, "typed", func(_ context.Context, p payload) error {
+		got = p
+		return nil
+	})
+
+	if err := q.handlers["typed"](context.Background(), json.RawMessage(`{"OrgID":"abc"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got.OrgID != "abc" {
+		t.Errorf("payload = %+v", got)
+	}
+	if err := q.handlers["typed"](context.Background(), json.RawMessage(`[1]`)); err == nil {
+		t.Error("expected a decode error")
+	}
+}
+
+// These tests need a migrated Postgres database and are skipped unless
+// TEST_DATABASE_URL is set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// testKind returns a kind unique to the test, so workers only claim the
+// test's own jobs.
+func testKind(t *testing.T, pool *pgxpool.Pool) string {
+	kind := "test-" + uuid.NewString()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM jobs WHERE kind = $1`, kind)
+	})
+	return kind
+}
+
+func jobsOfKind(t *testing.T, q *Queue, kind string) []*Job {
+	t.Helper()
+	rows, err := q.pool.Query(context.Background(), `SELECT `+jobColumns+` FROM jobs WHERE kind = $1 ORDER BY created_at`, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []*Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, j)
+	}
+	return out
+}
+
+func TestEnqueueUniqueKey(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := New(pool, 1, time.Second)
+	kind := testKind(t, pool)
+	key := kind + "-key"
+	Handle(q, kind, func(context.Context, struct{}) error { return nil })
+
+	for i, want := range []bool{true, false} {
+		ok, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind, UniqueKey: key})
+		if err != nil || ok != want {
+			t.Fatalf("enqueue %d = %v, %v; want %v", i, ok, err, want)
+		}
+	}
+
+	// Once the job has run, the key is free again.
+	if ran, err := q.runNext(ctx, []string{kind}); !ran || err != nil {
+		t.Fatalf("runNext = %v, %v", ran, err)
+	}
+	if ok, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind, UniqueKey: key}); !ok || err != nil {
+		t.Fatalf("enqueue after run = %v, %v", ok, err)
+	}
+}
+
+func TestRunNextOrdersByPriorityAndRunAt(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := New(pool, 1, time.Second)
+	kind := testKind(t, pool)
+	var order []string
+	Handle(q, kind, func(_ context.Context, name string) error {
+		order = append(order, name)
+		return nil
+	})
+
+	enqueue := func(name string, priority int, runAt time.Time) {
+		if _, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind, Payload: name, Priority: priority, RunAt: runAt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	enqueue("low", 0, now.Add(-time.Minute))
+	enqueue("high", 10, now)
+	enqueue("later", 100, now.Add(time.Hour))
+
+	for {
+		ran, err := q.runNext(ctx, []string{kind})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ran {
+			break
+		}
+	}
+	if len(order) != 2 || order[0] != "high" || order[1] != "low" {
+		t.Errorf("ran %v, want [high low] with the scheduled job left", order)
+	}
+}
+
+func TestRunNextRetriesThenFails(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := New(pool, 1, time.Second)
+	kind := testKind(t, pool)
+	Handle(q, kind, func(context.Context, struct{}) error { return errors.New("boom") })
+	if _, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind, MaxAttempts: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.runNext(ctx, []string{kind}); err != nil {
+		t.Fatal(err)
+	}
+	job := jobsOfKind(t, q, kind)[0]
+	if job.Status != StatusQueued || job.Attempts != 1 || job.LastError == nil || !job.RunAt.After(time.Now().Add(5*time.Second)) {
+		t.Fatalf("after first failure = %+v, want queued with backoff", job)
+	}
+
+	pool.Exec(ctx, `UPDATE jobs SET run_at = NOW() WHERE id = $1`, job.ID)
+	if _, err := q.runNext(ctx, []string{kind}); err != nil {
+		t.Fatal(err)
+	}
+	if job = jobsOfKind(t, q, kind)[0]; job.Status != StatusFailed || job.FinishedAt == nil {
+		t.Fatalf("after last attempt = %+v, want failed", job)
+	}
+
+	stats, err := q.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stats {
+		if s.Kind == kind && s.Failed != 1 {
+			t.Errorf("stats = %+v, want one failed", s)
+		}
+	}
+}
+
+func TestShutdownDrainsRunningJobs(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := New(pool, 2, 10*time.Millisecond)
+	kind := testKind(t, pool)
+	started := make(chan struct{}, 1)
+	var finished atomic.Int32
+	Handle(q, kind, func(context.Context, struct{}) error {
+		started <- struct{}{}
+		time.Sleep(100 * time.Millisecond)
+		finished.Add(1)
+		return nil
+	})
+	if _, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind}); err != nil {
+		t.Fatal(err)
+	}
+
+	q.Start()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job never started")
+	}
+	drainCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := q.Shutdown(drainCtx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if finished.Load() != 1 {
+		t.Fatal("Shutdown returned before the running job finished")
+	}
+	if job := jobsOfKind(t, q, kind)[0]; job.Status != StatusSucceeded {
+		t.Errorf("job = %+v, want succeeded", job)
+	}
+}
+
+func TestRunNextRecordsHandlerPanic(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := New(pool, 1, time.Second)
+	kind := testKind(t, pool)
+	Handle(q, kind, func(context.Context, struct{}) error { panic("boom") })
+	if _, err := q.Enqueue(ctx, pool, EnqueueParams{Kind: kind, MaxAttempts: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.runNext(ctx, []string{kind}); err != nil {
+		t.Fatal(err)
+	}
+	job := jobsOfKind(t, q, kind)[0]
+	if job.Status != StatusFailed || job.LastError == nil || *job.LastError != "handler panicked: boom" {
+		t.Fatalf("job = %+v, want failed with the panic recorded", job)
+	}
+}
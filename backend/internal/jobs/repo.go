@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const jobColumns = `id, kind, payload, priority, status, unique_key, attempts, max_attempts, run_at, last_error, created_at, updated_at, finished_at`
+
+type repository struct{}
+
+func scanJob(row pgx.Row) (*Job, error) {
+	var j Job
+	err := row.Scan(&j.ID, &j.Kind, &j.Payload, &j.Priority, &j.Status, &j.UniqueKey, &j.Attempts, &j.MaxAttempts,
+		&j.RunAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Insert adds a job. It returns false if a queued or running job already
+// holds its unique key.
+func (r *repository) Insert(ctx context.Context, db database.DBTX, params EnqueueParams, payload []byte) (bool, error) {
+	var uniqueKey *string
+	if params.UniqueKey != "" {
+		uniqueKey = &params.UniqueKey
+	}
+	tag, err := db.Exec(ctx,
+		`INSERT INTO jobs (kind, payload, priority, run_at, unique_key, max_attempts)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (unique_key) WHERE status IN ('queued', 'running') DO NOTHING`,
+		params.Kind, payload, params.Priority, params.RunAt, uniqueKey, params.MaxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("insert job: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, db database.DBTX, id uuid.UUID) (*Job, error) {
+	j, err := scanJob(db.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	return j, nil
+}
+
+// ClaimNext marks the most urgent due job of the given kinds as running,
+// counts an attempt and locks it until lockedUntil. Running jobs whose lock
+// has lapsed are claimed too. It returns nil if there is none.
+func (r *repository) ClaimNext(ctx context.Context, db database.DBTX, kinds []string, lockedUntil time.Time) (*Job, error) {
+	j, err := scanJob(db.QueryRow(ctx,
+		`UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = $1, updated_at = NOW()
+		 WHERE id = (
+		     SELECT id FROM jobs
+		     WHERE kind = ANY($2)
+		       AND ((status = 'queued' AND run_at <= NOW()) OR (status = 'running' AND locked_until < NOW()))
+		     ORDER BY priority DESC, run_at
+		     LIMIT 1
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+jobColumns, lockedUntil, kinds))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *repository) MarkSucceeded(ctx context.Context, db database.DBTX, id uuid.UUID) error {
+	_, err := db.Exec(ctx,
+		`UPDATE jobs SET status = 'succeeded', finished_at = NOW(), locked_until = NULL, last_error = NULL, updated_at = NOW()
+		 WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark job succeeded: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed run. With retryAt the job is queued again for
+// then; without it the job has failed for good.
+func (r *repository) MarkFailed(ctx context.Context, db database.DBTX, id uuid.UUID, errMsg string, retryAt *time.Time) error {
+	var err error
+	if retryAt != nil {
+		_, err = db.Exec(ctx,
+			`UPDATE jobs SET status = 'queued', run_at = $3, last_error = $2, locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`, id, errMsg, *retryAt)
+	} else {
+		_, err = db.Exec(ctx,
+			`UPDATE jobs SET status = 'failed', finished_at = NOW(), last_error = $2, locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`, id, errMsg)
+	}
+	if err != nil {
+		return fmt.Errorf("mark job failed: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Stats(ctx context.Context, db database.DBTX) ([]KindStats, error) {
+	rows, err := db.Query(ctx,
+		`SELECT kind,
+		        COUNT(*) FILTER (WHERE status = 'queued'),
+		        COUNT(*) FILTER (WHERE status = 'running'),
+		        COUNT(*) FILTER (WHERE status = 'succeeded'),
+		        COUNT(*) FILTER (WHERE status = 'failed'),
+		        MIN(run_at) FILTER (WHERE status = 'queued' AND run_at <= NOW())
+		 FROM jobs
+		 GROUP BY kind
+		 ORDER BY kind`)
+	if err != nil {
+		return nil, fmt.Errorf("get job stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []KindStats{}
+	for rows.Next() {
+		var s KindStats
+		if err := rows.Scan(&s.Kind, &s.Queued, &s.Running, &s.Succeeded, &s.Failed, &s.OldestDueAt); err != nil {
+			return nil, fmt.Errorf("scan job stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func (r *repository) DeleteFinished(ctx context.Context, db database.DBTX, before time.Time) (int64, error) {
+	tag, err := db.Exec(ctx,
+		`DELETE FROM jobs WHERE status IN ('succeeded', 'failed') AND finished_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,119 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Start launches the workers. Jobs of kinds without a handler are left for
+// instances that have one.
+func (q *Queue) Start() {
+	q.stop = make(chan struct{})
+	var jobCtx context.Context
+	jobCtx, q.cancelJobs = context.WithCancel(context.Background())
+
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	for range q.workers {
+		q.wg.Add(1)
+		go q.work(jobCtx, kinds)
+	}
+}
+
+// Shutdown stops claiming jobs and waits for running ones to finish. If ctx
+// ends first, running jobs are cancelled; they are claimed again once their
+// lock lapses. Shutdown returns after every worker has exited.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	if q.stop == nil {
+		return nil
+	}
+	close(q.stop)
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		q.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work(ctx context.Context, kinds []string) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		ran, err := q.runNext(ctx, kinds)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("run job", "error", err)
+		}
+		if ran {
+			continue
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// runNext claims and runs the most urgent due job of the given kinds. It
+// reports whether there was one; the error is for claiming the job or
+// recording its outcome, not for the job failing.
+func (q *Queue) runNext(ctx context.Context, kinds []string) (bool, error) {
+	if len(kinds) == 0 {
+		return false, nil
+	}
+	job, err := q.repo.ClaimNext(ctx, q.pool, kinds, q.now().Add(claimLease))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	start := time.Now()
+	err = q.handle(runCtx, job)
+	cancel()
+	if ctx.Err() != nil {
+		// Shutting down; the job is claimed again once its lock lapses.
+		return true, ctx.Err()
+	}
+
+	if err == nil {
+		return true, q.repo.MarkSucceeded(ctx, q.pool, job.ID)
+	}
+	slog.Warn("job failed", "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts,
+		"duration", time.Since(start), "error", err)
+	if job.Attempts >= job.MaxAttempts {
+		return true, q.repo.MarkFailed(ctx, q.pool, job.ID, err.Error(), nil)
+	}
+	retryAt := q.now().Add(backoff(job.Attempts))
+	return true, q.repo.MarkFailed(ctx, q.pool, job.ID, err.Error(), &retryAt)
+}
+
+// handle runs job's handler. A panic counts as a failed attempt rather than
+// killing the worker.
+func (q *Queue) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return q.handlers[job.Kind](ctx, job.Payload)
+}
@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ConsoleTransport logs messages instead of sending them. It is meant for
+// local development, where the links in emails are copied from the log.
+type ConsoleTransport struct{}
+
+func NewConsoleTransport() *ConsoleTransport {
+	return &ConsoleTransport{}
+}
+
+func (t *ConsoleTransport) Send(_ context.Context, msg *Message) error {
+	slog.Info("email",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"body", msg.Text,
+	)
+	return nil
+}
+
+func (t *ConsoleTransport) Close() error {
+	return nil
+}
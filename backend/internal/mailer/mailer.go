@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Email is a templated email to one recipient.
+type Email struct {
+	To       string
+	Template string
+	// Locale picks the translation; see ResolveLocale.
+	Locale   string
+	Branding Branding
+	// Data is the template's data, e.g. InvitationData for
+	// TemplateInvitation.
+	Data any
+}
+
+// emailJSON is the JSON form of an Email.
+type emailJSON struct {
+	To       string          `json:"to"`
+	Template string          `json:"template"`
+	Locale   string          `json:"locale,omitempty"`
+	Branding Branding        `json:"branding"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func (e Email) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(emailJSON{To: e.To, Template: e.Template, Locale: e.Locale, Branding: e.Branding, Data: data})
+}
+
+// UnmarshalJSON decodes an Email, decoding Data into the template's data
+// type so it can be rendered.
+func (e *Email) UnmarshalJSON(b []byte) error {
+	var j emailJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	sample, ok := templateSamples[j.Template]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTemplate, j.Template)
+	}
+	data := reflect.New(reflect.TypeOf(sample))
+	if err := json.Unmarshal(j.Data, data.Interface()); err != nil {
+		return fmt.Errorf("%w: %v", ErrTemplateData, err)
+	}
+	*e = Email{To: j.To, Template: j.Template, Locale: j.Locale, Branding: j.Branding, Data: data.Elem().Interface()}
+	return nil
+}
+
+// Mailer renders templated emails and hands them to a Transport.
+type Mailer struct {
+	transport Transport
+	templates *Templates
+	from      string
+}
+
+// NewMailer creates a Mailer that sends from the given address, e.g.
+// "Agenteur <no-reply@agenteur.ai>".
+func NewMailer(transport Transport, templates *Templates, from string) *Mailer {
+	return &Mailer{transport: transport, templates: templates, from: from}
+}
+
+func (m *Mailer) Send(ctx context.Context, email Email) error {
+	rendered, err := m.templates.Render(email.Template, email.Locale, email.Branding, email.Data)
+	if err != nil {
+		return err
+	}
+	if err := m.transport.Send(ctx, &Message{
+		From:    m.from,
+		To:      []string{email.To},
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	}); err != nil {
+		return fmt.Errorf("send %s email: %w", email.Template, err)
+	}
+	return nil
+}
@@ -0,0 +1,455 @@
+// Package mailertest provides an in-process SMTP server for tests. It
+// supports STARTTLS, implicit TLS and AUTH PLAIN and LOGIN, and captures the
+// messages it receives instead of delivering them.
+package mailertest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Options configures a Server.
+type Options struct {
+	// ImplicitTLS makes the server speak TLS from the first byte instead of
+	// offering STARTTLS.
+	ImplicitTLS bool
+	// Username and Password, when set, are required before MAIL.
+	Username string
+	Password string
+}
+
+// Message is a message the server accepted.
+type Message struct {
+	From string
+	To   []string
+	// Data is the message as sent after DATA, with dot-stuffing removed and
+	// line endings normalized to LF.
+	Data []byte
+}
+
+// Parse parses the message's headers and body.
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(m.Data)))
+}
+
+// Part returns the decoded body of the message's part with the given media
+// type, e.g. "text/plain", whether the message is multipart or not.
+func (m Message) Part(mediaType string) (string, error) {
+	msg, err := m.Parse()
+	if err != nil {
+		return "", err
+	}
+	return findPart(textproto.MIMEHeader(msg.Header), msg.Body, mediaType)
+}
+
+func findPart(header textproto.MIMEHeader, body io.Reader, want string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", fmt.Errorf("no %s part", want)
+			}
+			if err != nil {
+				return "", err
+			}
+			if found, err := findPart(part.Header, part, want); err == nil {
+				return found, nil
+			}
+		}
+	}
+	if mediaType != want {
+		return "", fmt.Errorf("no %s part", want)
+	}
+	if strings.EqualFold(header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	}
+	b, err := io.ReadAll(body)
+	return string(b), err
+}
+
+// Server is a fake SMTP server listening on a loopback port.
+type Server struct {
+	opts      Options
+	ln        net.Listener
+	tlsConfig *tls.Config
+	roots     *x509.CertPool
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	conns    int
+	open     map[net.Conn]struct{}
+}
+
+// NewServer starts a server that is shut down when the test ends.
+func NewServer(t testing.TB, opts Options) *Server {
+	t.Helper()
+	cert, roots, err := selfSignedCert()
+	if err != nil {
+		t.Fatalf("mailertest: generate certificate: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mailertest: listen: %v", err)
+	}
+	s := &Server{
+		opts:      opts,
+		ln:        ln,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		roots:     roots,
+		open:      make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Host returns the host the server listens on.
+func (s *Server) Host() string {
+	return s.ln.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// ClientTLSConfig returns a TLS config that trusts the server's certificate.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: s.roots}
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Message, len(s.messages))
+	copy(out, s.messages)
+	return out
+}
+
+// Connections returns how many connections the server has accepted.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// DropConnections closes every open connection, as a server timing out idle
+// clients would.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.open {
+		conn.Close()
+	}
+}
+
+// Close stops the server and waits for its connections to finish.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.DropConnections()
+	s.wg.Wait()
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.open[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.open, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.serve(conn)
+		}()
+	}
+}
+
+// session is the state of one SMTP conversation.
+type session struct {
+	s      *Server
+	conn   net.Conn
+	tp     *textproto.Conn
+	tls    bool
+	authed bool
+	from   string
+	to     []string
+}
+
+func (s *Server) serve(conn net.Conn) {
+	sess := &session{s: s, conn: conn, tls: s.opts.ImplicitTLS}
+	if s.opts.ImplicitTLS {
+		tlsConn := tls.Server(conn, s.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		sess.conn = tlsConn
+	}
+	sess.tp = textproto.NewConn(sess.conn)
+	sess.reply(220, "mailertest ESMTP ready")
+
+	for {
+		line, err := sess.tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		if !sess.handle(strings.ToUpper(verb), arg) {
+			return
+		}
+	}
+}
+
+// handle runs one command and reports whether the session continues.
+func (sess *session) handle(verb, arg string) bool {
+	opts := sess.s.opts
+	switch verb {
+	case "EHLO":
+		lines := []string{"mailertest"}
+		if !sess.tls {
+			lines = append(lines, "STARTTLS")
+		}
+		if opts.Username != "" {
+			lines = append(lines, "AUTH PLAIN LOGIN")
+		}
+		lines = append(lines, "8BITMIME")
+		for i, l := range lines {
+			sep := "-"
+			if i == len(lines)-1 {
+				sep = " "
+			}
+			sess.tp.PrintfLine("250%s%s", sep, l)
+		}
+	case "HELO":
+		sess.reply(250, "mailertest")
+	case "STARTTLS":
+		if sess.tls {
+			sess.reply(503, "already using TLS")
+			return true
+		}
+		sess.reply(220, "ready to start TLS")
+		tlsConn := tls.Server(sess.conn, sess.s.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return false
+		}
+		sess.conn, sess.tls = tlsConn, true
+		sess.tp = textproto.NewConn(tlsConn)
+		sess.reset()
+	case "AUTH":
+		return sess.auth(arg)
+	case "MAIL":
+		if opts.Username != "" && !sess.authed {
+			sess.reply(530, "authentication required")
+			return true
+		}
+		addr, ok := pathArg(arg, "FROM:")
+		if !ok {
+			sess.reply(501, "syntax: MAIL FROM:<address>")
+			return true
+		}
+		sess.from = addr
+		sess.reply(250, "ok")
+	case "RCPT":
+		if sess.from == "" {
+			sess.reply(503, "need MAIL first")
+			return true
+		}
+		addr, ok := pathArg(arg, "TO:")
+		if !ok {
+			sess.reply(501, "syntax: RCPT TO:<address>")
+			return true
+		}
+		sess.to = append(sess.to, addr)
+		sess.reply(250, "ok")
+	case "DATA":
+		if len(sess.to) == 0 {
+			sess.reply(503, "need RCPT first")
+			return true
+		}
+		sess.reply(354, "end data with <CR><LF>.<CR><LF>")
+		data, err := sess.tp.ReadDotBytes()
+		if err != nil {
+			return false
+		}
+		sess.s.mu.Lock()
+		sess.s.messages = append(sess.s.messages, Message{From: sess.from, To: sess.to, Data: data})
+		sess.s.mu.Unlock()
+		sess.reset()
+		sess.reply(250, "queued")
+	case "RSET":
+		sess.reset()
+		sess.reply(250, "ok")
+	case "NOOP":
+		sess.reply(250, "ok")
+	case "QUIT":
+		sess.reply(221, "bye")
+		return false
+	default:
+		sess.reply(502, "command not implemented")
+	}
+	return true
+}
+
+func (sess *session) auth(arg string) bool {
+	opts := sess.s.opts
+	if opts.Username == "" || sess.authed {
+		sess.reply(503, "AUTH not allowed")
+		return true
+	}
+	mech, initial, _ := strings.Cut(arg, " ")
+
+	var username, password string
+	switch strings.ToUpper(mech) {
+	case "PLAIN":
+		if initial == "" {
+			resp, ok := sess.challenge("")
+			if !ok {
+				return false
+			}
+			initial = resp
+		}
+		raw, err := base64.StdEncoding.DecodeString(initial)
+		if err != nil {
+			sess.reply(501, "invalid base64")
+			return true
+		}
+		parts := strings.Split(string(raw), "\x00")
+		if len(parts) != 3 {
+			sess.reply(501, "invalid PLAIN response")
+			return true
+		}
+		username, password = parts[1], parts[2]
+	case "LOGIN":
+		var ok bool
+		if username, ok = sess.challengeDecoded("Username:"); !ok {
+			return false
+		}
+		if password, ok = sess.challengeDecoded("Password:"); !ok {
+			return false
+		}
+	default:
+		sess.reply(504, "mechanism not supported")
+		return true
+	}
+
+	if username != opts.Username || password != opts.Password {
+		sess.reply(535, "authentication failed")
+		return true
+	}
+	sess.authed = true
+	sess.reply(235, "authentication successful")
+	return true
+}
+
+// challenge sends a 334 challenge and returns the client's raw response.
+func (sess *session) challenge(prompt string) (string, bool) {
+	sess.tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, err := sess.tp.ReadLine()
+	return line, err == nil
+}
+
+func (sess *session) challengeDecoded(prompt string) (string, bool) {
+	line, ok := sess.challenge(prompt)
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+func (sess *session) reset() {
+	sess.from, sess.to = "", nil
+}
+
+func (sess *session) reply(code int, msg string) {
+	sess.tp.PrintfLine("%d %s", code, msg)
+}
+
+// pathArg extracts the address from "FROM:<addr>" or "TO:<addr>", ignoring
+// any parameters after it.
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := strings.TrimSpace(arg[len(prefix):])
+	if !strings.HasPrefix(rest, "<") {
+		return "", false
+	}
+	end := strings.IndexByte(rest, '>')
+	if end < 0 {
+		return "", false
+	}
+	return rest[1:end], true
+}
+
+// selfSignedCert creates a certificate for the loopback addresses and a pool
+// that trusts it.
+func selfSignedCert() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "mailertest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, roots, nil
+}
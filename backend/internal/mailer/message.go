@@ -0,0 +1,164 @@
+// Package mailer renders and delivers email. A Mailer renders one of the
+// embedded Templates into a Message and hands it to a Transport, such as
+// SMTPTransport, for delivery.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Transport delivers messages. Implementations must be safe for concurrent
+// use.
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// Message is an email with a plain text body, an HTML body, or both. With
+// both, it is sent as multipart/alternative so clients pick the richer one.
+type Message struct {
+	From    string
+	To      []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+var errNoBody = errors.New("message has no body")
+
+// Recipients returns the bare addresses of the message's recipients.
+func (m *Message) Recipients() ([]string, error) {
+	addrs := make([]string, len(m.To))
+	for i, to := range m.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient %q: %w", to, err)
+		}
+		addrs[i] = addr.Address
+	}
+	return addrs, nil
+}
+
+// Bytes renders the message in RFC 5322 format with CRLF line endings.
+func (m *Message) Bytes(now time.Time) ([]byte, error) {
+	if m.Text == "" && m.HTML == "" {
+		return nil, errNoBody
+	}
+	if len(m.To) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("subject contains a line break")
+	}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("parse sender %q: %w", m.From, err)
+	}
+	to := make([]string, len(m.To))
+	for i, raw := range m.To {
+		addr, err := mail.ParseAddress(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient %q: %w", raw, err)
+		}
+		to[i] = addr.String()
+	}
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from.String())
+	writeHeader(&buf, "To", strings.Join(to, ", "))
+	if m.ReplyTo != "" {
+		replyTo, err := mail.ParseAddress(m.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("parse reply-to %q: %w", m.ReplyTo, err)
+		}
+		writeHeader(&buf, "Reply-To", replyTo.String())
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", messageID)
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if m.Text == "" || m.HTML == "" {
+		contentType, body := "text/plain", m.Text
+		if m.HTML != "" {
+			contentType, body = "text/html", m.HTML
+		}
+		writeHeader(&buf, "Content-Type", contentType+"; charset=utf-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// newMessageID returns a unique Message-ID at the sender's domain.
+func newMessageID(fromAddress string) (string, error) {
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at >= 0 {
+		domain = fromAddress[at+1:]
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate message id: %w", err)
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageBytesMultipart(t *testing.T) {
+	msg := &Message{
+		From:    "Agenteur <no-reply@agenteur.ai>",
+		To:      []string{"Ada <ada@example.com>", "bob@example.com"},
+		ReplyTo: "support@agenteur.ai",
+		Subject: "Willkommen bei Agenteur",
+		Text:    "Hello Ada, join here: https://example.com/join?token=abc&x=1",
+		HTML:    `<p>Hello Ada, <a href="https://example.com/join">join</a></p>`,
+	}
+	raw, err := msg.Bytes(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if bytes.Contains(bytes.ReplaceAll(raw, []byte("\r\n"), nil), []byte("\n")) {
+		t.Error("message has bare LF line endings")
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	h := parsed.Header
+	if got := h.Get("From"); got != `"Agenteur" <no-reply@agenteur.ai>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := h.Get("To"); got != `"Ada" <ada@example.com>, <bob@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+	if got := h.Get("Reply-To"); got != "<support@agenteur.ai>" {
+		t.Errorf("Reply-To = %q", got)
+	}
+	if got := h.Get("Date"); got != "Fri, 02 Jan 2026 03:04:05 +0000" {
+		t.Errorf("Date = %q", got)
+	}
+	if id := h.Get("Message-ID"); !strings.HasSuffix(id, "@agenteur.ai>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", h.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, part.Header.Get("Content-Type")+"|"+string(body))
+	}
+	want := []string{
+		"text/plain; charset=utf-8|" + msg.Text,
+		"text/html; charset=utf-8|" + msg.HTML,
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("parts = %q, want %q", parts, want)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %q, want %q", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestMessageBytesSinglePartAndEncodedSubject(t *testing.T) {
+	msg := &Message{
+		From:    "no-reply@agenteur.ai",
+		To:      []string{"ada@example.com"},
+		Subject: "Grüße",
+		Text:    "plain only",
+	}
+	raw, err := msg.Bytes(time.Now())
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Grüße" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+}
+
+func TestMessageBytesRejectsInvalid(t *testing.T) {
+	base := Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "s", Text: "t"}
+	cases := map[string]func(m *Message){
+		"no body":            func(m *Message) { m.Text = "" },
+		"no recipients":      func(m *Message) { m.To = nil },
+		"header injection":   func(m *Message) { m.Subject = "hi\r\nBcc: victim@example.com" },
+		"bad sender":         func(m *Message) { m.From = "not an address" },
+		"bad recipient":      func(m *Message) { m.To = []string{"nope"} },
+		"bad reply-to":       func(m *Message) { m.ReplyTo = "<broken" },
+		"injected recipient": func(m *Message) { m.To = []string{"b@example.com\r\nBcc: c@example.com"} },
+	}
+	for name, mutate := range cases {
+		msg := base
+		mutate(&msg)
+		if _, err := msg.Bytes(time.Now()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLS modes for SMTPConfig.TLS.
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS and fails if the
+	// server does not offer it.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS from the start, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSNone sends in the clear. Credentials are only sent to localhost.
+	TLSNone = "none"
+)
+
+// Auth mechanisms for SMTPConfig.Auth.
+const (
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+)
+
+const (
+	defaultSMTPTimeout     = 30 * time.Second
+	defaultSMTPIdleTimeout = 30 * time.Second
+)
+
+// SMTPConfig configures an SMTPTransport.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with the server if Username is set.
+	Username string
+	Password string
+	// Auth is the authentication mechanism; it defaults to plain.
+	Auth string
+	// TLS is the TLS mode; it defaults to starttls.
+	TLS string
+	// Timeout bounds connecting and each send. It defaults to 30 seconds.
+	Timeout time.Duration
+	// IdleTimeout is how long a connection is kept open between sends for
+	// reuse. It defaults to 30 seconds; a negative value disables reuse.
+	IdleTimeout time.Duration
+	// TLSConfig overrides the TLS settings, e.g. to trust a private CA. Its
+	// ServerName defaults to Host.
+	TLSConfig *tls.Config
+}
+
+// SMTPTransport sends messages through an SMTP server. It holds one
+// connection open between sends so bursts of email don't reconnect and
+// re-authenticate for every message; sends are serialized over it.
+type SMTPTransport struct {
+	cfg  SMTPConfig
+	addr string
+	now  func() time.Time
+
+	mu       sync.Mutex
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+func NewSMTPTransport(cfg SMTPConfig) (*SMTPTransport, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == TLSImplicit {
+			cfg.Port = 465
+		}
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	switch cfg.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", cfg.TLS)
+	}
+	if cfg.Auth == "" {
+		cfg.Auth = AuthPlain
+	}
+	switch cfg.Auth {
+	case AuthPlain, AuthLogin, AuthCRAMMD5:
+	default:
+		return nil, fmt.Errorf("unknown smtp auth mechanism %q", cfg.Auth)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = defaultSMTPIdleTimeout
+	}
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{}
+	} else {
+		cfg.TLSConfig = cfg.TLSConfig.Clone()
+	}
+	if cfg.TLSConfig.ServerName == "" {
+		cfg.TLSConfig.ServerName = cfg.Host
+	}
+
+	return &SMTPTransport{
+		cfg:  cfg,
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		now:  time.Now,
+	}, nil
+}
+
+// Send delivers msg. A connection left over from an earlier send is reused if
+// it is still alive; otherwise a new one is opened.
+func (t *SMTPTransport) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("parse sender %q: %w", msg.From, err)
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	data, err := msg.Bytes(t.now())
+	if err != nil {
+		return fmt.Errorf("render message: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.connect(ctx); err != nil {
+		return err
+	}
+	// Cancelling ctx aborts any blocked read or write on the connection. The
+	// callback runs without t.mu, so it holds its own reference to the
+	// connection rather than reading t.conn, which discard clears.
+	conn := t.conn
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := t.deliver(from.Address, recipients, data); err != nil {
+		t.discard()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("send mail: %w", err)
+	}
+	t.lastUsed = t.now()
+	if t.cfg.IdleTimeout < 0 {
+		t.quit()
+	}
+	return nil
+}
+
+func (t *SMTPTransport) deliver(from string, recipients []string, data []byte) error {
+	if err := t.client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := t.client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := t.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// connect makes t.client ready for a new transaction, reusing the open
+// connection when it has not idled out and still answers, and sets the
+// deadline for this send.
+func (t *SMTPTransport) connect(ctx context.Context) error {
+	deadline := t.now().Add(t.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	if t.client != nil {
+		if t.now().Sub(t.lastUsed) < t.cfg.IdleTimeout {
+			t.conn.SetDeadline(deadline)
+			if err := t.client.Reset(); err == nil {
+				return nil
+			}
+			t.discard()
+		} else {
+			t.quit()
+		}
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return fmt.Errorf("connect to smtp server: %w", err)
+	}
+	conn.SetDeadline(deadline)
+	if t.cfg.TLS == TLSImplicit {
+		tlsConn := tls.Client(conn, t.cfg.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("smtp tls handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := t.handshake(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	t.conn, t.client = conn, client
+	return nil
+}
+
+// handshake greets the server, upgrades to TLS if configured and
+// authenticates.
+func (t *SMTPTransport) handshake(conn net.Conn) (*smtp.Client, error) {
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		return nil, fmt.Errorf("smtp greeting: %w", err)
+	}
+	if err := client.Hello(localName()); err != nil {
+		return nil, fmt.Errorf("smtp hello: %w", err)
+	}
+	if t.cfg.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(t.cfg.TLSConfig); err != nil {
+			return nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if t.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return nil, errors.New("smtp server does not support authentication")
+		}
+		if err := client.Auth(t.auth()); err != nil {
+			return nil, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (t *SMTPTransport) auth() smtp.Auth {
+	switch t.cfg.Auth {
+	case AuthLogin:
+		return &loginAuth{username: t.cfg.Username, password: t.cfg.Password, host: t.cfg.Host}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(t.cfg.Username, t.cfg.Password)
+	default:
+		return smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)
+	}
+}
+
+// Close ends the open connection, if any.
+func (t *SMTPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quit()
+	return nil
+}
+
+// quit politely ends the connection.
+func (t *SMTPTransport) quit() {
+	if t.client == nil {
+		return
+	}
+	t.conn.SetDeadline(t.now().Add(t.cfg.Timeout))
+	if err := t.client.Quit(); err != nil {
+		t.client.Close()
+	}
+	t.conn, t.client = nil, nil
+}
+
+// discard drops a connection in an unknown state without talking to the
+// server.
+func (t *SMTPTransport) discard() {
+	if t.client == nil {
+		return
+	}
+	t.client.Close()
+	t.conn, t.client = nil, nil
+}
+
+// localName is the name sent in EHLO.
+func localName() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" || strings.ContainsAny(name, " \r\n") {
+		return "localhost"
+	}
+	return name
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp lacks but some
+// providers still require. Like smtp.PlainAuth, it refuses to send
+// credentials over an unencrypted connection to anything but localhost.
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
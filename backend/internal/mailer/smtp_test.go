@@ -0,0 +1,167 @@
+package mailer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/mailer/mailertest"
+)
+
+func newTestTransport(t *testing.T, srv *mailertest.Server, cfg SMTPConfig) *SMTPTransport {
+	t.Helper()
+	cfg.Host = srv.Host()
+	cfg.Port = srv.Port()
+	cfg.TLSConfig = srv.ClientTLSConfig()
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	transport, err := NewSMTPTransport(cfg)
+	if err != nil {
+		t.Fatalf("NewSMTPTransport: %v", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return transport
+}
+
+func testMessage(to string) *Message {
+	return &Message{
+		From:    "Agenteur <no-reply@agenteur.ai>",
+		To:      []string{to},
+		Subject: "Hello",
+		Text:    "Hello there",
+		HTML:    "<p>Hello there</p>",
+	}
+}
+
+func TestSMTPTransportStartTLSAndAuth(t *testing.T) {
+	for _, auth := range []string{AuthPlain, AuthLogin} {
+		t.Run(auth, func(t *testing.T) {
+			srv := mailertest.NewServer(t, mailertest.Options{Username: "mailer", Password: "secret"})
+			transport := newTestTransport(t, srv, SMTPConfig{
+				Username: "mailer",
+				Password: "secret",
+				Auth:     auth,
+				TLS:      TLSStartTLS,
+			})
+
+			if err := transport.Send(context.Background(), testMessage("Ada <ada@example.com>")); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			msgs := srv.Messages()
+			if len(msgs) != 1 {
+				t.Fatalf("got %d messages, want 1", len(msgs))
+			}
+			if msgs[0].From != "no-reply@agenteur.ai" || len(msgs[0].To) != 1 || msgs[0].To[0] != "ada@example.com" {
+				t.Errorf("envelope = %s -> %v", msgs[0].From, msgs[0].To)
+			}
+			parsed, err := msgs[0].Parse()
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if parsed.Header.Get("Subject") != "Hello" {
+				t.Errorf("Subject = %q", parsed.Header.Get("Subject"))
+			}
+		})
+	}
+}
+
+func TestSMTPTransportWrongPassword(t *testing.T) {
+	srv := mailertest.NewServer(t, mailertest.Options{Username: "mailer", Password: "secret"})
+	transport := newTestTransport(t, srv, SMTPConfig{Username: "mailer", Password: "wrong"})
+
+	err := transport.Send(context.Background(), testMessage("ada@example.com"))
+	if err == nil || !strings.Contains(err.Error(), "auth") {
+		t.Fatalf("err = %v, want auth failure", err)
+	}
+	if len(srv.Messages()) != 0 {
+		t.Error("message was accepted without authentication")
+	}
+}
+
+func TestSMTPTransportImplicitTLS(t *testing.T) {
+	srv := mailertest.NewServer(t, mailertest.Options{ImplicitTLS: true, Username: "mailer", Password: "secret"})
+	transport := newTestTransport(t, srv, SMTPConfig{
+		Username: "mailer",
+		Password: "secret",
+		TLS:      TLSImplicit,
+	})
+
+	if err := transport.Send(context.Background(), testMessage("ada@example.com")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(srv.Messages()) != 1 {
+		t.Fatalf("got %d messages, want 1", len(srv.Messages()))
+	}
+}
+
+func TestSMTPTransportRequiresStartTLS(t *testing.T) {
+	// An implicit TLS server never advertises STARTTLS, so pointing a
+	// starttls client at a plain listener must not fall back to cleartext.
+	srv := mailertest.NewServer(t, mailertest.Options{ImplicitTLS: true})
+	transport := newTestTransport(t, srv, SMTPConfig{TLS: TLSStartTLS, Timeout: time.Second})
+
+	if err := transport.Send(context.Background(), testMessage("ada@example.com")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSMTPTransportReusesConnection(t *testing.T) {
+	srv := mailertest.NewServer(t, mailertest.Options{Username: "mailer", Password: "secret"})
+	transport := newTestTransport(t, srv, SMTPConfig{Username: "mailer", Password: "secret"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := transport.Send(ctx, testMessage("ada@example.com")); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+	if got := srv.Connections(); got != 1 {
+		t.Errorf("connections = %d, want 1", got)
+	}
+
+	// A connection the server dropped is replaced transparently.
+	srv.DropConnections()
+	if err := transport.Send(ctx, testMessage("ada@example.com")); err != nil {
+		t.Fatalf("Send after drop: %v", err)
+	}
+	if got := srv.Connections(); got != 2 {
+		t.Errorf("connections = %d, want 2", got)
+	}
+
+	// A connection idle past IdleTimeout is closed and replaced.
+	transport.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if err := transport.Send(ctx, testMessage("ada@example.com")); err != nil {
+		t.Fatalf("Send after idle: %v", err)
+	}
+	if got := srv.Connections(); got != 3 {
+		t.Errorf("connections = %d, want 3", got)
+	}
+	if got := len(srv.Messages()); got != 5 {
+		t.Errorf("messages = %d, want 5", got)
+	}
+}
+
+func TestSMTPTransportContextCancel(t *testing.T) {
+	srv := mailertest.NewServer(t, mailertest.Options{})
+	transport := newTestTransport(t, srv, SMTPConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := transport.Send(ctx, testMessage("ada@example.com")); err == nil {
+		t.Fatal("expected error for cancelled context")
+	}
+}
+
+func TestNewSMTPTransportValidates(t *testing.T) {
+	for _, cfg := range []SMTPConfig{
+		{},
+		{Host: "smtp.example.com", TLS: "ssl"},
+		{Host: "smtp.example.com", Auth: "xoauth2"},
+	} {
+		if _, err := NewSMTPTransport(cfg); err == nil {
+			t.Errorf("NewSMTPTransport(%+v): expected error", cfg)
+		}
+	}
+}
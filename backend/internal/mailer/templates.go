@@ -0,0 +1,282 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Template names.
+const (
+	TemplateInvitation        = "invitation"
+	TemplateEmailVerification = "email_verification"
+	TemplatePasswordReset     = "password_reset"
+	TemplateSecurityAlert     = "security_alert"
+	TemplateOrgDeleted        = "org_deleted"
+)
+
+// Security alert events, for SecurityAlertData.Event.
+const (
+	SecurityEventNewSignIn       = "new_sign_in"
+	SecurityEventPasswordChanged = "password_changed"
+	SecurityEventMFADisabled     = "mfa_disabled"
+)
+
+// DefaultLocale is the locale used when an email asks for one that has no
+// translation.
+const DefaultLocale = "en"
+
+// Locales are the languages every template is translated into.
+var Locales = []string{"en", "de"}
+
+const (
+	defaultBrandName  = "Agenteur"
+	defaultBrandColor = "#4f46e5"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown email template")
+	ErrTemplateData    = errors.New("wrong data type for email template")
+
+	brandColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Branding customizes the look of an email, typically per org. Empty fields
+// fall back to the product's own branding.
+type Branding struct {
+	// Name is shown in the header when there is no logo, and in the footer.
+	Name    string `json:"name,omitempty"`
+	LogoURL string `json:"logoUrl,omitempty"`
+	// PrimaryColor is a #rrggbb color for the header rule and buttons.
+	PrimaryColor string `json:"primaryColor,omitempty"`
+}
+
+func (b Branding) withDefaults() Branding {
+	if b.Name == "" {
+		b.Name = defaultBrandName
+	}
+	if !brandColorPattern.MatchString(b.PrimaryColor) {
+		b.PrimaryColor = defaultBrandColor
+	}
+	if !strings.HasPrefix(b.LogoURL, "https://") {
+		b.LogoURL = ""
+	}
+	return b
+}
+
+type InvitationData struct {
+	InviterName string
+	OrgName     string
+	InviteURL   string
+	ExpiresAt   time.Time
+}
+
+type EmailVerificationData struct {
+	VerifyURL string
+	ExpiresAt time.Time
+}
+
+type PasswordResetData struct {
+	ResetURL  string
+	ExpiresAt time.Time
+}
+
+type SecurityAlertData struct {
+	// Event is one of the SecurityEvent constants.
+	Event      string
+	IPAddress  string
+	UserAgent  string
+	OccurredAt time.Time
+}
+
+type OrgDeletedData struct {
+	OrgName   string
+	RestoreBy time.Time
+}
+
+// templateSamples holds sample data for each template. It lists the known
+// templates, fixes the data type each one takes, and feeds previews.
+var templateSamples = map[string]any{
+	TemplateInvitation: InvitationData{
+		InviterName: "Ada Lovelace",
+		OrgName:     "Analytical Engines Ltd",
+		InviteURL:   "https://app.example.com/invitations/sample-token",
+		ExpiresAt:   time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC),
+	},
+	TemplateEmailVerification: EmailVerificationData{
+		VerifyURL: "https://app.example.com/verify-email/sample-token",
+		ExpiresAt: time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC),
+	},
+	TemplatePasswordReset: PasswordResetData{
+		ResetURL:  "https://app.example.com/reset-password/sample-token",
+		ExpiresAt: time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC),
+	},
+	TemplateSecurityAlert: SecurityAlertData{
+		Event:      SecurityEventNewSignIn,
+		IPAddress:  "203.0.113.7",
+		UserAgent:  "Firefox on macOS",
+		OccurredAt: time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC),
+	},
+	TemplateOrgDeleted: OrgDeletedData{
+		OrgName:   "Analytical Engines Ltd",
+		RestoreBy: time.Date(2030, 2, 14, 12, 0, 0, 0, time.UTC),
+	},
+}
+
+// Rendered is a template rendered for one recipient.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// renderContext is the value templates execute against.
+type renderContext struct {
+	Locale  string
+	Subject string
+	Brand   Branding
+	Data    any
+}
+
+// Templates holds the parsed email templates for every locale.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// LoadTemplates parses the embedded templates. Each template has a text file
+// defining "subject" and "text" and an HTML file defining "content", per
+// locale, wrapped in the shared layouts.
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+	for _, locale := range Locales {
+		funcs := templateFuncs(locale)
+		for name := range templateSamples {
+			key := locale + "/" + name
+			txt, err := texttemplate.New(name).Funcs(funcs).ParseFS(templateFS,
+				"templates/layout.txt", "templates/"+key+".txt")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s text template: %w", key, err)
+			}
+			html, err := htmltemplate.New(name).Funcs(funcs).ParseFS(templateFS,
+				"templates/layout.html", "templates/"+key+".html")
+			if err != nil {
+				return nil, fmt.Errorf("parse %s html template: %w", key, err)
+			}
+			t.text[key], t.html[key] = txt, html
+		}
+	}
+	return t, nil
+}
+
+// Names returns the template names in alphabetical order.
+func (t *Templates) Names() []string {
+	names := make([]string, 0, len(templateSamples))
+	for name := range templateSamples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Sample returns sample data for a template.
+func (t *Templates) Sample(name string) (any, bool) {
+	data, ok := templateSamples[name]
+	return data, ok
+}
+
+// Render renders a template in the locale ResolveLocale picks for locale.
+// data must be the template's data type, e.g. InvitationData for
+// TemplateInvitation.
+func (t *Templates) Render(name, locale string, brand Branding, data any) (*Rendered, error) {
+	sample, ok := templateSamples[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+	if reflect.TypeOf(data) != reflect.TypeOf(sample) {
+		return nil, fmt.Errorf("%w: %s takes %T, got %T", ErrTemplateData, name, sample, data)
+	}
+
+	locale = ResolveLocale(locale)
+	key := locale + "/" + name
+	ctx := renderContext{Locale: locale, Brand: brand.withDefaults(), Data: data}
+
+	var subject, text, html bytes.Buffer
+	if err := t.text[key].ExecuteTemplate(&subject, "subject", ctx); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", key, err)
+	}
+	ctx.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	if err := t.text[key].ExecuteTemplate(&text, "layout", ctx); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", key, err)
+	}
+	if err := t.html[key].ExecuteTemplate(&html, "layout", ctx); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", key, err)
+	}
+	return &Rendered{
+		Subject: ctx.Subject,
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// ResolveLocale maps a requested locale, such as "de" or "de-AT", to a
+// supported one, falling back to DefaultLocale.
+func ResolveLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if slices.Contains(Locales, locale) {
+		return locale
+	}
+	if base, _, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-"); ok && slices.Contains(Locales, base) {
+		return base
+	}
+	return DefaultLocale
+}
+
+// buttonArgs are the arguments to the HTML layout's "button" template.
+type buttonArgs struct {
+	URL   string
+	Label string
+	Color string
+}
+
+var monthNames = map[string][12]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+}
+
+// templateFuncs returns the functions available to a locale's templates.
+// Times are shown in UTC.
+func templateFuncs(locale string) map[string]any {
+	date := func(t time.Time) string {
+		t = t.UTC()
+		if names, ok := monthNames[locale]; ok {
+			return fmt.Sprintf("%d. %s %d", t.Day(), names[t.Month()-1], t.Year())
+		}
+		return t.Format("January 2, 2006")
+	}
+	return map[string]any{
+		"button": func(url, label, color string) buttonArgs {
+			return buttonArgs{URL: url, Label: label, Color: color}
+		},
+		"date": date,
+		"datetime": func(t time.Time) string {
+			if locale == "de" {
+				return date(t) + " um " + t.UTC().Format("15:04") + " UTC"
+			}
+			return date(t) + " at " + t.UTC().Format("15:04") + " UTC"
+		},
+	}
+}
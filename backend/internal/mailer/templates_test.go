@@ -0,0 +1,184 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"agenteur.ai/api/internal/mailer/mailertest"
+)
+
+func loadTestTemplates(t *testing.T) *Templates {
+	t.Helper()
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	return templates
+}
+
+func TestRenderEveryTemplateAndLocale(t *testing.T) {
+	templates := loadTestTemplates(t)
+	for _, name := range templates.Names() {
+		sample, _ := templates.Sample(name)
+		for _, locale := range Locales {
+			r, err := templates.Render(name, locale, Branding{}, sample)
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+			if r.Subject == "" || strings.ContainsAny(r.Subject, "\r\n") {
+				t.Errorf("%s/%s: subject = %q", locale, name, r.Subject)
+			}
+			if strings.Contains(r.Text, "<no value>") || strings.Contains(r.HTML, "<no value>") {
+				t.Errorf("%s/%s: missing value in output", locale, name)
+			}
+			if !strings.Contains(r.HTML, `<html lang="`+locale+`">`) || !strings.Contains(r.HTML, defaultBrandName) {
+				t.Errorf("%s/%s: HTML is not wrapped in the layout", locale, name)
+			}
+		}
+	}
+}
+
+func TestRenderLocalizesAndEscapes(t *testing.T) {
+	templates := loadTestTemplates(t)
+	data := InvitationData{
+		InviterName: "Grace <script>",
+		OrgName:     "Acme & Co",
+		InviteURL:   "https://app.example.com/invitations/tok",
+		ExpiresAt:   time.Date(2030, 3, 9, 0, 0, 0, 0, time.UTC),
+	}
+
+	en, err := templates.Render(TemplateInvitation, "en-US", Branding{}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if en.Subject != "Grace <script> invited you to join Acme & Co" {
+		t.Errorf("en subject = %q", en.Subject)
+	}
+	if !strings.Contains(en.Text, "March 9, 2030") || !strings.Contains(en.Text, "Grace <script>") {
+		t.Errorf("en text = %q", en.Text)
+	}
+	if strings.Contains(en.HTML, "<script>") || !strings.Contains(en.HTML, "Acme &amp; Co") {
+		t.Errorf("HTML is not escaped:\n%s", en.HTML)
+	}
+
+	de, err := templates.Render(TemplateInvitation, "de_AT", Branding{}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(de.Subject, "eingeladen") || !strings.Contains(de.Text, "9. März 2030") {
+		t.Errorf("de = %q / %q", de.Subject, de.Text)
+	}
+
+	fallback, err := templates.Render(TemplateInvitation, "fr", Branding{}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback.Subject != en.Subject {
+		t.Errorf("fr subject = %q, want English fallback", fallback.Subject)
+	}
+}
+
+func TestRenderBranding(t *testing.T) {
+	templates := loadTestTemplates(t)
+	sample, _ := templates.Sample(TemplateEmailVerification)
+
+	r, err := templates.Render(TemplateEmailVerification, "en", Branding{
+		Name:         "Acme",
+		LogoURL:      "https://cdn.example.com/logo.png",
+		PrimaryColor: "#ff6600",
+	}, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`src="https://cdn.example.com/logo.png"`, `alt="Acme"`, "#ff6600"} {
+		if !strings.Contains(r.HTML, want) {
+			t.Errorf("HTML does not contain %s", want)
+		}
+	}
+	if !strings.Contains(r.Text, "\n--\nAcme\n") {
+		t.Errorf("text footer = %q", r.Text)
+	}
+
+	// Unsafe values fall back to the defaults.
+	r, err = templates.Render(TemplateEmailVerification, "en", Branding{
+		LogoURL:      "javascript:alert(1)",
+		PrimaryColor: "red;background:url(x)",
+	}, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(r.HTML, "javascript:") || strings.Contains(r.HTML, "url(x)") || !strings.Contains(r.HTML, defaultBrandColor) {
+		t.Errorf("unsafe branding was rendered:\n%s", r.HTML)
+	}
+}
+
+func TestRenderRejectsUnknownTemplateAndWrongData(t *testing.T) {
+	templates := loadTestTemplates(t)
+	if _, err := templates.Render("newsletter", "en", Branding{}, nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("err = %v, want ErrUnknownTemplate", err)
+	}
+	if _, err := templates.Render(TemplateInvitation, "en", Branding{}, PasswordResetData{}); !errors.Is(err, ErrTemplateData) {
+		t.Errorf("err = %v, want ErrTemplateData", err)
+	}
+}
+
+func TestMailerSendsMultipartEmail(t *testing.T) {
+	srv := mailertest.NewServer(t, mailertest.Options{})
+	transport := newTestTransport(t, srv, SMTPConfig{})
+	m := NewMailer(transport, loadTestTemplates(t), "Agenteur <no-reply@agenteur.ai>")
+
+	url := "https://app.example.com/invitations/tok123"
+	err := m.Send(context.Background(), Email{
+		To:       "ada@example.com",
+		Template: TemplateInvitation,
+		Locale:   "de",
+		Branding: Branding{Name: "Acme"},
+		Data:     InvitationData{InviterName: "Grace", OrgName: "Acme", InviteURL: url, ExpiresAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(msgs))
+	}
+	text, err := msgs[0].Part("text/plain")
+	if err != nil || !strings.Contains(text, url) || !strings.Contains(text, "Einladung annehmen") {
+		t.Errorf("text part = %q (%v)", text, err)
+	}
+	html, err := msgs[0].Part("text/html")
+	if err != nil || !strings.Contains(html, `href="`+url+`"`) {
+		t.Errorf("html part = %q (%v)", html, err)
+	}
+}
+
+func TestEmailJSONRoundTrip(t *testing.T) {
+	for name, sample := range templateSamples {
+		in := Email{To: "ada@example.com", Template: name, Locale: "de", Branding: Branding{Name: "Acme"}, Data: sample}
+		raw, err := json.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", name, err)
+		}
+		var out Email
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("%s: Unmarshal: %v", name, err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("%s: round trip = %+v, want %+v", name, out, in)
+		}
+	}
+
+	var out Email
+	if err := json.Unmarshal([]byte(`{"template":"nope","data":{}}`), &out); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("unknown template: err = %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"template":"invitation","data":"x"}`), &out); !errors.Is(err, ErrTemplateData) {
+		t.Errorf("bad data: err = %v", err)
+	}
+}
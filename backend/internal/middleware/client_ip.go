@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPContextKey string
+
+const clientIPKey clientIPContextKey = "client_ip"
+
+// ClientIP resolves the client's IP address and stores it in the request
+// context. X-Forwarded-For is only consulted when the direct peer is one of
+// trustedProxies; see ResolveClientIP.
+func ClientIP(trustedProxies []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ResolveClientIP(r, trustedProxies)
+			ctx := context.WithValue(r.Context(), clientIPKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientIP returns the address resolved by ClientIP, or the zero Addr if
+// it was not resolved.
+func GetClientIP(ctx context.Context) netip.Addr {
+	ip, ok := ctx.Value(clientIPKey).(netip.Addr)
+	if !ok {
+		return netip.Addr{}
+	}
+	return ip
+}
+
+// ResolveClientIP returns the address of the client that made r. If the
+// direct peer is a trusted proxy, X-Forwarded-For is walked from the right,
+// skipping further trusted proxies, and the first untrusted address is the
+// client. Entries left of it were supplied by the client and are ignored, so
+// they cannot be spoofed. The zero Addr is returned if RemoteAddr is not an
+// IP address.
+func ResolveClientIP(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	peer, err := netip.ParseAddr(remoteIP(r.RemoteAddr))
+	if err != nil {
+		return netip.Addr{}
+	}
+	peer = peer.Unmap()
+	if !isTrustedProxy(peer, trustedProxies) {
+		return peer
+	}
+
+	hops := forwardedFor(r.Header.Values("X-Forwarded-For"))
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		client = hop.Unmap()
+		if !isTrustedProxy(client, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+// forwardedFor splits X-Forwarded-For header values into hops, oldest first.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			hop = strings.TrimSpace(hop)
+			if host, _, err := net.SplitHostPort(hop); err == nil {
+				hop = host
+			}
+			hops = append(hops, strings.Trim(hop, "[]"))
+		}
+	}
+	return hops
+}
+
+func isTrustedProxy(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, p := range trustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
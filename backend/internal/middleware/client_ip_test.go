@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"untrusted peer ignores header", "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer uses header", "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed left entries ignored", "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"skips chained proxies", "10.0.0.2:4000", []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, "198.51.100.1"},
+		{"all trusted uses leftmost", "10.0.0.2:4000", []string{"10.3.3.3, 10.1.1.1"}, "10.3.3.3"},
+		{"garbage stops walk", "10.0.0.2:4000", []string{"198.51.100.1, nonsense"}, "10.0.0.2"},
+		{"no header", "10.0.0.2:4000", nil, "10.0.0.2"},
+		{"ipv6 peer", "[2001:db8::1]:4000", []string{"[2001:db8:ffff::1]:9000, 2600::1"}, "2600::1"},
+		{"ipv4-mapped peer", "[::ffff:203.0.113.7]:4000", nil, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := ResolveClientIP(req, trusted).String(); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientIPStoresAddressInContext(t *testing.T) {
+	var got netip.Addr
+	h := ClientIP([]netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetClientIP(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.String() != "198.51.100.9" {
+		t.Fatalf("expected 198.51.100.9, got %s", got)
+	}
+}
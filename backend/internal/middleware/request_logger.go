@@ -44,7 +44,7 @@ func RequestLogger(logger *slog.Logger) Middleware {
 				"path", r.URL.Path,
 				"status", recorder.status,
 				"duration_ms", time.Since(start).Milliseconds(),
-				"remote_ip", remoteIP(r.RemoteAddr),
+				"remote_ip", clientIPString(r),
 				"user_agent", r.UserAgent(),
 				"request_id", GetRequestID(r.Context()),
 				"bytes", recorder.bytes,
@@ -62,6 +62,15 @@ func RequestLogger(logger *slog.Logger) Middleware {
 	}
 }
 
+// clientIPString returns the address resolved by ClientIP, falling back to
+// the direct peer when ClientIP has not run.
+func clientIPString(r *http.Request) string {
+	if ip := GetClientIP(r.Context()); ip.IsValid() {
+		return ip.String()
+	}
+	return remoteIP(r.RemoteAddr)
+}
+
 func remoteIP(remoteAddr string) string {
 	host, _, err := net.SplitHostPort(remoteAddr)
 	if err != nil {
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type userAgentContextKey string
+
+const userAgentKey userAgentContextKey = "user_agent"
+
+// maxUserAgentLength bounds the User-Agent kept in context; longer values
+// are truncated.
+const maxUserAgentLength = 512
+
+// UserAgent stores the request's User-Agent header in the request context.
+func UserAgent() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ua := r.UserAgent()
+			if len(ua) > maxUserAgentLength {
+				ua = ua[:maxUserAgentLength]
+			}
+			ctx := context.WithValue(r.Context(), userAgentKey, ua)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetUserAgent returns the User-Agent stored by UserAgent, or "" if there is
+// none.
+func GetUserAgent(ctx context.Context) string {
+	ua, ok := ctx.Value(userAgentKey).(string)
+	if !ok {
+		return ""
+	}
+	return ua
+}
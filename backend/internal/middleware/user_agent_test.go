@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserAgentStoresHeader(t *testing.T) {
+	var got string
+	h := UserAgent()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetUserAgent(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "curl/8.5.0" {
+		t.Fatalf("expected curl/8.5.0, got %q", got)
+	}
+
+	req.Header.Set("User-Agent", strings.Repeat("x", 2000))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if len(got) != maxUserAgentLength {
+		t.Fatalf("expected user agent truncated to %d bytes, got %d", maxUserAgentLength, len(got))
+	}
+}
@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agenteur.ai/api/internal/database"
+	"agenteur.ai/api/internal/mailer"
+)
+
+// KindEmail is the kind of messages that send a templated email.
+const KindEmail = "email"
+
+// EmailQueue sends templated emails through the outbox, so an email goes out
+// only if the transaction that asked for it commits.
+type EmailQueue struct {
+	outbox *Outbox
+}
+
+func NewEmailQueue(outbox *Outbox) *EmailQueue {
+	return &EmailQueue{outbox: outbox}
+}
+
+// Send enqueues email through db.
+func (q *EmailQueue) Send(ctx context.Context, db database.DBTX, email mailer.Email) error {
+	return q.outbox.Enqueue(ctx, db, KindEmail, fmt.Sprintf("%s email to %s", email.Template, email.To), email)
+}
+
+// EmailHandler delivers queued emails with m. Emails that can't be decoded or
+// rendered are dead-lettered without retrying.
+func EmailHandler(m *mailer.Mailer) Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var email mailer.Email
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return Permanent(fmt.Errorf("decode email: %w", err))
+		}
+		err := m.Send(ctx, email)
+		if errors.Is(err, mailer.ErrUnknownTemplate) || errors.Is(err, mailer.ErrTemplateData) {
+			return Permanent(err)
+		}
+		return err
+	}
+}
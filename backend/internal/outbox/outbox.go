@@ -0,0 +1,222 @@
+// Package outbox implements a transactional outbox. Side effects, such as
+// sending an email, are enqueued in the same transaction as the change that
+// causes them, so they happen if and only if it commits. A dispatcher then
+// delivers them in the background, retrying failures with exponential backoff
+// and dead-lettering messages that keep failing until an admin replays them.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Message statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	// dispatchBatchSize is how many due messages are claimed at a time.
+	dispatchBatchSize = 20
+	// claimLease is how long a claimed message is hidden from other
+	// dispatchers. Delivery must finish within it or the message may be
+	// delivered twice.
+	claimLease = 5 * time.Minute
+	// Retry delays start at backoffBase and double per attempt up to
+	// backoffMax.
+	backoffBase = 15 * time.Second
+	backoffMax  = time.Hour
+)
+
+var (
+	ErrNotFound = errors.New("outbox message not found")
+	ErrNotDead  = errors.New("only dead messages can be replayed")
+)
+
+// Message is a queued side effect.
+type Message struct {
+	ID            uuid.UUID       `json:"id"`
+	Kind          string          `json:"kind"`
+	Summary       string          `json:"summary"`
+	Payload       json.RawMessage `json:"-"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"maxAttempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     *string         `json:"lastError"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	DeliveredAt   *time.Time      `json:"deliveredAt"`
+}
+
+// Handler delivers the payload of one kind of message. Returning an error
+// schedules a retry, unless it is wrapped with Permanent.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a delivery error as one that retrying won't fix, so the
+// message is dead-lettered straight away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Outbox enqueues messages and dispatches them to the handler registered for
+// their kind.
+type Outbox struct {
+	pool        *pgxpool.Pool
+	repo        *repository
+	maxAttempts int
+	handlers    map[string]Handler
+	now         func() time.Time
+}
+
+// New creates an Outbox that gives up on a message after maxAttempts failed
+// deliveries.
+func New(pool *pgxpool.Pool, maxAttempts int) *Outbox {
+	return &Outbox{
+		pool:        pool,
+		repo:        &repository{},
+		maxAttempts: maxAttempts,
+		handlers:    make(map[string]Handler),
+		now:         time.Now,
+	}
+}
+
+// Handle registers the handler for a kind of message. It must be called
+// before dispatching starts.
+func (o *Outbox) Handle(kind string, h Handler) {
+	o.handlers[kind] = h
+}
+
+// Enqueue queues a message through db, which should be the transaction making
+// the change the message reports. summary is a short description shown to
+// admins, who don't see the payload.
+func (o *Outbox) Enqueue(ctx context.Context, db database.DBTX, kind, summary string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s outbox payload: %w", kind, err)
+	}
+	return o.repo.Insert(ctx, db, kind, summary, raw, o.maxAttempts)
+}
+
+// Dispatch delivers due messages until none are left and returns how many
+// were delivered. Only kinds with a registered handler are claimed, so
+// messages queued by a newer release wait for an instance that handles them.
+func (o *Outbox) Dispatch(ctx context.Context) (int, error) {
+	if len(o.handlers) == 0 {
+		return 0, nil
+	}
+	kinds := make([]string, 0, len(o.handlers))
+	for kind := range o.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	delivered := 0
+	for {
+		msgs, err := o.repo.ClaimDue(ctx, o.pool, kinds, o.now().Add(claimLease), dispatchBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(msgs) == 0 {
+			return delivered, nil
+		}
+		for _, msg := range msgs {
+			ok, err := o.deliver(ctx, msg)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+	}
+}
+
+// deliver runs a claimed message's handler and records the outcome. It
+// reports whether the message was delivered; the error is only for failing
+// to record the outcome.
+func (o *Outbox) deliver(ctx context.Context, msg *Message) (bool, error) {
+	err := o.handle(ctx, msg)
+	if err == nil {
+		return true, o.repo.MarkDelivered(ctx, o.pool, msg.ID)
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and another run retries it.
+		return false, ctx.Err()
+	}
+
+	var perm *permanentError
+	if errors.As(err, &perm) || msg.Attempts >= msg.MaxAttempts {
+		return false, o.repo.MarkFailed(ctx, o.pool, msg.ID, err.Error(), nil)
+	}
+	next := o.now().Add(backoff(msg.Attempts))
+	return false, o.repo.MarkFailed(ctx, o.pool, msg.ID, err.Error(), &next)
+}
+
+// handle runs msg's handler. A panic counts as a failed attempt rather than
+// taking down the dispatcher.
+func (o *Outbox) handle(ctx context.Context, msg *Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return o.handlers[msg.Kind](ctx, msg.Payload)
+}
+
+// backoff returns the delay before retrying after the given number of
+// attempts, with up to 20% jitter so failures don't retry in lockstep.
+func backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts && d < backoffMax; i++ {
+		d *= 2
+	}
+	d = min(d, backoffMax)
+	return d + time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+// List returns messages with the given status, most recently updated first,
+// and the total count.
+func (o *Outbox) List(ctx context.Context, status string, page, perPage int) ([]*Message, int, error) {
+	return o.repo.List(ctx, o.pool, status, perPage, (page-1)*perPage)
+}
+
+// Replay queues a dead message for immediate delivery with a fresh set of
+// attempts.
+func (o *Outbox) Replay(ctx context.Context, id uuid.UUID) (*Message, error) {
+	msg, err := o.repo.Replay(ctx, o.pool, id)
+	if err != nil {
+		return nil, err
+	}
+	if msg != nil {
+		return msg, nil
+	}
+	existing, err := o.repo.GetByID(ctx, o.pool, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrNotFound
+	}
+	return nil, ErrNotDead
+}
+
+// PurgeDelivered deletes messages delivered before the given time and returns
+// how many it removed.
+func (o *Outbox) PurgeDelivered(ctx context.Context, before time.Time) (int64, error) {
+	return o.repo.DeleteDelivered(ctx, o.pool, before)
+}
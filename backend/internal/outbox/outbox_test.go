@@ -0,0 +1,234 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 15 * time.Second},
+		{2, 30 * time.Second},
+		{3, time.Minute},
+		{9, 64 * time.Minute},
+		{50, time.Hour},
+	}
+	for _, c := range cases {
+		base := min(c.want, backoffMax)
+		for range 20 {
+			got := backoff(c.attempts)
+			if got < base || got > base+base/5 {
+				t.Fatalf("backoff(%d) = %v, want %v plus up to 20%%", c.attempts, got, base)
+			}
+		}
+	}
+}
+
+func TestPermanentUnwraps(t *testing.T) {
+	cause := errors.New("bad address")
+	err := Permanent(cause)
+	var perm *permanentError
+	if !errors.Is(err, cause) || !errors.As(err, &perm) || err.Error() != "bad address" {
+		t.Fatalf("Permanent(%v) = %v", cause, err)
+	}
+}
+
+// These tests need a migrated Postgres database and are skipped unless
+// TEST_DATABASE_URL is set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// testKind returns a kind unique to the test, so dispatching only claims the
+// test's own messages.
+func testKind(t *testing.T, pool *pgxpool.Pool) string {
+	kind := "test-" + uuid.NewString()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM outbox WHERE kind = $1`, kind)
+	})
+	return kind
+}
+
+func getMessage(t *testing.T, o *Outbox, kind string) *Message {
+	t.Helper()
+	var id uuid.UUID
+	if err := o.pool.QueryRow(context.Background(), `SELECT id FROM outbox WHERE kind = $1`, kind).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := o.repo.GetByID(context.Background(), o.pool, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestEnqueueIsTransactional(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	o := New(pool, 3)
+	kind := testKind(t, pool)
+	var delivered []string
+	o.Handle(kind, func(_ context.Context, payload json.RawMessage) error {
+		var p struct{ Name string }
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		delivered = append(delivered, p.Name)
+		return nil
+	})
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Enqueue(ctx, tx, kind, "rolled back", map[string]string{"Name": "rolled back"}); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback(ctx)
+	if err := o.Enqueue(ctx, pool, kind, "committed", map[string]string{"Name": "committed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := o.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	if n != 1 || len(delivered) != 1 || delivered[0] != "committed" {
+		t.Fatalf("Dispatch = %d, delivered %v; want only the committed message", n, delivered)
+	}
+	msg := getMessage(t, o, kind)
+	if msg.Status != StatusDelivered || msg.DeliveredAt == nil || msg.Attempts != 1 {
+		t.Errorf("message = %+v, want delivered on the first attempt", msg)
+	}
+}
+
+func TestDispatchRetriesThenDeadLettersAndReplays(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	o := New(pool, 2)
+	kind := testKind(t, pool)
+	fail := true
+	o.Handle(kind, func(context.Context, json.RawMessage) error {
+		if fail {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})
+	if err := o.Enqueue(ctx, pool, kind, "flaky", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := o.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	msg := getMessage(t, o, kind)
+	if msg.Status != StatusPending || msg.Attempts != 1 || msg.LastError == nil || *msg.LastError != "smtp unavailable" {
+		t.Fatalf("after first failure = %+v", msg)
+	}
+	if msg.NextAttemptAt.Before(time.Now().Add(10 * time.Second)) {
+		t.Errorf("retry scheduled at %v, want backed off", msg.NextAttemptAt)
+	}
+
+	// Not due yet: nothing is claimed.
+	if _, err := o.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if again := getMessage(t, o, kind); again.Attempts != 1 {
+		t.Fatalf("attempts = %d, want the retry to wait for its backoff", again.Attempts)
+	}
+
+	pool.Exec(ctx, `UPDATE outbox SET next_attempt_at = NOW() WHERE id = $1`, msg.ID)
+	if _, err := o.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if msg = getMessage(t, o, kind); msg.Status != StatusDead || msg.Attempts != 2 {
+		t.Fatalf("after max attempts = %+v, want dead", msg)
+	}
+
+	dead, total, err := o.List(ctx, StatusDead, 1, 100)
+	if err != nil || total < 1 {
+		t.Fatalf("List dead = %d, %v", total, err)
+	}
+	found := false
+	for _, m := range dead {
+		found = found || m.ID == msg.ID
+	}
+	if !found {
+		t.Error("dead message missing from List")
+	}
+
+	fail = false
+	replayed, err := o.Replay(ctx, msg.ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed.Status != StatusPending || replayed.Attempts != 0 {
+		t.Errorf("replayed = %+v", replayed)
+	}
+	if n, err := o.Dispatch(ctx); err != nil || n != 1 {
+		t.Fatalf("Dispatch after replay = %d, %v", n, err)
+	}
+	if _, err := o.Replay(ctx, msg.ID); !errors.Is(err, ErrNotDead) {
+		t.Errorf("Replay delivered message: err = %v, want ErrNotDead", err)
+	}
+	if _, err := o.Replay(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Replay unknown message: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPermanentErrorDeadLettersImmediately(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	o := New(pool, 5)
+	kind := testKind(t, pool)
+	o.Handle(kind, func(context.Context, json.RawMessage) error {
+		return Permanent(errors.New("unknown template"))
+	})
+	if err := o.Enqueue(ctx, pool, kind, "broken", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if msg := getMessage(t, o, kind); msg.Status != StatusDead || msg.Attempts != 1 {
+		t.Fatalf("message = %+v, want dead after one attempt", msg)
+	}
+}
+
+func TestHandlerPanicCountsAsFailedAttempt(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	o := New(pool, 5)
+	kind := testKind(t, pool)
+	o.Handle(kind, func(context.Context, json.RawMessage) error { panic("nil template") })
+	if err := o.Enqueue(ctx, pool, kind, "broken", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Dispatch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	msg := getMessage(t, o, kind)
+	if msg.Status != StatusPending || msg.Attempts != 1 || msg.LastError == nil || *msg.LastError != "handler panicked: nil template" {
+		t.Fatalf("message = %+v, want a failed attempt scheduled for retry", msg)
+	}
+}
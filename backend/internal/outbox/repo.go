@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const outboxColumns = `id, kind, summary, payload, status, attempts, max_attempts, next_attempt_at, last_error, created_at, updated_at, delivered_at`
+
+type repository struct{}
+
+func scanMessage(row pgx.Row) (*Message, error) {
+	var m Message
+	err := row.Scan(&m.ID, &m.Kind, &m.Summary, &m.Payload, &m.Status, &m.Attempts, &m.MaxAttempts, &m.NextAttemptAt,
+		&m.LastError, &m.CreatedAt, &m.UpdatedAt, &m.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repository) Insert(ctx context.Context, db database.DBTX, kind, summary string, payload []byte, maxAttempts int) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO outbox (kind, summary, payload, max_attempts) VALUES ($1, $2, $3, $4)`,
+		kind, summary, payload, maxAttempts)
+	if err != nil {
+		return fmt.Errorf("insert outbox message: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, db database.DBTX, id uuid.UUID) (*Message, error) {
+	m, err := scanMessage(db.QueryRow(ctx, `SELECT `+outboxColumns+` FROM outbox WHERE id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get outbox message: %w", err)
+	}
+	return m, nil
+}
+
+// ClaimDue takes up to limit pending messages of the given kinds that are due
+// and not claimed by another dispatcher, counts an attempt for each and hides
+// them from other dispatchers until lockedUntil.
+func (r *repository) ClaimDue(ctx context.Context, db database.DBTX, kinds []string, lockedUntil time.Time, limit int) ([]*Message, error) {
+	rows, err := db.Query(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, locked_until = $1, updated_at = NOW()
+		 WHERE id IN (
+		     SELECT id FROM outbox
+		     WHERE status = 'pending' AND kind = ANY($2) AND next_attempt_at <= NOW()
+		       AND (locked_until IS NULL OR locked_until < NOW())
+		     ORDER BY next_attempt_at
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+outboxColumns, lockedUntil, kinds, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*Message
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan outbox message: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
+
+func (r *repository) MarkDelivered(ctx context.Context, db database.DBTX, id uuid.UUID) error {
+	_, err := db.Exec(ctx,
+		`UPDATE outbox SET status = 'delivered', delivered_at = NOW(), locked_until = NULL, last_error = NULL, updated_at = NOW()
+		 WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark outbox message delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt. With nextAttemptAt the message is
+// retried then; without it the message is dead.
+func (r *repository) MarkFailed(ctx context.Context, db database.DBTX, id uuid.UUID, errMsg string, nextAttemptAt *time.Time) error {
+	var err error
+	if nextAttemptAt != nil {
+		_, err = db.Exec(ctx,
+			`UPDATE outbox SET last_error = $2, next_attempt_at = $3, locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`, id, errMsg, *nextAttemptAt)
+	} else {
+		_, err = db.Exec(ctx,
+			`UPDATE outbox SET status = 'dead', last_error = $2, locked_until = NULL, updated_at = NOW()
+			 WHERE id = $1`, id, errMsg)
+	}
+	if err != nil {
+		return fmt.Errorf("mark outbox message failed: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) List(ctx context.Context, db database.DBTX, status string, limit, offset int) ([]*Message, int, error) {
+	var total int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM outbox WHERE status = $1`, status).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count outbox messages: %w", err)
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT `+outboxColumns+` FROM outbox WHERE status = $1
+		 ORDER BY updated_at DESC, id
+		 LIMIT $2 OFFSET $3`, status, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	msgs := []*Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan outbox message: %w", err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, total, rows.Err()
+}
+
+// Replay makes a dead message pending again with its attempts reset. It
+// returns nil if the message does not exist or is not dead.
+func (r *repository) Replay(ctx context.Context, db database.DBTX, id uuid.UUID) (*Message, error) {
+	m, err := scanMessage(db.QueryRow(ctx,
+		`UPDATE outbox SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL, updated_at = NOW()
+		 WHERE id = $1 AND status = 'dead'
+		 RETURNING `+outboxColumns, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("replay outbox message: %w", err)
+	}
+	return m, nil
+}
+
+func (r *repository) DeleteDelivered(ctx context.Context, db database.DBTX, before time.Time) (int64, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM outbox WHERE status = 'delivered' AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete delivered outbox messages: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
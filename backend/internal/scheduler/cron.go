@@ -0,0 +1,245 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr string
+	loc  *time.Location
+	// every is set for "@every <duration>" schedules, which fire at
+	// multiples of the duration since the zero time.
+	every time.Duration
+
+	second, minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a "*" or "?" day field. When both day fields
+	// are restricted a day matches either, as in Vixie cron.
+	domAny, dowAny bool
+}
+
+type fieldBounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = fieldBounds{name: "second", min: 0, max: 59}
+	minuteField = fieldBounds{name: "minute", min: 0, max: 59}
+	hourField   = fieldBounds{name: "hour", min: 0, max: 23}
+	domField    = fieldBounds{name: "day of month", min: 1, max: 31}
+	monthField  = fieldBounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week 7 is also Sunday.
+	dowField = fieldBounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression evaluated in loc:
+//
+//   - five fields: minute hour day-of-month month day-of-week
+//   - six fields: second minute hour day-of-month month day-of-week
+//   - a macro: @yearly, @monthly, @weekly, @daily, @hourly
+//   - "@every <duration>", e.g. "@every 15m"
+//
+// Fields accept *, lists, ranges, steps and month and weekday names. A
+// "CRON_TZ=<zone>" prefix overrides loc, e.g. "CRON_TZ=Europe/Berlin 0 9 * * 1-5".
+func Parse(expr string, loc *time.Location) (*Schedule, error) {
+	s := &Schedule{expr: expr, loc: loc}
+	spec := strings.TrimSpace(expr)
+	if rest, ok := strings.CutPrefix(spec, "CRON_TZ="); ok {
+		zone, fields, _ := strings.Cut(rest, " ")
+		l, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: unknown time zone %q", expr, zone)
+		}
+		s.loc, spec = l, strings.TrimSpace(fields)
+	}
+	if s.loc == nil {
+		s.loc = time.UTC
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("cron %q: @every needs a duration of at least 1s", expr)
+		}
+		s.every = d
+		return s, nil
+	}
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron %q: want 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	parse := func(field string, b fieldBounds) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseField(field, b)
+		if err != nil {
+			err = fmt.Errorf("cron %q: %w", expr, err)
+		}
+		return bits
+	}
+	s.second = parse(fields[0], secondField)
+	s.minute = parse(fields[1], minuteField)
+	s.hour = parse(fields[2], hourField)
+	s.dom = parse(fields[3], domField)
+	s.month = parse(fields[4], monthField)
+	s.dow = parse(fields[5], dowField)
+	if err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[3] == "*" || fields[3] == "?"
+	s.dowAny = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+// parseField parses one comma-separated field into a bit set of the values
+// it matches.
+func parseField(field string, b fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q in %s", stepStr, b.name)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*" || rng == "?":
+			lo, hi = b.min, b.max
+		case strings.Contains(rng, "-"):
+			from, to, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(from, b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(to, b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("bad range %q in %s", rng, b.name)
+			}
+		default:
+			v, err := parseValue(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if hasStep {
+				// "5/15" means every 15 starting at 5.
+				hi = b.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b fieldBounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("bad %s %q", b.name, s)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Location returns the time zone the schedule is evaluated in.
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next returns the first time after t the schedule fires, or the zero time
+// if it never does within five years (e.g. "0 0 30 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(s.every).Add(s.every)
+	}
+
+	t = t.In(s.loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		y, mo, d := t.Date()
+		h, mi, sec := t.Clock()
+		switch {
+		case s.month&(1<<int(mo)) == 0:
+			t = advance(t, time.Date(y, mo+1, 1, 0, 0, 0, 0, s.loc))
+		case !s.dayMatches(t):
+			t = advance(t, time.Date(y, mo, d+1, 0, 0, 0, 0, s.loc))
+		case s.hour&(1<<h) == 0:
+			t = advance(t, time.Date(y, mo, d, h+1, 0, 0, 0, s.loc))
+		case s.minute&(1<<mi) == 0:
+			t = advance(t, time.Date(y, mo, d, h, mi+1, 0, 0, s.loc))
+		case s.second&(1<<sec) == 0:
+			t = advance(t, time.Date(y, mo, d, h, mi, sec+1, 0, s.loc))
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<t.Day()) != 0
+	dowOK := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// advance moves to next, or by a second if a daylight saving transition
+// makes next no later than t.
+func advance(t, next time.Time) time.Time {
+	if !next.After(t) {
+		return t.Add(time.Second)
+	}
+	return next
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, expr string, loc *time.Location) *Schedule {
+	t.Helper()
+	s, err := Parse(expr, loc)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	return s
+}
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2026, 3, 14, 10, 17, 30, 0, time.UTC) // a Saturday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 3, 14, 10, 25, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2026, 4, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"45 * * * * *", time.Date(2026, 3, 14, 10, 17, 45, 0, time.UTC)},
+		{"0,30 8-9 * * *", time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches.
+		{"0 0 20 * SUN", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@every 20m", time.Date(2026, 3, 14, 10, 20, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := mustParse(t, c.expr, time.UTC).Next(from); !got.Equal(c.want) {
+			t.Errorf("%q.Next = %v, want %v", c.expr, got, c.want)
+		}
+	}
+
+	if got := mustParse(t, "0 0 30 2 *", time.UTC).Next(from); !got.IsZero() {
+		t.Errorf("impossible schedule fired at %v", got)
+	}
+}
+
+func TestScheduleTimeZones(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+
+	s := mustParse(t, "0 9 * * *", berlin)
+	got := s.Next(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("9:00 Berlin in summer = %v, want %v", got.UTC(), want)
+	}
+
+	s = mustParse(t, "CRON_TZ=Europe/Berlin 0 9 * * *", time.UTC)
+	got = s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("CRON_TZ 9:00 Berlin in winter = %v, want %v", got.UTC(), want)
+	}
+
+	// 02:30 doesn't exist on the spring-forward day; the next one is a day later.
+	s = mustParse(t, "30 2 * * *", berlin)
+	got = s.Next(time.Date(2026, 3, 29, 0, 0, 0, 0, berlin))
+	if want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("across DST = %v, want %v", got, want)
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 10ms",
+		"@every soon",
+		"CRON_TZ=Mars/Olympus 0 * * * *",
+	} {
+		if _, err := Parse(expr, time.UTC); err == nil {
+			t.Errorf("Parse(%q): expected error", expr)
+		}
+	}
+}
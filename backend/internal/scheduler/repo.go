@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"agenteur.ai/api/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const runColumns = `id, schedule_name, trigger, scheduled_for, status, host, started_at, finished_at, duration_ms, error`
+
+type repository struct{}
+
+func scanRun(row pgx.Row) (*Run, error) {
+	var r Run
+	err := row.Scan(&r.ID, &r.ScheduleName, &r.Trigger, &r.ScheduledFor, &r.Status, &r.Host, &r.StartedAt,
+		&r.FinishedAt, &r.DurationMS, &r.Error)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// lockKey namespaces schedule names in the advisory lock space.
+func lockKey(name string) string {
+	return "schedule:" + name
+}
+
+// TryLock takes the schedule's session-level advisory lock without waiting.
+// db must be a dedicated connection, which Unlock is later called on.
+func (r *repository) TryLock(ctx context.Context, db database.DBTX, name string) (bool, error) {
+	var locked bool
+	if err := db.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, lockKey(name)).Scan(&locked); err != nil {
+		return false, fmt.Errorf("lock schedule: %w", err)
+	}
+	return locked, nil
+}
+
+func (r *repository) Unlock(ctx context.Context, db database.DBTX, name string) error {
+	if _, err := db.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, lockKey(name)); err != nil {
+		return fmt.Errorf("unlock schedule: %w", err)
+	}
+	return nil
+}
+
+// InsertRun records the start of a run. It returns nil if a cron run for
+// the same slot already exists.
+func (r *repository) InsertRun(ctx context.Context, db database.DBTX, name, trigger string, scheduledFor time.Time, host string) (*Run, error) {
+	run, err := scanRun(db.QueryRow(ctx,
+		`INSERT INTO schedule_runs (schedule_name, trigger, scheduled_for, host)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (schedule_name, scheduled_for) WHERE trigger = 'cron' DO NOTHING
+		 RETURNING `+runColumns, name, trigger, scheduledFor, host))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("insert schedule run: %w", err)
+	}
+	return run, nil
+}
+
+func (r *repository) FinishRun(ctx context.Context, db database.DBTX, id uuid.UUID, status string, duration time.Duration, errMsg *string) error {
+	_, err := db.Exec(ctx,
+		`UPDATE schedule_runs SET status = $2, finished_at = NOW(), duration_ms = $3, error = $4 WHERE id = $1`,
+		id, status, duration.Milliseconds(), errMsg)
+	if err != nil {
+		return fmt.Errorf("finish schedule run: %w", err)
+	}
+	return nil
+}
+
+// LastRuns returns the most recent run of each named schedule that has one.
+func (r *repository) LastRuns(ctx context.Context, db database.DBTX, names []string) (map[string]*Run, error) {
+	rows, err := db.Query(ctx,
+		`SELECT DISTINCT ON (schedule_name) `+runColumns+`
+		 FROM schedule_runs WHERE schedule_name = ANY($1)
+		 ORDER BY schedule_name, started_at DESC`, names)
+	if err != nil {
+		return nil, fmt.Errorf("get last schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	last := make(map[string]*Run)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan schedule run: %w", err)
+		}
+		last[run.ScheduleName] = run
+	}
+	return last, rows.Err()
+}
+
+func (r *repository) ListRuns(ctx context.Context, db database.DBTX, name string, limit, offset int) ([]*Run, int, error) {
+	var total int
+	if err := db.QueryRow(ctx, `SELECT COUNT(*) FROM schedule_runs WHERE schedule_name = $1`, name).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count schedule runs: %w", err)
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT `+runColumns+` FROM schedule_runs WHERE schedule_name = $1
+		 ORDER BY started_at DESC, id
+		 LIMIT $2 OFFSET $3`, name, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*Run{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("scan schedule run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, total, rows.Err()
+}
+
+func (r *repository) DeleteFinishedRuns(ctx context.Context, db database.DBTX, before time.Time) (int64, error) {
+	tag, err := db.Exec(ctx, `DELETE FROM schedule_runs WHERE finished_at IS NOT NULL AND started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete schedule runs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
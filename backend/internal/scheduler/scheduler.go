@@ -0,0 +1,398 @@
+// Package scheduler runs recurring tasks on cron schedules. Every API replica
+// runs a Scheduler with the same schedules; a Postgres advisory lock per
+// schedule and a run record per cron slot make sure each slot fires on one
+// replica only. Every run is recorded with its duration and error, except
+// that schedules firing more often than once a minute record only failures.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Run triggers.
+const (
+	TriggerCron   = "cron"
+	TriggerManual = "manual"
+)
+
+// Run statuses.
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// tickInterval is how often due schedules are checked, and so the finest
+// resolution a schedule fires at.
+const tickInterval = time.Second
+
+// pollerInterval is the period below which a schedule is a poller: its
+// successful cron runs are not recorded, so they don't flood the history.
+const pollerInterval = time.Minute
+
+var (
+	ErrUnknownSchedule = errors.New("unknown schedule")
+	ErrRunning         = errors.New("schedule is already running")
+)
+
+// Task is the work a schedule runs.
+type Task func(ctx context.Context) error
+
+// Run is one execution of a schedule.
+type Run struct {
+	ID           uuid.UUID  `json:"id"`
+	ScheduleName string     `json:"scheduleName"`
+	Trigger      string     `json:"trigger"`
+	ScheduledFor time.Time  `json:"scheduledFor"`
+	Status       string     `json:"status"`
+	Host         string     `json:"host"`
+	StartedAt    time.Time  `json:"startedAt"`
+	FinishedAt   *time.Time `json:"finishedAt"`
+	DurationMS   *int64     `json:"durationMs"`
+	Error        *string    `json:"error"`
+}
+
+// Info describes a registered schedule.
+type Info struct {
+	Name     string `json:"name"`
+	Spec     string `json:"spec"`
+	TimeZone string `json:"timeZone"`
+	// NextRunAt is when this replica next fires the schedule; nil if it
+	// never fires again.
+	NextRunAt *time.Time `json:"nextRunAt"`
+	LastRun   *Run       `json:"lastRun"`
+}
+
+type entry struct {
+	name     string
+	schedule *Schedule
+	task     Task
+	next     time.Time
+}
+
+// poller reports whether the entry fires more often than once a minute.
+func (e *entry) poller() bool {
+	return e.schedule.every > 0 && e.schedule.every < pollerInterval
+}
+
+// Scheduler fires registered tasks on their schedules.
+type Scheduler struct {
+	pool *pgxpool.Pool
+	repo *repository
+	loc  *time.Location
+	host string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries []*entry
+	byName  map[string]*entry
+
+	stop       chan struct{}
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// New creates a Scheduler that evaluates schedules in loc unless they name
+// their own time zone.
+func New(pool *pgxpool.Pool, loc *time.Location) *Scheduler {
+	host, _ := os.Hostname()
+	runCtx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		pool:       pool,
+		repo:       &repository{},
+		loc:        loc,
+		host:       host,
+		now:        time.Now,
+		byName:     make(map[string]*entry),
+		runCtx:     runCtx,
+		cancelRuns: cancel,
+	}
+}
+
+// Register adds a schedule. The name identifies it across replicas and in
+// the run history, so it must be stable and unique.
+func (s *Scheduler) Register(name, spec string, task Task) error {
+	schedule, err := Parse(spec, s.loc)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byName[name]; ok {
+		return fmt.Errorf("schedule %q registered twice", name)
+	}
+	e := &entry{name: name, schedule: schedule, task: task, next: schedule.Next(s.now())}
+	s.entries = append(s.entries, e)
+	s.byName[name] = e
+	return nil
+}
+
+// Start begins firing schedules.
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Shutdown stops firing schedules and waits for running tasks to finish. If
+// ctx ends first, running tasks are cancelled.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	if s.stop != nil {
+		close(s.stop)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancelRuns()
+		return nil
+	case <-ctx.Done():
+		s.cancelRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		now := s.now()
+		s.mu.Lock()
+		for _, e := range s.entries {
+			if e.next.IsZero() || now.Before(e.next) {
+				continue
+			}
+			// Slots missed while the replica was busy or down are skipped.
+			slot := e.next
+			e.next = e.schedule.Next(now)
+			s.wg.Add(1)
+			go s.fire(e, slot)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// fire runs a cron slot unless another replica holds the schedule's lock or
+// has already fired the slot.
+func (s *Scheduler) fire(e *entry, slot time.Time) {
+	defer s.wg.Done()
+	if e.poller() {
+		s.poll(e, slot)
+		return
+	}
+	conn, run, err := s.begin(s.runCtx, e, TriggerCron, slot)
+	if err != nil {
+		if !errors.Is(err, ErrRunning) && s.runCtx.Err() == nil {
+			slog.Error("start scheduled run", "schedule", e.name, "error", err)
+		}
+		return
+	}
+	if run == nil {
+		conn.Release()
+		return
+	}
+	s.execute(conn, e, run)
+}
+
+// Trigger runs a schedule now, outside its cron slots. The run continues in
+// the background; the returned record shows it as running.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*Run, error) {
+	s.mu.Lock()
+	e, ok := s.byName[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownSchedule
+	}
+
+	conn, run, err := s.begin(ctx, e, TriggerManual, s.now())
+	if err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(conn, e, run)
+	}()
+	return run, nil
+}
+
+// begin takes the schedule's advisory lock on a dedicated connection and
+// records the run. It returns ErrRunning if the lock is held elsewhere, and
+// a nil run, with the lock released, if the cron slot was already fired. On
+// success the caller owns conn and must pass it to execute.
+func (s *Scheduler) begin(ctx context.Context, e *entry, trigger string, slot time.Time) (*pgxpool.Conn, *Run, error) {
+	conn, err := s.lock(ctx, e)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	run, err := s.repo.InsertRun(ctx, conn, e.name, trigger, slot, s.host)
+	if err != nil || run == nil {
+		s.unlock(conn, e)
+		if err != nil {
+			conn.Release()
+			return nil, nil, err
+		}
+	}
+	return conn, run, nil
+}
+
+// lock takes the schedule's advisory lock on a dedicated connection, or
+// returns ErrRunning if it is held elsewhere. On success the caller owns conn
+// and must unlock and release it.
+func (s *Scheduler) lock(ctx context.Context, e *entry) (*pgxpool.Conn, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	locked, err := s.repo.TryLock(ctx, conn, e.name)
+	if err != nil {
+		conn.Release()
+		return nil, err
+	}
+	if !locked {
+		conn.Release()
+		return nil, ErrRunning
+	}
+	return conn, nil
+}
+
+// poll runs a poller's cron slot under the schedule's lock and records the
+// run only if it fails. With no record of successful slots, a replica may
+// run a slot another has just finished; pollers are safe to run again.
+func (s *Scheduler) poll(e *entry, slot time.Time) {
+	conn, err := s.lock(s.runCtx, e)
+	if err != nil {
+		if !errors.Is(err, ErrRunning) && s.runCtx.Err() == nil {
+			slog.Error("start scheduled run", "schedule", e.name, "error", err)
+		}
+		return
+	}
+	defer conn.Release()
+	recordCtx := context.WithoutCancel(s.runCtx)
+	defer s.unlock(conn, e)
+
+	start := time.Now()
+	err = runTask(s.runCtx, e)
+	duration := time.Since(start)
+	if err == nil {
+		return
+	}
+	msg := err.Error()
+	slog.Error("scheduled run failed", "schedule", e.name, "trigger", TriggerCron, "duration", duration, "error", err)
+	run, err := s.repo.InsertRun(recordCtx, conn, e.name, TriggerCron, slot, s.host)
+	if err == nil && run != nil {
+		err = s.repo.FinishRun(recordCtx, conn, run.ID, RunFailed, duration, &msg)
+	}
+	if err != nil {
+		slog.Error("record scheduled run", "schedule", e.name, "error", err)
+	}
+}
+
+// execute runs the task, records the outcome and releases the lock.
+func (s *Scheduler) execute(conn *pgxpool.Conn, e *entry, run *Run) {
+	defer conn.Release()
+	// Record the outcome even if shutdown cancelled the task.
+	recordCtx := context.WithoutCancel(s.runCtx)
+	defer s.unlock(conn, e)
+
+	start := time.Now()
+	err := runTask(s.runCtx, e)
+	duration := time.Since(start)
+
+	status, errMsg := RunSucceeded, (*string)(nil)
+	if err != nil {
+		status = RunFailed
+		msg := err.Error()
+		errMsg = &msg
+		slog.Error("scheduled run failed", "schedule", e.name, "trigger", run.Trigger, "duration", duration, "error", err)
+	}
+	if err := s.repo.FinishRun(recordCtx, conn, run.ID, status, duration, errMsg); err != nil {
+		slog.Error("record scheduled run", "schedule", e.name, "run_id", run.ID, "error", err)
+	}
+}
+
+// runTask runs e's task. A panic counts as a failed run rather than leaving
+// the run unfinished and the lock held.
+func runTask(ctx context.Context, e *entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return e.task(ctx)
+}
+
+func (s *Scheduler) unlock(conn *pgxpool.Conn, e *entry) {
+	ctx := context.WithoutCancel(s.runCtx)
+	if err := s.repo.Unlock(ctx, conn, e.name); err != nil {
+		// A session lock dies with its connection; close it rather than
+		// return it to the pool still holding the lock.
+		conn.Conn().Close(ctx)
+	}
+}
+
+// List returns the registered schedules in registration order with their
+// next and most recent runs.
+func (s *Scheduler) List(ctx context.Context) ([]Info, error) {
+	s.mu.Lock()
+	infos := make([]Info, len(s.entries))
+	names := make([]string, len(s.entries))
+	for i, e := range s.entries {
+		infos[i] = Info{Name: e.name, Spec: e.schedule.String(), TimeZone: e.schedule.Location().String()}
+		if !e.next.IsZero() {
+			next := e.next
+			infos[i].NextRunAt = &next
+		}
+		names[i] = e.name
+	}
+	s.mu.Unlock()
+
+	last, err := s.repo.LastRuns(ctx, s.pool, names)
+	if err != nil {
+		return nil, err
+	}
+	for i := range infos {
+		infos[i].LastRun = last[infos[i].Name]
+	}
+	return infos, nil
+}
+
+// History returns a schedule's runs, newest first, and the total count.
+func (s *Scheduler) History(ctx context.Context, name string, page, perPage int) ([]*Run, int, error) {
+	s.mu.Lock()
+	_, ok := s.byName[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, 0, ErrUnknownSchedule
+	}
+	return s.repo.ListRuns(ctx, s.pool, name, perPage, (page-1)*perPage)
+}
+
+// PurgeHistory deletes finished runs started before the given time and
+// returns how many it removed.
+func (s *Scheduler) PurgeHistory(ctx context.Context, before time.Time) (int64, error) {
+	return s.repo.DeleteFinishedRuns(ctx, s.pool, before)
+}
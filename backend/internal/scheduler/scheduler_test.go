@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestRegisterRejectsBadSpecAndDuplicates(t *testing.T) {
+	s := New(nil, time.UTC)
+	noop := func(context.Context) error { return nil }
+	if err := s.Register("a", "not cron", noop); err == nil {
+		t.Error("expected a parse error")
+	}
+	if err := s.Register("a", "@hourly", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("a", "@daily", noop); err == nil {
+		t.Error("expected a duplicate name error")
+	}
+	if _, err := s.Trigger(context.Background(), "missing"); !errors.Is(err, ErrUnknownSchedule) {
+		t.Errorf("Trigger unknown: err = %v", err)
+	}
+}
+
+// These tests need a migrated Postgres database and are skipped unless
+// TEST_DATABASE_URL is set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func testScheduleName(t *testing.T, pool *pgxpool.Pool) string {
+	name := "test-" + uuid.NewString()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM schedule_runs WHERE schedule_name = $1`, name)
+	})
+	return name
+}
+
+func TestSlotFiresOnceAcrossReplicas(t *testing.T) {
+	pool := testPool(t)
+	name := testScheduleName(t, pool)
+	var runs atomic.Int32
+	task := func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}
+
+	slot := time.Now().Truncate(time.Minute)
+	for range 2 {
+		replica := New(pool, time.UTC)
+		if err := replica.Register(name, "* * * * *", task); err != nil {
+			t.Fatal(err)
+		}
+		replica.wg.Add(1)
+		replica.fire(replica.byName[name], slot)
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("slot ran %d times, want once", n)
+	}
+
+	s := New(pool, time.UTC)
+	s.Register(name, "* * * * *", task)
+	history, total, err := s.History(context.Background(), name, 1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("History = %d, %v", total, err)
+	}
+	run := history[0]
+	if run.Status != RunSucceeded || run.Trigger != TriggerCron || !run.ScheduledFor.Equal(slot) || run.DurationMS == nil {
+		t.Errorf("run = %+v", run)
+	}
+}
+
+func TestTriggerWhileRunningConflicts(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	name := testScheduleName(t, pool)
+	release := make(chan struct{})
+	s := New(pool, time.UTC)
+	if err := s.Register(name, "@yearly", func(context.Context) error {
+		<-release
+		return errors.New("gave up")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := s.Trigger(ctx, name)
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if run.Status != RunRunning || run.Trigger != TriggerManual {
+		t.Errorf("run = %+v", run)
+	}
+	// Another replica can't start it while the lock is held.
+	other := New(pool, time.UTC)
+	other.Register(name, "@yearly", func(context.Context) error { return nil })
+	if _, err := other.Trigger(ctx, name); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Trigger: err = %v, want ErrRunning", err)
+	}
+
+	close(release)
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := infos[0].LastRun
+	if last == nil || last.Status != RunFailed || last.Error == nil || *last.Error != "gave up" {
+		t.Errorf("last run = %+v, want the failure recorded", last)
+	}
+}
+
+func TestTaskPanicRecordsFailedRun(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	name := testScheduleName(t, pool)
+	s := New(pool, time.UTC)
+	if err := s.Register(name, "@yearly", func(context.Context) error { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Trigger(ctx, name); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := infos[0].LastRun
+	if last == nil || last.Status != RunFailed || last.Error == nil || *last.Error != "task panicked: boom" {
+		t.Errorf("last run = %+v, want the panic recorded as a failure", last)
+	}
+}
+
+func TestPollerRecordsOnlyFailures(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	name := testScheduleName(t, pool)
+	var fail atomic.Bool
+	s := New(pool, time.UTC)
+	if err := s.Register(name, "@every 2s", func(context.Context) error {
+		if fail.Load() {
+			return errors.New("database down")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	slot := time.Now().Truncate(2 * time.Second)
+	s.wg.Add(1)
+	s.fire(s.byName[name], slot)
+	if _, total, err := s.History(ctx, name, 1, 10); err != nil || total != 0 {
+		t.Fatalf("after success: History = %d, %v; want no runs", total, err)
+	}
+
+	fail.Store(true)
+	s.wg.Add(1)
+	s.fire(s.byName[name], slot.Add(2*time.Second))
+	history, total, err := s.History(ctx, name, 1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("after failure: History = %d, %v; want one run", total, err)
+	}
+	if run := history[0]; run.Status != RunFailed || run.Error == nil || *run.Error != "database down" {
+		t.Errorf("run = %+v, want the failure recorded", run)
+	}
+}
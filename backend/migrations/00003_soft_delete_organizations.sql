@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE organizations ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX idx_organizations_deleted_at ON organizations (deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00003_soft_delete_organizations');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_organizations_deleted_at;
+ALTER TABLE organizations DROP COLUMN IF EXISTS deleted_at;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00003_soft_delete_organizations';
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE organization_slug_history (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    slug            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_organization_slug_history_slug ON organization_slug_history (LOWER(slug));
+CREATE INDEX idx_organization_slug_history_org ON organization_slug_history (organization_id);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00004_organization_slug_history');
+
+-- +goose Down
+DROP TABLE IF EXISTS organization_slug_history;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00004_organization_slug_history';
@@ -0,0 +1,57 @@
+-- +goose Up
+CREATE TABLE org_roles (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    key             TEXT NOT NULL,
+    name            TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    permissions     TEXT[] NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Built-in roles have no organization; their permissions are defined in code.
+CREATE UNIQUE INDEX idx_org_roles_builtin_key ON org_roles (key) WHERE organization_id IS NULL;
+CREATE UNIQUE INDEX idx_org_roles_org_key ON org_roles (organization_id, key) WHERE organization_id IS NOT NULL;
+
+INSERT INTO org_roles (organization_id, key, name, description) VALUES
+    (NULL, 'admin', 'Admin', 'Full control of the organization'),
+    (NULL, 'user', 'User', 'Standard member');
+
+ALTER TABLE org_memberships ADD COLUMN role_id UUID REFERENCES org_roles(id);
+UPDATE org_memberships m SET role_id = r.id
+FROM org_roles r
+WHERE r.organization_id IS NULL AND r.key = m.role::text;
+ALTER TABLE org_memberships ALTER COLUMN role_id SET NOT NULL;
+ALTER TABLE org_memberships DROP COLUMN role;
+CREATE INDEX idx_org_memberships_role ON org_memberships (role_id);
+
+ALTER TABLE invitations ADD COLUMN role_id UUID REFERENCES org_roles(id);
+UPDATE invitations i SET role_id = r.id
+FROM org_roles r
+WHERE r.organization_id IS NULL AND r.key = i.role::text;
+ALTER TABLE invitations ALTER COLUMN role_id SET NOT NULL;
+ALTER TABLE invitations DROP COLUMN role;
+
+DROP TYPE org_role;
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00005_org_roles_and_permissions');
+
+-- +goose Down
+CREATE TYPE org_role AS ENUM ('admin', 'user');
+
+-- Custom roles have no enum equivalent and fall back to 'user'.
+ALTER TABLE invitations ADD COLUMN role org_role NOT NULL DEFAULT 'user';
+UPDATE invitations i SET role = 'admin'
+FROM org_roles r
+WHERE r.id = i.role_id AND r.organization_id IS NULL AND r.key = 'admin';
+ALTER TABLE invitations DROP COLUMN role_id;
+
+ALTER TABLE org_memberships ADD COLUMN role org_role NOT NULL DEFAULT 'user';
+UPDATE org_memberships m SET role = 'admin'
+FROM org_roles r
+WHERE r.id = m.role_id AND r.organization_id IS NULL AND r.key = 'admin';
+DROP INDEX IF EXISTS idx_org_memberships_role;
+ALTER TABLE org_memberships DROP COLUMN role_id;
+
+DROP TABLE IF EXISTS org_roles;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00005_org_roles_and_permissions';
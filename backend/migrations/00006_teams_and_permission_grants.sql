@@ -0,0 +1,52 @@
+-- +goose Up
+CREATE TABLE teams (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name            TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_teams_org_name ON teams (organization_id, LOWER(name));
+-- Lets team_memberships reference (team, org) so a team member is always a
+-- member of the team's org.
+CREATE UNIQUE INDEX idx_teams_id_org ON teams (id, organization_id);
+
+CREATE TABLE team_memberships (
+    team_id         UUID NOT NULL,
+    user_id         UUID NOT NULL,
+    organization_id UUID NOT NULL,
+    role            TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('maintainer', 'member')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (team_id, user_id),
+    FOREIGN KEY (team_id, organization_id) REFERENCES teams (id, organization_id) ON DELETE CASCADE,
+    -- Removing someone from the org removes them from all of its teams.
+    FOREIGN KEY (user_id, organization_id) REFERENCES org_memberships (user_id, organization_id) ON DELETE CASCADE
+);
+CREATE INDEX idx_team_memberships_user_org ON team_memberships (user_id, organization_id);
+
+-- Permissions granted on top of a member's role, either to a team or to an
+-- individual org member.
+CREATE TABLE permission_grants (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL,
+    team_id         UUID,
+    user_id         UUID,
+    permission      TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((team_id IS NULL) <> (user_id IS NULL)),
+    FOREIGN KEY (team_id, organization_id) REFERENCES teams (id, organization_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id, organization_id) REFERENCES org_memberships (user_id, organization_id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_permission_grants_team ON permission_grants (team_id, permission) WHERE team_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_permission_grants_user ON permission_grants (organization_id, user_id, permission) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_permission_grants_org ON permission_grants (organization_id);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00006_teams_and_permission_grants');
+
+-- +goose Down
+DROP TABLE IF EXISTS permission_grants;
+DROP TABLE IF EXISTS team_memberships;
+DROP TABLE IF EXISTS teams;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00006_teams_and_permission_grants';
@@ -0,0 +1,49 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE TABLE email_verification_tokens (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_email_verification_tokens_hash ON email_verification_tokens (token_hash);
+CREATE INDEX idx_email_verification_tokens_user ON email_verification_tokens (user_id);
+
+CREATE TABLE org_domains (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id    UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    domain             TEXT NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at        TIMESTAMPTZ,
+    join_policy        TEXT NOT NULL DEFAULT 'off' CHECK (join_policy IN ('off', 'auto_join', 'request')),
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_org_domains_org_domain ON org_domains (organization_id, LOWER(domain));
+-- Any number of orgs may claim a domain, but only one can verify it.
+CREATE UNIQUE INDEX idx_org_domains_verified ON org_domains (LOWER(domain)) WHERE verified_at IS NOT NULL;
+
+CREATE TYPE join_request_status AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE org_join_requests (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status          join_request_status NOT NULL DEFAULT 'pending',
+    decided_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_org_join_requests_pending ON org_join_requests (organization_id, user_id) WHERE status = 'pending';
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00007_verified_domains_and_join_requests');
+
+-- +goose Down
+DROP TABLE IF EXISTS org_join_requests;
+DROP TYPE IF EXISTS join_request_status;
+DROP TABLE IF EXISTS org_domains;
+DROP TABLE IF EXISTS email_verification_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00007_verified_domains_and_join_requests';
@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE scim_tokens (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash      TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    created_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at    TIMESTAMPTZ,
+    revoked_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_scim_tokens_hash ON scim_tokens (token_hash);
+CREATE INDEX idx_scim_tokens_org ON scim_tokens (organization_id);
+
+-- Users provisioned into an org by its identity provider. The row outlives
+-- the membership so deactivated users remain visible to the IdP.
+CREATE TABLE scim_users (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id         UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    external_id     TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+ALTER TABLE teams ADD COLUMN external_id TEXT NOT NULL DEFAULT '';
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00008_scim_provisioning');
+
+-- +goose Down
+ALTER TABLE teams DROP COLUMN IF EXISTS external_id;
+DROP TABLE IF EXISTS scim_users;
+DROP TABLE IF EXISTS scim_tokens;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00008_scim_provisioning';
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Settings hold only the values an org has set explicitly; defaults live in
+-- the Go schema. version increments on every write.
+CREATE TABLE org_settings (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    version         INTEGER NOT NULL DEFAULT 0,
+    settings        JSONB NOT NULL DEFAULT '{}',
+    updated_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE org_settings_versions (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    version         INTEGER NOT NULL,
+    settings        JSONB NOT NULL,
+    updated_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, version)
+);
+
+-- Rotated refresh tokens carry the original login time so a session lifetime
+-- cap cannot be extended by refreshing.
+ALTER TABLE refresh_tokens ADD COLUMN session_started_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00009_org_settings');
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS session_started_at;
+DROP TABLE IF EXISTS org_settings_versions;
+DROP TABLE IF EXISTS org_settings;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00009_org_settings';
@@ -0,0 +1,26 @@
+-- +goose Up
+-- A user's TOTP authenticator. confirmed_at is NULL until the user proves the
+-- authenticator works; last_used_step blocks replay of a used code.
+CREATE TABLE user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    confirmed_at   TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Authentication methods (RFC 8176 amr values) of the login that began a
+-- session, carried through refresh token rotation.
+ALTER TABLE refresh_tokens ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{pwd}';
+
+-- When the org's MFA requirement was last switched on; the grace period runs
+-- from here.
+ALTER TABLE org_settings ADD COLUMN mfa_required_at TIMESTAMPTZ;
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00010_mfa');
+
+-- +goose Down
+ALTER TABLE org_settings DROP COLUMN IF EXISTS mfa_required_at;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS amr;
+DROP TABLE IF EXISTS user_totp;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00010_mfa';
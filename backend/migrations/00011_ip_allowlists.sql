@@ -0,0 +1,31 @@
+-- +goose Up
+-- An org with no entries allows every address.
+CREATE TABLE org_ip_allowlist (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    cidr            CIDR NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    created_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, cidr)
+);
+
+-- Security-relevant changes to an org, newest first per org.
+CREATE TABLE audit_events (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    actor_id        UUID REFERENCES users(id) ON DELETE SET NULL,
+    action          TEXT NOT NULL,
+    metadata        JSONB NOT NULL DEFAULT '{}',
+    ip_address      INET,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_org_created ON audit_events (organization_id, created_at DESC);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00011_ip_allowlists');
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS org_ip_allowlist;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00011_ip_allowlists';
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Bulk invitation imports. rows holds every submitted row with its outcome;
+-- a worker claims pending jobs and fills the outcomes in batches.
+CREATE TABLE invitation_import_jobs (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_by      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    inviter_name    TEXT NOT NULL,
+    org_name        TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    rows            JSONB NOT NULL,
+    error           TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_invitation_import_jobs_org ON invitation_import_jobs (organization_id, created_at DESC);
+CREATE INDEX idx_invitation_import_jobs_open ON invitation_import_jobs (updated_at)
+    WHERE status IN ('pending', 'running');
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00012_invitation_imports');
+
+-- +goose Down
+DROP TABLE IF EXISTS invitation_import_jobs;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00012_invitation_imports';
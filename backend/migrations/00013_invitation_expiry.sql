@@ -0,0 +1,10 @@
+-- +goose Up
+-- Lets the expiry sweeper find past-due pending invitations without scanning
+-- accepted and revoked ones.
+CREATE INDEX idx_invitations_pending_expiry ON invitations (expires_at) WHERE status = 'pending';
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00013_invitation_expiry');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_invitations_pending_expiry;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00013_invitation_expiry';
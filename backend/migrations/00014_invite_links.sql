@@ -0,0 +1,35 @@
+-- +goose Up
+-- Multi-use invite links. Like invitations, only a hash of the token is
+-- stored. use_count is incremented under the row lock when a link is used,
+-- so max_uses holds under concurrent joins.
+CREATE TABLE org_invite_links (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash      TEXT NOT NULL,
+    role_id         UUID NOT NULL REFERENCES org_roles(id),
+    max_uses        INT NOT NULL CHECK (max_uses > 0),
+    use_count       INT NOT NULL DEFAULT 0 CHECK (use_count <= max_uses),
+    allowed_domain  TEXT,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    disabled_at     TIMESTAMPTZ,
+    created_by      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_org_invite_links_token_hash ON org_invite_links (token_hash);
+CREATE INDEX idx_org_invite_links_org ON org_invite_links (organization_id, created_at DESC);
+CREATE INDEX idx_org_invite_links_role ON org_invite_links (role_id);
+
+-- Who joined through each link.
+CREATE TABLE org_invite_link_uses (
+    link_id   UUID NOT NULL REFERENCES org_invite_links(id) ON DELETE CASCADE,
+    user_id   UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (link_id, user_id)
+);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00014_invite_links');
+
+-- +goose Down
+DROP TABLE IF EXISTS org_invite_link_uses;
+DROP TABLE IF EXISTS org_invite_links;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00014_invite_links';
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Transactional outbox. Side effects such as emails are written here in the
+-- same transaction as the change that causes them, and a background
+-- dispatcher delivers them with retries. Messages that keep failing are
+-- dead-lettered until an admin replays them.
+CREATE TABLE outbox (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind            TEXT NOT NULL,
+    summary         TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts        INT NOT NULL DEFAULT 0,
+    max_attempts    INT NOT NULL CHECK (max_attempts > 0),
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMPTZ,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ
+);
+CREATE INDEX idx_outbox_due ON outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_status ON outbox (status, updated_at DESC);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00015_outbox');
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00015_outbox';
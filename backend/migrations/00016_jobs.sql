@@ -0,0 +1,35 @@
+-- +goose Up
+-- Background jobs. Workers claim due jobs with FOR UPDATE SKIP LOCKED,
+-- highest priority first. A running job whose lock has lapsed belongs to a
+-- worker that died and is claimed again.
+CREATE TABLE jobs (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind         TEXT NOT NULL,
+    payload      JSONB NOT NULL DEFAULT '{}',
+    priority     INT NOT NULL DEFAULT 0,
+    status       TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    -- At most one queued or running job may hold a given unique key.
+    unique_key   TEXT,
+    attempts     INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL CHECK (max_attempts > 0),
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMPTZ
+);
+CREATE INDEX idx_jobs_queued ON jobs (priority DESC, run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs (locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_finished ON jobs (finished_at) WHERE status IN ('succeeded', 'failed');
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs (unique_key) WHERE status IN ('queued', 'running');
+
+-- Expired refresh tokens are deleted in the background.
+CREATE INDEX idx_refresh_tokens_expires ON refresh_tokens (expires_at);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00016_jobs');
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_expires;
+DROP TABLE IF EXISTS jobs;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00016_jobs';
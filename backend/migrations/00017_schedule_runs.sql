@@ -0,0 +1,23 @@
+-- +goose Up
+-- History of recurring schedule runs. A cron run is recorded once per
+-- schedule and slot, so replicas that reach the same slot fire it once.
+CREATE TABLE schedule_runs (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_name TEXT NOT NULL,
+    trigger       TEXT NOT NULL CHECK (trigger IN ('cron', 'manual')),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    host          TEXT NOT NULL,
+    started_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at   TIMESTAMPTZ,
+    duration_ms   BIGINT,
+    error         TEXT
+);
+CREATE UNIQUE INDEX idx_schedule_runs_slot ON schedule_runs (schedule_name, scheduled_for) WHERE trigger = 'cron';
+CREATE INDEX idx_schedule_runs_name ON schedule_runs (schedule_name, started_at DESC);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00017_schedule_runs');
+
+-- +goose Down
+DROP TABLE IF EXISTS schedule_runs;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00017_schedule_runs';
@@ -0,0 +1,47 @@
+-- +goose Up
+-- Audit events outlive the orgs and users they mention, so the foreign keys
+-- go: a cascade or SET NULL would rewrite history.
+ALTER TABLE audit_events
+    DROP CONSTRAINT audit_events_organization_id_fkey,
+    DROP CONSTRAINT audit_events_actor_id_fkey,
+    ADD COLUMN target_type TEXT NOT NULL DEFAULT '',
+    ADD COLUMN target_id   UUID,
+    ADD COLUMN changes     JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN user_agent  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN request_id  TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_audit_events_created ON audit_events (created_at DESC);
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_id, created_at DESC);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00018_audit_log');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+DROP INDEX IF EXISTS idx_audit_events_target;
+DROP INDEX IF EXISTS idx_audit_events_actor;
+DROP INDEX IF EXISTS idx_audit_events_created;
+ALTER TABLE audit_events
+    DROP COLUMN request_id,
+    DROP COLUMN user_agent,
+    DROP COLUMN changes,
+    DROP COLUMN target_id,
+    DROP COLUMN target_type,
+    ADD CONSTRAINT audit_events_organization_id_fkey
+        FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE NOT VALID,
+    ADD CONSTRAINT audit_events_actor_id_fkey
+        FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL NOT VALID;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00018_audit_log';
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Each event records the transaction that wrote it. Once a transaction ID is
+-- below the oldest one still running, no more events can appear under it, so
+-- a sink's cursor of (txid, id) never skips an event that commits late.
+ALTER TABLE audit_events
+    ADD COLUMN txid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_audit_events_txid ON audit_events (txid, id);
+
+CREATE TABLE audit_sink_cursors (
+    sink       TEXT PRIMARY KEY,
+    last_txid  xid8 NOT NULL DEFAULT '0',
+    last_id    UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    delivered  BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00019_audit_stream');
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_sink_cursors;
+DROP INDEX IF EXISTS idx_audit_events_txid;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS txid;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00019_audit_stream';
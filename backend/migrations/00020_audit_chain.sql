@@ -0,0 +1,60 @@
+-- +goose Up
+-- Audit events form one hash chain per organization, plus one for events
+-- outside any organization, keyed by chain_id. Each event's hash covers its
+-- contents and the previous event's hash, so editing or deleting an event
+-- breaks every link after it. Events recorded before this migration are not
+-- chained.
+ALTER TABLE audit_events
+    ADD COLUMN chain_id  UUID GENERATED ALWAYS AS
+        (COALESCE(organization_id, '00000000-0000-0000-0000-000000000000')) STORED,
+    ADD COLUMN chain_seq BIGINT,
+    ADD COLUMN prev_hash TEXT,
+    ADD COLUMN hash      TEXT;
+
+CREATE UNIQUE INDEX idx_audit_events_chain ON audit_events (chain_id, chain_seq) WHERE chain_seq IS NOT NULL;
+
+-- Checkpoints are signed copies of a chain's head, published daily, so a
+-- chain rewritten from some point on no longer matches them.
+CREATE TABLE audit_checkpoints (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chain_id   UUID NOT NULL,
+    chain_seq  BIGINT NOT NULL,
+    hash       TEXT NOT NULL,
+    key_id     TEXT NOT NULL,
+    signature  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_checkpoints_chain ON audit_checkpoints (chain_id, chain_seq);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_checkpoints_append_only
+    BEFORE UPDATE OR DELETE ON audit_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00020_audit_chain');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_checkpoints_append_only ON audit_checkpoints;
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP INDEX IF EXISTS idx_audit_events_chain;
+ALTER TABLE audit_events
+    DROP COLUMN hash,
+    DROP COLUMN prev_hash,
+    DROP COLUMN chain_seq,
+    DROP COLUMN chain_id;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00020_audit_chain';
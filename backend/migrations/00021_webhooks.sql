@@ -0,0 +1,57 @@
+-- +goose Up
+-- Outbound webhooks. Org events are POSTed to each subscribed endpoint
+-- through the outbox, which retries failed deliveries with backoff. Every
+-- request and its response is kept in the delivery log.
+CREATE TABLE webhook_endpoints (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id      UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    url                  TEXT NOT NULL,
+    description          TEXT NOT NULL DEFAULT '',
+    -- Empty means every event type.
+    event_types          TEXT[] NOT NULL DEFAULT '{}',
+    secret               TEXT NOT NULL,
+    enabled              BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_reason      TEXT,
+    disabled_at          TIMESTAMPTZ,
+    created_by           UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_webhook_endpoints_org ON webhook_endpoints (organization_id);
+
+CREATE TABLE webhook_deliveries (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    endpoint_id     UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id        UUID NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INT NOT NULL DEFAULT 0,
+    last_attempt_at TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_created ON webhook_deliveries (created_at);
+
+CREATE TABLE webhook_delivery_attempts (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id      UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    request_headers  JSONB NOT NULL,
+    request_body     TEXT NOT NULL,
+    response_status  INT,
+    response_headers JSONB,
+    response_body    TEXT,
+    error            TEXT,
+    duration_ms      INT NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id, created_at);
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00021_webhooks');
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00021_webhooks';
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Names sent by an org's identity provider. Accounts are shared across orgs,
+-- so the IdP's names are kept per org instead of overwriting the account's;
+-- NULL falls back to the account's own name.
+ALTER TABLE scim_users
+    ADD COLUMN given_name  TEXT,
+    ADD COLUMN family_name TEXT;
+
+INSERT INTO schema_migrations_audit (migration_name) VALUES ('00022_scim_user_names');
+
+-- +goose Down
+ALTER TABLE scim_users
+    DROP COLUMN IF EXISTS family_name,
+    DROP COLUMN IF EXISTS given_name;
+DELETE FROM schema_migrations_audit WHERE migration_name = '00022_scim_user_names';